@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/app"
+	"github.com/nemirlev/zenmoney-export/v2/internal/audit"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/spf13/cobra"
+)
+
+func NewAuditCommand(root *RootCommand) *cobra.Command {
+	opts := &config.AuditOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Find dangling references and duplicates in the database",
+		Long: `Scans the database for references to missing objects, e.g. transactions pointing at missing accounts,
+tags with missing parents or reminder markers whose reminder is gone, and for objects stored more than once.
+With --fix the missing objects are fetched from ZenMoney and saved without touching the sync state.
+The command exits with an error if problems remain. Rows with the same content but different ids are
+reported as warnings, they may be distinct objects and don't fail the command.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{skipAppAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withStorage(cmd.Context(), root, func(storage interfaces.Storage) error {
+				report, err := audit.Run(cmd.Context(), storage)
+				if err != nil {
+					return err
+				}
+
+				if opts.Fix && len(report.Orphans) > 0 {
+					client, err := app.NewClient(root.cfg)
+					if err != nil {
+						return err
+					}
+					fetched, err := audit.Fix(cmd.Context(), storage, client, report.Orphans)
+					if err != nil {
+						return err
+					}
+					if report, err = audit.Run(cmd.Context(), storage); err != nil {
+						return err
+					}
+					report.Fetched = fetched
+				}
+
+				if err := printAudit(cmd, root.cfg.Format, report); err != nil {
+					return err
+				}
+				if n := report.Problems(); n > 0 {
+					cmd.SilenceUsage = true
+					return fmt.Errorf("found %d problems", n)
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Fix, "fix", false, "fetch missing objects from ZenMoney")
+	return cmd
+}
+
+func printAudit(cmd *cobra.Command, format string, report *audit.Report) error {
+	return printResult(cmd, format, report, func(w io.Writer) error {
+		if len(report.Fetched) > 0 {
+			fmt.Fprintln(w, "FETCHED\tOBJECTS")
+			for _, entity := range slices.Sorted(maps.Keys(report.Fetched)) {
+				fmt.Fprintf(w, "%s\t%d\n", entity, report.Fetched[entity])
+			}
+			fmt.Fprintln(w)
+		}
+		if report.Problems() == 0 && report.Warnings() == 0 {
+			fmt.Fprintln(w, "No problems found")
+			return nil
+		}
+
+		fmt.Fprintln(w, "CATEGORY\tCOUNT")
+		for _, c := range report.Categories {
+			fmt.Fprintf(w, "%s\t%d\n", c.Name, c.Count)
+		}
+		if len(report.Orphans) > 0 {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "ENTITY\tID\tFIELD\tMISSING")
+			for _, o := range report.Orphans {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s %s\n", o.Entity, o.ID, o.Field, o.Reference, o.MissingID)
+			}
+		}
+		if len(report.Duplicates) > 0 {
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, "ENTITY\tREASON\tROWS\tIDS")
+			for _, d := range report.Duplicates {
+				fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", d.Entity, d.Reason, d.Rows, strings.Join(d.IDs, " "))
+			}
+		}
+		if report.Problems() == 0 {
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "No problems found, %d warnings\n", report.Warnings())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/backup"
+	"github.com/nemirlev/zenmoney-export/v2/internal/encrypt"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/spf13/cobra"
+)
+
+func NewBackupCommand(root *RootCommand) *cobra.Command {
+	opts := &config.BackupOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up the database into an archive",
+		Long: `Writes every entity, the sync statuses and the deletion history into a zstd compressed
+tar archive with a manifest of checksums. The archive can be restored with the restore command
+into a fresh database, also of another type. Tombstones of soft deleted objects and history
+tables aren't included. --encrypt encrypts the archive with age, to --recipient public keys
+or with a passphrase.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{skipAppAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			encrypter, err := newEncrypter(opts.EncryptOptions)
+			if err != nil {
+				return err
+			}
+
+			output := opts.Output
+			if output == "" {
+				output = "zenexport-" + time.Now().Format("20060102-150405") + ".tar.zst" + encrypter.Extension()
+			}
+
+			return withStorage(cmd.Context(), root, func(storage interfaces.Storage) error {
+				manifest, err := writeBackup(cmd, storage, root.cfg.DBType, output, encrypter)
+				if err != nil {
+					return err
+				}
+
+				return printResult(cmd, root.cfg.Format, manifest, func(w io.Writer) error {
+					fmt.Fprintf(w, "Backup written to %s\n", output)
+					printManifest(w, manifest, nil)
+					return nil
+				})
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "archive file (default: zenexport-<time>.tar.zst)")
+	addEncryptFlags(cmd, &opts.EncryptOptions)
+	return cmd
+}
+
+// writeBackup writes the archive next to output and renames it once it's complete,
+// so an interrupted backup doesn't leave a truncated archive behind
+func writeBackup(
+	cmd *cobra.Command,
+	storage interfaces.Storage,
+	storageType, output string,
+	encrypter *encrypt.Encrypter,
+) (*backup.Manifest, error) {
+	tmp := output + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp)
+	}()
+
+	w, err := encrypter.Wrap(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	manifest, err := backup.Backup(cmd.Context(), storage, storageType, w)
+	if cerr := w.Close(); cerr != nil && err == nil {
+		err = fmt.Errorf("failed to encrypt backup: %w", cerr)
+	}
+	if cerr := f.Close(); cerr != nil && err == nil {
+		err = fmt.Errorf("failed to close backup file: %w", cerr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(tmp, output); err != nil {
+		return nil, fmt.Errorf("failed to write backup file: %w", err)
+	}
+	return manifest, nil
+}
+
+func NewRestoreCommand(root *RootCommand) *cobra.Command {
+	opts := &config.RestoreOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "restore <archive>",
+		Short: "Restore the database from a backup archive",
+		Long: `Verifies the checksums of a backup archive and loads it into the configured database.
+The database should be fresh with the schema migrated; restore refuses a database which has
+been synced already unless --force is set. Sync statuses are restored last, so the next sync
+continues incrementally from the server timestamp of the backup. Encrypted archives are decrypted
+with the age private keys of --identity or with a passphrase.`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{skipAppAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+			decrypter, err := newDecrypter(opts.DecryptOptions)
+			if err != nil {
+				return err
+			}
+
+			if opts.VerifyOnly {
+				manifest, err := backup.Verify(path, decrypter)
+				if err != nil {
+					return err
+				}
+				return printResult(cmd, root.cfg.Format, manifest, func(w io.Writer) error {
+					fmt.Fprintf(w, "Backup %s is valid\n", path)
+					printManifest(w, manifest, nil)
+					return nil
+				})
+			}
+
+			return withStorage(cmd.Context(), root, func(storage interfaces.Storage) error {
+				if !opts.Force {
+					last, err := storage.GetLastSyncStatus(cmd.Context())
+					if err != nil {
+						return err
+					}
+					if last.ID != 0 {
+						return errors.New("the database has been synced already, restore into a fresh database or use --force")
+					}
+				}
+
+				manifest, counts, err := backup.Restore(cmd.Context(), storage, path, decrypter)
+				if err != nil {
+					return err
+				}
+
+				result := map[string]any{"manifest": manifest, "restored": counts}
+				return printResult(cmd, root.cfg.Format, result, func(w io.Writer) error {
+					fmt.Fprintf(w, "Restored %s\n", path)
+					printManifest(w, manifest, counts)
+					return nil
+				})
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.Force, "force", false, "restore into a database which has been synced already")
+	flags.BoolVar(&opts.VerifyOnly, "verify-only", false, "only verify the archive, don't connect to the database")
+	addDecryptFlags(cmd, &opts.DecryptOptions)
+	return cmd
+}
+
+// printManifest writes the files of a manifest, with the restored rows if counts are given
+func printManifest(w io.Writer, manifest *backup.Manifest, counts map[string]int) {
+	fmt.Fprintf(w, "Created %s from %s, server timestamp %d\n",
+		manifest.CreatedAt.Format(time.RFC3339), manifest.Storage, manifest.ServerTimestamp)
+	if counts == nil {
+		fmt.Fprintln(w, "FILE\tROWS\tSHA256")
+		for _, file := range manifest.Files {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", file.Name, file.Rows, file.SHA256)
+		}
+		return
+	}
+
+	fmt.Fprintln(w, "FILE\tROWS\tRESTORED")
+	for _, file := range manifest.Files {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", file.Name, file.Rows, counts[file.Name])
+	}
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func NewCheckCommand(root *RootCommand) *cobra.Command {
+	opts := &config.CheckOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check the database, the migrations and the API token",
+		Long: `Checks that the database is reachable and writable, that its schema matches the migrations
+of this zenexport and that ZenMoney accepts the token. Without flags all checks run. The command
+exits with an error if any check fails, so it can serve as a Docker healthcheck.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{skipAppAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var checks []string
+			for _, c := range []struct {
+				enabled bool
+				name    string
+			}{
+				{opts.DBConnection, app.CheckDatabase},
+				{opts.Write, app.CheckWrite},
+				{opts.Migrations, app.CheckMigrations},
+				{opts.APIToken, app.CheckToken},
+			} {
+				if c.enabled {
+					checks = append(checks, c.name)
+				}
+			}
+
+			results := app.Check(cmd.Context(), root.cfg, checks)
+			failed := 0
+			for _, result := range results {
+				if !result.OK {
+					failed++
+				}
+			}
+
+			err := printResult(cmd, root.cfg.Format, results, func(w io.Writer) error {
+				fmt.Fprintln(w, "CHECK\tSTATUS\tTIME\tMESSAGE")
+				for _, result := range results {
+					status := "ok"
+					if !result.OK {
+						status = "FAIL"
+					}
+					// driver errors may span lines, which would break the table
+					message := strings.Join(strings.Fields(result.Message), " ")
+					fmt.Fprintf(w, "%s\t%s\t%dms\t%s\n", result.Name, status, result.Duration, message)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if failed > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d checks failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.DBConnection, "db-connection", false, "check the database connection")
+	flags.BoolVar(&opts.Write, "write", false, "check that the database can be written to")
+	flags.BoolVar(&opts.Migrations, "migrations", false, "check that the schema migrations are up to date")
+	flags.BoolVar(&opts.APIToken, "api-token", false, "validate the ZenMoney API token")
+	return cmd
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/app"
+	"github.com/nemirlev/zenmoney-export/v2/internal/backup"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/spf13/cobra"
+)
+
+func NewCopyCommand(root *RootCommand) *cobra.Command {
+	opts := &config.CopyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "copy",
+		Short: "Copy the database into another storage",
+		Long: `Streams every entity, the deletion history and the sync statuses from one storage into another
+and verifies the row counts of the target afterwards. The source defaults to the configured database.
+The target should be fresh; copy refuses a target which has been synced already unless --force is set.
+Both storages share the other settings of the configuration, like db_schema and delete_mode.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{skipAppAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.ToConfig == "" {
+				return errors.New("--to-config is required")
+			}
+
+			source := *root.cfg
+			if opts.FromType != "" {
+				source.DBType = opts.FromType
+			}
+			if opts.FromConfig != "" {
+				source.DBConfig = opts.FromConfig
+			}
+			target := source
+			if opts.ToType != "" {
+				target.DBType = opts.ToType
+			}
+			target.DBConfig = opts.ToConfig
+
+			if source.DBType == target.DBType && source.DBConfig == target.DBConfig {
+				return errors.New("the source and the target of the copy are the same storage")
+			}
+
+			counts, err := copyStorage(cmd.Context(), &source, &target, opts.Force)
+			if counts == nil {
+				return err
+			}
+
+			result := map[string]any{"from": source.DBType, "to": target.DBType, "counts": counts}
+			if perr := printResult(cmd, root.cfg.Format, result, func(w io.Writer) error {
+				fmt.Fprintln(w, "ENTITY\tSOURCE\tTARGET")
+				for _, c := range counts {
+					fmt.Fprintf(w, "%s\t%d\t%d\n", c.Entity, c.Source, c.Target)
+				}
+				return nil
+			}); perr != nil && err == nil {
+				err = perr
+			}
+			return err
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.FromType, "from-type", "", "source database type (default: --db-type)")
+	flags.StringVar(&opts.FromConfig, "from-config", "", "source database connection URL (default: --db-url)")
+	flags.StringVar(&opts.ToType, "to-type", "", "target database type (default: the source type)")
+	flags.StringVar(&opts.ToConfig, "to-config", "", "target database connection URL")
+	flags.BoolVar(&opts.Force, "force", false, "copy into a storage which has been synced already")
+	return cmd
+}
+
+// copyStorage connects to both storages and copies the source into the target.
+// The counts are returned along with a failed verification, so they can be shown.
+func copyStorage(ctx context.Context, source, target *config.Config, force bool) ([]backup.Count, error) {
+	from, err := app.NewStorage(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the source: %w", err)
+	}
+	defer closeStorage(ctx, from)
+
+	to, err := app.NewStorage(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the target: %w", err)
+	}
+	defer closeStorage(ctx, to)
+
+	if !force {
+		last, err := to.GetLastSyncStatus(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if last.ID != 0 {
+			return nil, errors.New("the target has been synced already, copy into a fresh storage or use --force")
+		}
+	}
+
+	return backup.Copy(ctx, from, to)
+}
+
+func closeStorage(ctx context.Context, storage interfaces.Storage) {
+	if err := storage.Close(ctx); err != nil {
+		slog.Error("failed to close storage", "error", err)
+	}
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/encrypt"
+	"github.com/spf13/cobra"
+)
+
+// addEncryptFlags adds the flags of commands writing encrypted output
+func addEncryptFlags(cmd *cobra.Command, opts *config.EncryptOptions) {
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.Encrypt, "encrypt", false,
+		"encrypt the output with age, to --recipient keys or with the passphrase of --passphrase-file or "+encrypt.PassphraseEnv)
+	flags.StringArrayVar(&opts.Recipients, "recipient", nil, "age public key to encrypt to, repeatable")
+	flags.StringVar(&opts.RecipientsFile, "recipients-file", "", "file of age public keys to encrypt to, one per line")
+	flags.StringVar(&opts.PassphraseFile, "passphrase-file", "", "file holding the passphrase to encrypt with")
+}
+
+// newEncrypter returns the encrypter of the flags, nil without --encrypt
+func newEncrypter(opts config.EncryptOptions) (*encrypt.Encrypter, error) {
+	if !opts.Encrypt {
+		if len(opts.Recipients) > 0 || opts.RecipientsFile != "" || opts.PassphraseFile != "" {
+			return nil, errors.New("--recipient, --recipients-file and --passphrase-file need --encrypt")
+		}
+		return nil, nil
+	}
+	return encrypt.NewEncrypter(opts.Recipients, opts.RecipientsFile, opts.PassphraseFile)
+}
+
+// addDecryptFlags adds the flags of commands reading encrypted input
+func addDecryptFlags(cmd *cobra.Command, opts *config.DecryptOptions) {
+	flags := cmd.Flags()
+	flags.StringVar(&opts.IdentityFile, "identity", "",
+		"file of age private keys to decrypt with (default: "+encrypt.IdentityEnv+")")
+	flags.StringVar(&opts.PassphraseFile, "passphrase-file", "",
+		"file holding the passphrase to decrypt with (default: "+encrypt.PassphraseEnv+")")
+}
+
+// newDecrypter returns the decrypter of the flags, which reads unencrypted input as well
+func newDecrypter(opts config.DecryptOptions) (*encrypt.Decrypter, error) {
+	return encrypt.NewDecrypter(opts.IdentityFile, opts.PassphraseFile)
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/export"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/spf13/cobra"
+)
+
+func NewExportCommand(root *RootCommand) *cobra.Command {
+	opts := &config.ExportOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export data from the database",
+		Long: `Exports objects from the database as CSV, newline-delimited JSON or an XLSX workbook.
+A single entity is written to stdout unless --output is set, several entities
+are written into the --output directory, one file per entity.
+The xlsx format writes all entities into one workbook, a sheet per entity.
+Journal formats (beancount, ledger, hledger) write accounts and transactions into one journal;
+exporting into an existing journal appends only the entries it doesn't have yet.
+Statement formats (qif, ofx) write the transactions of every account, into one file
+or, if --output is a directory, into a file per account.
+Presets (firefly, actual, ynab) write transactions as CSV files other budget apps import;
+actual and ynab write a file per account into the --output directory.
+--template runs a Go template over the entities instead: markdown and html are built in,
+anything else is read as a template file.
+--encrypt encrypts the output with age, to --recipient public keys or with a passphrase.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{skipAppAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			exportOpts, err := parseExportOptions(opts)
+			if err != nil {
+				return err
+			}
+
+			entities, err := export.ParseEntities(opts.Entities)
+			if err != nil {
+				return err
+			}
+
+			return withStorage(cmd.Context(), root, func(storage interfaces.Storage) error {
+				exporter := export.NewExporter(storage, exportOpts)
+				counts, err := exporter.ExportTo(cmd.Context(), entities, opts.Output, cmd.OutOrStdout())
+				if err != nil {
+					return err
+				}
+
+				names := make([]string, 0, len(counts))
+				for name := range counts {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					slog.Info("Exported", "entity", name, "rows", counts[name])
+				}
+				return nil
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Format, "format", "csv", "export format (csv, json, xlsx, beancount, ledger, hledger, qif, ofx)")
+	flags.StringVarP(&opts.Output, "output", "o", "", "output file, or directory for several entities (default: stdout)")
+	flags.StringVar(&opts.Entities, "entities", "all", "comma-separated list of entities to export")
+	flags.StringVar(&opts.From, "from", "", "start date of dated entities (YYYY-MM-DD)")
+	flags.StringVar(&opts.To, "to", "", "end date of dated entities (YYYY-MM-DD)")
+	flags.StringVar(&opts.Compress, "compress", "none", "output compression (none, gzip, zstd)")
+	flags.StringVar(&opts.Preset, "preset", "", "CSV layout of another app instead of --format (firefly, actual, ynab)")
+	flags.StringVar(&opts.Template, "template", "", "Go template instead of --format: markdown, html or a template file")
+	flags.StringVar(&opts.Mapping, "mapping", "", "YAML file mapping accounts, tags and instruments to journal names")
+	flags.IntVar(&opts.PageSize, "page-size", export.DefaultPageSize, "number of objects read from the database at once")
+	addEncryptFlags(cmd, &opts.EncryptOptions)
+	return cmd
+}
+
+// parseExportOptions validates the export flags
+func parseExportOptions(opts *config.ExportOptions) (export.Options, error) {
+	format := export.Format(opts.Format)
+	switch format {
+	case export.FormatCSV, export.FormatJSON, export.FormatXLSX,
+		export.FormatBeancount, export.FormatLedger, export.FormatHledger,
+		export.FormatQIF, export.FormatOFX:
+	default:
+		return export.Options{}, fmt.Errorf("unsupported export format: %s", opts.Format)
+	}
+
+	compression, err := export.ParseCompression(opts.Compress)
+	if err != nil {
+		return export.Options{}, err
+	}
+
+	from, err := parseDate(opts.From)
+	if err != nil {
+		return export.Options{}, fmt.Errorf("invalid --from: %w", err)
+	}
+	to, err := parseDate(opts.To)
+	if err != nil {
+		return export.Options{}, fmt.Errorf("invalid --to: %w", err)
+	}
+
+	mapping, err := export.LoadMapping(opts.Mapping)
+	if err != nil {
+		return export.Options{}, err
+	}
+
+	var preset *export.Preset
+	if opts.Preset != "" {
+		if preset, err = export.ParsePreset(opts.Preset); err != nil {
+			return export.Options{}, err
+		}
+	}
+
+	encrypter, err := newEncrypter(opts.EncryptOptions)
+	if err != nil {
+		return export.Options{}, err
+	}
+
+	return export.Options{
+		Format:      format,
+		Compression: compression,
+		From:        from,
+		To:          to,
+		PageSize:    opts.PageSize,
+		Mapping:     mapping,
+		Preset:      preset,
+		Template:    opts.Template,
+		Encrypter:   encrypter,
+	}, nil
+}
+
+// parseDate parses an optional YYYY-MM-DD date
+func parseDate(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	date, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
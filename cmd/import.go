@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/importer"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/spf13/cobra"
+)
+
+func NewImportCommand(root *RootCommand) *cobra.Command {
+	opts := &config.ImportOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "import <statement>",
+		Short: "Import a bank statement and match it against transactions",
+		Long: `Reads a bank statement in CSV, OFX or MT940 and matches its records against the synced
+transactions of --account by amount, a date window and the payee. Every record is kept in the
+imported_record table with the transaction it matched, so importing a statement again skips the
+matched records and links the others once their transactions are synced. --create creates a
+transaction in the database for each record without a match instead; they aren't sent to ZenMoney.
+The format is told by the file extension unless --statement-format is set, "-" reads the statement from stdin.`,
+		Args:        cobra.ExactArgs(1),
+		Annotations: map[string]string{skipAppAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Account == "" {
+				return errors.New("--account is required")
+			}
+			if opts.Window < 0 {
+				return errors.New("--window can't be negative")
+			}
+
+			path := args[0]
+			format, err := importer.ParseFormat(opts.Format, path)
+			if err != nil {
+				return err
+			}
+			mapping, err := importer.LoadCSVMapping(opts.Mapping)
+			if err != nil {
+				return err
+			}
+
+			var r io.Reader = cmd.InOrStdin()
+			if path != "-" {
+				f, err := os.Open(path)
+				if err != nil {
+					return fmt.Errorf("failed to open statement: %w", err)
+				}
+				defer f.Close()
+				r = f
+			}
+
+			return withStorage(cmd.Context(), root, func(storage interfaces.Storage) error {
+				result, err := importer.Import(cmd.Context(), storage, r, importer.Options{
+					Format:      format,
+					Mapping:     mapping,
+					Account:     opts.Account,
+					Window:      opts.Window,
+					StrictPayee: opts.StrictPayee,
+					Create:      opts.Create,
+					DryRun:      opts.DryRun,
+				})
+				if result == nil {
+					return err
+				}
+
+				if perr := printResult(cmd, root.cfg.Format, result, func(w io.Writer) error {
+					printImport(w, result, opts.DryRun)
+					return nil
+				}); perr != nil && err == nil {
+					err = perr
+				}
+				return err
+			})
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Account, "account", "", "ID or title of the account the statement belongs to")
+	flags.StringVar(&opts.Format, "statement-format", "", "statement format: csv, ofx or mt940 (default: by extension)")
+	flags.StringVar(&opts.Mapping, "mapping", "", "YAML file mapping the columns of a CSV statement")
+	flags.IntVar(&opts.Window, "window", 3, "days the date of a matching transaction may differ by")
+	flags.BoolVar(&opts.StrictPayee, "strict-payee", false, "match only transactions whose payee resembles the record's")
+	flags.BoolVar(&opts.Create, "create", false, "create transactions for records without a match")
+	flags.BoolVar(&opts.DryRun, "dry-run", false, "report the matches without saving anything")
+	return cmd
+}
+
+// printImport writes the counts of an import and its records
+func printImport(w io.Writer, result *importer.Result, dryRun bool) {
+	if dryRun {
+		fmt.Fprintln(w, "Dry run, nothing was saved")
+	}
+	fmt.Fprintf(w, "Account %s: %d matched, %d new, %d created, %d duplicate\n", result.Account,
+		result.Counts[importer.StatusMatched], result.Counts[importer.StatusNew],
+		result.Counts[importer.StatusCreated], result.Counts[importer.StatusDuplicate])
+	if len(result.Lines) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "DATE\tAMOUNT\tPAYEE\tSTATUS\tTRANSACTION")
+	for _, line := range result.Lines {
+		fmt.Fprintf(w, "%s\t%.2f\t%s\t%s\t%s\n", line.Date.Format(time.DateOnly), line.Amount,
+			line.Payee, line.Status, line.TransactionID)
+	}
+}
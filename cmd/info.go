@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// settingFlags maps config keys to the root flags bound to them in addFlags
+var settingFlags = map[string]string{
+	"token":     "token",
+	"log_level": "log-level",
+	"format":    "format",
+	"db_type":   "db-type",
+	"db_config": "db-url",
+}
+
+// dsnPassword matches the password of a key/value connection string, e.g. "host=db password=secret"
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// redacted replaces secrets in the output of info config, the same way url.URL.Redacted does
+const redacted = "xxxxx"
+
+// syncStatusView is a sync status as shown by info status
+type syncStatusView struct {
+	ID         int64      `json:"id"`
+	SyncType   string     `json:"sync_type"`
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// Duration is nil for a sync still in progress
+	Duration         *int64  `json:"duration_ms,omitempty"`
+	RecordsProcessed int     `json:"records_processed"`
+	ServerTimestamp  int64   `json:"server_timestamp"`
+	Error            *string `json:"error,omitempty"`
+}
+
+type syncInfo struct {
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	// SinceLastSuccess is the time since the last completed sync in seconds
+	SinceLastSuccess *int64           `json:"since_last_success_seconds,omitempty"`
+	Syncs            []syncStatusView `json:"syncs"`
+}
+
+// setting is a config value and where it came from: flag, env, file or default
+type setting struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+type configInfo struct {
+	File     string    `json:"file,omitempty"`
+	Settings []setting `json:"settings"`
+}
+
+func NewInfoCommand(root *RootCommand) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "info",
+		Short:       "Show the sync status, the configuration and database statistics",
+		Annotations: map[string]string{skipAppAnnotation: "true"},
+	}
+
+	cmd.AddCommand(newInfoStatusCommand(root))
+	cmd.AddCommand(newInfoConfigCommand(root))
+	cmd.AddCommand(newInfoDBCommand(root))
+
+	return cmd
+}
+
+func newInfoStatusCommand(root *RootCommand) *cobra.Command {
+	opts := &config.InfoOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the latest syncs and the time since the last successful one",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Limit < 1 {
+				return fmt.Errorf("invalid limit: %d", opts.Limit)
+			}
+
+			return withStorage(cmd.Context(), root, func(storage interfaces.Storage) error {
+				statuses, err := storage.ListRecentSyncStatuses(cmd.Context(), opts.Limit, "")
+				if err != nil {
+					return err
+				}
+				completed, err := storage.ListRecentSyncStatuses(cmd.Context(), 1, "completed")
+				if err != nil {
+					return err
+				}
+
+				info := syncInfo{Syncs: make([]syncStatusView, 0, len(statuses))}
+				now := time.Now()
+				if len(completed) > 0 {
+					last := completed[0].StartedAt
+					if completed[0].FinishedAt != nil {
+						last = *completed[0].FinishedAt
+					}
+					since := int64(now.Sub(last) / time.Second)
+					info.LastSuccess, info.SinceLastSuccess = &last, &since
+				}
+				for _, status := range statuses {
+					view := syncStatusView{
+						ID:               status.ID,
+						SyncType:         status.SyncType,
+						Status:           status.Status,
+						StartedAt:        status.StartedAt,
+						FinishedAt:       status.FinishedAt,
+						RecordsProcessed: status.RecordsProcessed,
+						ServerTimestamp:  status.ServerTimestamp,
+						Error:            status.ErrorMessage,
+					}
+					if status.FinishedAt != nil {
+						duration := status.FinishedAt.Sub(status.StartedAt).Milliseconds()
+						view.Duration = &duration
+					}
+					info.Syncs = append(info.Syncs, view)
+				}
+
+				return printResult(cmd, root.cfg.Format, info, func(w io.Writer) error {
+					if info.LastSuccess == nil {
+						fmt.Fprintln(w, "Last success:\tnever")
+					} else {
+						ago := time.Duration(*info.SinceLastSuccess) * time.Second
+						fmt.Fprintf(w, "Last success:\t%s (%s ago)\n", info.LastSuccess.Local().Format(time.DateTime), ago)
+					}
+					if len(info.Syncs) == 0 {
+						fmt.Fprintln(w, "No syncs yet")
+						return nil
+					}
+
+					fmt.Fprintln(w)
+					fmt.Fprintln(w, "ID\tTYPE\tSTATUS\tSTARTED\tDURATION\tRECORDS\tERROR")
+					for _, s := range info.Syncs {
+						duration, message := "-", ""
+						if s.Duration != nil {
+							duration = (time.Duration(*s.Duration) * time.Millisecond).String()
+						}
+						if s.Error != nil {
+							message = strings.Join(strings.Fields(*s.Error), " ")
+						}
+						fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%s\n", s.ID, s.SyncType, s.Status,
+							s.StartedAt.Local().Format(time.DateTime), duration, s.RecordsProcessed, message)
+					}
+					return nil
+				})
+			})
+		},
+	}
+
+	cmd.Flags().IntVarP(&opts.Limit, "limit", "n", 10, "number of syncs to show")
+	return cmd
+}
+
+func newInfoConfigCommand(root *RootCommand) *cobra.Command {
+	return &cobra.Command{
+		Use:   "config",
+		Short: "Show the effective configuration",
+		Long: `Shows every setting after merging flags, environment variables, the config file and defaults,
+with the source each value came from. The token and the database password are redacted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := configInfo{File: viper.ConfigFileUsed(), Settings: settings(cmd, root.cfg)}
+
+			return printResult(cmd, root.cfg.Format, info, func(w io.Writer) error {
+				file := info.File
+				if file == "" {
+					file = "none"
+				}
+				fmt.Fprintf(w, "Config file:\t%s\n\n", file)
+				fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+				for _, s := range info.Settings {
+					fmt.Fprintf(w, "%s\t%v\t%s\n", s.Key, s.Value, s.Source)
+				}
+				return nil
+			})
+		},
+	}
+}
+
+func newInfoDBCommand(root *RootCommand) *cobra.Command {
+	return &cobra.Command{
+		Use:   "db",
+		Short: "Show row counts, date ranges and sizes of the tables",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withStorage(cmd.Context(), root, func(storage interfaces.Storage) error {
+				stats, err := storage.TableStats(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				return printResult(cmd, root.cfg.Format, stats, func(w io.Writer) error {
+					fmt.Fprintln(w, "TABLE\tROWS\tSIZE\tFROM\tTO\tUSERS")
+					for _, s := range stats {
+						from, to := "-", "-"
+						if s.From != nil {
+							from = s.From.Format(time.DateOnly)
+						}
+						if s.To != nil {
+							to = s.To.Format(time.DateOnly)
+						}
+						users := make([]string, 0, len(s.Users))
+						for _, user := range slices.Sorted(maps.Keys(s.Users)) {
+							users = append(users, fmt.Sprintf("%d:%d", user, s.Users[user]))
+						}
+						fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", s.Table, s.Rows, formatBytes(s.Size), from, to,
+							strings.Join(users, " "))
+					}
+					return nil
+				})
+			})
+		},
+	}
+}
+
+// settings lists the fields of cfg by their config keys in declaration order
+func settings(cmd *cobra.Command, cfg *config.Config) []setting {
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	result := make([]setting, 0, t.NumField())
+	for i := range t.NumField() {
+		key := t.Field(i).Tag.Get("mapstructure")
+		if key == "" {
+			continue
+		}
+		result = append(result, setting{
+			Key:    key,
+			Value:  redact(key, v.Field(i).Interface()),
+			Source: settingSource(cmd, key),
+		})
+	}
+	return result
+}
+
+// settingSource reports where viper took the value of key from, in viper's order of precedence
+func settingSource(cmd *cobra.Command, key string) string {
+	if name, ok := settingFlags[key]; ok {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			return "flag"
+		}
+	}
+	if value, ok := os.LookupEnv(strings.ToUpper(key)); ok && value != "" {
+		return "env"
+	}
+	if viper.InConfig(key) {
+		return "file"
+	}
+	return "default"
+}
+
+// redact hides the token and the password of the database connection string
+func redact(key string, value any) any {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return value
+	}
+	switch key {
+	case "token":
+		return redacted
+	case "db_config":
+		if u, err := url.Parse(s); err == nil && u.Scheme != "" {
+			if _, has := u.User.Password(); has {
+				u.User = url.UserPassword(u.User.Username(), redacted)
+			}
+			// passwords may also come as a query parameter
+			query := u.Query()
+			if query.Has("password") {
+				query.Set("password", redacted)
+				u.RawQuery = query.Encode()
+			}
+			return u.String()
+		}
+		return dsnPassword.ReplaceAllString(s, "${1}"+redacted)
+	}
+	return value
+}
+
+// formatBytes formats a size in bytes with a binary unit, e.g. 1.5 MiB
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
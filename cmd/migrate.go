@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/app"
+	"github.com/nemirlev/zenmoney-export/v2/internal/db"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/internal/migrate"
+	"github.com/nemirlev/zenmoney-export/v2/migrations"
+	"github.com/spf13/cobra"
+)
+
+func NewMigrateCommand(root *RootCommand) *cobra.Command {
+	opts := &config.MigrateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage database migrations",
+		Long: `Applies and reverts database migrations. Migrations are embedded into the binary,
+use --path to run migrations from a directory instead.`,
+		Annotations: map[string]string{skipAppAnnotation: "true"},
+	}
+
+	cmd.PersistentFlags().StringVar(&opts.Path, "path", "", "path to migration files (default: embedded migrations)")
+
+	cmd.AddCommand(
+		newMigrateUpCommand(root, opts),
+		newMigrateDownCommand(root, opts),
+		newMigrateStatusCommand(root, opts),
+		newMigrateVersionCommand(root, opts),
+		newMigrateForceCommand(root, opts),
+		newMigrateCreateCommand(root, opts),
+		newMigrateRepartitionCommand(root),
+	)
+
+	return cmd
+}
+
+func newMigrateUpCommand(root *RootCommand, opts *config.MigrateOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "up [N]",
+		Short: "Apply all or N up migrations",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := parseSteps(args)
+			if err != nil {
+				return err
+			}
+
+			return withRunner(cmd.Context(), root, opts, func(r *migrate.Runner) error {
+				if cmd.Flags().Changed("version") {
+					return noChange(r.UpTo(cmd.Context(), opts.Version))
+				}
+				return noChange(r.Up(cmd.Context(), n))
+			})
+		},
+	}
+
+	cmd.Flags().Uint64Var(&opts.Version, "version", 0, "target version to migrate up to")
+	return cmd
+}
+
+func newMigrateDownCommand(root *RootCommand, opts *config.MigrateOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "down [N]",
+		Short: "Apply all or N down migrations",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := parseSteps(args)
+			if err != nil {
+				return err
+			}
+			if n == 0 && !opts.All && !cmd.Flags().Changed("version") {
+				return errors.New("refusing to revert all migrations: pass N, --version or --all")
+			}
+
+			return withRunner(cmd.Context(), root, opts, func(r *migrate.Runner) error {
+				if cmd.Flags().Changed("version") {
+					return noChange(r.DownTo(cmd.Context(), opts.Version))
+				}
+				return noChange(r.Down(cmd.Context(), n))
+			})
+		},
+	}
+
+	cmd.Flags().Uint64Var(&opts.Version, "version", 0, "target version to migrate down to")
+	cmd.Flags().BoolVar(&opts.All, "all", false, "revert all migrations")
+	return cmd
+}
+
+func newMigrateStatusCommand(root *RootCommand, opts *config.MigrateOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show migration status",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withRunner(cmd.Context(), root, opts, func(r *migrate.Runner) error {
+				status, err := r.Status(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				return printResult(cmd, root.cfg.Format, status, func(w io.Writer) error {
+					fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+					for _, s := range status {
+						fmt.Fprintf(w, "%d\t%s\t%t\n", s.Version, s.Name, s.Applied)
+					}
+					return nil
+				})
+			})
+		},
+	}
+}
+
+func newMigrateVersionCommand(root *RootCommand, opts *config.MigrateOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Show current migration version",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withRunner(cmd.Context(), root, opts, func(r *migrate.Runner) error {
+				version, dirty, err := r.Version(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				result := struct {
+					Version uint64 `json:"version"`
+					Latest  uint64 `json:"latest"`
+					Dirty   bool   `json:"dirty"`
+				}{version, r.Latest(), dirty}
+
+				return printResult(cmd, root.cfg.Format, result, func(w io.Writer) error {
+					fmt.Fprintf(w, "version:\t%d\n", result.Version)
+					fmt.Fprintf(w, "latest:\t%d\n", result.Latest)
+					fmt.Fprintf(w, "dirty:\t%t\n", result.Dirty)
+					return nil
+				})
+			})
+		},
+	}
+}
+
+func newMigrateForceCommand(root *RootCommand, opts *config.MigrateOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "force VERSION",
+		Short: "Set migration version without running migrations and clear the dirty flag",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			version, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid version %q: %w", args[0], err)
+			}
+
+			return withRunner(cmd.Context(), root, opts, func(r *migrate.Runner) error {
+				return r.Force(cmd.Context(), version)
+			})
+		},
+	}
+}
+
+func newMigrateCreateCommand(root *RootCommand, opts *config.MigrateOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "create NAME",
+		Short: "Create a new migration file",
+		Long: `Creates a pair of empty up and down migration files with the next version.
+Files are created in --path, or in migrations/<db-type> by default.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := opts.Path
+			if dir == "" {
+				dir = filepath.Join("migrations", root.cfg.DBType)
+			}
+
+			up, down, err := migrate.Create(dir, args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), up)
+			fmt.Fprintln(cmd.OutOrStdout(), down)
+			return nil
+		},
+	}
+}
+
+func newMigrateRepartitionCommand(root *RootCommand) *cobra.Command {
+	return &cobra.Command{
+		Use:   "repartition",
+		Short: "Rebuild the partitioned tables in the configured partitioning",
+		Long: `Rebuilds transaction and reminder_marker when they are partitioned differently from the
+partitioning setting, keeping their rows. The other commands refuse to start until they match.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tables, err := app.Repartition(cmd.Context(), root.cfg)
+			if err != nil {
+				return err
+			}
+			if tables == nil {
+				tables = []string{}
+			}
+
+			return printResult(cmd, root.cfg.Format, tables, func(w io.Writer) error {
+				if len(tables) == 0 {
+					fmt.Fprintln(w, "The tables already match the partitioning")
+				}
+				for _, table := range tables {
+					fmt.Fprintf(w, "%s repartitioned by %q\n", table, root.cfg.Partitioning)
+				}
+				return nil
+			})
+		},
+	}
+}
+
+// withRunner creates a migration runner for the configured storage and closes it after fn returns
+func withRunner(
+	ctx context.Context,
+	root *RootCommand,
+	opts *config.MigrateOptions,
+	fn func(r *migrate.Runner) error,
+) error {
+	source, err := migrationSource(root.cfg.DBType, opts.Path)
+	if err != nil {
+		return err
+	}
+
+	driver, err := db.NewMigrationDriver(ctx, interfaces.StorageType(root.cfg.DBType), root.cfg.DBConfig,
+		root.cfg.StorageOptions()...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := driver.Close(ctx); err != nil {
+			slog.Error("failed to close migration driver", "error", err)
+		}
+	}()
+
+	runner, err := migrate.NewRunner(driver, source)
+	if err != nil {
+		return err
+	}
+
+	return fn(runner)
+}
+
+func migrationSource(dbType string, path string) (fs.FS, error) {
+	if path != "" {
+		return os.DirFS(path), nil
+	}
+	return migrations.Source(dbType)
+}
+
+func parseSteps(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid number of migrations: %s", args[0])
+	}
+	return n, nil
+}
+
+// noChange turns migrate.ErrNoChange into a log message
+func noChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		slog.Info("No migrations to apply")
+		return nil
+	}
+	return err
+}
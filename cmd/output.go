@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// printResult writes v as indented JSON or, for the text format, through the text callback
+func printResult(cmd *cobra.Command, format string, v any, text func(w io.Writer) error) error {
+	out := cmd.OutOrStdout()
+
+	switch format {
+	case "json", "":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "text":
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		if err := text(w); err != nil {
+			return err
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/spf13/cobra"
+)
+
+func NewPurgeCommand(root *RootCommand) *cobra.Command {
+	opts := &config.PurgeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Permanently remove soft deleted objects",
+		Long: `Permanently removes tombstones of objects deleted in ZenMoney (delete_mode: soft)
+that were deleted longer ago than the retention period.
+Tombstones still referenced by other objects are kept until those are purged.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{skipAppAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			retention, err := parseRetention(opts.OlderThan)
+			if err != nil {
+				return err
+			}
+			before := time.Now().Add(-retention)
+
+			return withStorage(cmd.Context(), root, func(storage interfaces.Storage) error {
+				purged, err := storage.PurgeDeleted(cmd.Context(), before)
+				if err != nil {
+					return err
+				}
+
+				return printResult(cmd, root.cfg.Format, purged, func(w io.Writer) error {
+					entities := make([]string, 0, len(purged))
+					for entity := range purged {
+						entities = append(entities, entity)
+					}
+					sort.Strings(entities)
+
+					fmt.Fprintln(w, "ENTITY\tPURGED")
+					for _, entity := range entities {
+						fmt.Fprintf(w, "%s\t%d\n", entity, purged[entity])
+					}
+					return nil
+				})
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.OlderThan, "older-than", "90d",
+		"retention period of tombstones, in days (90d) or as a duration (720h)")
+	return cmd
+}
+
+// parseRetention parses a retention period in days like 90d or a Go duration like 720h
+func parseRetention(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid retention period: %s", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid retention period: %s", s)
+	}
+	return d, nil
+}
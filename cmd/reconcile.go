@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/internal/reconcile"
+	"github.com/spf13/cobra"
+)
+
+func NewReconcileCommand(root *RootCommand) *cobra.Command {
+	opts := &config.ReconcileOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Compare account balances with the sum of their transactions",
+		Long: `Recomputes the balance of every account as its start balance plus the income less the outcome
+of its transactions and compares it with the balance ZenMoney reports. In the history mode it also looks
+for the first sync after which the balances differed and the earliest date of the transactions it changed.
+The command exits with an error if a balance diverged.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{skipAppAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withStorage(cmd.Context(), root, func(storage interfaces.Storage) error {
+				results, err := reconcile.Reconcile(cmd.Context(), storage, reconcile.Options{
+					Account: opts.Account,
+					History: root.cfg.History,
+				})
+				if err != nil {
+					return err
+				}
+
+				diverged := 0
+				for _, r := range results {
+					if r.Status == reconcile.StatusDiverged {
+						diverged++
+					}
+				}
+
+				err = printResult(cmd, root.cfg.Format, results, func(w io.Writer) error {
+					fmt.Fprintln(w, "ACCOUNT\tTYPE\tREPORTED\tCOMPUTED\tDIFFERENCE\tSTATUS\tDIVERGED\tNOTE")
+					for _, r := range results {
+						since := "-"
+						if d := r.DivergedAt; d != nil {
+							since = "sync " + fmt.Sprint(d.SyncID)
+							if d.Date != nil {
+								since = d.Date.Format(time.DateOnly) + " (" + since + ")"
+							}
+						}
+						fmt.Fprintf(w, "%s\t%s\t%.2f\t%.2f\t%.2f\t%s\t%s\t%s\n", r.Title, r.Type,
+							r.Reported, r.Computed, r.Difference, r.Status, since, r.Note)
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				if diverged > 0 {
+					cmd.SilenceUsage = true
+					return fmt.Errorf("%d of %d balances diverged", diverged, len(results))
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Account, "account", "", "account ID or title, all accounts if empty")
+	return cmd
+}
@@ -8,13 +8,19 @@ import (
 
 	"github.com/nemirlev/zenmoney-export/v2/config"
 	"github.com/nemirlev/zenmoney-export/v2/internal/app"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// skipAppAnnotation marks commands that work without a full Application
+// (storage connection and ZenMoney client), e.g. migrations
+const skipAppAnnotation = "zenexport/skip-app"
+
 type RootCommand struct {
 	cmd  *cobra.Command
 	app  *app.Application
+	cfg  *config.Config
 	opts *config.CommandOptions
 }
 
@@ -42,6 +48,8 @@ func (r *RootCommand) addFlags() {
 	flags.StringVar(&r.opts.Token, "token", "", "ZenMoney API token")
 	flags.StringVar(&r.opts.LogLevel, "log-level", "info", "log level (debug, info, warn, error)")
 	flags.StringVar(&r.opts.Format, "format", "json", "output format (text, json)")
+	flags.StringVar(&r.opts.DBType, "db-type", "", "database type (postgres)")
+	flags.StringVar(&r.opts.DBConfig, "db-url", "", "database connection URL")
 
 	err := viper.BindPFlag("token", flags.Lookup("token"))
 	if err != nil {
@@ -58,10 +66,33 @@ func (r *RootCommand) addFlags() {
 		slog.Error("failed to bind format flag", "error", err)
 		return
 	}
+	err = viper.BindPFlag("db_type", flags.Lookup("db-type"))
+	if err != nil {
+		slog.Error("failed to bind db-type flag", "error", err)
+		return
+	}
+	err = viper.BindPFlag("db_config", flags.Lookup("db-url"))
+	if err != nil {
+		slog.Error("failed to bind db-url flag", "error", err)
+		return
+	}
 }
 
 func (r *RootCommand) addCommands() {
 	r.cmd.AddCommand(NewSyncCommand(r))
+	r.cmd.AddCommand(NewMigrateCommand(r))
+	r.cmd.AddCommand(NewPurgeCommand(r))
+	r.cmd.AddCommand(NewSchemaCommand(r))
+	r.cmd.AddCommand(NewExportCommand(r))
+	r.cmd.AddCommand(NewBackupCommand(r))
+	r.cmd.AddCommand(NewRestoreCommand(r))
+	r.cmd.AddCommand(NewCopyCommand(r))
+	r.cmd.AddCommand(NewImportCommand(r))
+	r.cmd.AddCommand(NewCheckCommand(r))
+	r.cmd.AddCommand(NewInfoCommand(r))
+	r.cmd.AddCommand(NewAuditCommand(r))
+	r.cmd.AddCommand(NewReconcileCommand(r))
+	r.cmd.AddCommand(NewServeCommand(r))
 }
 
 func (r *RootCommand) preRun(cmd *cobra.Command, args []string) error {
@@ -73,6 +104,11 @@ func (r *RootCommand) preRun(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	r.cfg = cfg
+
+	if skipApp(cmd) {
+		return nil
+	}
 
 	ctx := context.Background()
 	application, err := app.NewApplication(ctx, cfg)
@@ -84,6 +120,32 @@ func (r *RootCommand) preRun(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// skipApp reports whether the command or any of its parents is marked with skipAppAnnotation
+func skipApp(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[skipAppAnnotation] == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// withStorage connects to the configured storage for commands marked with skipAppAnnotation
+// and closes it after fn returns
+func withStorage(ctx context.Context, root *RootCommand, fn func(storage interfaces.Storage) error) error {
+	storage, err := app.NewStorage(ctx, root.cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := storage.Close(ctx); err != nil {
+			slog.Error("failed to close storage", "error", err)
+		}
+	}()
+
+	return fn(storage)
+}
+
 func Execute() {
 	root := NewRootCommand()
 	if err := root.Execute(); err != nil {
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/spf13/cobra"
+)
+
+func NewSchemaCommand(root *RootCommand) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "schema",
+		Short:       "Inspect the storage schema",
+		Annotations: map[string]string{skipAppAnnotation: "true"},
+	}
+
+	cmd.AddCommand(newSchemaDriftCommand(root))
+
+	return cmd
+}
+
+func newSchemaDriftCommand(root *RootCommand) *cobra.Command {
+	return &cobra.Command{
+		Use:   "drift",
+		Short: "List payload fields without a column",
+		Long: `Lists keys of the raw payloads stored with every object which aren't mapped to columns,
+e.g. fields added to the ZenMoney API after the schema was written.
+Their values are kept in the raw column of the table.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withStorage(cmd.Context(), root, func(storage interfaces.Storage) error {
+				drift, err := storage.SchemaDrift(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				return printResult(cmd, root.cfg.Format, drift, func(w io.Writer) error {
+					if len(drift) == 0 {
+						fmt.Fprintln(w, "No schema drift")
+						return nil
+					}
+
+					fmt.Fprintln(w, "ENTITY\tKEY\tCOLUMN\tROWS")
+					for _, d := range drift {
+						fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", d.Entity, d.Key, d.Column, d.Rows)
+					}
+					return nil
+				})
+			})
+		},
+	}
+}
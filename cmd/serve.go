@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/app"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/internal/server"
+	"github.com/spf13/cobra"
+)
+
+func NewServeCommand(root *RootCommand) *cobra.Command {
+	opts := &config.ServeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the stored data over a read-only HTTP JSON API",
+		Long: `Serves list and get endpoints for every entity under /api/v1 and their OpenAPI document
+at /openapi.json. With --sync the sync daemon runs in the same process, so the API serves the data
+as it's synced. The API has no authentication, don't expose it beyond a trusted network.`,
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{skipAppAnnotation: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			if !opts.Sync {
+				return withStorage(cmd.Context(), root, func(storage interfaces.Storage) error {
+					return server.New(storage).Serve(ctx, opts.Listen)
+				})
+			}
+
+			if opts.Interval < 1 {
+				return fmt.Errorf("invalid interval: %d", opts.Interval)
+			}
+			application, err := app.NewApplication(cmd.Context(), root.cfg)
+			if err != nil {
+				return fmt.Errorf("failed to initialize application: %w", err)
+			}
+			defer func() {
+				if err := application.Storage().Close(cmd.Context()); err != nil {
+					slog.Error("failed to close storage", "error", err)
+				}
+			}()
+
+			syncCtx, cancel := context.WithCancel(ctx)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				err := application.SyncService.DaemonSync(syncCtx, &app.SyncParams{Entities: "all"}, opts.Interval)
+				if err != nil && !errors.Is(err, context.Canceled) {
+					slog.Error("sync daemon stopped", "error", err)
+				}
+			}()
+
+			err = server.New(application.Storage()).Serve(ctx, opts.Listen)
+			// let a sync in progress stop before the storage is closed
+			cancel()
+			<-done
+			return err
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Listen, "listen", ":8080", "address to listen on")
+	flags.BoolVar(&opts.Sync, "sync", false, "run the sync daemon in the same process")
+	flags.IntVar(&opts.Interval, "interval", 30, "sync interval in minutes, with --sync")
+	return cmd
+}
@@ -5,16 +5,48 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"regexp"
 
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
 	"github.com/spf13/viper"
 )
 
+// Schema version check modes on startup
+const (
+	MigrateModeCheck = "check"
+	MigrateModeAuto  = "auto"
+	MigrateModeOff   = "off"
+)
+
+// identPattern and prefixPattern restrict schema names and table prefixes to plain SQL identifiers
+var (
+	identPattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	prefixPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+)
+
 type Config struct {
 	DBType        string `mapstructure:"db_type"`
 	DBConfig      string `mapstructure:"db_config"`
 	ZenMoneyToken string `mapstructure:"token"`
 	LogLevel      string `mapstructure:"log_level"`
 	Format        string `mapstructure:"format"`
+	// MigrateMode controls the schema version check on startup:
+	// check (default) refuses to start on mismatch, auto applies pending migrations, off skips the check
+	MigrateMode string `mapstructure:"migrate_mode"`
+	// OrphanMode controls dangling references on sync:
+	// fail (default) aborts the sync, report logs them and saves the rest without the objects which add them
+	OrphanMode string `mapstructure:"orphan_mode"`
+	// DeleteMode controls objects deleted in ZenMoney:
+	// hard (default) removes the rows, soft keeps them as tombstones with deleted_at set
+	DeleteMode string `mapstructure:"delete_mode"`
+	// History keeps previous versions of synced objects in history tables
+	History bool `mapstructure:"history"`
+	// DBSchema is the database schema of the tables, the default schema of the connection if empty
+	DBSchema string `mapstructure:"db_schema"`
+	// DBTablePrefix is prepended to the names of all tables
+	DBTablePrefix string `mapstructure:"db_table_prefix"`
+	// Partitioning partitions transaction and reminder_marker by month or year of date, off if empty
+	Partitioning string `mapstructure:"partitioning"`
 }
 
 type CommandOptions struct {
@@ -36,6 +68,110 @@ type SyncOptions struct {
 	DryRun    bool
 }
 
+type PurgeOptions struct {
+	CommandOptions
+	OlderThan string
+}
+
+// EncryptOptions select the age recipients or the passphrase output is encrypted with.
+// The passphrase is read from a file or the environment, never from a flag.
+type EncryptOptions struct {
+	Encrypt        bool
+	Recipients     []string
+	RecipientsFile string
+	PassphraseFile string
+}
+
+// DecryptOptions select the age private keys or the passphrase encrypted input is decrypted with
+type DecryptOptions struct {
+	IdentityFile   string
+	PassphraseFile string
+}
+
+type BackupOptions struct {
+	CommandOptions
+	EncryptOptions
+	Output string
+}
+
+type RestoreOptions struct {
+	CommandOptions
+	DecryptOptions
+	Force      bool
+	VerifyOnly bool
+}
+
+type CopyOptions struct {
+	CommandOptions
+	FromType   string
+	FromConfig string
+	ToType     string
+	ToConfig   string
+	Force      bool
+}
+
+type CheckOptions struct {
+	CommandOptions
+	DBConnection bool
+	Write        bool
+	Migrations   bool
+	APIToken     bool
+}
+
+type AuditOptions struct {
+	CommandOptions
+	Fix bool
+}
+
+type InfoOptions struct {
+	CommandOptions
+	Limit int
+}
+
+type ReconcileOptions struct {
+	CommandOptions
+	Account string
+}
+
+type ServeOptions struct {
+	CommandOptions
+	Listen   string
+	Sync     bool
+	Interval int
+}
+
+type ImportOptions struct {
+	CommandOptions
+	Account     string
+	Format      string
+	Mapping     string
+	Window      int
+	StrictPayee bool
+	Create      bool
+	DryRun      bool
+}
+
+type ExportOptions struct {
+	CommandOptions
+	EncryptOptions
+	Format   string
+	Output   string
+	Entities string
+	From     string
+	To       string
+	Compress string
+	PageSize int
+	Mapping  string
+	Preset   string
+	Template string
+}
+type MigrateOptions struct {
+	CommandOptions
+	Path    string
+	Version uint64
+	All     bool
+}
+
 func NewConfigFromViper() (*Config, error) {
 	if err := initViper(); err != nil {
 		return nil, err
@@ -77,6 +213,41 @@ func initViper() error {
 		slog.Error("error binding env", "error", err)
 		return err
 	}
+	err = viper.BindEnv("migrate_mode", "MIGRATE_MODE")
+	if err != nil {
+		slog.Error("error binding env", "error", err)
+		return err
+	}
+	err = viper.BindEnv("orphan_mode", "ORPHAN_MODE")
+	if err != nil {
+		slog.Error("error binding env", "error", err)
+		return err
+	}
+	err = viper.BindEnv("delete_mode", "DELETE_MODE")
+	if err != nil {
+		slog.Error("error binding env", "error", err)
+		return err
+	}
+	err = viper.BindEnv("history", "HISTORY")
+	if err != nil {
+		slog.Error("error binding env", "error", err)
+		return err
+	}
+	err = viper.BindEnv("db_schema", "DB_SCHEMA")
+	if err != nil {
+		slog.Error("error binding env", "error", err)
+		return err
+	}
+	err = viper.BindEnv("db_table_prefix", "DB_TABLE_PREFIX")
+	if err != nil {
+		slog.Error("error binding env", "error", err)
+		return err
+	}
+	err = viper.BindEnv("partitioning", "PARTITIONING")
+	if err != nil {
+		slog.Error("error binding env", "error", err)
+		return err
+	}
 	viper.AutomaticEnv()
 
 	if err := viper.ReadInConfig(); err != nil {
@@ -94,9 +265,47 @@ func ValidateConfig(cfg *Config) error {
 	default:
 		return fmt.Errorf("invalid log level: %s", cfg.LogLevel)
 	}
+	switch cfg.MigrateMode {
+	case MigrateModeCheck, MigrateModeAuto, MigrateModeOff, "":
+	default:
+		return fmt.Errorf("invalid migrate mode: %s", cfg.MigrateMode)
+	}
+	switch interfaces.OrphanMode(cfg.OrphanMode) {
+	case interfaces.OrphanModeFail, interfaces.OrphanModeReport, "":
+	default:
+		return fmt.Errorf("invalid orphan mode: %s", cfg.OrphanMode)
+	}
+	switch interfaces.DeleteMode(cfg.DeleteMode) {
+	case interfaces.DeleteModeHard, interfaces.DeleteModeSoft, "":
+	default:
+		return fmt.Errorf("invalid delete mode: %s", cfg.DeleteMode)
+	}
+	if cfg.DBSchema != "" && !identPattern.MatchString(cfg.DBSchema) {
+		return fmt.Errorf("invalid db schema: %s", cfg.DBSchema)
+	}
+	if cfg.DBTablePrefix != "" && !prefixPattern.MatchString(cfg.DBTablePrefix) {
+		return fmt.Errorf("invalid db table prefix: %s", cfg.DBTablePrefix)
+	}
+	switch interfaces.Partitioning(cfg.Partitioning) {
+	case interfaces.PartitioningNone, interfaces.PartitioningMonth, interfaces.PartitioningYear:
+	default:
+		return fmt.Errorf("invalid partitioning: %s", cfg.Partitioning)
+	}
 	return nil
 }
 
+// StorageOptions returns the storage settings of the config
+func (c *Config) StorageOptions() []interfaces.StorageOption {
+	return []interfaces.StorageOption{
+		interfaces.WithOrphanMode(interfaces.OrphanMode(c.OrphanMode)),
+		interfaces.WithDeleteMode(interfaces.DeleteMode(c.DeleteMode)),
+		interfaces.WithHistory(c.History),
+		interfaces.WithSchema(c.DBSchema),
+		interfaces.WithTablePrefix(c.DBTablePrefix),
+		interfaces.WithPartitioning(interfaces.Partitioning(c.Partitioning)),
+	}
+}
+
 func NewLogger(cfg *Config) *slog.Logger {
 	level := slog.LevelInfo
 	switch cfg.LogLevel {
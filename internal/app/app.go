@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
 	"github.com/nemirlev/zenmoney-export/v2/config"
@@ -22,7 +23,7 @@ type Application struct {
 func NewApplication(ctx context.Context, cfg *config.Config) (*Application, error) {
 	logger := config.NewLogger(cfg)
 
-	storage, err := db.NewStorage(ctx, interfaces.StorageType(cfg.DBType), cfg.DBConfig)
+	storage, err := NewStorage(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -43,3 +44,35 @@ func NewApplication(ctx context.Context, cfg *config.Config) (*Application, erro
 
 	return app, nil
 }
+
+// Storage returns the storage the application syncs into
+func (a *Application) Storage() interfaces.Storage {
+	return a.db
+}
+
+// NewStorage checks the schema version and the partitioning and connects to the configured storage.
+// Commands which don't talk to ZenMoney use it instead of a full Application.
+func NewStorage(ctx context.Context, cfg *config.Config) (interfaces.Storage, error) {
+	if err := ensureSchema(ctx, cfg, config.NewLogger(cfg)); err != nil {
+		return nil, err
+	}
+
+	storage, err := db.NewStorage(ctx, interfaces.StorageType(cfg.DBType), cfg.DBConfig, cfg.StorageOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.CheckPartitioning(ctx); err != nil {
+		_ = storage.Close(ctx)
+		return nil, err
+	}
+	return storage, nil
+}
+
+// NewClient creates a ZenMoney API client with the configured token
+// for commands which talk to ZenMoney without a full Application
+func NewClient(cfg *config.Config) (*api.Client, error) {
+	if cfg.ZenMoneyToken == "" {
+		return nil, errors.New("no token configured, set --token or TOKEN")
+	}
+	return api.NewClient(cfg.ZenMoneyToken)
+}
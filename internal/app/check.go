@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/db"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/internal/migrate"
+	"github.com/nemirlev/zenmoney-export/v2/migrations"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/api"
+)
+
+// Names of the health checks
+const (
+	CheckDatabase   = "database"
+	CheckWrite      = "write"
+	CheckMigrations = "migrations"
+	CheckToken      = "token"
+)
+
+// CheckTimeout bounds every health check, so a hanging database or API fails the check
+const CheckTimeout = 15 * time.Second
+
+// CheckResult is the outcome of a health check
+type CheckResult struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Message string `json:"message"`
+	// Duration is the time the check took in milliseconds
+	Duration int64 `json:"duration_ms"`
+}
+
+// Check runs the named health checks in the order given, all of them if none are named.
+// Unlike NewApplication it connects without verifying the schema and reports every failure
+// instead of stopping at the first one; the write check needs the database check to pass.
+func Check(ctx context.Context, cfg *config.Config, checks []string) []CheckResult {
+	if len(checks) == 0 {
+		checks = []string{CheckDatabase, CheckWrite, CheckMigrations, CheckToken}
+	}
+
+	var storage interfaces.Storage
+	defer func() {
+		if storage != nil {
+			_ = storage.Close(ctx)
+		}
+	}()
+	connect := func(ctx context.Context) (interfaces.Storage, error) {
+		if storage == nil {
+			s, err := db.NewStorage(ctx, interfaces.StorageType(cfg.DBType), cfg.DBConfig, cfg.StorageOptions()...)
+			if err != nil {
+				return nil, err
+			}
+			storage = s
+		}
+		return storage, nil
+	}
+
+	results := make([]CheckResult, 0, len(checks))
+	databaseFailed := false
+	for _, name := range checks {
+		var fn func(ctx context.Context) (string, error)
+		switch name {
+		case CheckDatabase:
+			fn = func(ctx context.Context) (string, error) {
+				s, err := connect(ctx)
+				if err != nil {
+					return "", err
+				}
+				if err := s.Ping(ctx); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("connected to %s", cfg.DBType), nil
+			}
+		case CheckWrite:
+			fn = func(ctx context.Context) (string, error) {
+				if databaseFailed {
+					return "", errors.New("skipped, the database check failed")
+				}
+				s, err := connect(ctx)
+				if err != nil {
+					return "", err
+				}
+				if err := s.CheckWrite(ctx); err != nil {
+					return "", err
+				}
+				return "the database accepts writes", nil
+			}
+		case CheckMigrations:
+			fn = func(ctx context.Context) (string, error) { return checkMigrations(ctx, cfg) }
+		case CheckToken:
+			fn = func(ctx context.Context) (string, error) { return checkToken(ctx, cfg.ZenMoneyToken) }
+		default:
+			results = append(results, CheckResult{Name: name, Message: "unknown check"})
+			continue
+		}
+
+		started := time.Now()
+		checkCtx, cancel := context.WithTimeout(ctx, CheckTimeout)
+		message, err := fn(checkCtx)
+		cancel()
+
+		result := CheckResult{Name: name, OK: err == nil, Message: message, Duration: time.Since(started).Milliseconds()}
+		if err != nil {
+			result.Message = err.Error()
+			databaseFailed = databaseFailed || name == CheckDatabase
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// checkMigrations compares the schema version of the database with the embedded migrations
+func checkMigrations(ctx context.Context, cfg *config.Config) (string, error) {
+	source, err := migrations.Source(cfg.DBType)
+	if err != nil {
+		return "", err
+	}
+
+	driver, err := db.NewMigrationDriver(ctx, interfaces.StorageType(cfg.DBType), cfg.DBConfig,
+		cfg.StorageOptions()...)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = driver.Close(ctx)
+	}()
+
+	runner, err := migrate.NewRunner(driver, source)
+	if err != nil {
+		return "", err
+	}
+	if err := runner.Check(ctx); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("schema version %d is up to date", runner.Latest()), nil
+}
+
+// checkToken validates the token with a sync of the changes since now, which carries next to no data
+func checkToken(ctx context.Context, token string) (string, error) {
+	if token == "" {
+		return "", errors.New("no token configured, set --token or TOKEN")
+	}
+
+	client, err := api.NewClient(token, api.WithTimeout(CheckTimeout), api.WithRetryPolicy(0, 0))
+	if err != nil {
+		return "", err
+	}
+	response, err := client.SyncSince(ctx, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("ZenMoney API request failed: %w", err)
+	}
+	return fmt.Sprintf("token accepted, server time %s",
+		time.Unix(int64(response.ServerTimestamp), 0).UTC().Format(time.RFC3339)), nil
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/nemirlev/zenmoney-export/v2/config"
+	"github.com/nemirlev/zenmoney-export/v2/internal/db"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/internal/migrate"
+	"github.com/nemirlev/zenmoney-export/v2/migrations"
+)
+
+// ensureSchema verifies that the storage schema matches the embedded migrations.
+// In auto mode pending migrations are applied under the migration lock,
+// in check mode a mismatch stops the application before any sync starts.
+func ensureSchema(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
+	if cfg.MigrateMode == config.MigrateModeOff {
+		return nil
+	}
+
+	source, err := migrations.Source(cfg.DBType)
+	if err != nil {
+		return err
+	}
+
+	driver, err := db.NewMigrationDriver(ctx, interfaces.StorageType(cfg.DBType), cfg.DBConfig,
+		cfg.StorageOptions()...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := driver.Close(ctx); err != nil {
+			logger.Error("failed to close migration driver", "error", err)
+		}
+	}()
+
+	runner, err := migrate.NewRunner(driver, source)
+	if err != nil {
+		return err
+	}
+
+	if cfg.MigrateMode == config.MigrateModeAuto {
+		err = runner.Up(ctx, 0)
+		switch {
+		case errors.Is(err, migrate.ErrNoChange):
+			logger.Debug("Database schema is up to date", "version", runner.Latest())
+		case err != nil:
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		default:
+			logger.Info("Database schema migrated", "version", runner.Latest())
+		}
+	}
+
+	return runner.Check(ctx)
+}
+
+// Repartition migrates the schema and rebuilds the tables partitioned differently from the partitioning setting,
+// which NewStorage refuses to start with. It returns the names of the rebuilt tables.
+func Repartition(ctx context.Context, cfg *config.Config) ([]string, error) {
+	if err := ensureSchema(ctx, cfg, config.NewLogger(cfg)); err != nil {
+		return nil, err
+	}
+
+	storage, err := db.NewStorage(ctx, interfaces.StorageType(cfg.DBType), cfg.DBConfig, cfg.StorageOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = storage.Close(ctx)
+	}()
+
+	return storage.Repartition(ctx)
+}
@@ -62,12 +62,17 @@ func (s *SyncService) Sync(ctx context.Context, p *SyncParams) error {
 	return nil
 }
 
+// DaemonSync syncs every interval minutes until ctx is done
 func (s *SyncService) DaemonSync(ctx context.Context, p *SyncParams, interval int) error {
 	slog.Info("Running daemon sync", "interval_minutes", interval)
 	for {
 		if err := s.Sync(ctx, p); err != nil {
 			slog.Error("sync failed", "error", err)
 		}
-		time.Sleep(time.Duration(interval) * time.Minute)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(interval) * time.Minute):
+		}
 	}
 }
@@ -0,0 +1,208 @@
+// Package audit finds dangling references and duplicated rows in a storage
+// and fetches the objects missing from it from ZenMoney
+package audit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+)
+
+// Fetcher requests full lists of entities from ZenMoney, api.Client implements it
+type Fetcher interface {
+	ForceSyncEntities(ctx context.Context, entityTypes ...models.EntityType) (models.Response, error)
+}
+
+// Category counts the problems of one kind, e.g. transactions referring to missing merchants
+type Category struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Report lists the problems found in a storage
+type Report struct {
+	Categories []Category             `json:"categories"`
+	Orphans    []interfaces.Orphan    `json:"orphans"`
+	Duplicates []interfaces.Duplicate `json:"duplicates"`
+	// Fetched counts the missing objects Fix found in ZenMoney and saved, per entity
+	Fetched map[string]int `json:"fetched,omitempty"`
+}
+
+// Problems returns the number of dangling references and groups of rows with the same id
+func (r *Report) Problems() int {
+	return len(r.Orphans) + len(r.Duplicates) - r.Warnings()
+}
+
+// Warnings returns the number of groups of rows with the same content, which may be distinct objects
+// and don't count as problems
+func (r *Report) Warnings() int {
+	n := 0
+	for _, d := range r.Duplicates {
+		if d.Warning {
+			n++
+		}
+	}
+	return n
+}
+
+// entityTypes maps the entities references point to to the entity types of the API
+var entityTypes = map[string]models.EntityType{
+	"instrument":      models.EntityTypeInstrument,
+	"company":         models.EntityTypeCompany,
+	"user":            models.EntityTypeUser,
+	"account":         models.EntityTypeAccount,
+	"tag":             models.EntityTypeTag,
+	"merchant":        models.EntityTypeMerchant,
+	"reminder":        models.EntityTypeReminder,
+	"reminder_marker": models.EntityTypeReminderMarker,
+}
+
+// fetchOrder lists the entities in the order Save writes them, parents first
+var fetchOrder = []string{
+	"instrument", "company", "user", "account", "tag", "merchant", "reminder", "reminder_marker",
+}
+
+// Run scans the storage for dangling references and duplicates
+func Run(ctx context.Context, storage interfaces.Storage) (*Report, error) {
+	orphans, err := storage.FindOrphans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	duplicates, err := storage.FindDuplicates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Categories: []Category{},
+		Orphans:    orphans,
+		Duplicates: duplicates,
+	}
+	if report.Orphans == nil {
+		report.Orphans = []interfaces.Orphan{}
+	}
+	if report.Duplicates == nil {
+		report.Duplicates = []interfaces.Duplicate{}
+	}
+
+	index := make(map[string]int)
+	count := func(name string) {
+		i, ok := index[name]
+		if !ok {
+			i = len(report.Categories)
+			index[name] = i
+			report.Categories = append(report.Categories, Category{Name: name})
+		}
+		report.Categories[i].Count++
+	}
+	for _, o := range orphans {
+		count(fmt.Sprintf("%s.%s refers to a missing %s", o.Entity, o.Field, o.Reference))
+	}
+	for _, d := range duplicates {
+		if d.Warning {
+			count(fmt.Sprintf("%s possible duplicates: %s", d.Entity, d.Reason))
+		} else {
+			count(fmt.Sprintf("%s duplicates: %s", d.Entity, d.Reason))
+		}
+	}
+
+	return report, nil
+}
+
+// Fix fetches the entities the orphans refer to from ZenMoney and saves the missing objects among them,
+// parents first. Nothing else of the response is saved and no sync status is recorded, so the next
+// incremental sync continues where the last one stopped. Objects deleted in ZenMoney stay missing.
+// It returns the number of saved objects per entity.
+func Fix(ctx context.Context, storage interfaces.Storage, fetcher Fetcher, orphans []interfaces.Orphan) (map[string]int, error) {
+	missing := make(map[string]map[string]bool)
+	for _, o := range orphans {
+		if _, ok := entityTypes[o.Reference]; !ok {
+			continue
+		}
+		if missing[o.Reference] == nil {
+			missing[o.Reference] = make(map[string]bool)
+		}
+		missing[o.Reference][o.MissingID] = true
+	}
+
+	fetched := make(map[string]int)
+	if len(missing) == 0 {
+		return fetched, nil
+	}
+
+	var types []models.EntityType
+	for _, entity := range fetchOrder {
+		if missing[entity] != nil {
+			types = append(types, entityTypes[entity])
+		}
+	}
+	response, err := fetcher.ForceSyncEntities(ctx, types...)
+	if err != nil {
+		return fetched, fmt.Errorf("failed to fetch missing objects: %w", err)
+	}
+
+	steps := []func() error{
+		func() error {
+			return saveMissing(ctx, fetched, "instrument", missing, response.Instrument,
+				func(i models.Instrument) string { return strconv.Itoa(i.ID) }, storage.SaveInstruments)
+		},
+		func() error {
+			return saveMissing(ctx, fetched, "company", missing, response.Company,
+				func(c models.Company) string { return strconv.Itoa(c.ID) }, storage.SaveCompanies)
+		},
+		func() error {
+			return saveMissing(ctx, fetched, "user", missing, response.User,
+				func(u models.User) string { return strconv.Itoa(u.ID) }, storage.SaveUsers)
+		},
+		func() error {
+			return saveMissing(ctx, fetched, "account", missing, response.Account,
+				func(a models.Account) string { return a.ID }, storage.SaveAccounts)
+		},
+		func() error {
+			return saveMissing(ctx, fetched, "tag", missing, response.Tag,
+				func(t models.Tag) string { return t.ID }, storage.SaveTags)
+		},
+		func() error {
+			return saveMissing(ctx, fetched, "merchant", missing, response.Merchant,
+				func(m models.Merchant) string { return m.ID }, storage.SaveMerchants)
+		},
+		func() error {
+			return saveMissing(ctx, fetched, "reminder", missing, response.Reminder,
+				func(r models.Reminder) string { return r.ID }, storage.SaveReminders)
+		},
+		func() error {
+			return saveMissing(ctx, fetched, "reminder_marker", missing, response.ReminderMarker,
+				func(m models.ReminderMarker) string { return m.ID }, storage.SaveReminderMarkers)
+		},
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return fetched, err
+		}
+	}
+
+	return fetched, nil
+}
+
+// saveMissing saves the objects whose ids are missing from the entity and counts them in fetched
+func saveMissing[T any](ctx context.Context, fetched map[string]int, entity string, missing map[string]map[string]bool,
+	objects []T, id func(T) string, save func(context.Context, []T) error) error {
+	var found []T
+	for _, object := range objects {
+		if missing[entity][id(object)] {
+			found = append(found, object)
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	if err := save(ctx, found); err != nil {
+		return fmt.Errorf("failed to save missing %s: %w", entity, err)
+	}
+	fetched[entity] = len(found)
+	return nil
+}
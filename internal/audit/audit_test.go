@@ -0,0 +1,120 @@
+package audit_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/audit"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/mocks"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fetcher returns response and records the requested entity types
+type fetcher struct {
+	response models.Response
+	err      error
+	types    []models.EntityType
+}
+
+func (f *fetcher) ForceSyncEntities(_ context.Context, entityTypes ...models.EntityType) (models.Response, error) {
+	f.types = entityTypes
+	return f.response, f.err
+}
+
+var orphans = []interfaces.Orphan{
+	{Entity: "transaction", ID: "tx-1", Field: "merchant", Reference: "merchant", MissingID: "merchant-1"},
+	{Entity: "transaction", ID: "tx-2", Field: "merchant", Reference: "merchant", MissingID: "merchant-1"},
+	{Entity: "transaction", ID: "tx-3", Field: "tag", Reference: "tag", MissingID: "tag-9"},
+	{Entity: "account", ID: "acc-1", Field: "instrument", Reference: "instrument", MissingID: "5"},
+}
+
+func TestRun(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("FindOrphans", mock.Anything).Return(orphans, nil)
+	storage.On("FindDuplicates", mock.Anything).Return([]interfaces.Duplicate{
+		{Entity: "transaction", Reason: "same id", IDs: []string{"tx-4"}, Rows: 2},
+		{Entity: "transaction", Reason: "same user, date, accounts, amounts and payee", IDs: []string{"tx-5", "tx-6"}, Rows: 2,
+			Warning: true},
+	}, nil)
+
+	report, err := audit.Run(context.Background(), storage)
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Problems())
+	assert.Equal(t, 1, report.Warnings())
+	assert.Equal(t, []audit.Category{
+		{Name: "transaction.merchant refers to a missing merchant", Count: 2},
+		{Name: "transaction.tag refers to a missing tag", Count: 1},
+		{Name: "account.instrument refers to a missing instrument", Count: 1},
+		{Name: "transaction duplicates: same id", Count: 1},
+		{Name: "transaction possible duplicates: same user, date, accounts, amounts and payee", Count: 1},
+	}, report.Categories)
+}
+
+func TestRun_Clean(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("FindOrphans", mock.Anything).Return(nil, nil)
+	storage.On("FindDuplicates", mock.Anything).Return(nil, nil)
+
+	report, err := audit.Run(context.Background(), storage)
+	require.NoError(t, err)
+	assert.Zero(t, report.Problems())
+	assert.NotNil(t, report.Orphans)
+	assert.NotNil(t, report.Duplicates)
+}
+
+func TestRun_Error(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("FindOrphans", mock.Anything).Return(nil, errors.New("query error"))
+
+	_, err := audit.Run(context.Background(), storage)
+	assert.EqualError(t, err, "query error")
+}
+
+func TestFix(t *testing.T) {
+	f := &fetcher{response: models.Response{
+		Instrument: []models.Instrument{{ID: 1}, {ID: 5}},
+		Merchant:   []models.Merchant{{ID: "merchant-1"}, {ID: "merchant-2"}},
+		// tag-9 was deleted in ZenMoney
+		Tag: []models.Tag{{ID: "tag-1"}},
+	}}
+
+	var saved []string
+	storage := mocks.NewStorage(t)
+	storage.On("SaveInstruments", mock.Anything, []models.Instrument{{ID: 5}}).Return(nil).
+		Run(func(mock.Arguments) { saved = append(saved, "instrument") }).Once()
+	storage.On("SaveMerchants", mock.Anything, []models.Merchant{{ID: "merchant-1"}}).Return(nil).
+		Run(func(mock.Arguments) { saved = append(saved, "merchant") }).Once()
+
+	fetched, err := audit.Fix(context.Background(), storage, f, orphans)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"instrument": 1, "merchant": 1}, fetched)
+	assert.Equal(t, []string{"instrument", "merchant"}, saved)
+	assert.Equal(t, []models.EntityType{
+		models.EntityTypeInstrument, models.EntityTypeTag, models.EntityTypeMerchant,
+	}, f.types)
+}
+
+func TestFix_NoOrphans(t *testing.T) {
+	f := &fetcher{}
+	fetched, err := audit.Fix(context.Background(), mocks.NewStorage(t), f, nil)
+	require.NoError(t, err)
+	assert.Empty(t, fetched)
+	assert.Nil(t, f.types)
+}
+
+func TestFix_Errors(t *testing.T) {
+	f := &fetcher{err: errors.New("unauthorized")}
+	_, err := audit.Fix(context.Background(), mocks.NewStorage(t), f, orphans)
+	assert.ErrorContains(t, err, "failed to fetch missing objects: unauthorized")
+
+	f = &fetcher{response: models.Response{Merchant: []models.Merchant{{ID: "merchant-1"}}}}
+	storage := mocks.NewStorage(t)
+	storage.On("SaveMerchants", mock.Anything, mock.Anything).Return(errors.New("connection reset"))
+	_, err = audit.Fix(context.Background(), storage, f, orphans)
+	assert.ErrorContains(t, err, "failed to save missing merchant: connection reset")
+}
@@ -0,0 +1,221 @@
+// Package backup writes everything a storage holds into a portable archive and restores it
+// into another storage, possibly of another type, or copies it between storages directly
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/nemirlev/zenmoney-export/v2/internal/encrypt"
+	"github.com/nemirlev/zenmoney-export/v2/internal/export"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+)
+
+const (
+	// FormatVersion is the version of the archive layout, newer archives aren't restored
+	FormatVersion = 1
+	// DefaultPageSize is the number of objects read from or written to the storage at once
+	DefaultPageSize = 1000
+
+	manifestName        = "manifest.json"
+	syncStatusName      = "sync_status.ndjson"
+	deletionHistoryName = "deletion_history.ndjson"
+	importedRecordName  = "imported_record.ndjson"
+)
+
+// Manifest describes the files of an archive. It is the last file of the archive,
+// so the files can be written as they are read from the storage.
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Storage is the type of the storage the backup was taken from
+	Storage string `json:"storage"`
+	// ServerTimestamp is the ZenMoney server timestamp of the last sync, a restored storage syncs on from it
+	ServerTimestamp int64  `json:"server_timestamp"`
+	Files           []File `json:"files"`
+}
+
+// File is a newline-delimited JSON file of the archive
+type File struct {
+	Name string `json:"name"`
+	// Entity is the exported entity of the file, empty for sync_status, deletion_history and imported_record
+	Entity string `json:"entity,omitempty"`
+	Rows   int    `json:"rows"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// entityFile returns the archive file name of an entity
+func entityFile(entity string) string {
+	return "entities/" + entity + ".ndjson"
+}
+
+// Backup writes all entities, sync statuses, the deletion history and the imported bank statement records of the storage
+// into a zstd compressed tar archive. Soft deleted objects and history tables aren't included.
+func Backup(ctx context.Context, storage interfaces.Storage, storageType string, w io.Writer) (*Manifest, error) {
+	last, err := storage.GetLastSyncStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zstd compressor: %w", err)
+	}
+	tw := tar.NewWriter(zw)
+
+	manifest := &Manifest{
+		Version:         FormatVersion,
+		CreatedAt:       time.Now().UTC().Truncate(time.Second),
+		Storage:         storageType,
+		ServerTimestamp: last.ServerTimestamp,
+	}
+	b := &archive{tw: tw, manifest: manifest, created: manifest.CreatedAt}
+
+	exporter := export.NewExporter(storage, export.Options{Format: export.FormatJSON, PageSize: DefaultPageSize})
+	for _, entity := range export.Entities {
+		err := b.add(entityFile(entity.Name), entity.Name, func(w io.Writer) (int, error) {
+			return exporter.Export(ctx, entity, w)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = b.add(syncStatusName, "", func(w io.Writer) (int, error) {
+		return writePages(ctx, w, storage.ListSyncStatuses)
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = b.add(deletionHistoryName, "", func(w io.Writer) (int, error) {
+		return writePages(ctx, w, storage.ListDeletionHistory)
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = b.add(importedRecordName, "", func(w io.Writer) (int, error) {
+		return writePages(ctx, w, storage.ListImportedRecords)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := b.write(manifestName, int64(len(data)), bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress archive: %w", err)
+	}
+	return manifest, nil
+}
+
+// archive is an archive being written
+type archive struct {
+	tw       *tar.Writer
+	manifest *Manifest
+	created  time.Time
+}
+
+// add writes a file produced by fn into an encrypted temporary file, as tar needs the size ahead of
+// the content, and then into the archive
+func (a *archive) add(name, entity string, fn func(w io.Writer) (int, error)) error {
+	spool, err := encrypt.NewSpool("zenexport-backup-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = spool.Close()
+	}()
+
+	hash := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(spool, hash)}
+	rows, err := fn(counter)
+	if err != nil {
+		return err
+	}
+
+	r, err := spool.Reader()
+	if err != nil {
+		return err
+	}
+	if err := a.write(name, counter.n, r); err != nil {
+		return err
+	}
+
+	a.manifest.Files = append(a.manifest.Files, File{
+		Name:   name,
+		Entity: entity,
+		Rows:   rows,
+		Size:   counter.n,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	})
+	return nil
+}
+
+// write writes a file of the given size into the archive
+func (a *archive) write(name string, size int64, r io.Reader) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    size,
+		ModTime: a.created,
+		Format:  tar.FormatPAX,
+	}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := io.Copy(a.tw, r); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// writePages writes all objects listed page by page as newline-delimited JSON and returns their number
+func writePages[T any](ctx context.Context, w io.Writer, list func(context.Context, interfaces.Filter) ([]T, error)) (int, error) {
+	enc := json.NewEncoder(w)
+	count := 0
+	for page := 1; ; page++ {
+		objects, err := list(ctx, interfaces.Filter{Page: page, Limit: DefaultPageSize})
+		if err != nil {
+			return count, err
+		}
+		for _, object := range objects {
+			if err := enc.Encode(object); err != nil {
+				return count, fmt.Errorf("failed to encode: %w", err)
+			}
+			count++
+		}
+		if len(objects) < DefaultPageSize {
+			return count, nil
+		}
+	}
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,225 @@
+package backup_test
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"github.com/klauspost/compress/zstd"
+	"github.com/nemirlev/zenmoney-export/v2/internal/backup"
+	"github.com/nemirlev/zenmoney-export/v2/internal/encrypt"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/mocks"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	accounts = []models.Account{{ID: "acc-1", Title: "Wallet", Type: "cash"}}
+	tags     = []models.Tag{{ID: "tag-1", Title: "Food"}}
+	txs      = []models.Transaction{
+		{ID: "tx-1", Date: "2024-01-10", Outcome: 100, IncomeAccount: "acc-1", Tag: []string{"tag-1"}},
+	}
+	statuses = []interfaces.SyncStatus{
+		{ID: 1, SyncType: "full", ServerTimestamp: 100, Status: "completed"},
+		{ID: 2, SyncType: "incremental", ServerTimestamp: 200, Status: "completed"},
+	}
+	deletions = []interfaces.DeletionRecord{
+		{ID: 1, ObjectID: "acc-0", ObjectType: "account", UserID: 1,
+			DeletedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	imported = []interfaces.ImportedRecord{
+		{Account: "acc-1", ExternalID: "fit-1", Date: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), Amount: -100,
+			Source: "ofx", TransactionID: &txs[0].ID, ImportedAt: time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)},
+	}
+)
+
+// sourceStorage returns a storage with a few objects of every kind the archive holds
+func sourceStorage(t *testing.T) *mocks.Storage {
+	storage := mocks.NewStorage(t)
+	for _, method := range []string{
+		"ListInstruments", "ListCountries", "ListCompanies", "ListUsers", "ListMerchants",
+		"ListBudgets", "ListReminders", "ListReminderMarkers",
+	} {
+		storage.On(method, mock.Anything, mock.Anything).Return(nil, nil)
+	}
+	storage.On("ListAccounts", mock.Anything, mock.Anything).Return(accounts, nil)
+	storage.On("ListTags", mock.Anything, mock.Anything).Return(tags, nil)
+	storage.On("ListTransactions", mock.Anything, mock.Anything).Return(txs, nil)
+	storage.On("ListSyncStatuses", mock.Anything, mock.Anything).Return(statuses, nil)
+	storage.On("ListDeletionHistory", mock.Anything, mock.Anything).Return(deletions, nil)
+	storage.On("ListImportedRecords", mock.Anything, mock.Anything).Return(imported, nil)
+	return storage
+}
+
+func writeBackup(t *testing.T) (string, *backup.Manifest) {
+	path := filepath.Join(t.TempDir(), "backup.tar.zst")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	source := sourceStorage(t)
+	source.On("GetLastSyncStatus", mock.Anything).Return(statuses[1], nil)
+	manifest, err := backup.Backup(context.Background(), source, "postgres", f)
+	require.NoError(t, err)
+	return path, manifest
+}
+
+func TestBackupRestore(t *testing.T) {
+	path, manifest := writeBackup(t)
+	assert.Equal(t, backup.FormatVersion, manifest.Version)
+	assert.Equal(t, "postgres", manifest.Storage)
+	assert.Equal(t, int64(200), manifest.ServerTimestamp)
+
+	rows := make(map[string]int)
+	for _, file := range manifest.Files {
+		rows[file.Name] = file.Rows
+	}
+	assert.Equal(t, 1, rows["entities/accounts.ndjson"])
+	assert.Equal(t, 0, rows["entities/budgets.ndjson"])
+	assert.Equal(t, 2, rows["sync_status.ndjson"])
+	assert.Equal(t, 1, rows["deletion_history.ndjson"])
+	assert.Equal(t, 1, rows["imported_record.ndjson"])
+
+	verified, err := backup.Verify(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Files, verified.Files)
+
+	var calls []string
+	record := func(args mock.Arguments) { calls = append(calls, "save") }
+	target := mocks.NewStorage(t)
+	target.On("SaveAccounts", mock.Anything, accounts).Return(nil).Run(record).Once()
+	target.On("SaveTags", mock.Anything, tags).Return(nil).Run(record).Once()
+	target.On("SaveTransactions", mock.Anything, txs).Return(nil).Run(record).Once()
+	target.On("SaveDeletionHistory", mock.Anything, deletions).Return(nil).Run(record).Once()
+	target.On("SaveImportedRecords", mock.Anything, imported).Return(nil).Run(record).Once()
+	for _, status := range statuses {
+		target.On("SaveSyncStatus", mock.Anything, status).Return(nil).Run(func(args mock.Arguments) {
+			calls = append(calls, "status")
+		}).Once()
+	}
+
+	_, counts, err := backup.Restore(context.Background(), target, path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, counts["entities/transactions.ndjson"])
+	assert.Equal(t, 2, counts["sync_status.ndjson"])
+	assert.Equal(t, []string{"save", "save", "save", "save", "save", "status", "status"}, calls)
+}
+
+func TestRestore_ParentTagOnLaterPage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backup.tar.zst")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	source := tagStorage(t, tagPages())
+	source.On("GetLastSyncStatus", mock.Anything).Return(interfaces.SyncStatus{}, nil)
+	_, err = backup.Backup(context.Background(), source, "postgres", f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	target := mocks.NewStorage(t)
+	var batches [][]models.Tag
+	target.On("SaveTags", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		batches = append(batches, slices.Clone(args.Get(1).([]models.Tag)))
+	})
+
+	_, counts, err := backup.Restore(context.Background(), target, path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, backup.DefaultPageSize+1, counts["entities/tags.ndjson"])
+	assertParentsFirst(t, batches)
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	path, _ := writeBackup(t)
+
+	// rewrite the archive with a changed accounts file
+	src, err := os.Open(path)
+	require.NoError(t, err)
+	zr, err := zstd.NewReader(src)
+	require.NoError(t, err)
+
+	tampered := filepath.Join(t.TempDir(), "tampered.tar.zst")
+	dst, err := os.Create(tampered)
+	require.NoError(t, err)
+	zw, err := zstd.NewWriter(dst)
+	require.NoError(t, err)
+	tw := tar.NewWriter(zw)
+
+	tr := tar.NewReader(zr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		if header.Name == "entities/accounts.ndjson" {
+			data = []byte(`{"id":"acc-2"}` + "\n")
+			header.Size = int64(len(data))
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		_, err = tw.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, zw.Close())
+	require.NoError(t, dst.Close())
+	zr.Close()
+	require.NoError(t, src.Close())
+
+	_, err = backup.Verify(tampered, nil)
+	assert.ErrorContains(t, err, "checksum mismatch of entities/accounts.ndjson")
+
+	// restore verifies first and doesn't touch the storage
+	_, _, err = backup.Restore(context.Background(), mocks.NewStorage(t), tampered, nil)
+	assert.Error(t, err)
+}
+
+func TestVerify_NotAnArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "backup.tar.zst")
+	require.NoError(t, os.WriteFile(path, []byte("not a backup"), 0o644))
+
+	_, err := backup.Verify(path, nil)
+	assert.Error(t, err)
+}
+
+func TestVerify_Encrypted(t *testing.T) {
+	t.Setenv(encrypt.IdentityEnv, "")
+	t.Setenv(encrypt.PassphraseEnv, "")
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	encrypter, err := encrypt.NewEncrypter([]string{identity.Recipient().String()}, "", "")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "backup.tar.zst.age")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w, err := encrypter.Wrap(f)
+	require.NoError(t, err)
+	source := sourceStorage(t)
+	source.On("GetLastSyncStatus", mock.Anything).Return(statuses[1], nil)
+	manifest, err := backup.Backup(context.Background(), source, "postgres", w)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	_, err = backup.Verify(path, nil)
+	assert.ErrorContains(t, err, "the input is encrypted")
+
+	identityFile := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(identityFile, []byte(identity.String()), 0o600))
+	decrypter, err := encrypt.NewDecrypter(identityFile, "")
+	require.NoError(t, err)
+	verified, err := backup.Verify(path, decrypter)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Files, verified.Files)
+}
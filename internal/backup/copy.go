@@ -0,0 +1,182 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+)
+
+// Count is the number of objects of an entity copied from the source and found in the target
+type Count struct {
+	Entity string `json:"entity"`
+	Source int    `json:"source"`
+	Target int    `json:"target"`
+}
+
+// pipe copies the objects of an entity page by page and counts them in a storage
+type pipe struct {
+	entity string
+	copy   func(ctx context.Context, from, to interfaces.Storage) (int, error)
+	count  func(ctx context.Context, storage interfaces.Storage) (int, error)
+}
+
+// pipes copy the entities in the order of the archive files, referenced objects first.
+// The sync statuses come last, so the target syncs on from the copied server timestamp
+// only once all objects are in place.
+var pipes = []pipe{
+	newPipe("instruments", interfaces.Storage.ListInstruments, interfaces.Storage.SaveInstruments),
+	newPipe("countries", interfaces.Storage.ListCountries, interfaces.Storage.SaveCountries),
+	newPipe("companies", interfaces.Storage.ListCompanies, interfaces.Storage.SaveCompanies),
+	newPipe("users", interfaces.Storage.ListUsers, interfaces.Storage.SaveUsers),
+	newPipe("accounts", interfaces.Storage.ListAccounts, interfaces.Storage.SaveAccounts),
+	newOrderedPipe("tags", interfaces.Storage.ListTags, interfaces.Storage.SaveTags, parentTagsFirst),
+	newPipe("merchants", interfaces.Storage.ListMerchants, interfaces.Storage.SaveMerchants),
+	newPipe("budgets", interfaces.Storage.ListBudgets, interfaces.Storage.SaveBudgets),
+	newPipe("reminders", interfaces.Storage.ListReminders, interfaces.Storage.SaveReminders),
+	newPipe("reminder_markers", interfaces.Storage.ListReminderMarkers, interfaces.Storage.SaveReminderMarkers),
+	newPipe("transactions", interfaces.Storage.ListTransactions, interfaces.Storage.SaveTransactions),
+	newPipe("deletion_history", interfaces.Storage.ListDeletionHistory, interfaces.Storage.SaveDeletionHistory),
+	newPipe("imported_record", interfaces.Storage.ListImportedRecords, interfaces.Storage.SaveImportedRecords),
+	newPipe("sync_status", interfaces.Storage.ListSyncStatuses,
+		func(storage interfaces.Storage, ctx context.Context, statuses []interfaces.SyncStatus) error {
+			for _, status := range statuses {
+				if err := storage.SaveSyncStatus(ctx, status); err != nil {
+					return err
+				}
+			}
+			return nil
+		}),
+}
+
+// newPipe creates the pipe of an entity from its list and batch save methods
+func newPipe[T any](
+	entity string,
+	list func(interfaces.Storage, context.Context, interfaces.Filter) ([]T, error),
+	save func(interfaces.Storage, context.Context, []T) error,
+) pipe {
+	return newOrderedPipe(entity, list, save, nil)
+}
+
+// newOrderedPipe creates the pipe of an entity whose objects refer to each other. With order set
+// the objects are read at once and saved in its order, so no batch refers to a later one.
+func newOrderedPipe[T any](
+	entity string,
+	list func(interfaces.Storage, context.Context, interfaces.Filter) ([]T, error),
+	save func(interfaces.Storage, context.Context, []T) error,
+	order func([]T) []T,
+) pipe {
+	each := func(ctx context.Context, storage interfaces.Storage, fn func([]T) error) (int, error) {
+		count := 0
+		for page := 1; ; page++ {
+			objects, err := list(storage, ctx, interfaces.Filter{Page: page, Limit: DefaultPageSize})
+			if err != nil {
+				return count, fmt.Errorf("failed to list %s: %w", entity, err)
+			}
+			if len(objects) > 0 {
+				if err := fn(objects); err != nil {
+					return count, err
+				}
+			}
+			count += len(objects)
+			if len(objects) < DefaultPageSize {
+				return count, nil
+			}
+		}
+	}
+
+	return pipe{
+		entity: entity,
+		copy: func(ctx context.Context, from, to interfaces.Storage) (int, error) {
+			if order == nil {
+				return each(ctx, from, func(objects []T) error {
+					if err := save(to, ctx, objects); err != nil {
+						return fmt.Errorf("failed to save %s: %w", entity, err)
+					}
+					return nil
+				})
+			}
+
+			var all []T
+			n, err := each(ctx, from, func(objects []T) error {
+				all = append(all, objects...)
+				return nil
+			})
+			if err != nil {
+				return n, err
+			}
+			for objects := range slices.Chunk(order(all), DefaultPageSize) {
+				if err := save(to, ctx, objects); err != nil {
+					return n, fmt.Errorf("failed to save %s: %w", entity, err)
+				}
+			}
+			return n, nil
+		},
+		count: func(ctx context.Context, storage interfaces.Storage) (int, error) {
+			return each(ctx, storage, func([]T) error { return nil })
+		},
+	}
+}
+
+// parentTagsFirst orders tags so that every tag comes after its parent,
+// tags whose parent is missing keep their place
+func parentTagsFirst(tags []models.Tag) []models.Tag {
+	byID := make(map[string]models.Tag, len(tags))
+	for _, tag := range tags {
+		byID[tag.ID] = tag
+	}
+
+	ordered := make([]models.Tag, 0, len(tags))
+	added := make(map[string]bool, len(tags))
+	var add func(tag models.Tag)
+	add = func(tag models.Tag) {
+		if added[tag.ID] {
+			return
+		}
+		added[tag.ID] = true
+		if tag.Parent != nil {
+			if parent, ok := byID[*tag.Parent]; ok {
+				add(parent)
+			}
+		}
+		ordered = append(ordered, tag)
+	}
+	for _, tag := range tags {
+		add(tag)
+	}
+	return ordered
+}
+
+// Copy streams every entity, the deletion history and the sync statuses from one storage into another
+// with the batch save methods, then counts the objects in the target. Soft deleted objects and history
+// tables aren't copied. A target holding other objects than the copied ones fails the verification,
+// the counts are returned along with its error.
+func Copy(ctx context.Context, from, to interfaces.Storage) ([]Count, error) {
+	counts := make([]Count, 0, len(pipes))
+	for _, p := range pipes {
+		n, err := p.copy(ctx, from, to)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, Count{Entity: p.entity, Source: n})
+	}
+
+	var errs []error
+	for i, p := range pipes {
+		n, err := p.count(ctx, to)
+		if err != nil {
+			return nil, err
+		}
+		counts[i].Target = n
+		if n != counts[i].Source {
+			errs = append(errs, fmt.Errorf("%s: copied %d, the target has %d", p.entity, counts[i].Source, n))
+		}
+	}
+	if len(errs) > 0 {
+		return counts, fmt.Errorf("copy verification failed: %w", errors.Join(errs...))
+	}
+	return counts, nil
+}
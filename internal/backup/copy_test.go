@@ -0,0 +1,134 @@
+package backup_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/backup"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/mocks"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// targetStorage expects the objects of sourceStorage to be saved and lists what was saved
+func targetStorage(t *testing.T, extraTags []models.Tag) *mocks.Storage {
+	storage := mocks.NewStorage(t)
+	for _, method := range []string{
+		"ListInstruments", "ListCountries", "ListCompanies", "ListUsers", "ListMerchants",
+		"ListBudgets", "ListReminders", "ListReminderMarkers",
+	} {
+		storage.On(method, mock.Anything, mock.Anything).Return(nil, nil).Once()
+	}
+	storage.On("SaveAccounts", mock.Anything, accounts).Return(nil).Once()
+	storage.On("SaveTags", mock.Anything, tags).Return(nil).Once()
+	storage.On("SaveTransactions", mock.Anything, txs).Return(nil).Once()
+	storage.On("SaveDeletionHistory", mock.Anything, deletions).Return(nil).Once()
+	storage.On("SaveImportedRecords", mock.Anything, imported).Return(nil).Once()
+	for _, status := range statuses {
+		storage.On("SaveSyncStatus", mock.Anything, status).Return(nil).Once()
+	}
+
+	storage.On("ListAccounts", mock.Anything, mock.Anything).Return(accounts, nil).Once()
+	storage.On("ListTags", mock.Anything, mock.Anything).Return(append(tags, extraTags...), nil).Once()
+	storage.On("ListTransactions", mock.Anything, mock.Anything).Return(txs, nil).Once()
+	storage.On("ListSyncStatuses", mock.Anything, mock.Anything).Return(statuses, nil).Once()
+	storage.On("ListDeletionHistory", mock.Anything, mock.Anything).Return(deletions, nil).Once()
+	storage.On("ListImportedRecords", mock.Anything, mock.Anything).Return(imported, nil).Once()
+	return storage
+}
+
+func TestCopy(t *testing.T) {
+	source := sourceStorage(t)
+	counts, err := backup.Copy(context.Background(), source, targetStorage(t, nil))
+	require.NoError(t, err)
+
+	assert.Len(t, counts, 14)
+	assert.Equal(t, backup.Count{Entity: "accounts", Source: 1, Target: 1}, counts[4])
+	assert.Equal(t, backup.Count{Entity: "sync_status", Source: 2, Target: 2}, counts[13])
+	source.AssertNotCalled(t, "SaveAccounts", mock.Anything, mock.Anything)
+}
+
+func TestCopy_CountMismatch(t *testing.T) {
+	target := targetStorage(t, []models.Tag{{ID: "tag-2", Title: "Cafe"}})
+	counts, err := backup.Copy(context.Background(), sourceStorage(t), target)
+	assert.ErrorContains(t, err, "tags: copied 1, the target has 2")
+	assert.Equal(t, backup.Count{Entity: "tags", Source: 1, Target: 2}, counts[5])
+}
+
+func TestCopy_SaveError(t *testing.T) {
+	target := mocks.NewStorage(t)
+	target.On("SaveAccounts", mock.Anything, mock.Anything).Return(assert.AnError)
+
+	source := mocks.NewStorage(t)
+	for _, method := range []string{"ListInstruments", "ListCountries", "ListCompanies", "ListUsers"} {
+		source.On(method, mock.Anything, mock.Anything).Return(nil, nil)
+	}
+	source.On("ListAccounts", mock.Anything, interfaces.Filter{Page: 1, Limit: backup.DefaultPageSize}).
+		Return(accounts, nil)
+
+	counts, err := backup.Copy(context.Background(), source, target)
+	assert.ErrorContains(t, err, "failed to save accounts")
+	assert.Nil(t, counts)
+}
+
+// tagPages are two pages of tags, the parent of the first tag is on the second page
+func tagPages() [][]models.Tag {
+	first := make([]models.Tag, backup.DefaultPageSize)
+	for i := range first {
+		first[i] = models.Tag{ID: fmt.Sprintf("tag-%d", i), Title: "Tag"}
+	}
+	first[0].Parent = new("parent")
+	return [][]models.Tag{first, {{ID: "parent", Title: "Parent"}}}
+}
+
+// tagStorage returns a storage listing the tag pages and no other objects
+func tagStorage(t *testing.T, pages [][]models.Tag) *mocks.Storage {
+	storage := mocks.NewStorage(t)
+	for _, method := range []string{
+		"ListInstruments", "ListCountries", "ListCompanies", "ListUsers", "ListAccounts", "ListMerchants",
+		"ListBudgets", "ListReminders", "ListReminderMarkers", "ListTransactions",
+		"ListDeletionHistory", "ListImportedRecords", "ListSyncStatuses",
+	} {
+		storage.On(method, mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	}
+	for i, page := range pages {
+		storage.On("ListTags", mock.Anything, mock.MatchedBy(func(filter interfaces.Filter) bool {
+			return filter.Page == i+1
+		})).Return(page, nil).Maybe()
+	}
+	return storage
+}
+
+// assertParentsFirst checks that the saved batches hold every tag and save the parent before its child
+func assertParentsFirst(t *testing.T, batches [][]models.Tag) {
+	t.Helper()
+	require.Len(t, batches, 2)
+	assert.Len(t, batches[0], backup.DefaultPageSize)
+	assert.Len(t, batches[1], 1)
+
+	position := make(map[string]int)
+	for _, tag := range slices.Concat(batches...) {
+		position[tag.ID] = len(position)
+	}
+	assert.Len(t, position, backup.DefaultPageSize+1)
+	assert.Less(t, position["parent"], position["tag-0"])
+}
+
+func TestCopy_ParentTagOnLaterPage(t *testing.T) {
+	pages := tagPages()
+	target := tagStorage(t, pages)
+	var batches [][]models.Tag
+	target.On("SaveTags", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		batches = append(batches, slices.Clone(args.Get(1).([]models.Tag)))
+	})
+
+	counts, err := backup.Copy(context.Background(), tagStorage(t, pages), target)
+	require.NoError(t, err)
+	assert.Equal(t, backup.Count{Entity: "tags", Source: backup.DefaultPageSize + 1, Target: backup.DefaultPageSize + 1}, counts[5])
+	assertParentsFirst(t, batches)
+}
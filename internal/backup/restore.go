@@ -0,0 +1,263 @@
+package backup
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/nemirlev/zenmoney-export/v2/internal/encrypt"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+)
+
+// loadFunc saves the objects of a newline-delimited JSON file in batches and returns their number
+type loadFunc func(ctx context.Context, storage interfaces.Storage, r io.Reader) (int, error)
+
+// loaders restore the files of an archive. Entity files come in export order,
+// which saves referenced objects before the objects referring to them, and tags are saved parents first.
+var loaders = map[string]loadFunc{
+	entityFile("instruments"):      loader(interfaces.Storage.SaveInstruments),
+	entityFile("countries"):        loader(interfaces.Storage.SaveCountries),
+	entityFile("companies"):        loader(interfaces.Storage.SaveCompanies),
+	entityFile("users"):            loader(interfaces.Storage.SaveUsers),
+	entityFile("accounts"):         loader(interfaces.Storage.SaveAccounts),
+	entityFile("tags"):             orderedLoader(interfaces.Storage.SaveTags, parentTagsFirst),
+	entityFile("merchants"):        loader(interfaces.Storage.SaveMerchants),
+	entityFile("budgets"):          loader(interfaces.Storage.SaveBudgets),
+	entityFile("reminders"):        loader(interfaces.Storage.SaveReminders),
+	entityFile("reminder_markers"): loader(interfaces.Storage.SaveReminderMarkers),
+	entityFile("transactions"):     loader(interfaces.Storage.SaveTransactions),
+	syncStatusName: loader(func(storage interfaces.Storage, ctx context.Context, statuses []interfaces.SyncStatus) error {
+		for _, status := range statuses {
+			if err := storage.SaveSyncStatus(ctx, status); err != nil {
+				return err
+			}
+		}
+		return nil
+	}),
+	deletionHistoryName: loader(interfaces.Storage.SaveDeletionHistory),
+	importedRecordName:  loader(interfaces.Storage.SaveImportedRecords),
+}
+
+// loader decodes objects of type T and saves them with save
+func loader[T any](save func(interfaces.Storage, context.Context, []T) error) loadFunc {
+	return func(ctx context.Context, storage interfaces.Storage, r io.Reader) (int, error) {
+		dec := json.NewDecoder(r)
+		batch := make([]T, 0, DefaultPageSize)
+		count := 0
+
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			if err := save(storage, ctx, batch); err != nil {
+				return err
+			}
+			count += len(batch)
+			batch = batch[:0]
+			return nil
+		}
+
+		for {
+			var object T
+			if err := dec.Decode(&object); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return count, fmt.Errorf("failed to decode: %w", err)
+			}
+			batch = append(batch, object)
+			if len(batch) == DefaultPageSize {
+				if err := flush(); err != nil {
+					return count, err
+				}
+			}
+		}
+		return count, flush()
+	}
+}
+
+// orderedLoader decodes all objects of type T and saves them in batches in the order of order,
+// for entities whose objects refer to each other, e.g. tags to their parents
+func orderedLoader[T any](save func(interfaces.Storage, context.Context, []T) error, order func([]T) []T) loadFunc {
+	return func(ctx context.Context, storage interfaces.Storage, r io.Reader) (int, error) {
+		dec := json.NewDecoder(r)
+		var objects []T
+		for {
+			var object T
+			if err := dec.Decode(&object); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return 0, fmt.Errorf("failed to decode: %w", err)
+			}
+			objects = append(objects, object)
+		}
+
+		count := 0
+		for batch := range slices.Chunk(order(objects), DefaultPageSize) {
+			if err := save(storage, ctx, batch); err != nil {
+				return count, err
+			}
+			count += len(batch)
+		}
+		return count, nil
+	}
+}
+
+// Verify checks that the archive at path is complete: every file listed in the manifest is present
+// with its size, checksum and number of rows, and no other files are. An encrypted archive is
+// decrypted with dec, which may be nil for archives in the clear.
+func Verify(path string, dec *encrypt.Decrypter) (*Manifest, error) {
+	var manifest *Manifest
+	found := make(map[string]File)
+
+	err := readArchive(path, dec, func(name string, r io.Reader) error {
+		if name == manifestName {
+			var err error
+			manifest, err = readManifest(r)
+			return err
+		}
+
+		hash := sha256.New()
+		counter := &countingWriter{w: hash}
+		rows := 0
+		scanner := bufio.NewScanner(io.TeeReader(r, counter))
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		for scanner.Scan() {
+			if len(bytes.TrimSpace(scanner.Bytes())) > 0 {
+				rows++
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		found[name] = File{Name: name, Rows: rows, Size: counter.n, SHA256: hex.EncodeToString(hash.Sum(nil))}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, errors.New("invalid backup: manifest.json is missing")
+	}
+
+	for _, file := range manifest.Files {
+		got, ok := found[file.Name]
+		if !ok {
+			return nil, fmt.Errorf("invalid backup: %s is missing", file.Name)
+		}
+		if got.SHA256 != file.SHA256 || got.Size != file.Size {
+			return nil, fmt.Errorf("invalid backup: checksum mismatch of %s", file.Name)
+		}
+		if got.Rows != file.Rows {
+			return nil, fmt.Errorf("invalid backup: %s has %d rows, the manifest lists %d", file.Name, got.Rows, file.Rows)
+		}
+		if _, ok := loaders[file.Name]; !ok {
+			return nil, fmt.Errorf("invalid backup: unknown file %s", file.Name)
+		}
+		delete(found, file.Name)
+	}
+	for _, file := range found {
+		return nil, fmt.Errorf("invalid backup: %s isn't listed in the manifest", file.Name)
+	}
+	return manifest, nil
+}
+
+// Restore verifies the archive at path and saves its contents into the storage.
+// The sync statuses are restored last, so a sync continues from the saved server timestamp
+// only once all objects are in place. It returns the manifest and the number of restored rows per file.
+func Restore(ctx context.Context, storage interfaces.Storage, path string, dec *encrypt.Decrypter) (*Manifest, map[string]int, error) {
+	manifest, err := Verify(path, dec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	counts := make(map[string]int, len(manifest.Files))
+	var statuses []byte
+	err = readArchive(path, dec, func(name string, r io.Reader) error {
+		switch name {
+		case manifestName:
+			return nil
+		case syncStatusName:
+			var err error
+			statuses, err = io.ReadAll(r)
+			return err
+		}
+
+		n, err := loaders[name](ctx, storage, r)
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		counts[name] = n
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n, err := loaders[syncStatusName](ctx, storage, bytes.NewReader(statuses))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to restore %s: %w", syncStatusName, err)
+	}
+	counts[syncStatusName] = n
+
+	return manifest, counts, nil
+}
+
+// readManifest decodes a manifest and checks its version
+func readManifest(r io.Reader) (*Manifest, error) {
+	var manifest Manifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("invalid backup: failed to decode manifest: %w", err)
+	}
+	if manifest.Version < 1 || manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("unsupported backup version %d, this zenexport reads up to version %d",
+			manifest.Version, FormatVersion)
+	}
+	return &manifest, nil
+}
+
+// readArchive calls fn with every file of the archive at path in archive order
+func readArchive(path string, dec *encrypt.Decrypter, fn func(name string, r io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer f.Close()
+
+	r, err := dec.Reader(f)
+	if err != nil {
+		return err
+	}
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid backup: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(header.Name, tr); err != nil {
+			return err
+		}
+	}
+}
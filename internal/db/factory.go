@@ -42,10 +42,11 @@ func NewStorage(
 	ctx context.Context,
 	storageType interfaces.StorageType,
 	connectionString string,
+	opts ...interfaces.StorageOption,
 ) (interfaces.Storage, error) {
 	switch storageType {
 	case interfaces.PostgresStorage:
-		return postgres.NewPostgresStorage(connectionString)
+		return postgres.NewPostgresStorage(connectionString, opts...)
 	// case MySQLStorage:
 	//	return NewMySQLStorage(connectionString)
 	// case MongoStorage:
@@ -60,3 +61,18 @@ func NewStorage(
 		return nil, fmt.Errorf("unsupported storage type: %s", storageType)
 	}
 }
+
+// NewMigrationDriver creates a migration driver for the storage of the specified type
+func NewMigrationDriver(
+	ctx context.Context,
+	storageType interfaces.StorageType,
+	connectionString string,
+	opts ...interfaces.StorageOption,
+) (interfaces.MigrationDriver, error) {
+	switch storageType {
+	case interfaces.PostgresStorage:
+		return postgres.NewMigrationDriver(ctx, connectionString, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported storage type: %s", storageType)
+	}
+}
@@ -20,8 +20,8 @@ func (s *DB) GetAccount(ctx context.Context, id string) (*models.Account, error)
                start_date, capitalization, percent, changed, sync_id,
                enable_sms, end_date_offset, end_date_offset_interval,
                payoff_step, payoff_interval
-        FROM account
-        WHERE id = $1`
+        FROM ` + s.table("account") + `
+        WHERE id = $1 AND deleted_at IS NULL`
 
 	account := &models.Account{}
 	err := s.pool.QueryRow(ctx, query, id).Scan(
@@ -44,7 +44,7 @@ func (s *DB) GetAccount(ctx context.Context, id string) (*models.Account, error)
 		&account.StartDate,
 		&account.Capitalization,
 		&account.Percent,
-		&account.Changed,
+		scanUnix(&account.Changed),
 		&account.SyncID,
 		&account.EnableSMS,
 		&account.EndDateOffset,
@@ -54,7 +54,7 @@ func (s *DB) GetAccount(ctx context.Context, id string) (*models.Account, error)
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("account not found: %s", id)
+			return nil, fmt.Errorf("account %w: %s", interfaces.ErrNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get account: %w", err)
 	}
@@ -75,6 +75,9 @@ func (s *DB) ListAccounts(ctx context.Context, filter interfaces.Filter) ([]mode
 		argNum++
 	}
 
+	from, args := s.source("account", filter, args)
+	argNum = len(args) + 1
+
 	query := `
         SELECT id, "user", instrument, type, role, private, savings,
                title, in_balance, credit_limit, start_balance, balance,
@@ -82,13 +85,16 @@ func (s *DB) ListAccounts(ctx context.Context, filter interfaces.Filter) ([]mode
                start_date, capitalization, percent, changed, sync_id,
                enable_sms, end_date_offset, end_date_offset_interval,
                payoff_step, payoff_interval
-        FROM account`
+        FROM ` + from
+
+	conditions = notDeleted(conditions, filter)
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
 	// Add pagination
+	query += ` ORDER BY id`
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argNum, argNum+1)
 	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
 
@@ -121,7 +127,7 @@ func (s *DB) ListAccounts(ctx context.Context, filter interfaces.Filter) ([]mode
 			&account.StartDate,
 			&account.Capitalization,
 			&account.Percent,
-			&account.Changed,
+			scanUnix(&account.Changed),
 			&account.SyncID,
 			&account.EnableSMS,
 			&account.EndDateOffset,
@@ -145,7 +151,7 @@ func (s *DB) ListAccounts(ctx context.Context, filter interfaces.Filter) ([]mode
 // CreateAccount creates a new account record
 func (s *DB) CreateAccount(ctx context.Context, account *models.Account) error {
 	query := `
-        INSERT INTO account (
+        INSERT INTO ` + s.table("account") + ` (
             id, "user", instrument, type, role, private, savings,
             title, in_balance, credit_limit, start_balance, balance,
             company, archive, enable_correction, balance_correction_type,
@@ -175,7 +181,7 @@ func (s *DB) CreateAccount(ctx context.Context, account *models.Account) error {
 		account.StartDate,
 		account.Capitalization,
 		account.Percent,
-		account.Changed,
+		unixTime(account.Changed),
 		account.SyncID,
 		account.EnableSMS,
 		account.EndDateOffset,
@@ -193,7 +199,7 @@ func (s *DB) CreateAccount(ctx context.Context, account *models.Account) error {
 // UpdateAccount updates an existing account record
 func (s *DB) UpdateAccount(ctx context.Context, account *models.Account) error {
 	query := `
-        UPDATE account SET
+        UPDATE ` + s.table("account") + ` SET
             "user" = $2,
             instrument = $3,
             type = $4,
@@ -241,7 +247,7 @@ func (s *DB) UpdateAccount(ctx context.Context, account *models.Account) error {
 		account.StartDate,
 		account.Capitalization,
 		account.Percent,
-		account.Changed,
+		unixTime(account.Changed),
 		account.SyncID,
 		account.EnableSMS,
 		account.EndDateOffset,
@@ -254,7 +260,7 @@ func (s *DB) UpdateAccount(ctx context.Context, account *models.Account) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("account not found: %s", account.ID)
+		return fmt.Errorf("account %w: %s", interfaces.ErrNotFound, account.ID)
 	}
 
 	return nil
@@ -262,7 +268,7 @@ func (s *DB) UpdateAccount(ctx context.Context, account *models.Account) error {
 
 // DeleteAccount deletes an account by its ID
 func (s *DB) DeleteAccount(ctx context.Context, id string) error {
-	query := `DELETE FROM account WHERE id = $1`
+	query := s.deleteQuery("account", `id = $1`, "now()")
 
 	commandTag, err := s.pool.Exec(ctx, query, id)
 	if err != nil {
@@ -270,7 +276,7 @@ func (s *DB) DeleteAccount(ctx context.Context, id string) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("account not found: %s", id)
+		return fmt.Errorf("account %w: %s", interfaces.ErrNotFound, id)
 	}
 
 	return nil
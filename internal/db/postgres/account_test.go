@@ -175,7 +175,7 @@ func TestCreateAccount_Success(t *testing.T) {
 			account.Balance, account.Company, account.Archive,
 			account.EnableCorrection, account.BalanceCorrectionType,
 			account.StartDate, account.Capitalization, account.Percent,
-			account.Changed, account.SyncID, account.EnableSMS,
+			unixTime(account.Changed), account.SyncID, account.EnableSMS,
 			account.EndDateOffset, account.EndDateOffsetInterval,
 			account.PayoffStep, account.PayoffInterval,
 		).
@@ -210,7 +210,7 @@ func TestCreateAccount_QueryError(t *testing.T) {
 			account.Balance, account.Company, account.Archive,
 			account.EnableCorrection, account.BalanceCorrectionType,
 			account.StartDate, account.Capitalization, account.Percent,
-			account.Changed, account.SyncID, account.EnableSMS,
+			unixTime(account.Changed), account.SyncID, account.EnableSMS,
 			account.EndDateOffset, account.EndDateOffsetInterval,
 			account.PayoffStep, account.PayoffInterval,
 		).
@@ -243,7 +243,7 @@ func TestUpdateAccount_Success(t *testing.T) {
 			account.Balance, account.Company, account.Archive,
 			account.EnableCorrection, account.BalanceCorrectionType,
 			account.StartDate, account.Capitalization, account.Percent,
-			account.Changed, account.SyncID, account.EnableSMS,
+			unixTime(account.Changed), account.SyncID, account.EnableSMS,
 			account.EndDateOffset, account.EndDateOffsetInterval,
 			account.PayoffStep, account.PayoffInterval,
 		).
@@ -275,7 +275,7 @@ func TestUpdateAccount_NotFound(t *testing.T) {
 			account.Balance, account.Company, account.Archive,
 			account.EnableCorrection, account.BalanceCorrectionType,
 			account.StartDate, account.Capitalization, account.Percent,
-			account.Changed, account.SyncID, account.EnableSMS,
+			unixTime(account.Changed), account.SyncID, account.EnableSMS,
 			account.EndDateOffset, account.EndDateOffsetInterval,
 			account.PayoffStep, account.PayoffInterval,
 		).
@@ -16,17 +16,24 @@ func (s *DB) SaveInstruments(ctx context.Context, instruments []models.Instrumen
 	}
 
 	query := `
-        INSERT INTO instrument (id, title, short_title, symbol, rate, changed)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO ` + s.table("instrument") + ` (id, title, short_title, symbol, rate, changed, raw)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
         ON CONFLICT (id) DO UPDATE SET
             title = EXCLUDED.title,
             short_title = EXCLUDED.short_title,
             symbol = EXCLUDED.symbol,
             rate = EXCLUDED.rate,
-            changed = EXCLUDED.changed`
+            changed = EXCLUDED.changed,
+            raw = EXCLUDED.raw,
+            deleted_at = NULL`
+
+	payloads, err := rawPayloads(instruments)
+	if err != nil {
+		return err
+	}
 
 	batch := &pgx.Batch{}
-	for _, inst := range instruments {
+	for i, inst := range instruments {
 		batch.Queue(
 			query,
 			inst.ID,
@@ -34,7 +41,8 @@ func (s *DB) SaveInstruments(ctx context.Context, instruments []models.Instrumen
 			inst.ShortTitle,
 			inst.Symbol,
 			inst.Rate,
-			inst.Changed,
+			unixTime(inst.Changed),
+			payloads[i],
 		)
 	}
 
@@ -58,16 +66,23 @@ func (s *DB) SaveCountries(ctx context.Context, countries []models.Country) erro
 	}
 
 	query := `
-        INSERT INTO country (id, title, currency, domain)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO ` + s.table("country") + ` (id, title, currency, domain, raw)
+        VALUES ($1, $2, $3, $4, $5)
         ON CONFLICT (id) DO UPDATE SET
             title = EXCLUDED.title,
             currency = EXCLUDED.currency,
-            domain = EXCLUDED.domain`
+            domain = EXCLUDED.domain,
+            raw = EXCLUDED.raw,
+            deleted_at = NULL`
+
+	payloads, err := rawPayloads(countries)
+	if err != nil {
+		return err
+	}
 
 	batch := &pgx.Batch{}
-	for _, country := range countries {
-		batch.Queue(query, country.ID, country.Title, country.Currency, country.Domain)
+	for i, country := range countries {
+		batch.Queue(query, country.ID, country.Title, country.Currency, country.Domain, payloads[i])
 	}
 
 	br := s.pool.SendBatch(ctx, batch)
@@ -90,10 +105,10 @@ func (s *DB) SaveCompanies(ctx context.Context, companies []models.Company) erro
 	}
 
 	query := `
-        INSERT INTO company (
+        INSERT INTO ` + s.table("company") + ` (
             id, title, full_title, www, country, deleted,
-            country_code, changed
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+            country_code, changed, raw
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
         ON CONFLICT (id) DO UPDATE SET
             title = EXCLUDED.title,
             full_title = EXCLUDED.full_title,
@@ -101,14 +116,22 @@ func (s *DB) SaveCompanies(ctx context.Context, companies []models.Company) erro
             country = EXCLUDED.country,
             deleted = EXCLUDED.deleted,
             country_code = EXCLUDED.country_code,
-            changed = EXCLUDED.changed`
+            changed = EXCLUDED.changed,
+            raw = EXCLUDED.raw,
+            deleted_at = NULL`
+
+	payloads, err := rawPayloads(companies)
+	if err != nil {
+		return err
+	}
 
 	batch := &pgx.Batch{}
-	for _, company := range companies {
+	for i, company := range companies {
 		batch.Queue(query,
 			company.ID, company.Title, company.FullTitle,
 			company.Www, company.Country, company.Deleted,
-			company.CountryCode, company.Changed,
+			company.CountryCode, unixTime(company.Changed),
+			payloads[i],
 		)
 	}
 
@@ -132,12 +155,12 @@ func (s *DB) SaveUsers(ctx context.Context, users []models.User) error {
 	}
 
 	query := `
-        INSERT INTO "user" (
+        INSERT INTO ` + s.table("user") + ` (
             id, country, login, parent, country_code, email,
             changed, currency, paid_till, month_start_day,
             is_forecast_enabled, plan_balance_mode, plan_settings,
-            subscription, subscription_renewal_date
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+            subscription, subscription_renewal_date, raw
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
         ON CONFLICT (id) DO UPDATE SET
             country = EXCLUDED.country,
             login = EXCLUDED.login,
@@ -152,17 +175,25 @@ func (s *DB) SaveUsers(ctx context.Context, users []models.User) error {
             plan_balance_mode = EXCLUDED.plan_balance_mode,
             plan_settings = EXCLUDED.plan_settings,
             subscription = EXCLUDED.subscription,
-            subscription_renewal_date = EXCLUDED.subscription_renewal_date`
+            subscription_renewal_date = EXCLUDED.subscription_renewal_date,
+            raw = EXCLUDED.raw,
+            deleted_at = NULL`
+
+	payloads, err := rawPayloads(users)
+	if err != nil {
+		return err
+	}
 
 	batch := &pgx.Batch{}
-	for _, user := range users {
+	for i, user := range users {
 		batch.Queue(query,
 			user.ID, user.Country, user.Login, user.Parent,
-			user.CountryCode, user.Email, user.Changed,
+			user.CountryCode, user.Email, unixTime(user.Changed),
 			user.Currency, user.PaidTill, user.MonthStartDay,
 			user.IsForecastEnabled, user.PlanBalanceMode,
 			user.PlanSettings, user.Subscription,
 			user.SubscriptionRenewalDate,
+			payloads[i],
 		)
 	}
 
@@ -185,15 +216,15 @@ func (s *DB) SaveAccounts(ctx context.Context, accounts []models.Account) error
 	}
 
 	query := `
-        INSERT INTO account (
+        INSERT INTO ` + s.table("account") + ` (
             id, "user", instrument, type, role, private, savings,
             title, in_balance, credit_limit, start_balance, balance,
             company, archive, enable_correction, balance_correction_type,
             start_date, capitalization, percent, changed, sync_id,
             enable_sms, end_date_offset, end_date_offset_interval,
-            payoff_step, payoff_interval
+            payoff_step, payoff_interval, raw
         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
-                  $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26)
+                  $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27)
         ON CONFLICT (id) DO UPDATE SET
             "user" = EXCLUDED.user,
             instrument = EXCLUDED.instrument,
@@ -219,10 +250,17 @@ func (s *DB) SaveAccounts(ctx context.Context, accounts []models.Account) error
             end_date_offset = EXCLUDED.end_date_offset,
             end_date_offset_interval = EXCLUDED.end_date_offset_interval,
             payoff_step = EXCLUDED.payoff_step,
-            payoff_interval = EXCLUDED.payoff_interval`
+            payoff_interval = EXCLUDED.payoff_interval,
+            raw = EXCLUDED.raw,
+            deleted_at = NULL`
+
+	payloads, err := rawPayloads(accounts)
+	if err != nil {
+		return err
+	}
 
 	batch := &pgx.Batch{}
-	for _, account := range accounts {
+	for i, account := range accounts {
 		batch.Queue(query,
 			account.ID,
 			account.User,
@@ -243,13 +281,14 @@ func (s *DB) SaveAccounts(ctx context.Context, accounts []models.Account) error
 			account.StartDate,
 			account.Capitalization,
 			account.Percent,
-			account.Changed,
+			unixTime(account.Changed),
 			account.SyncID,
 			account.EnableSMS,
 			account.EndDateOffset,
 			account.EndDateOffsetInterval,
 			account.PayoffStep,
 			account.PayoffInterval,
+			payloads[i],
 		)
 	}
 
@@ -272,11 +311,11 @@ func (s *DB) SaveTags(ctx context.Context, tags []models.Tag) error {
 	}
 
 	query := `
-        INSERT INTO tag (
+        INSERT INTO ` + s.table("tag") + ` (
             id, "user", changed, icon, budget_income, budget_outcome,
             required, color, picture, title, show_income, show_outcome,
-            parent, static_id
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+            parent, static_id, raw
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
         ON CONFLICT (id) DO UPDATE SET
             "user" = EXCLUDED.user,
             changed = EXCLUDED.changed,
@@ -290,14 +329,21 @@ func (s *DB) SaveTags(ctx context.Context, tags []models.Tag) error {
             show_income = EXCLUDED.show_income,
             show_outcome = EXCLUDED.show_outcome,
             parent = EXCLUDED.parent,
-            static_id = EXCLUDED.static_id`
+            static_id = EXCLUDED.static_id,
+            raw = EXCLUDED.raw,
+            deleted_at = NULL`
+
+	payloads, err := rawPayloads(tags)
+	if err != nil {
+		return err
+	}
 
 	batch := &pgx.Batch{}
-	for _, tag := range tags {
+	for i, tag := range tags {
 		batch.Queue(query,
 			tag.ID,
 			tag.User,
-			tag.Changed,
+			unixTime(tag.Changed),
 			tag.Icon,
 			tag.BudgetIncome,
 			tag.BudgetOutcome,
@@ -309,6 +355,7 @@ func (s *DB) SaveTags(ctx context.Context, tags []models.Tag) error {
 			tag.ShowOutcome,
 			tag.Parent,
 			tag.StaticID,
+			payloads[i],
 		)
 	}
 
@@ -331,20 +378,28 @@ func (s *DB) SaveMerchants(ctx context.Context, merchants []models.Merchant) err
 	}
 
 	query := `
-        INSERT INTO merchant (id, "user", title, changed)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO ` + s.table("merchant") + ` (id, "user", title, changed, raw)
+        VALUES ($1, $2, $3, $4, $5)
         ON CONFLICT (id) DO UPDATE SET
             "user" = EXCLUDED.user,
             title = EXCLUDED.title,
-            changed = EXCLUDED.changed`
+            changed = EXCLUDED.changed,
+            raw = EXCLUDED.raw,
+            deleted_at = NULL`
+
+	payloads, err := rawPayloads(merchants)
+	if err != nil {
+		return err
+	}
 
 	batch := &pgx.Batch{}
-	for _, merchant := range merchants {
+	for i, merchant := range merchants {
 		batch.Queue(query,
 			merchant.ID,
 			merchant.User,
 			merchant.Title,
-			merchant.Changed,
+			unixTime(merchant.Changed),
+			payloads[i],
 		)
 	}
 
@@ -361,22 +416,38 @@ func (s *DB) SaveMerchants(ctx context.Context, merchants []models.Merchant) err
 }
 
 // SaveBudgets saves a batch of budgets to the database
+// It performs an upsert operation: budgets are identified by user, tag and date
 func (s *DB) SaveBudgets(ctx context.Context, budgets []models.Budget) error {
 	if len(budgets) == 0 {
 		return nil
 	}
 
 	query := `
-        INSERT INTO budget (
+        INSERT INTO ` + s.table("budget") + ` (
             "user", changed, date, tag, income, outcome,
-            income_lock, outcome_lock, is_income_forecast, is_outcome_forecast
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+            income_lock, outcome_lock, is_income_forecast, is_outcome_forecast, raw
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        ON CONFLICT ("user", tag, date) DO UPDATE SET
+            changed = EXCLUDED.changed,
+            income = EXCLUDED.income,
+            outcome = EXCLUDED.outcome,
+            income_lock = EXCLUDED.income_lock,
+            outcome_lock = EXCLUDED.outcome_lock,
+            is_income_forecast = EXCLUDED.is_income_forecast,
+            is_outcome_forecast = EXCLUDED.is_outcome_forecast,
+            raw = EXCLUDED.raw,
+            deleted_at = NULL`
+
+	payloads, err := rawPayloads(budgets)
+	if err != nil {
+		return err
+	}
 
 	batch := &pgx.Batch{}
-	for _, budget := range budgets {
+	for i, budget := range budgets {
 		batch.Queue(query,
 			budget.User,
-			budget.Changed,
+			unixTime(budget.Changed),
 			budget.Date,
 			budget.Tag,
 			budget.Income,
@@ -385,6 +456,7 @@ func (s *DB) SaveBudgets(ctx context.Context, budgets []models.Budget) error {
 			budget.OutcomeLock,
 			budget.IsIncomeForecast,
 			budget.IsOutcomeForecast,
+			payloads[i],
 		)
 	}
 
@@ -407,13 +479,13 @@ func (s *DB) SaveReminders(ctx context.Context, reminders []models.Reminder) err
 	}
 
 	query := `
-        INSERT INTO reminder (
+        INSERT INTO ` + s.table("reminder") + ` (
             id, "user", income, outcome, changed, income_instrument,
             outcome_instrument, step, points, tag, start_date, end_date,
             notify, interval, income_account, outcome_account, comment,
-            payee, merchant
+            payee, merchant, raw
         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
-                  $14, $15, $16, $17, $18, $19)
+                  $14, $15, $16, $17, $18, $19, $20)
         ON CONFLICT (id) DO UPDATE SET
             "user" = EXCLUDED.user,
             income = EXCLUDED.income,
@@ -432,16 +504,23 @@ func (s *DB) SaveReminders(ctx context.Context, reminders []models.Reminder) err
             outcome_account = EXCLUDED.outcome_account,
             comment = EXCLUDED.comment,
             payee = EXCLUDED.payee,
-            merchant = EXCLUDED.merchant`
+            merchant = EXCLUDED.merchant,
+            raw = EXCLUDED.raw,
+            deleted_at = NULL`
+
+	payloads, err := rawPayloads(reminders)
+	if err != nil {
+		return err
+	}
 
 	batch := &pgx.Batch{}
-	for _, reminder := range reminders {
+	for i, reminder := range reminders {
 		batch.Queue(query,
 			reminder.ID,
 			reminder.User,
 			reminder.Income,
 			reminder.Outcome,
-			reminder.Changed,
+			unixTime(reminder.Changed),
 			reminder.IncomeInstrument,
 			reminder.OutcomeInstrument,
 			reminder.Step,
@@ -456,6 +535,7 @@ func (s *DB) SaveReminders(ctx context.Context, reminders []models.Reminder) err
 			reminder.Comment,
 			reminder.Payee,
 			reminder.Merchant,
+			payloads[i],
 		)
 	}
 
@@ -477,15 +557,24 @@ func (s *DB) SaveReminderMarkers(ctx context.Context, markers []models.ReminderM
 		return nil
 	}
 
-	query := `
-        INSERT INTO reminder_marker (
+	dates := make([]string, 0, len(markers))
+	for _, marker := range markers {
+		dates = append(dates, marker.Date)
+	}
+	if err := s.ensurePartitions(ctx, "reminder_marker", dates); err != nil {
+		return err
+	}
+
+	prefix, target := s.upsertKey("reminder_marker")
+	query := prefix + `
+        INSERT INTO ` + s.table("reminder_marker") + ` (
             id, "user", date, income, outcome, changed,
             income_instrument, outcome_instrument, state, is_forecast,
             reminder, income_account, outcome_account, comment,
-            payee, merchant, notify, tag
+            payee, merchant, notify, tag, raw
         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
-                  $14, $15, $16, $17, $18)
-        ON CONFLICT (id) DO UPDATE SET
+                  $14, $15, $16, $17, $18, $19)
+        ON CONFLICT ` + target + ` DO UPDATE SET
             "user" = EXCLUDED.user,
             date = EXCLUDED.date,
             income = EXCLUDED.income,
@@ -502,17 +591,24 @@ func (s *DB) SaveReminderMarkers(ctx context.Context, markers []models.ReminderM
             payee = EXCLUDED.payee,
             merchant = EXCLUDED.merchant,
             notify = EXCLUDED.notify,
-            tag = EXCLUDED.tag`
+            tag = EXCLUDED.tag,
+            raw = EXCLUDED.raw,
+            deleted_at = NULL`
+
+	payloads, err := rawPayloads(markers)
+	if err != nil {
+		return err
+	}
 
 	batch := &pgx.Batch{}
-	for _, marker := range markers {
+	for i, marker := range markers {
 		batch.Queue(query,
 			marker.ID,
 			marker.User,
 			marker.Date,
 			marker.Income,
 			marker.Outcome,
-			marker.Changed,
+			unixTime(marker.Changed),
 			marker.IncomeInstrument,
 			marker.OutcomeInstrument,
 			marker.State,
@@ -525,6 +621,7 @@ func (s *DB) SaveReminderMarkers(ctx context.Context, markers []models.ReminderM
 			marker.Merchant,
 			marker.Notify,
 			marker.Tag,
+			payloads[i],
 		)
 	}
 
@@ -546,18 +643,27 @@ func (s *DB) SaveTransactions(ctx context.Context, transactions []models.Transac
 		return nil
 	}
 
-	query := `
-       INSERT INTO transaction (
+	dates := make([]string, 0, len(transactions))
+	for _, tx := range transactions {
+		dates = append(dates, tx.Date)
+	}
+	if err := s.ensurePartitions(ctx, "transaction", dates); err != nil {
+		return err
+	}
+
+	prefix, target := s.upsertKey("transaction")
+	query := prefix + `
+       INSERT INTO ` + s.table("transaction") + ` (
            id, "user", date, income, outcome, changed, income_instrument,
            outcome_instrument, created, original_payee, deleted, viewed,
            hold, qr_code, source, income_account, outcome_account, tag,
            comment, payee, op_income, op_outcome, op_income_instrument,
            op_outcome_instrument, latitude, longitude, merchant,
-           income_bank_id, outcome_bank_id, reminder_marker
+           income_bank_id, outcome_bank_id, reminder_marker, raw
        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
                  $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24,
-                 $25, $26, $27, $28, $29, $30)
-       ON CONFLICT (id) DO UPDATE SET
+                 $25, $26, $27, $28, $29, $30, $31)
+       ON CONFLICT ` + target + ` DO UPDATE SET
            "user" = EXCLUDED.user,
            date = EXCLUDED.date,
            income = EXCLUDED.income,
@@ -586,20 +692,27 @@ func (s *DB) SaveTransactions(ctx context.Context, transactions []models.Transac
            merchant = EXCLUDED.merchant,
            income_bank_id = EXCLUDED.income_bank_id,
            outcome_bank_id = EXCLUDED.outcome_bank_id,
-           reminder_marker = EXCLUDED.reminder_marker`
+           reminder_marker = EXCLUDED.reminder_marker,
+           raw = EXCLUDED.raw,
+           deleted_at = NULL`
+
+	payloads, err := rawPayloads(transactions)
+	if err != nil {
+		return err
+	}
 
 	batch := &pgx.Batch{}
-	for _, tx := range transactions {
+	for i, tx := range transactions {
 		batch.Queue(query,
 			tx.ID,
 			tx.User,
 			tx.Date,
 			tx.Income,
 			tx.Outcome,
-			tx.Changed,
+			unixTime(tx.Changed),
 			tx.IncomeInstrument,
 			tx.OutcomeInstrument,
-			tx.Created,
+			unixTime(tx.Created),
 			tx.OriginalPayee,
 			tx.Deleted,
 			tx.Viewed,
@@ -621,6 +734,7 @@ func (s *DB) SaveTransactions(ctx context.Context, transactions []models.Transac
 			tx.IncomeBankID,
 			tx.OutcomeBankID,
 			tx.ReminderMarker,
+			payloads[i],
 		)
 	}
 
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
@@ -22,7 +23,7 @@ func TestSaveInstruments_Success(t *testing.T) {
 
 	batch := mock.ExpectBatch()
 	batch.ExpectExec("INSERT INTO instrument").
-		WithArgs(instruments[0].ID, instruments[0].Title, instruments[0].ShortTitle, instruments[0].Symbol, instruments[0].Rate, instruments[0].Changed).
+		WithArgs(instruments[0].ID, instruments[0].Title, instruments[0].ShortTitle, instruments[0].Symbol, instruments[0].Rate, unixTime(instruments[0].Changed), mustJSON(t, instruments[0])).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = db.SaveInstruments(context.Background(), instruments)
@@ -43,7 +44,7 @@ func TestSaveCountries_Success(t *testing.T) {
 
 	batch := mock.ExpectBatch()
 	batch.ExpectExec("INSERT INTO country").
-		WithArgs(countries[0].ID, countries[0].Title, countries[0].Currency, countries[0].Domain).
+		WithArgs(countries[0].ID, countries[0].Title, countries[0].Currency, countries[0].Domain, mustJSON(t, countries[0])).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = db.SaveCountries(context.Background(), countries)
@@ -73,7 +74,7 @@ func TestSaveCompanies_Success(t *testing.T) {
 
 	batch := mock.ExpectBatch()
 	batch.ExpectExec("INSERT INTO company").
-		WithArgs(companies[0].ID, companies[0].Title, companies[0].FullTitle, companies[0].Www, companies[0].Country, companies[0].Deleted, companies[0].CountryCode, companies[0].Changed).
+		WithArgs(companies[0].ID, companies[0].Title, companies[0].FullTitle, companies[0].Www, companies[0].Country, companies[0].Deleted, companies[0].CountryCode, unixTime(companies[0].Changed), mustJSON(t, companies[0])).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = db.SaveCompanies(context.Background(), companies)
@@ -94,7 +95,7 @@ func TestSaveUsers_Success(t *testing.T) {
 
 	batch := mock.ExpectBatch()
 	batch.ExpectExec("INSERT INTO \"user\"").
-		WithArgs(users[0].ID, users[0].Country, users[0].Login, users[0].Parent, users[0].CountryCode, users[0].Email, users[0].Changed, users[0].Currency, users[0].PaidTill, users[0].MonthStartDay, users[0].IsForecastEnabled, users[0].PlanBalanceMode, users[0].PlanSettings, users[0].Subscription, users[0].SubscriptionRenewalDate).
+		WithArgs(users[0].ID, users[0].Country, users[0].Login, users[0].Parent, users[0].CountryCode, users[0].Email, unixTime(users[0].Changed), users[0].Currency, users[0].PaidTill, users[0].MonthStartDay, users[0].IsForecastEnabled, users[0].PlanBalanceMode, users[0].PlanSettings, users[0].Subscription, users[0].SubscriptionRenewalDate, mustJSON(t, users[0])).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = db.SaveUsers(context.Background(), users)
@@ -115,7 +116,7 @@ func TestSaveAccounts_Success(t *testing.T) {
 
 	batch := mock.ExpectBatch()
 	batch.ExpectExec("INSERT INTO account").
-		WithArgs(accounts[0].ID, accounts[0].User, accounts[0].Instrument, accounts[0].Type, accounts[0].Role, accounts[0].Private, accounts[0].Savings, accounts[0].Title, accounts[0].InBalance, accounts[0].CreditLimit, accounts[0].StartBalance, accounts[0].Balance, accounts[0].Company, accounts[0].Archive, accounts[0].EnableCorrection, accounts[0].BalanceCorrectionType, accounts[0].StartDate, accounts[0].Capitalization, accounts[0].Percent, accounts[0].Changed, accounts[0].SyncID, accounts[0].EnableSMS, accounts[0].EndDateOffset, accounts[0].EndDateOffsetInterval, accounts[0].PayoffStep, accounts[0].PayoffInterval).
+		WithArgs(accounts[0].ID, accounts[0].User, accounts[0].Instrument, accounts[0].Type, accounts[0].Role, accounts[0].Private, accounts[0].Savings, accounts[0].Title, accounts[0].InBalance, accounts[0].CreditLimit, accounts[0].StartBalance, accounts[0].Balance, accounts[0].Company, accounts[0].Archive, accounts[0].EnableCorrection, accounts[0].BalanceCorrectionType, accounts[0].StartDate, accounts[0].Capitalization, accounts[0].Percent, unixTime(accounts[0].Changed), accounts[0].SyncID, accounts[0].EnableSMS, accounts[0].EndDateOffset, accounts[0].EndDateOffsetInterval, accounts[0].PayoffStep, accounts[0].PayoffInterval, mustJSON(t, accounts[0])).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = db.SaveAccounts(context.Background(), accounts)
@@ -136,7 +137,7 @@ func TestSaveTags_Success(t *testing.T) {
 
 	batch := mock.ExpectBatch()
 	batch.ExpectExec("INSERT INTO tag").
-		WithArgs(tags[0].ID, tags[0].User, tags[0].Changed, tags[0].Icon, tags[0].BudgetIncome, tags[0].BudgetOutcome, tags[0].Required, tags[0].Color, tags[0].Picture, tags[0].Title, tags[0].ShowIncome, tags[0].ShowOutcome, tags[0].Parent, tags[0].StaticID).
+		WithArgs(tags[0].ID, tags[0].User, unixTime(tags[0].Changed), tags[0].Icon, tags[0].BudgetIncome, tags[0].BudgetOutcome, tags[0].Required, tags[0].Color, tags[0].Picture, tags[0].Title, tags[0].ShowIncome, tags[0].ShowOutcome, tags[0].Parent, tags[0].StaticID, mustJSON(t, tags[0])).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = db.SaveTags(context.Background(), tags)
@@ -157,7 +158,7 @@ func TestSaveMerchants_Success(t *testing.T) {
 
 	batch := mock.ExpectBatch()
 	batch.ExpectExec("INSERT INTO merchant").
-		WithArgs(merchants[0].ID, merchants[0].User, merchants[0].Title, merchants[0].Changed).
+		WithArgs(merchants[0].ID, merchants[0].User, merchants[0].Title, unixTime(merchants[0].Changed), mustJSON(t, merchants[0])).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = db.SaveMerchants(context.Background(), merchants)
@@ -188,7 +189,7 @@ func TestSaveBudgets_Success(t *testing.T) {
 
 	batch := mock.ExpectBatch()
 	batch.ExpectExec("INSERT INTO budget").
-		WithArgs(budgets[0].User, budgets[0].Changed, budgets[0].Date, budgets[0].Tag, budgets[0].Income, budgets[0].Outcome, budgets[0].IncomeLock, budgets[0].OutcomeLock, budgets[0].IsIncomeForecast, budgets[0].IsOutcomeForecast).
+		WithArgs(budgets[0].User, unixTime(budgets[0].Changed), budgets[0].Date, budgets[0].Tag, budgets[0].Income, budgets[0].Outcome, budgets[0].IncomeLock, budgets[0].OutcomeLock, budgets[0].IsIncomeForecast, budgets[0].IsOutcomeForecast, mustJSON(t, budgets[0])).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = db.SaveBudgets(context.Background(), budgets)
@@ -229,7 +230,7 @@ func TestSaveReminders_Success(t *testing.T) {
 
 	batch := mock.ExpectBatch()
 	batch.ExpectExec("INSERT INTO reminder").
-		WithArgs(reminders[0].ID, reminders[0].User, reminders[0].Income, reminders[0].Outcome, reminders[0].Changed, reminders[0].IncomeInstrument, reminders[0].OutcomeInstrument, reminders[0].Step, reminders[0].Points, reminders[0].Tag, reminders[0].StartDate, reminders[0].EndDate, reminders[0].Notify, reminders[0].Interval, reminders[0].IncomeAccount, reminders[0].OutcomeAccount, reminders[0].Comment, reminders[0].Payee, reminders[0].Merchant).
+		WithArgs(reminders[0].ID, reminders[0].User, reminders[0].Income, reminders[0].Outcome, unixTime(reminders[0].Changed), reminders[0].IncomeInstrument, reminders[0].OutcomeInstrument, reminders[0].Step, reminders[0].Points, reminders[0].Tag, reminders[0].StartDate, reminders[0].EndDate, reminders[0].Notify, reminders[0].Interval, reminders[0].IncomeAccount, reminders[0].OutcomeAccount, reminders[0].Comment, reminders[0].Payee, reminders[0].Merchant, mustJSON(t, reminders[0])).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = db.SaveReminders(context.Background(), reminders)
@@ -269,7 +270,7 @@ func TestSaveReminderMarkers_Success(t *testing.T) {
 
 	batch := mock.ExpectBatch()
 	batch.ExpectExec("INSERT INTO reminder_marker").
-		WithArgs(markers[0].ID, markers[0].User, markers[0].Date, markers[0].Income, markers[0].Outcome, markers[0].Changed, markers[0].IncomeInstrument, markers[0].OutcomeInstrument, markers[0].State, markers[0].IsForecast, markers[0].Reminder, markers[0].IncomeAccount, markers[0].OutcomeAccount, markers[0].Comment, markers[0].Payee, markers[0].Merchant, markers[0].Notify, markers[0].Tag).
+		WithArgs(markers[0].ID, markers[0].User, markers[0].Date, markers[0].Income, markers[0].Outcome, unixTime(markers[0].Changed), markers[0].IncomeInstrument, markers[0].OutcomeInstrument, markers[0].State, markers[0].IsForecast, markers[0].Reminder, markers[0].IncomeAccount, markers[0].OutcomeAccount, markers[0].Comment, markers[0].Payee, markers[0].Merchant, markers[0].Notify, markers[0].Tag, mustJSON(t, markers[0])).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = db.SaveReminderMarkers(context.Background(), markers)
@@ -321,10 +322,20 @@ func TestSaveTransactions_Success(t *testing.T) {
 
 	batch := mock.ExpectBatch()
 	batch.ExpectExec("INSERT INTO transaction").
-		WithArgs(transactions[0].ID, transactions[0].User, transactions[0].Date, transactions[0].Income, transactions[0].Outcome, transactions[0].Changed, transactions[0].IncomeInstrument, transactions[0].OutcomeInstrument, transactions[0].Created, transactions[0].OriginalPayee, transactions[0].Deleted, transactions[0].Viewed, transactions[0].Hold, transactions[0].QRCode, transactions[0].Source, transactions[0].IncomeAccount, transactions[0].OutcomeAccount, transactions[0].Tag, transactions[0].Comment, transactions[0].Payee, transactions[0].OpIncome, transactions[0].OpOutcome, transactions[0].OpIncomeInstrument, transactions[0].OpOutcomeInstrument, transactions[0].Latitude, transactions[0].Longitude, transactions[0].Merchant, transactions[0].IncomeBankID, transactions[0].OutcomeBankID, transactions[0].ReminderMarker).
+		WithArgs(transactions[0].ID, transactions[0].User, transactions[0].Date, transactions[0].Income, transactions[0].Outcome, unixTime(transactions[0].Changed), transactions[0].IncomeInstrument, transactions[0].OutcomeInstrument, unixTime(transactions[0].Created), transactions[0].OriginalPayee, transactions[0].Deleted, transactions[0].Viewed, transactions[0].Hold, transactions[0].QRCode, transactions[0].Source, transactions[0].IncomeAccount, transactions[0].OutcomeAccount, transactions[0].Tag, transactions[0].Comment, transactions[0].Payee, transactions[0].OpIncome, transactions[0].OpOutcome, transactions[0].OpIncomeInstrument, transactions[0].OpOutcomeInstrument, transactions[0].Latitude, transactions[0].Longitude, transactions[0].Merchant, transactions[0].IncomeBankID, transactions[0].OutcomeBankID, transactions[0].ReminderMarker, mustJSON(t, transactions[0])).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = db.SaveTransactions(context.Background(), transactions)
 	assert.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+// mustJSON returns the raw payload expected for an object
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	payload, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
@@ -12,24 +12,25 @@ import (
 	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
 )
 
-// GetBudget retrieves a specific budget by user ID, tag ID and date
+// GetBudget retrieves a specific budget by user ID, tag ID and date.
+// A nil tag ID gets the total budget of the month, which is stored with a NULL tag.
 func (s *DB) GetBudget(
 	ctx context.Context,
 	userID int,
-	tagID string,
+	tagID *string,
 	date time.Time,
 ) (*models.Budget, error) {
 	query := `
         SELECT "user", changed, date, tag, income, outcome, 
                income_lock, outcome_lock, is_income_forecast, is_outcome_forecast
-        FROM budget
-        WHERE "user" = $1 AND tag = $2 AND date = $3`
+        FROM ` + s.table("budget") + `
+        WHERE "user" = $1 AND tag IS NOT DISTINCT FROM $2 AND date = $3 AND deleted_at IS NULL`
 
 	budget := &models.Budget{}
 	err := s.pool.QueryRow(ctx, query, userID, tagID, date.Format("2006-01-02")).Scan(
 		&budget.User,
-		&budget.Changed,
-		&budget.Date,
+		scanUnix(&budget.Changed),
+		scanDate(&budget.Date),
 		&budget.Tag,
 		&budget.Income,
 		&budget.Outcome,
@@ -40,8 +41,12 @@ func (s *DB) GetBudget(
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("budget not found for user %d, tag %s, date %s",
-				userID, tagID, date.Format("2006-01-02"))
+			tag := "total"
+			if tagID != nil {
+				tag = "tag " + *tagID
+			}
+			return nil, fmt.Errorf("budget %w for user %d, %s, date %s",
+				interfaces.ErrNotFound, userID, tag, date.Format("2006-01-02"))
 		}
 		return nil, fmt.Errorf("failed to get budget: %w", err)
 	}
@@ -73,15 +78,21 @@ func (s *DB) ListBudgets(ctx context.Context, filter interfaces.Filter) ([]model
 		argNum++
 	}
 
+	from, args := s.source("budget", filter, args)
+	argNum = len(args) + 1
+
 	query := `
         SELECT "user", changed, date, tag, income, outcome,
                income_lock, outcome_lock, is_income_forecast, is_outcome_forecast
-        FROM budget`
+        FROM ` + from
+
+	conditions = notDeleted(conditions, filter)
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
+	query += ` ORDER BY "user", tag, date`
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argNum, argNum+1)
 	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
 
@@ -96,8 +107,8 @@ func (s *DB) ListBudgets(ctx context.Context, filter interfaces.Filter) ([]model
 		var budget models.Budget
 		err := rows.Scan(
 			&budget.User,
-			&budget.Changed,
-			&budget.Date,
+			scanUnix(&budget.Changed),
+			scanDate(&budget.Date),
 			&budget.Tag,
 			&budget.Income,
 			&budget.Outcome,
@@ -122,14 +133,14 @@ func (s *DB) ListBudgets(ctx context.Context, filter interfaces.Filter) ([]model
 // CreateBudget creates a new budget record
 func (s *DB) CreateBudget(ctx context.Context, budget *models.Budget) error {
 	query := `
-        INSERT INTO budget (
+        INSERT INTO ` + s.table("budget") + ` (
             "user", changed, date, tag, income, outcome,
             income_lock, outcome_lock, is_income_forecast, is_outcome_forecast
         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
 	_, err := s.pool.Exec(ctx, query,
 		budget.User,
-		budget.Changed,
+		unixTime(budget.Changed),
 		budget.Date,
 		budget.Tag,
 		budget.Income,
@@ -149,7 +160,7 @@ func (s *DB) CreateBudget(ctx context.Context, budget *models.Budget) error {
 // UpdateBudget updates an existing budget record
 func (s *DB) UpdateBudget(ctx context.Context, budget *models.Budget) error {
 	query := `
-        UPDATE budget 
+        UPDATE ` + s.table("budget") + ` 
         SET changed = $4,
             income = $5,
             outcome = $6,
@@ -163,7 +174,7 @@ func (s *DB) UpdateBudget(ctx context.Context, budget *models.Budget) error {
 		budget.User,
 		budget.Tag,
 		budget.Date,
-		budget.Changed,
+		unixTime(budget.Changed),
 		budget.Income,
 		budget.Outcome,
 		budget.IncomeLock,
@@ -176,8 +187,8 @@ func (s *DB) UpdateBudget(ctx context.Context, budget *models.Budget) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("budget not found for user %d, tag %s, date %s",
-			budget.User, *budget.Tag, budget.Date)
+		return fmt.Errorf("budget %w for user %d, tag %s, date %s",
+			interfaces.ErrNotFound, budget.User, *budget.Tag, budget.Date)
 	}
 
 	return nil
@@ -185,7 +196,7 @@ func (s *DB) UpdateBudget(ctx context.Context, budget *models.Budget) error {
 
 // DeleteBudget deletes a budget by user ID, tag ID and date
 func (s *DB) DeleteBudget(ctx context.Context, userID int, tagID string, date time.Time) error {
-	query := `DELETE FROM budget WHERE "user" = $1 AND tag = $2 AND date = $3`
+	query := s.deleteQuery("budget", `"user" = $1 AND tag = $2 AND date = $3`, "now()")
 
 	commandTag, err := s.pool.Exec(ctx, query, userID, tagID, date.Format("2006-01-02"))
 	if err != nil {
@@ -193,8 +204,8 @@ func (s *DB) DeleteBudget(ctx context.Context, userID int, tagID string, date ti
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("budget not found for user %d, tag %s, date %s",
-			userID, tagID, date.Format("2006-01-02"))
+		return fmt.Errorf("budget %w for user %d, tag %s, date %s",
+			interfaces.ErrNotFound, userID, tagID, date.Format("2006-01-02"))
 	}
 
 	return nil
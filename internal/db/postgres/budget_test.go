@@ -55,10 +55,10 @@ func TestGetBudget_Success(t *testing.T) {
 	)
 
 	mock.ExpectQuery(`SELECT "user", changed, date, tag, income, outcome, income_lock, outcome_lock, is_income_forecast, is_outcome_forecast FROM budget`).
-		WithArgs(userID, tagID, date.Format("2006-01-02")).
+		WithArgs(userID, &tagID, date.Format("2006-01-02")).
 		WillReturnRows(rows)
 
-	result, err := db.GetBudget(context.Background(), userID, tagID, date)
+	result, err := db.GetBudget(context.Background(), userID, &tagID, date)
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 	assert.Equal(t, expectedBudget, result)
@@ -80,10 +80,10 @@ func TestGetBudget_NotFound(t *testing.T) {
 	date := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
 
 	mock.ExpectQuery(`SELECT "user", changed, date, tag, income, outcome, income_lock, outcome_lock, is_income_forecast, is_outcome_forecast FROM budget`).
-		WithArgs(userID, tagID, date.Format("2006-01-02")).
+		WithArgs(userID, &tagID, date.Format("2006-01-02")).
 		WillReturnError(pgx.ErrNoRows)
 
-	result, err := db.GetBudget(context.Background(), userID, tagID, date)
+	result, err := db.GetBudget(context.Background(), userID, &tagID, date)
 	assert.Nil(t, result)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "budget not found")
@@ -91,6 +91,40 @@ func TestGetBudget_NotFound(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestGetBudget_Total(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Fatalf("failed to create mock pool: %v", err)
+	}
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	date := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	rows := mock.NewRows([]string{
+		"user", "changed", "date", "tag", "income", "outcome",
+		"income_lock", "outcome_lock", "is_income_forecast", "is_outcome_forecast",
+	}).AddRow(1, int64(1234567890), "2025-02-01", (*string)(nil), 0.0, 5000.0, false, false, false, false)
+
+	// the total budget has a NULL tag, which tag = $2 never matches
+	mock.ExpectQuery(`FROM budget\s+WHERE "user" = \$1 AND tag IS NOT DISTINCT FROM \$2 AND date = \$3`).
+		WithArgs(1, (*string)(nil), "2025-02-01").
+		WillReturnRows(rows)
+
+	result, err := db.GetBudget(context.Background(), 1, nil, date)
+	assert.NoError(t, err)
+	assert.Nil(t, result.Tag)
+	assert.Equal(t, 5000.0, result.Outcome)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	mock.ExpectQuery(`FROM budget`).
+		WithArgs(1, (*string)(nil), "2025-02-01").
+		WillReturnError(pgx.ErrNoRows)
+	_, err = db.GetBudget(context.Background(), 1, nil, date)
+	assert.ErrorIs(t, err, interfaces.ErrNotFound)
+	assert.EqualError(t, err, "budget not found for user 1, total, date 2025-02-01")
+}
+
 func TestGetBudget_QueryError(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	if err != nil {
@@ -105,10 +139,10 @@ func TestGetBudget_QueryError(t *testing.T) {
 	date := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
 
 	mock.ExpectQuery(`SELECT "user", changed, date, tag, income, outcome, income_lock, outcome_lock, is_income_forecast, is_outcome_forecast FROM budget`).
-		WithArgs(userID, tagID, date.Format("2006-01-02")).
+		WithArgs(userID, &tagID, date.Format("2006-01-02")).
 		WillReturnError(errors.New("database error"))
 
-	result, err := db.GetBudget(context.Background(), userID, tagID, date)
+	result, err := db.GetBudget(context.Background(), userID, &tagID, date)
 	assert.Nil(t, result)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to get budget")
@@ -140,7 +174,7 @@ func TestListBudgets_Success(t *testing.T) {
 		1, 1234567890, "2025-01-15", new("test-tag"), 1000.0, 500.0, true, false, true, false,
 	)
 
-	mock.ExpectQuery(`SELECT "user", changed, date, tag, income, outcome, income_lock, outcome_lock, is_income_forecast, is_outcome_forecast FROM budget WHERE "user" = \$1 AND date >= \$2 AND date <= \$3 LIMIT \$4 OFFSET \$5`).
+	mock.ExpectQuery(`SELECT "user", changed, date, tag, income, outcome, income_lock, outcome_lock, is_income_forecast, is_outcome_forecast FROM budget WHERE "user" = \$1 AND date >= \$2 AND date <= \$3 AND deleted_at IS NULL ORDER BY "user", tag, date LIMIT \$4 OFFSET \$5`).
 		WithArgs(1, "2025-01-01", "2025-02-01", 10, 0).
 		WillReturnRows(rows)
 
@@ -169,7 +203,7 @@ func TestListBudgets_QueryError(t *testing.T) {
 		Page:      1,
 	}
 
-	mock.ExpectQuery(`SELECT "user", changed, date, tag, income, outcome, income_lock, outcome_lock, is_income_forecast, is_outcome_forecast FROM budget WHERE "user" = \$1 AND date >= \$2 AND date <= \$3 LIMIT \$4 OFFSET \$5`).
+	mock.ExpectQuery(`SELECT "user", changed, date, tag, income, outcome, income_lock, outcome_lock, is_income_forecast, is_outcome_forecast FROM budget WHERE "user" = \$1 AND date >= \$2 AND date <= \$3 AND deleted_at IS NULL ORDER BY "user", tag, date LIMIT \$4 OFFSET \$5`).
 		WithArgs(1, "2025-01-01", "2025-02-01", 10, 0).
 		WillReturnError(errors.New("database error"))
 
@@ -203,7 +237,7 @@ func TestCreateBudget_Success(t *testing.T) {
 
 	mock.ExpectExec(`(?i)INSERT INTO budget \(\s*"user",\s*changed,\s*date,\s*tag,\s*income,\s*outcome,\s*income_lock,\s*outcome_lock,\s*is_income_forecast,\s*is_outcome_forecast\s*\) VALUES \(\s*\$1,\s*\$2,\s*\$3,\s*\$4,\s*\$5,\s*\$6,\s*\$7,\s*\$8,\s*\$9,\s*\$10\s*\)`).
 		WithArgs(
-			budget.User, budget.Changed, budget.Date, budget.Tag, budget.Income,
+			budget.User, unixTime(budget.Changed), budget.Date, budget.Tag, budget.Income,
 			budget.Outcome, budget.IncomeLock, budget.OutcomeLock,
 			budget.IsIncomeForecast, budget.IsOutcomeForecast,
 		).
@@ -236,7 +270,7 @@ func TestCreateBudget_QueryError(t *testing.T) {
 
 	mock.ExpectExec(`(?i)INSERT INTO budget \(\s*"user",\s*changed,\s*date,\s*tag,\s*income,\s*outcome,\s*income_lock,\s*outcome_lock,\s*is_income_forecast,\s*is_outcome_forecast\s*\) VALUES \(\s*\$1,\s*\$2,\s*\$3,\s*\$4,\s*\$5,\s*\$6,\s*\$7,\s*\$8,\s*\$9,\s*\$10\s*\)`).
 		WithArgs(
-			budget.User, budget.Changed, budget.Date, budget.Tag, budget.Income,
+			budget.User, unixTime(budget.Changed), budget.Date, budget.Tag, budget.Income,
 			budget.Outcome, budget.IncomeLock, budget.OutcomeLock,
 			budget.IsIncomeForecast, budget.IsOutcomeForecast,
 		).
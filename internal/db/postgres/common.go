@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -12,6 +13,7 @@ import (
 
 type DB struct {
 	pool PgxIface
+	opts interfaces.StorageOptions
 }
 
 // PgxIface — interface for pgxpool.Pool
@@ -38,7 +40,7 @@ type PgxIface interface {
 }
 
 // NewPostgresStorage creates a new PostgreSQL storage instance
-func NewPostgresStorage(connectionString string) (interfaces.Storage, error) {
+func NewPostgresStorage(connectionString string, opts ...interfaces.StorageOption) (interfaces.Storage, error) {
 	config, err := pgxpool.ParseConfig(connectionString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse postgres config: %w", err)
@@ -51,9 +53,51 @@ func NewPostgresStorage(connectionString string) (interfaces.Storage, error) {
 
 	return &DB{
 		pool: pool,
+		opts: interfaces.NewStorageOptions(opts...),
 	}, nil
 }
 
+// txPool routes queries of the pool into a transaction,
+// so the batch methods can be reused inside Save
+type txPool struct {
+	PgxIface
+	tx pgx.Tx
+}
+
+func (p txPool) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return p.tx.QueryRow(ctx, sql, args...)
+}
+
+func (p txPool) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return p.tx.Query(ctx, sql, args...)
+}
+
+func (p txPool) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return p.tx.Exec(ctx, sql, args...)
+}
+
+func (p txPool) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	return p.tx.SendBatch(ctx, b)
+}
+
+// Begin starts a nested transaction (savepoint)
+func (p txPool) Begin(ctx context.Context) (pgx.Tx, error) {
+	return p.tx.Begin(ctx)
+}
+
+// BeginTx starts a nested transaction (savepoint), options can't be changed inside a transaction
+func (p txPool) BeginTx(ctx context.Context, _ pgx.TxOptions) (pgx.Tx, error) {
+	return p.tx.Begin(ctx)
+}
+
+// withTx returns a copy of the storage that runs all queries in tx
+func (s *DB) withTx(tx pgx.Tx) *DB {
+	return &DB{
+		pool: txPool{PgxIface: s.pool, tx: tx},
+		opts: s.opts,
+	}
+}
+
 // Close closes the database connection pool
 func (s *DB) Close(ctx context.Context) error {
 	s.pool.Close()
@@ -64,3 +108,22 @@ func (s *DB) Close(ctx context.Context) error {
 func (s *DB) Ping(ctx context.Context) error {
 	return s.pool.Ping(ctx)
 }
+
+// CheckWrite saves a sync status in a transaction which is rolled back, so a read-only database
+// or a role without write privileges fails the check without leaving anything behind
+func (s *DB) CheckWrite(ctx context.Context) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// sync_type is an enum, the status tells the row apart should a rollback ever fail to happen
+	return s.withTx(tx).SaveSyncStatus(ctx, interfaces.SyncStatus{
+		StartedAt: time.Now(),
+		SyncType:  "partial",
+		Status:    "check",
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
@@ -66,3 +67,38 @@ func TestDB_Ping(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestDB_CheckWrite(t *testing.T) {
+	t.Run("writable", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO sync_status`).
+			WithArgs(pgxmock.AnyArg(), (*time.Time)(nil), "partial", int64(0), 0, "check", (*string)(nil),
+				pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(1)))
+		mock.ExpectRollback()
+
+		db := &DB{pool: mock}
+		assert.NoError(t, db.CheckWrite(context.Background()))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("read-only", func(t *testing.T) {
+		mock, err := pgxmock.NewPool()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO sync_status`).
+			WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+				pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+			WillReturnError(errors.New("cannot execute INSERT in a read-only transaction"))
+		mock.ExpectRollback()
+
+		db := &DB{pool: mock}
+		err = db.CheckWrite(context.Background())
+		assert.ErrorContains(t, err, "read-only transaction")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -15,8 +15,8 @@ import (
 func (s *DB) GetCompany(ctx context.Context, id int) (*models.Company, error) {
 	query := `
         SELECT id, title, full_title, www, country, deleted, country_code, changed
-        FROM company
-        WHERE id = $1`
+        FROM ` + s.table("company") + `
+        WHERE id = $1 AND deleted_at IS NULL`
 
 	company := &models.Company{}
 	err := s.pool.QueryRow(ctx, query, id).Scan(
@@ -27,11 +27,11 @@ func (s *DB) GetCompany(ctx context.Context, id int) (*models.Company, error) {
 		&company.Country,
 		&company.Deleted,
 		&company.CountryCode,
-		&company.Changed,
+		scanUnix(&company.Changed),
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("company not found: %d", id)
+			return nil, fmt.Errorf("company %w: %d", interfaces.ErrNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get company: %w", err)
 	}
@@ -55,15 +55,21 @@ func (s *DB) ListCompanies(
 		argNum++
 	}
 
+	from, args := s.source("company", filter, args)
+	argNum = len(args) + 1
+
 	query := `
         SELECT id, title, full_title, www, country, deleted, country_code, changed
-        FROM company`
+        FROM ` + from
+
+	conditions = notDeleted(conditions, filter)
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
 	// Add pagination
+	query += ` ORDER BY id`
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argNum, argNum+1)
 	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
 
@@ -84,7 +90,7 @@ func (s *DB) ListCompanies(
 			&company.Country,
 			&company.Deleted,
 			&company.CountryCode,
-			&company.Changed,
+			scanUnix(&company.Changed),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan company: %w", err)
@@ -102,7 +108,7 @@ func (s *DB) ListCompanies(
 // CreateCompany creates a new company record
 func (s *DB) CreateCompany(ctx context.Context, company *models.Company) error {
 	query := `
-        INSERT INTO company (
+        INSERT INTO ` + s.table("company") + ` (
             id, title, full_title, www, country, deleted,
             country_code, changed
         ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
@@ -115,7 +121,7 @@ func (s *DB) CreateCompany(ctx context.Context, company *models.Company) error {
 		company.Country,
 		company.Deleted,
 		company.CountryCode,
-		company.Changed,
+		unixTime(company.Changed),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create company: %w", err)
@@ -127,7 +133,7 @@ func (s *DB) CreateCompany(ctx context.Context, company *models.Company) error {
 // UpdateCompany updates an existing company record
 func (s *DB) UpdateCompany(ctx context.Context, company *models.Company) error {
 	query := `
-        UPDATE company
+        UPDATE ` + s.table("company") + `
         SET title = $2, full_title = $3, www = $4, country = $5,
             deleted = $6, country_code = $7, changed = $8
         WHERE id = $1`
@@ -140,14 +146,14 @@ func (s *DB) UpdateCompany(ctx context.Context, company *models.Company) error {
 		company.Country,
 		company.Deleted,
 		company.CountryCode,
-		company.Changed,
+		unixTime(company.Changed),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update company: %w", err)
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("company not found: %d", company.ID)
+		return fmt.Errorf("company %w: %d", interfaces.ErrNotFound, company.ID)
 	}
 
 	return nil
@@ -155,7 +161,7 @@ func (s *DB) UpdateCompany(ctx context.Context, company *models.Company) error {
 
 // DeleteCompany deletes a company by its ID
 func (s *DB) DeleteCompany(ctx context.Context, id int) error {
-	query := `DELETE FROM company WHERE id = $1`
+	query := s.deleteQuery("company", `id = $1`, "now()")
 
 	commandTag, err := s.pool.Exec(ctx, query, id)
 	if err != nil {
@@ -163,7 +169,7 @@ func (s *DB) DeleteCompany(ctx context.Context, id int) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("company not found: %d", id)
+		return fmt.Errorf("company %w: %d", interfaces.ErrNotFound, id)
 	}
 
 	return nil
@@ -100,7 +100,7 @@ func TestListCompanies_Success(t *testing.T) {
 		1, "Test Company", "Test Company Full Title", "https://testcompany.com", 1, false, "TC", 1234567890,
 	)
 
-	mock.ExpectQuery(`SELECT id, title, full_title, www, country, deleted, country_code, changed FROM company WHERE user_id = \$1 LIMIT \$2 OFFSET \$3`).
+	mock.ExpectQuery(`SELECT id, title, full_title, www, country, deleted, country_code, changed FROM company WHERE user_id = \$1 AND deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
 		WithArgs(1, 10, 0).
 		WillReturnRows(rows)
 
@@ -126,7 +126,7 @@ func TestListCompanies_QueryError(t *testing.T) {
 		Page:   1,
 	}
 
-	mock.ExpectQuery(`SELECT id, title, full_title, www, country, deleted, country_code, changed FROM company WHERE user_id = \$1 LIMIT \$2 OFFSET \$3`).
+	mock.ExpectQuery(`SELECT id, title, full_title, www, country, deleted, country_code, changed FROM company WHERE user_id = \$1 AND deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
 		WithArgs(1, 10, 0).
 		WillReturnError(errors.New("query error"))
 
@@ -160,7 +160,7 @@ func TestCreateCompany_Success(t *testing.T) {
 	mock.ExpectExec(`INSERT INTO company`).
 		WithArgs(
 			company.ID, company.Title, company.FullTitle, company.Www,
-			company.Country, company.Deleted, company.CountryCode, company.Changed,
+			company.Country, company.Deleted, company.CountryCode, unixTime(company.Changed),
 		).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
@@ -192,7 +192,7 @@ func TestCreateCompany_QueryError(t *testing.T) {
 	mock.ExpectExec(`INSERT INTO company`).
 		WithArgs(
 			company.ID, company.Title, company.FullTitle, company.Www,
-			company.Country, company.Deleted, company.CountryCode, company.Changed,
+			company.Country, company.Deleted, company.CountryCode, unixTime(company.Changed),
 		).
 		WillReturnError(errors.New("insert error"))
 
@@ -225,7 +225,7 @@ func TestUpdateCompany_Success(t *testing.T) {
 	mock.ExpectExec(`UPDATE company SET`).
 		WithArgs(
 			company.ID, company.Title, company.FullTitle, company.Www,
-			company.Country, company.Deleted, company.CountryCode, company.Changed,
+			company.Country, company.Deleted, company.CountryCode, unixTime(company.Changed),
 		).
 		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
@@ -257,7 +257,7 @@ func TestUpdateCompany_NotFound(t *testing.T) {
 	mock.ExpectExec(`UPDATE company SET`).
 		WithArgs(
 			company.ID, company.Title, company.FullTitle, company.Www,
-			company.Country, company.Deleted, company.CountryCode, company.Changed,
+			company.Country, company.Deleted, company.CountryCode, unixTime(company.Changed),
 		).
 		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
 
@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
@@ -13,8 +14,8 @@ import (
 func (s *DB) GetCountry(ctx context.Context, id int) (*models.Country, error) {
 	query := `
         SELECT id, title, currency, domain
-        FROM country
-        WHERE id = $1`
+        FROM ` + s.table("country") + `
+        WHERE id = $1 AND deleted_at IS NULL`
 
 	country := &models.Country{}
 	err := s.pool.QueryRow(ctx, query, id).Scan(
@@ -25,7 +26,7 @@ func (s *DB) GetCountry(ctx context.Context, id int) (*models.Country, error) {
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("country not found: %d", id)
+			return nil, fmt.Errorf("country %w: %d", interfaces.ErrNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get country: %w", err)
 	}
@@ -38,12 +39,21 @@ func (s *DB) ListCountries(
 	ctx context.Context,
 	filter interfaces.Filter,
 ) ([]models.Country, error) {
+	from, args := s.source("country", filter, nil)
+
 	query := `
         SELECT id, title, currency, domain
-        FROM country
-        LIMIT $1 OFFSET $2`
+        FROM ` + from
+
+	if conditions := notDeleted(nil, filter); len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += ` ORDER BY id`
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
 
-	rows, err := s.pool.Query(ctx, query, filter.Limit, (filter.Page-1)*filter.Limit)
+	rows, err := s.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list countries: %w", err)
 	}
@@ -74,7 +84,7 @@ func (s *DB) ListCountries(
 // CreateCountry creates a new country record
 func (s *DB) CreateCountry(ctx context.Context, country *models.Country) error {
 	query := `
-        INSERT INTO country (id, title, currency, domain)
+        INSERT INTO ` + s.table("country") + ` (id, title, currency, domain)
         VALUES ($1, $2, $3, $4)`
 
 	_, err := s.pool.Exec(ctx, query,
@@ -93,7 +103,7 @@ func (s *DB) CreateCountry(ctx context.Context, country *models.Country) error {
 // UpdateCountry updates an existing country record
 func (s *DB) UpdateCountry(ctx context.Context, country *models.Country) error {
 	query := `
-        UPDATE country
+        UPDATE ` + s.table("country") + `
         SET title = $2, currency = $3, domain = $4
         WHERE id = $1`
 
@@ -108,7 +118,7 @@ func (s *DB) UpdateCountry(ctx context.Context, country *models.Country) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("country not found: %d", country.ID)
+		return fmt.Errorf("country %w: %d", interfaces.ErrNotFound, country.ID)
 	}
 
 	return nil
@@ -116,7 +126,7 @@ func (s *DB) UpdateCountry(ctx context.Context, country *models.Country) error {
 
 // DeleteCountry deletes a country by its ID
 func (s *DB) DeleteCountry(ctx context.Context, id int) error {
-	query := `DELETE FROM country WHERE id = $1`
+	query := s.deleteQuery("country", `id = $1`, "now()")
 
 	commandTag, err := s.pool.Exec(ctx, query, id)
 	if err != nil {
@@ -124,7 +134,7 @@ func (s *DB) DeleteCountry(ctx context.Context, id int) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("country not found: %d", id)
+		return fmt.Errorf("country %w: %d", interfaces.ErrNotFound, id)
 	}
 
 	return nil
@@ -103,7 +103,7 @@ func TestListCountries_Success(t *testing.T) {
 		AddRow(1, "Country 1", 1, "domain1").
 		AddRow(2, "Country 2", 2, "domain2")
 
-	mock.ExpectQuery(`SELECT id, title, currency, domain FROM country LIMIT \$1 OFFSET \$2`).
+	mock.ExpectQuery(`SELECT id, title, currency, domain FROM country WHERE deleted_at IS NULL ORDER BY id LIMIT \$1 OFFSET \$2`).
 		WithArgs(10, 0).
 		WillReturnRows(rows)
 
@@ -129,7 +129,7 @@ func TestListCountries_QueryError(t *testing.T) {
 		Page:  1,
 	}
 
-	mock.ExpectQuery(`SELECT id, title, currency, domain FROM country LIMIT \$1 OFFSET \$2`).
+	mock.ExpectQuery(`SELECT id, title, currency, domain FROM country WHERE deleted_at IS NULL ORDER BY id LIMIT \$1 OFFSET \$2`).
 		WithArgs(10, 0).
 		WillReturnError(errors.New("query error"))
 
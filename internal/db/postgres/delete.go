@@ -4,13 +4,18 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
 	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
 )
 
 // DeleteObjects handles deletion of multiple objects from different tables
 // based on the Deletion objects received from ZenMoney API.
+// In soft delete mode the rows are kept and marked with deleted_at instead.
 // It processes deletions in a single transaction to ensure data consistency.
 // Each Deletion object contains:
 // - ID: the object's ID
@@ -35,28 +40,44 @@ func (s *DB) DeleteObjects(ctx context.Context, deletions []models.Deletion) err
 
 	// Process each deletion
 	for _, del := range deletions {
-		query := ""
+		table, where := "", `id = $1 AND "user" = $2`
+		args := []interface{}{del.ID, del.User}
 		switch del.Object {
 		case string(models.EntityTypeAccount):
-			query = `DELETE FROM account WHERE id = $1 AND "user" = $2`
+			table = "account"
 		case string(models.EntityTypeTag):
-			query = `DELETE FROM tag WHERE id = $1 AND "user" = $2`
+			table = "tag"
 		case string(models.EntityTypeMerchant):
-			query = `DELETE FROM merchant WHERE id = $1 AND "user" = $2`
+			table = "merchant"
 		case string(models.EntityTypeBudget):
-			query = `DELETE FROM budget WHERE "user" = $1 AND date = $2`
+			user, tag, date, err := parseBudgetDeletionID(del.ID, del.User)
+			if err != nil {
+				// one deletion in an unexpected format mustn't block every following sync
+				slog.Warn("skipping budget deletion", "id", del.ID, "user", del.User, "error", err)
+				continue
+			}
+			table, where = "budget", `"user" = $1 AND tag IS NOT DISTINCT FROM $2 AND date = $3`
+			args = []interface{}{user, tag, date}
 		case string(models.EntityTypeReminder):
-			query = `DELETE FROM reminder WHERE id = $1 AND "user" = $2`
+			table = "reminder"
 		case string(models.EntityTypeReminderMarker):
-			query = `DELETE FROM reminder_marker WHERE id = $1 AND "user" = $2`
+			table = "reminder_marker"
 		case string(models.EntityTypeTransaction):
-			query = `DELETE FROM transaction WHERE id = $1 AND "user" = $2`
+			table = "transaction"
 		default:
 			return fmt.Errorf("unsupported object type for deletion: %s", del.Object)
 		}
 
+		// Tombstones are stamped with the time of deletion in ZenMoney
+		stamp := ""
+		if s.opts.DeleteMode == interfaces.DeleteModeSoft {
+			args = append(args, unixTime(del.Stamp))
+			stamp = fmt.Sprintf("$%d", len(args))
+		}
+		query := s.deleteQuery(table, where, stamp)
+
 		// Execute the delete query
-		commandTag, err := tx.Exec(ctx, query, del.ID, del.User)
+		commandTag, err := tx.Exec(ctx, query, args...)
 		if err != nil {
 			return fmt.Errorf("failed to delete %s with ID %s: %w", del.Object, del.ID, err)
 		}
@@ -70,7 +91,7 @@ func (s *DB) DeleteObjects(ctx context.Context, deletions []models.Deletion) err
 
 		// Record the deletion in deletion_history table for audit
 		_, err = tx.Exec(ctx, `
-            INSERT INTO deletion_history (
+            INSERT INTO `+s.table("deletion_history")+` (
                 object_id, object_type, user_id, deleted_at
             ) VALUES ($1, $2, $3, to_timestamp($4))`,
 			del.ID, del.Object, del.User, del.Stamp,
@@ -87,3 +108,117 @@ func (s *DB) DeleteObjects(ctx context.Context, deletions []models.Deletion) err
 
 	return nil
 }
+
+// ListDeletionHistory lists deletion_history records ordered by ID, the order they were recorded in
+func (s *DB) ListDeletionHistory(ctx context.Context, filter interfaces.Filter) ([]interfaces.DeletionRecord, error) {
+	query := `
+        SELECT id, object_id, object_type, user_id, deleted_at, created_at
+        FROM ` + s.table("deletion_history") + `
+        ORDER BY id
+        LIMIT $1 OFFSET $2`
+
+	rows, err := s.pool.Query(ctx, query, filter.Limit, (filter.Page-1)*filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deletion history: %w", err)
+	}
+	defer rows.Close()
+
+	var records []interfaces.DeletionRecord
+	for rows.Next() {
+		var record interfaces.DeletionRecord
+		err := rows.Scan(
+			&record.ID, &record.ObjectID, &record.ObjectType,
+			&record.UserID, &record.DeletedAt, &record.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan deletion record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deletion history: %w", err)
+	}
+
+	return records, nil
+}
+
+// SaveDeletionHistory inserts deletion_history records as they are, new IDs are assigned
+func (s *DB) SaveDeletionHistory(ctx context.Context, records []interfaces.DeletionRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	query := `
+        INSERT INTO ` + s.table("deletion_history") + ` (
+            object_id, object_type, user_id, deleted_at, created_at
+        ) VALUES ($1, $2, $3, $4, $5)`
+
+	batch := &pgx.Batch{}
+	for _, record := range records {
+		batch.Queue(query, record.ObjectID, record.ObjectType, record.UserID, record.DeletedAt, record.CreatedAt)
+	}
+
+	br := s.pool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	for i := 0; i < batch.Len(); i++ {
+		if _, err := br.Exec(); err != nil {
+			return fmt.Errorf("failed to save deletion record %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// deleteQuery builds a statement removing rows of the table matching the condition.
+// In soft delete mode live rows are marked with deleted_at = stamp instead,
+// stamp is an SQL expression like now() or a placeholder.
+func (s *DB) deleteQuery(table, where, stamp string) string {
+	if s.opts.DeleteMode == interfaces.DeleteModeSoft {
+		return fmt.Sprintf(`UPDATE %s SET deleted_at = %s WHERE %s AND deleted_at IS NULL`,
+			s.table(table), stamp, where)
+	}
+	return fmt.Sprintf(`DELETE FROM %s WHERE %s`, s.table(table), where)
+}
+
+// notDeleted hides tombstones from listings unless the filter asks for them.
+// Reads as of a time already skip objects deleted before it.
+func notDeleted(conditions []string, filter interfaces.Filter) []string {
+	if filter.IncludeDeleted || filter.AsOf != nil {
+		return conditions
+	}
+	return append(conditions, "deleted_at IS NULL")
+}
+
+// parseBudgetDeletionID extracts the budget identity from a deletion ID.
+// Budgets have no ID of their own, so their deletions carry a composite
+// identifier of the tag and the month, optionally prefixed with the user:
+// "[user:]tag:yyyy-MM-dd". An empty or "null" tag stands for the total budget.
+func parseBudgetDeletionID(id string, user int) (int, *string, string, error) {
+	parts := strings.Split(id, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, nil, "", fmt.Errorf("invalid budget deletion ID: %s", id)
+	}
+
+	if len(parts) == 3 {
+		u, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return 0, nil, "", fmt.Errorf("invalid user in budget deletion ID %s: %w", id, err)
+		}
+		user = u
+		parts = parts[1:]
+	}
+
+	date, err := time.Parse("2006-01-02", parts[1])
+	if err != nil {
+		return 0, nil, "", fmt.Errorf("invalid date in budget deletion ID %s: %w", id, err)
+	}
+
+	var tag *string
+	if parts[0] != "" && parts[0] != "null" {
+		tag = &parts[0]
+	}
+
+	return user, tag, date.Format("2006-01-02"), nil
+}
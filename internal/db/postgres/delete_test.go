@@ -2,9 +2,12 @@ package postgres
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
 	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
 	"github.com/pashagolub/pgxmock/v4"
 	"github.com/stretchr/testify/assert"
@@ -48,6 +51,45 @@ func TestDeleteObjects_Success(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+// TestDeleteObjects_SoftDelete tests that rows are marked with the deletion stamp in soft delete mode
+func TestDeleteObjects_SoftDelete(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock, opts: interfaces.NewStorageOptions(interfaces.WithDeleteMode(interfaces.DeleteModeSoft))}
+
+	deletions := []models.Deletion{
+		{ID: "1", Object: "transaction", User: 1, Stamp: 1234567890},
+		{ID: "1:tag-1:2024-01-01", Object: "budget", User: 1, Stamp: 1234567890},
+	}
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`UPDATE transaction SET deleted_at = \$3 WHERE id = \$1 AND "user" = \$2 AND deleted_at IS NULL`).
+		WithArgs("1", 1, unixTime(1234567890)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	mock.ExpectExec(`INSERT INTO deletion_history \(.+\) VALUES \(.+\)`).
+		WithArgs("1", "transaction", 1, 1234567890).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	mock.ExpectExec(`UPDATE budget SET deleted_at = \$4 WHERE "user" = \$1 AND tag IS NOT DISTINCT FROM \$2 AND date = \$3 AND deleted_at IS NULL`).
+		WithArgs(1, ptr("tag-1"), "2024-01-01", unixTime(1234567890)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	mock.ExpectExec(`INSERT INTO deletion_history \(.+\) VALUES \(.+\)`).
+		WithArgs("1:tag-1:2024-01-01", "budget", 1, 1234567890).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	mock.ExpectCommit()
+
+	err = db.DeleteObjects(context.Background(), deletions)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // TestDeleteObjects_QueryError tests the case when there is a query error
 func TestDeleteObjects_QueryError(t *testing.T) {
 	mock, err := pgxmock.NewPool()
@@ -95,3 +137,188 @@ func TestDeleteObjects_UnsupportedObject(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestDeleteObjects_Budget(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	deletions := []models.Deletion{
+		{ID: "tag-1:2024-03-01", Object: "budget", User: 1, Stamp: 1234567890},
+	}
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`DELETE FROM budget WHERE "user" = \$1 AND tag IS NOT DISTINCT FROM \$2 AND date = \$3`).
+		WithArgs(1, new("tag-1"), "2024-03-01").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	mock.ExpectExec(`INSERT INTO deletion_history \(.+\) VALUES \(.+\)`).
+		WithArgs("tag-1:2024-03-01", "budget", 1, 1234567890).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	mock.ExpectCommit()
+
+	err = db.DeleteObjects(context.Background(), deletions)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteObjects_BudgetPayload(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	// the deletion part of a diff response, the budget is the one of example.json of the SDK
+	payload := `{
+		"serverTimestamp": 1727100000,
+		"deletion": [
+			{"id": "784712:fa2dd7d5-61db-4532-98c3-3d0c16a3b6b5:2024-10-01", "object": "budget", "stamp": 1727015084, "user": 784712},
+			{"id": "fa2dd7d5-61db-4532-98c3-3d0c16a3b6b5", "object": "budget", "stamp": 1727015084, "user": 784712}
+		]
+	}`
+	var response models.Response
+	assert.NoError(t, json.Unmarshal([]byte(payload), &response))
+
+	mock.ExpectBegin()
+
+	mock.ExpectExec(`DELETE FROM budget WHERE "user" = \$1 AND tag IS NOT DISTINCT FROM \$2 AND date = \$3`).
+		WithArgs(784712, new("fa2dd7d5-61db-4532-98c3-3d0c16a3b6b5"), "2024-10-01").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	mock.ExpectExec(`INSERT INTO deletion_history \(.+\) VALUES \(.+\)`).
+		WithArgs("784712:fa2dd7d5-61db-4532-98c3-3d0c16a3b6b5:2024-10-01", "budget", 784712, 1727015084).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	// the second deletion has no date, it is skipped instead of failing the sync
+	mock.ExpectCommit()
+
+	err = db.DeleteObjects(context.Background(), response.Deletion)
+	assert.NoError(t, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestParseBudgetDeletionID(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		user    int
+		tag     *string
+		date    string
+		wantErr bool
+	}{
+		{name: "tag and date", id: "tag-1:2024-03-01", user: 1, tag: new("tag-1"), date: "2024-03-01"},
+		{name: "with user", id: "42:tag-1:2024-03-01", user: 42, tag: new("tag-1"), date: "2024-03-01"},
+		{name: "total budget", id: "null:2024-03-01", user: 1, date: "2024-03-01"},
+		{name: "empty tag", id: ":2024-03-01", user: 1, date: "2024-03-01"},
+		{name: "plain id", id: "123", wantErr: true},
+		{name: "invalid date", id: "tag-1:2024-13-01", wantErr: true},
+		{name: "invalid user", id: "x:tag-1:2024-03-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, tag, date, err := parseBudgetDeletionID(tt.id, 1)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.user, user)
+			assert.Equal(t, tt.tag, tag)
+			assert.Equal(t, tt.date, date)
+		})
+	}
+}
+
+func TestDeleteQuery(t *testing.T) {
+	hard := &DB{}
+	assert.Equal(t, `DELETE FROM "user" WHERE id = $1`, hard.deleteQuery("user", "id = $1", "now()"))
+
+	soft := &DB{opts: interfaces.NewStorageOptions(interfaces.WithDeleteMode(interfaces.DeleteModeSoft))}
+	assert.Equal(t,
+		`UPDATE "user" SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL`,
+		soft.deleteQuery("user", "id = $1", "now()"))
+}
+
+func TestNotDeleted(t *testing.T) {
+	assert.Equal(t, []string{`"user" = $1`, "deleted_at IS NULL"},
+		notDeleted([]string{`"user" = $1`}, interfaces.Filter{}))
+	assert.Equal(t, []string{`"user" = $1`},
+		notDeleted([]string{`"user" = $1`}, interfaces.Filter{IncludeDeleted: true}))
+}
+
+func TestListDeletionHistory_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	now := time.Now()
+	rows := pgxmock.NewRows([]string{"id", "object_id", "object_type", "user_id", "deleted_at", "created_at"}).
+		AddRow(int64(1), "acc-1", "account", 1, now, now)
+
+	mock.ExpectQuery(`SELECT id, object_id, object_type, user_id, deleted_at, created_at\s+FROM deletion_history\s+ORDER BY id`).
+		WithArgs(100, 0).
+		WillReturnRows(rows)
+
+	records, err := db.ListDeletionHistory(context.Background(), interfaces.Filter{Page: 1, Limit: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, []interfaces.DeletionRecord{
+		{ID: 1, ObjectID: "acc-1", ObjectType: "account", UserID: 1, DeletedAt: now, CreatedAt: now},
+	}, records)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveDeletionHistory_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	now := time.Now()
+	records := []interfaces.DeletionRecord{
+		{ID: 7, ObjectID: "acc-1", ObjectType: "account", UserID: 1, DeletedAt: now, CreatedAt: now},
+		{ID: 8, ObjectID: "tag-1", ObjectType: "tag", UserID: 1, DeletedAt: now, CreatedAt: now},
+	}
+
+	batch := mock.ExpectBatch()
+	batch.ExpectExec(`INSERT INTO deletion_history`).
+		WithArgs("acc-1", "account", 1, now, now).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	batch.ExpectExec(`INSERT INTO deletion_history`).
+		WithArgs("tag-1", "tag", 1, now, now).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	assert.NoError(t, db.SaveDeletionHistory(context.Background(), records))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveDeletionHistory_Error(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	now := time.Now()
+	batch := mock.ExpectBatch()
+	batch.ExpectExec(`INSERT INTO deletion_history`).
+		WithArgs("acc-1", "account", 1, now, now).
+		WillReturnError(errors.New("insert error"))
+
+	err = db.SaveDeletionHistory(context.Background(), []interfaces.DeletionRecord{
+		{ObjectID: "acc-1", ObjectType: "account", UserID: 1, DeletedAt: now, CreatedAt: now},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to save deletion record 0")
+}
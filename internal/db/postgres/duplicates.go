@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+)
+
+// duplicateCheck groups the rows of a table which describe the same object
+type duplicateCheck struct {
+	table  string
+	reason string
+	// key is the column list the rows are grouped by
+	key string
+	// ids selects the ids of a group, distinct ids for content duplicates
+	ids string
+	// live skips soft deleted rows
+	live bool
+	// warning marks content duplicates, which may be distinct objects
+	warning bool
+}
+
+// duplicateChecks lists the duplicates Save can produce. Partitioned tables are keyed by id and date,
+// so an object whose date changed may be left behind in its old partition.
+var duplicateChecks = []duplicateCheck{
+	{table: "transaction", reason: "same id", key: "id", ids: "array_agg(DISTINCT id::text)"},
+	{table: "reminder_marker", reason: "same id", key: "id", ids: "array_agg(DISTINCT id::text)"},
+	{
+		table:   "transaction",
+		reason:  "same user, date, accounts, amounts and payee",
+		key:     `"user", date, income_account, outcome_account, income, outcome, COALESCE(payee, '')`,
+		ids:     "array_agg(id::text ORDER BY id)",
+		live:    true,
+		warning: true,
+	},
+	{
+		table:   "reminder_marker",
+		reason:  "same reminder and date",
+		key:     "reminder, date",
+		ids:     "array_agg(id::text ORDER BY id)",
+		live:    true,
+		warning: true,
+	},
+}
+
+// FindDuplicates returns groups of rows which store the same object more than once,
+// rows with the same content but different ids are marked as warnings
+func (s *DB) FindDuplicates(ctx context.Context) ([]interfaces.Duplicate, error) {
+	var duplicates []interfaces.Duplicate
+
+	for _, check := range duplicateChecks {
+		where := ""
+		if check.live {
+			where = "\n        WHERE deleted_at IS NULL"
+		}
+		query := fmt.Sprintf(`
+        SELECT %[3]s, count(*)
+        FROM %[1]s%[4]s
+        GROUP BY %[2]s
+        HAVING count(*) > 1
+        ORDER BY min(date)`,
+			s.table(check.table), check.key, check.ids, where)
+
+		rows, err := s.pool.Query(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find duplicates in %s: %w", check.table, err)
+		}
+
+		for rows.Next() {
+			duplicate := interfaces.Duplicate{Entity: check.table, Reason: check.reason, Warning: check.warning}
+			if err := rows.Scan(&duplicate.IDs, &duplicate.Rows); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan duplicate: %w", err)
+			}
+			duplicates = append(duplicates, duplicate)
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error iterating duplicates: %w", err)
+		}
+	}
+
+	return duplicates, nil
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDuplicates_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	mock.ExpectQuery(`SELECT array_agg\(DISTINCT id::text\), count\(\*\)\s+FROM transaction\s+GROUP BY id\s+HAVING count\(\*\) > 1`).
+		WillReturnRows(pgxmock.NewRows([]string{"ids", "count"}).AddRow([]string{"tx-1"}, int64(2)))
+	mock.ExpectQuery(`FROM reminder_marker\s+GROUP BY id`).
+		WillReturnRows(pgxmock.NewRows([]string{"ids", "count"}))
+	mock.ExpectQuery(`FROM transaction\s+WHERE deleted_at IS NULL\s+GROUP BY "user", date`).
+		WillReturnRows(pgxmock.NewRows([]string{"ids", "count"}).AddRow([]string{"tx-2", "tx-3"}, int64(2)))
+	mock.ExpectQuery(`FROM reminder_marker\s+WHERE deleted_at IS NULL\s+GROUP BY reminder, date`).
+		WillReturnRows(pgxmock.NewRows([]string{"ids", "count"}))
+
+	duplicates, err := db.FindDuplicates(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []interfaces.Duplicate{
+		{Entity: "transaction", Reason: "same id", IDs: []string{"tx-1"}, Rows: 2},
+		{Entity: "transaction", Reason: "same user, date, accounts, amounts and payee", IDs: []string{"tx-2", "tx-3"}, Rows: 2,
+			Warning: true},
+	}, duplicates)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindDuplicates_QueryError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+	mock.ExpectQuery(`SELECT array_agg`).WillReturnError(errors.New("query error"))
+
+	_, err = db.FindDuplicates(context.Background())
+	assert.ErrorContains(t, err, "failed to find duplicates in transaction")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+)
+
+// enableHistory makes the history triggers record previous versions of rows
+// changed by the current transaction, tagged with the sync run id
+func (s *DB) enableHistory(ctx context.Context, syncRunID int64) error {
+	_, err := s.pool.Exec(ctx,
+		`SELECT set_config('zenexport.history', 'on', true), set_config('zenexport.sync_run_id', $1, true)`,
+		strconv.FormatInt(syncRunID, 10),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enable history: %w", err)
+	}
+	return nil
+}
+
+// reserveSyncRunID takes the id of the sync_status record of the running sync from its sequence,
+// so the history rows written before the record is saved can refer to it
+func (s *DB) reserveSyncRunID(ctx context.Context) (int64, error) {
+	var id int64
+	err := s.pool.QueryRow(ctx, `SELECT nextval(pg_get_serial_sequence($1, 'id'))`, s.table("sync_status")).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve sync run id: %w", err)
+	}
+	return id, nil
+}
+
+// source returns the relation List methods read the table from. With filter.AsOf set
+// it is the union of the current rows and the history versions valid at that time.
+func (s *DB) source(table string, filter interfaces.Filter, args []interface{}) (string, []interface{}) {
+	if filter.AsOf == nil {
+		return s.table(table), args
+	}
+
+	args = append(args, *filter.AsOf)
+	n := len(args)
+
+	return fmt.Sprintf(`(
+            SELECT c.* FROM %[1]s c
+            WHERE %[2]s <= $%[3]d AND coalesce(c.deleted_at, 'infinity') > $%[3]d
+            UNION ALL
+            SELECT (jsonb_populate_record(NULL::%[1]s, to_jsonb(h))).* FROM %[4]s h
+            WHERE h.valid_from <= $%[3]d AND h.valid_to > $%[3]d
+        ) %[5]s`, s.table(table), s.versionStart(table), n, s.table(table+"_history"), quoteIdent(table)), args
+}
+
+// versionStart returns the time the current version of a row c of the table is valid from.
+// Countries have no change time, their current version starts where their last history version ends,
+// so it doesn't overlap with the history versions valid since ever.
+func (s *DB) versionStart(table string) string {
+	if table == "country" {
+		return fmt.Sprintf(`coalesce((SELECT max(v.valid_to) FROM %s v WHERE v.id = c.id), '-infinity')`,
+			s.table("country_history"))
+	}
+	return "c.changed"
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSource(t *testing.T) {
+	db := &DB{}
+
+	from, args := db.source("merchant", interfaces.Filter{}, []interface{}{1})
+	assert.Equal(t, "merchant", from)
+	assert.Equal(t, []interface{}{1}, args)
+
+	asOf := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	from, args = db.source("user", interfaces.Filter{AsOf: &asOf}, []interface{}{1})
+	from = strings.Join(strings.Fields(from), " ")
+	assert.Contains(t, from, `SELECT c.* FROM "user" c WHERE c.changed <= $2 AND coalesce(c.deleted_at, 'infinity') > $2`)
+	assert.Contains(t, from, `jsonb_populate_record(NULL::"user", to_jsonb(h))`)
+	assert.Contains(t, from, `FROM user_history h WHERE h.valid_from <= $2 AND h.valid_to > $2`)
+	assert.Equal(t, []interface{}{1, asOf}, args)
+
+	// the current version of a country starts where its history ends, not since ever like its first version
+	from, _ = db.source("country", interfaces.Filter{AsOf: &asOf}, nil)
+	from = strings.Join(strings.Fields(from), " ")
+	assert.Contains(t, from,
+		`WHERE coalesce((SELECT max(v.valid_to) FROM country_history v WHERE v.id = c.id), '-infinity') <= $1`)
+}
+
+func TestListMerchants_AsOf(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+	asOf := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	rows := mock.NewRows([]string{"id", "user", "title", "changed"}).
+		AddRow("test-id", 1, "Old Title", 1700000000)
+
+	mock.ExpectQuery(`SELECT id, "user", title, changed FROM \( SELECT c\.\* FROM merchant c .+ UNION ALL .+ FROM merchant_history h .+\) merchant WHERE "user" = \$1 ORDER BY id LIMIT \$3 OFFSET \$4`).
+		WithArgs(1, asOf, 10, 0).
+		WillReturnRows(rows)
+
+	merchants, err := db.ListMerchants(context.Background(), interfaces.Filter{
+		UserID: ptr(1),
+		AsOf:   &asOf,
+		Limit:  10,
+		Page:   1,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, merchants, 1)
+	assert.Equal(t, "Old Title", merchants[0].Title)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSave_History(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock, opts: interfaces.NewStorageOptions(interfaces.WithHistory(true))}
+
+	mock.ExpectBegin()
+	// history rows refer to the sync_status record of the sync, saved after the transaction
+	mock.ExpectQuery(`SELECT nextval\(pg_get_serial_sequence\(\$1, 'id'\)\)`).
+		WithArgs("sync_status").
+		WillReturnRows(pgxmock.NewRows([]string{"nextval"}).AddRow(int64(42)))
+	mock.ExpectExec(`SELECT set_config\('zenexport.history', 'on', true\), set_config\('zenexport.sync_run_id', \$1, true\)`).
+		WithArgs("42").
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`INSERT INTO sync_status .* VALUES \(42, \$1`).
+		WithArgs(
+			pgxmock.AnyArg(), pgxmock.AnyArg(), "full", int64(1700000000), 0,
+			"completed", (*string)(nil), pgxmock.AnyArg(), pgxmock.AnyArg(),
+		).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(42)))
+	mock.ExpectRollback()
+
+	err = db.Save(context.Background(), &models.Response{ServerTimestamp: 1700000000})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+)
+
+// ListImportedRecords lists imported bank statement lines ordered by date
+func (s *DB) ListImportedRecords(ctx context.Context, filter interfaces.Filter) ([]interfaces.ImportedRecord, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.StartDate != nil {
+		args = append(args, filter.StartDate.Format(dateLayout))
+		conditions = append(conditions, fmt.Sprintf("date >= $%d", len(args)))
+	}
+	if filter.EndDate != nil {
+		args = append(args, filter.EndDate.Format(dateLayout))
+		conditions = append(conditions, fmt.Sprintf("date <= $%d", len(args)))
+	}
+
+	query := `
+        SELECT account, external_id, date, amount, COALESCE(currency, ''),
+               COALESCE(payee, ''), COALESCE(memo, ''), source, transaction_id, imported_at
+        FROM ` + s.table("imported_record")
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY date, account, external_id LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list imported records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []interfaces.ImportedRecord
+	for rows.Next() {
+		var record interfaces.ImportedRecord
+		err := rows.Scan(
+			&record.Account, &record.ExternalID, &record.Date, &record.Amount,
+			&record.Currency, &record.Payee, &record.Memo, &record.Source,
+			&record.TransactionID, &record.ImportedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan imported record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating imported records: %w", err)
+	}
+
+	return records, nil
+}
+
+// SaveImportedRecords upserts imported bank statement lines by account and external ID.
+// Empty texts are stored as NULL, a zero ImportedAt as the current time.
+func (s *DB) SaveImportedRecords(ctx context.Context, records []interfaces.ImportedRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	query := `
+        INSERT INTO ` + s.table("imported_record") + ` (
+            account, external_id, date, amount, currency, payee, memo,
+            source, transaction_id, imported_at
+        ) VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8, $9, COALESCE($10, now()))
+        ON CONFLICT (account, external_id) DO UPDATE SET
+            date = EXCLUDED.date,
+            amount = EXCLUDED.amount,
+            currency = EXCLUDED.currency,
+            payee = EXCLUDED.payee,
+            memo = EXCLUDED.memo,
+            source = EXCLUDED.source,
+            transaction_id = EXCLUDED.transaction_id`
+
+	batch := &pgx.Batch{}
+	for _, record := range records {
+		var importedAt interface{}
+		if !record.ImportedAt.IsZero() {
+			importedAt = record.ImportedAt
+		}
+		batch.Queue(query,
+			record.Account, record.ExternalID, record.Date.Format(dateLayout), record.Amount,
+			record.Currency, record.Payee, record.Memo,
+			record.Source, record.TransactionID, importedAt,
+		)
+	}
+
+	br := s.pool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	for i := 0; i < batch.Len(); i++ {
+		if _, err := br.Exec(); err != nil {
+			return fmt.Errorf("failed to save imported record %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListImportedRecords_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	date := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+	txID := "tx-1"
+
+	rows := pgxmock.NewRows([]string{
+		"account", "external_id", "date", "amount", "currency", "payee", "memo", "source", "transaction_id", "imported_at",
+	}).
+		AddRow("acc-1", "fit-1", date, 4.5, "EUR", "Coffee House", "", "ofx", &txID, now).
+		AddRow("acc-1", "fit-2", date, -20.0, "", "", "", "ofx", (*string)(nil), now)
+
+	mock.ExpectQuery(`SELECT account, external_id, date, amount, .+\s+FROM imported_record WHERE date >= \$1 AND date <= \$2 ORDER BY date, account, external_id LIMIT \$3 OFFSET \$4`).
+		WithArgs("2024-01-01", "2024-01-31", 100, 100).
+		WillReturnRows(rows)
+
+	records, err := db.ListImportedRecords(context.Background(), interfaces.Filter{
+		StartDate: &from, EndDate: &to, Page: 2, Limit: 100,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []interfaces.ImportedRecord{
+		{Account: "acc-1", ExternalID: "fit-1", Date: date, Amount: 4.5, Currency: "EUR", Payee: "Coffee House",
+			Source: "ofx", TransactionID: &txID, ImportedAt: now},
+		{Account: "acc-1", ExternalID: "fit-2", Date: date, Amount: -20, Source: "ofx", ImportedAt: now},
+	}, records)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListImportedRecords_QueryError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	mock.ExpectQuery(`SELECT account, external_id`).
+		WithArgs(100, 0).
+		WillReturnError(errors.New("query error"))
+
+	_, err = db.ListImportedRecords(context.Background(), interfaces.Filter{Page: 1, Limit: 100})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to list imported records")
+}
+
+func TestSaveImportedRecords_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	date := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+	txID := "tx-1"
+	records := []interfaces.ImportedRecord{
+		{Account: "acc-1", ExternalID: "fit-1", Date: date, Amount: -4.5, Currency: "EUR", Payee: "Coffee House",
+			Source: "ofx", TransactionID: &txID, ImportedAt: now},
+		{Account: "acc-1", ExternalID: "fit-2", Date: date, Amount: -20, Source: "ofx"},
+	}
+
+	batch := mock.ExpectBatch()
+	batch.ExpectExec(`INSERT INTO imported_record .+ ON CONFLICT \(account, external_id\) DO UPDATE`).
+		WithArgs("acc-1", "fit-1", "2024-01-10", -4.5, "EUR", "Coffee House", "", "ofx", &txID, now).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	batch.ExpectExec(`INSERT INTO imported_record`).
+		WithArgs("acc-1", "fit-2", "2024-01-10", -20.0, "", "", "", "ofx", (*string)(nil), nil).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	assert.NoError(t, db.SaveImportedRecords(context.Background(), records))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveImportedRecords_Empty(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	assert.NoError(t, db.SaveImportedRecords(context.Background(), nil))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveImportedRecords_Error(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	batch := mock.ExpectBatch()
+	batch.ExpectExec(`INSERT INTO imported_record`).
+		WillReturnError(errors.New("insert error"))
+
+	err = db.SaveImportedRecords(context.Background(), []interfaces.ImportedRecord{
+		{Account: "acc-1", ExternalID: "fit-1", Date: time.Now(), Source: "csv"},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to save imported record 0")
+}
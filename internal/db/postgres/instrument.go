@@ -15,8 +15,8 @@ import (
 func (s *DB) GetInstrument(ctx context.Context, id int) (*models.Instrument, error) {
 	query := `
         SELECT id, title, short_title, symbol, rate, changed
-        FROM instrument
-        WHERE id = $1`
+        FROM ` + s.table("instrument") + `
+        WHERE id = $1 AND deleted_at IS NULL`
 
 	instrument := &models.Instrument{}
 	err := s.pool.QueryRow(ctx, query, id).Scan(
@@ -25,11 +25,11 @@ func (s *DB) GetInstrument(ctx context.Context, id int) (*models.Instrument, err
 		&instrument.ShortTitle,
 		&instrument.Symbol,
 		&instrument.Rate,
-		&instrument.Changed,
+		scanUnix(&instrument.Changed),
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("instrument not found: %d", id)
+			return nil, fmt.Errorf("instrument %w: %d", interfaces.ErrNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get instrument: %w", err)
 	}
@@ -53,12 +53,18 @@ func (s *DB) ListInstruments(
 		argNum++
 	}
 
-	query := "SELECT id, title, short_title, symbol, rate, changed FROM instrument"
+	from, args := s.source("instrument", filter, args)
+	argNum = len(args) + 1
+
+	query := "SELECT id, title, short_title, symbol, rate, changed FROM " + from
+	conditions = notDeleted(conditions, filter)
+
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
 	// Add pagination
+	query += ` ORDER BY id`
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argNum, argNum+1)
 	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
 
@@ -77,7 +83,7 @@ func (s *DB) ListInstruments(
 			&instrument.ShortTitle,
 			&instrument.Symbol,
 			&instrument.Rate,
-			&instrument.Changed,
+			scanUnix(&instrument.Changed),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan instrument: %w", err)
@@ -95,7 +101,7 @@ func (s *DB) ListInstruments(
 // CreateInstrument creates a new instrument record
 func (s *DB) CreateInstrument(ctx context.Context, instrument *models.Instrument) error {
 	query := `
-        INSERT INTO instrument (id, title, short_title, symbol, rate, changed)
+        INSERT INTO ` + s.table("instrument") + ` (id, title, short_title, symbol, rate, changed)
         VALUES ($1, $2, $3, $4, $5, $6)`
 
 	_, err := s.pool.Exec(ctx, query,
@@ -104,7 +110,7 @@ func (s *DB) CreateInstrument(ctx context.Context, instrument *models.Instrument
 		instrument.ShortTitle,
 		instrument.Symbol,
 		instrument.Rate,
-		instrument.Changed,
+		unixTime(instrument.Changed),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create instrument: %w", err)
@@ -116,7 +122,7 @@ func (s *DB) CreateInstrument(ctx context.Context, instrument *models.Instrument
 // UpdateInstrument updates an existing instrument record
 func (s *DB) UpdateInstrument(ctx context.Context, instrument *models.Instrument) error {
 	query := `
-        UPDATE instrument
+        UPDATE ` + s.table("instrument") + `
         SET title = $2, short_title = $3, symbol = $4, rate = $5, changed = $6
         WHERE id = $1`
 
@@ -126,14 +132,14 @@ func (s *DB) UpdateInstrument(ctx context.Context, instrument *models.Instrument
 		instrument.ShortTitle,
 		instrument.Symbol,
 		instrument.Rate,
-		instrument.Changed,
+		unixTime(instrument.Changed),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update instrument: %w", err)
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("instrument not found: %d", instrument.ID)
+		return fmt.Errorf("instrument %w: %d", interfaces.ErrNotFound, instrument.ID)
 	}
 
 	return nil
@@ -141,7 +147,7 @@ func (s *DB) UpdateInstrument(ctx context.Context, instrument *models.Instrument
 
 // DeleteInstrument deletes an instrument by its ID
 func (s *DB) DeleteInstrument(ctx context.Context, id int) error {
-	query := `DELETE FROM instrument WHERE id = $1`
+	query := s.deleteQuery("instrument", `id = $1`, "now()")
 
 	commandTag, err := s.pool.Exec(ctx, query, id)
 	if err != nil {
@@ -149,7 +155,7 @@ func (s *DB) DeleteInstrument(ctx context.Context, id int) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("instrument not found: %d", id)
+		return fmt.Errorf("instrument %w: %d", interfaces.ErrNotFound, id)
 	}
 
 	return nil
@@ -103,7 +103,7 @@ func TestListInstruments_Success(t *testing.T) {
 		AddRow(1, "United States Dollar", "USD", "$", 1.0, 1234567890).
 		AddRow(2, "Euro", "EUR", "€", 0.85, 1234567891)
 
-	mock.ExpectQuery(`SELECT id, title, short_title, symbol, rate, changed FROM instrument WHERE user_id = \$1 LIMIT \$2 OFFSET \$3`).
+	mock.ExpectQuery(`SELECT id, title, short_title, symbol, rate, changed FROM instrument WHERE user_id = \$1 AND deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
 		WithArgs(1, 10, 0).
 		WillReturnRows(rows)
 
@@ -133,7 +133,7 @@ func TestListInstruments_QueryError(t *testing.T) {
 		Page:   1,
 	}
 
-	mock.ExpectQuery(`SELECT id, title, short_title, symbol, rate, changed FROM instrument WHERE user_id = \$1 LIMIT \$2 OFFSET \$3`).
+	mock.ExpectQuery(`SELECT id, title, short_title, symbol, rate, changed FROM instrument WHERE user_id = \$1 AND deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
 		WithArgs(1, 10, 0).
 		WillReturnError(errors.New("query error"))
 
@@ -162,7 +162,7 @@ func TestCreateInstrument_Success(t *testing.T) {
 	}
 
 	mock.ExpectExec(`INSERT INTO instrument \(id, title, short_title, symbol, rate, changed\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\)`).
-		WithArgs(instrument.ID, instrument.Title, instrument.ShortTitle, instrument.Symbol, instrument.Rate, instrument.Changed).
+		WithArgs(instrument.ID, instrument.Title, instrument.ShortTitle, instrument.Symbol, instrument.Rate, unixTime(instrument.Changed)).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = db.CreateInstrument(context.Background(), instrument)
@@ -188,7 +188,7 @@ func TestCreateInstrument_QueryError(t *testing.T) {
 	}
 
 	mock.ExpectExec(`INSERT INTO instrument \(id, title, short_title, symbol, rate, changed\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\)`).
-		WithArgs(instrument.ID, instrument.Title, instrument.ShortTitle, instrument.Symbol, instrument.Rate, instrument.Changed).
+		WithArgs(instrument.ID, instrument.Title, instrument.ShortTitle, instrument.Symbol, instrument.Rate, unixTime(instrument.Changed)).
 		WillReturnError(errors.New("insert error"))
 
 	err = db.CreateInstrument(context.Background(), instrument)
@@ -215,7 +215,7 @@ func TestUpdateInstrument_Success(t *testing.T) {
 	}
 
 	mock.ExpectExec(`UPDATE instrument SET title = \$2, short_title = \$3, symbol = \$4, rate = \$5, changed = \$6 WHERE id = \$1`).
-		WithArgs(instrument.ID, instrument.Title, instrument.ShortTitle, instrument.Symbol, instrument.Rate, instrument.Changed).
+		WithArgs(instrument.ID, instrument.Title, instrument.ShortTitle, instrument.Symbol, instrument.Rate, unixTime(instrument.Changed)).
 		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
 	err = db.UpdateInstrument(context.Background(), instrument)
@@ -241,7 +241,7 @@ func TestUpdateInstrument_NotFound(t *testing.T) {
 	}
 
 	mock.ExpectExec(`UPDATE instrument SET title = \$2, short_title = \$3, symbol = \$4, rate = \$5, changed = \$6 WHERE id = \$1`).
-		WithArgs(instrument.ID, instrument.Title, instrument.ShortTitle, instrument.Symbol, instrument.Rate, instrument.Changed).
+		WithArgs(instrument.ID, instrument.Title, instrument.ShortTitle, instrument.Symbol, instrument.Rate, unixTime(instrument.Changed)).
 		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
 
 	err = db.UpdateInstrument(context.Background(), instrument)
@@ -268,7 +268,7 @@ func TestUpdateInstrument_QueryError(t *testing.T) {
 	}
 
 	mock.ExpectExec(`UPDATE instrument SET title = \$2, short_title = \$3, symbol = \$4, rate = \$5, changed = \$6 WHERE id = \$1`).
-		WithArgs(instrument.ID, instrument.Title, instrument.ShortTitle, instrument.Symbol, instrument.Rate, instrument.Changed).
+		WithArgs(instrument.ID, instrument.Title, instrument.ShortTitle, instrument.Symbol, instrument.Rate, unixTime(instrument.Changed)).
 		WillReturnError(errors.New("update error"))
 
 	err = db.UpdateInstrument(context.Background(), instrument)
@@ -15,19 +15,19 @@ import (
 func (s *DB) GetMerchant(ctx context.Context, id string) (*models.Merchant, error) {
 	query := `
        SELECT id, "user", title, changed
-       FROM merchant
-       WHERE id = $1`
+       FROM ` + s.table("merchant") + `
+       WHERE id = $1 AND deleted_at IS NULL`
 
 	merchant := &models.Merchant{}
 	err := s.pool.QueryRow(ctx, query, id).Scan(
 		&merchant.ID,
 		&merchant.User,
 		&merchant.Title,
-		&merchant.Changed,
+		scanUnix(&merchant.Changed),
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("merchant not found: %s", id)
+			return nil, fmt.Errorf("merchant %w: %s", interfaces.ErrNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get merchant: %w", err)
 	}
@@ -50,14 +50,20 @@ func (s *DB) ListMerchants(
 		argNum++
 	}
 
+	from, args := s.source("merchant", filter, args)
+	argNum = len(args) + 1
+
 	query := `
        SELECT id, "user", title, changed
-       FROM merchant`
+       FROM ` + from
+
+	conditions = notDeleted(conditions, filter)
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
+	query += ` ORDER BY id`
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argNum, argNum+1)
 	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
 
@@ -74,7 +80,7 @@ func (s *DB) ListMerchants(
 			&merchant.ID,
 			&merchant.User,
 			&merchant.Title,
-			&merchant.Changed,
+			scanUnix(&merchant.Changed),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan merchant: %w", err)
@@ -92,14 +98,14 @@ func (s *DB) ListMerchants(
 // CreateMerchant creates a new merchant record
 func (s *DB) CreateMerchant(ctx context.Context, merchant *models.Merchant) error {
 	query := `
-       INSERT INTO merchant (id, "user", title, changed)
+       INSERT INTO ` + s.table("merchant") + ` (id, "user", title, changed)
        VALUES ($1, $2, $3, $4)`
 
 	_, err := s.pool.Exec(ctx, query,
 		merchant.ID,
 		merchant.User,
 		merchant.Title,
-		merchant.Changed,
+		unixTime(merchant.Changed),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create merchant: %w", err)
@@ -111,7 +117,7 @@ func (s *DB) CreateMerchant(ctx context.Context, merchant *models.Merchant) erro
 // UpdateMerchant updates an existing merchant record
 func (s *DB) UpdateMerchant(ctx context.Context, merchant *models.Merchant) error {
 	query := `
-       UPDATE merchant 
+       UPDATE ` + s.table("merchant") + ` 
        SET "user" = $2, title = $3, changed = $4
        WHERE id = $1`
 
@@ -119,14 +125,14 @@ func (s *DB) UpdateMerchant(ctx context.Context, merchant *models.Merchant) erro
 		merchant.ID,
 		merchant.User,
 		merchant.Title,
-		merchant.Changed,
+		unixTime(merchant.Changed),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update merchant: %w", err)
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("merchant not found: %s", merchant.ID)
+		return fmt.Errorf("merchant %w: %s", interfaces.ErrNotFound, merchant.ID)
 	}
 
 	return nil
@@ -134,7 +140,7 @@ func (s *DB) UpdateMerchant(ctx context.Context, merchant *models.Merchant) erro
 
 // DeleteMerchant deletes a merchant by its ID
 func (s *DB) DeleteMerchant(ctx context.Context, id string) error {
-	query := `DELETE FROM merchant WHERE id = $1`
+	query := s.deleteQuery("merchant", `id = $1`, "now()")
 
 	commandTag, err := s.pool.Exec(ctx, query, id)
 	if err != nil {
@@ -142,7 +148,7 @@ func (s *DB) DeleteMerchant(ctx context.Context, id string) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("merchant not found: %s", id)
+		return fmt.Errorf("merchant %w: %s", interfaces.ErrNotFound, id)
 	}
 
 	return nil
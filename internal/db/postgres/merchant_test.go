@@ -99,7 +99,7 @@ func TestListMerchants_Success(t *testing.T) {
 	rows := mock.NewRows([]string{"id", "user", "title", "changed"}).
 		AddRow("test-id", 1, "Test Merchant", 1234567890)
 
-	mock.ExpectQuery(`SELECT id, "user", title, changed FROM merchant WHERE "user" = \$1 LIMIT \$2 OFFSET \$3`).
+	mock.ExpectQuery(`SELECT id, "user", title, changed FROM merchant WHERE "user" = \$1 AND deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
 		WithArgs(1, 10, 0).
 		WillReturnRows(rows)
 
@@ -124,7 +124,7 @@ func TestListMerchants_QueryError(t *testing.T) {
 		Page:   1,
 	}
 
-	mock.ExpectQuery(`SELECT id, "user", title, changed FROM merchant WHERE "user" = \$1 LIMIT \$2 OFFSET \$3`).
+	mock.ExpectQuery(`SELECT id, "user", title, changed FROM merchant WHERE "user" = \$1 AND deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
 		WithArgs(1, 10, 0).
 		WillReturnError(errors.New("query error"))
 
@@ -149,7 +149,7 @@ func TestListMerchants_NoResults(t *testing.T) {
 		Page:   1,
 	}
 
-	mock.ExpectQuery(`SELECT id, "user", title, changed FROM merchant WHERE "user" = \$1 LIMIT \$2 OFFSET \$3`).
+	mock.ExpectQuery(`SELECT id, "user", title, changed FROM merchant WHERE "user" = \$1 AND deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
 		WithArgs(1, 10, 0).
 		WillReturnRows(pgxmock.NewRows([]string{"id", "user", "title", "changed"}))
 
@@ -175,7 +175,7 @@ func TestCreateMerchant_Success(t *testing.T) {
 	}
 
 	mock.ExpectExec(`INSERT INTO merchant \(id, "user", title, changed\) VALUES \(\$1, \$2, \$3, \$4\)`).
-		WithArgs(merchant.ID, merchant.User, merchant.Title, merchant.Changed).
+		WithArgs(merchant.ID, merchant.User, merchant.Title, unixTime(merchant.Changed)).
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 	err = db.CreateMerchant(context.Background(), merchant)
@@ -199,7 +199,7 @@ func TestCreateMerchant_QueryError(t *testing.T) {
 	}
 
 	mock.ExpectExec(`INSERT INTO merchant \(id, "user", title, changed\) VALUES \(\$1, \$2, \$3, \$4\)`).
-		WithArgs(merchant.ID, merchant.User, merchant.Title, merchant.Changed).
+		WithArgs(merchant.ID, merchant.User, merchant.Title, unixTime(merchant.Changed)).
 		WillReturnError(errors.New("query error"))
 
 	err = db.CreateMerchant(context.Background(), merchant)
@@ -224,7 +224,7 @@ func TestUpdateMerchant_Success(t *testing.T) {
 	}
 
 	mock.ExpectExec(`UPDATE merchant SET "user" = \$2, title = \$3, changed = \$4 WHERE id = \$1`).
-		WithArgs(merchant.ID, merchant.User, merchant.Title, merchant.Changed).
+		WithArgs(merchant.ID, merchant.User, merchant.Title, unixTime(merchant.Changed)).
 		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
 	err = db.UpdateMerchant(context.Background(), merchant)
@@ -248,7 +248,7 @@ func TestUpdateMerchant_QueryError(t *testing.T) {
 	}
 
 	mock.ExpectExec(`UPDATE merchant SET "user" = \$2, title = \$3, changed = \$4 WHERE id = \$1`).
-		WithArgs(merchant.ID, merchant.User, merchant.Title, merchant.Changed).
+		WithArgs(merchant.ID, merchant.User, merchant.Title, unixTime(merchant.Changed)).
 		WillReturnError(errors.New("query error"))
 
 	err = db.UpdateMerchant(context.Background(), merchant)
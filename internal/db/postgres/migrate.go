@@ -0,0 +1,221 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+)
+
+// migrationLockID is the key of the advisory lock held while migrations run
+const migrationLockID = 7_240_511_020
+
+// MigrationConnIface — interface for a single pgx.Conn used by migrations.
+// Advisory locks are bound to a session, so migrations don't go through the pool.
+type MigrationConnIface interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Close(ctx context.Context) error
+}
+
+// MigrationDriver applies migrations to PostgreSQL.
+// The version table layout is compatible with golang-migrate, so databases
+// migrated with the migrate/migrate container keep their version.
+type MigrationDriver struct {
+	conn MigrationConnIface
+	opts interfaces.StorageOptions
+}
+
+// NewMigrationDriver connects to PostgreSQL and creates a migration driver.
+// The schema and the table prefix of the options apply to the version table and the migration scripts.
+// Connecting doesn't write, so the version of a database can be checked with a read-only role.
+func NewMigrationDriver(
+	ctx context.Context,
+	connectionString string,
+	opts ...interfaces.StorageOption,
+) (interfaces.MigrationDriver, error) {
+	conn, err := pgx.Connect(ctx, connectionString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	return &MigrationDriver{conn: conn, opts: interfaces.NewStorageOptions(opts...)}, nil
+}
+
+// ensureVersionTable creates the schema and the schema_migrations table if they don't exist.
+// It's part of taking the lock, so only runs that write the schema need a role allowed to create them.
+func (d *MigrationDriver) ensureVersionTable(ctx context.Context) error {
+	if d.opts.Schema != "" {
+		if _, err := d.conn.Exec(ctx, `CREATE SCHEMA IF NOT EXISTS `+quoteIdent(d.opts.Schema)); err != nil {
+			return fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+
+	_, err := d.conn.Exec(ctx, `
+        CREATE TABLE IF NOT EXISTS `+d.table("schema_migrations")+` (
+            version BIGINT  NOT NULL PRIMARY KEY,
+            dirty   BOOLEAN NOT NULL
+        )`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+// Lock acquires the migration advisory lock, waiting for other runs to finish,
+// and creates the version table the migrations are recorded in
+func (d *MigrationDriver) Lock(ctx context.Context) error {
+	if _, err := d.conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	if err := d.ensureVersionTable(ctx); err != nil {
+		if uerr := d.Unlock(ctx); uerr != nil {
+			slog.Error("failed to release migration lock", "error", uerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// Unlock releases the migration advisory lock
+func (d *MigrationDriver) Unlock(ctx context.Context) error {
+	if _, err := d.conn.Exec(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to release migration lock: %w", err)
+	}
+	return nil
+}
+
+// Version returns the current schema version recorded in schema_migrations, 0 if there is no such table yet
+func (d *MigrationDriver) Version(ctx context.Context) (uint64, bool, error) {
+	var version int64
+	var dirty bool
+
+	err := d.conn.QueryRow(ctx, `SELECT version, dirty FROM `+d.table("schema_migrations")+` LIMIT 1`).
+		Scan(&version, &dirty)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return 0, false, nil
+	case errors.As(err, &pgErr) && (pgErr.Code == "42P01" || pgErr.Code == "3F000"):
+		// the table or the schema doesn't exist until the first migration
+		return 0, false, nil
+	case err != nil:
+		return 0, false, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	return uint64(version), dirty, nil
+}
+
+// SetVersion overwrites the recorded schema version
+func (d *MigrationDriver) SetVersion(ctx context.Context, version uint64, dirty bool) error {
+	tx, err := d.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer d.rollback(ctx, tx)
+
+	if err := d.setVersion(ctx, tx, version, dirty); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit schema version: %w", err)
+	}
+	return nil
+}
+
+// Apply runs the migration script and records the version in one transaction,
+// so a failed migration leaves the schema untouched
+func (d *MigrationDriver) Apply(ctx context.Context, script string, version uint64) error {
+	script, err := d.render(script)
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer d.rollback(ctx, tx)
+
+	if script != "" {
+		if _, err := tx.Exec(ctx, script); err != nil {
+			return fmt.Errorf("failed to execute migration script: %w", err)
+		}
+	}
+
+	if err := d.setVersion(ctx, tx, version, false); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit migration: %w", err)
+	}
+	return nil
+}
+
+// Close closes the migration connection
+func (d *MigrationDriver) Close(ctx context.Context) error {
+	return d.conn.Close(ctx)
+}
+
+func (d *MigrationDriver) rollback(ctx context.Context, tx pgx.Tx) {
+	if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+		slog.Error("failed to rollback migration transaction", "error", err)
+	}
+}
+
+func (d *MigrationDriver) setVersion(ctx context.Context, tx pgx.Tx, version uint64, dirty bool) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM `+d.table("schema_migrations")); err != nil {
+		return fmt.Errorf("failed to clear schema version: %w", err)
+	}
+
+	if version == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx,
+		`INSERT INTO `+d.table("schema_migrations")+` (version, dirty) VALUES ($1, $2)`,
+		int64(version), dirty,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to set schema version: %w", err)
+	}
+	return nil
+}
+
+// table returns the qualified name of a schema object, see DB.table
+func (d *MigrationDriver) table(name string) string {
+	return qualify(d.opts.Schema, d.opts.TablePrefix+name)
+}
+
+// render expands the names in a migration script:
+//
+//	{{ table "account" }}  qualified and prefixed name of a table, type or function
+//	{{ name "idx_x" }}     prefixed name of an index or constraint, which can't be qualified
+//	{{ prefix }}           the table prefix itself
+//	{{ partition }}        the partitioning granularity, empty if tables aren't partitioned
+func (d *MigrationDriver) render(script string) (string, error) {
+	tmpl, err := template.New("migration").Funcs(template.FuncMap{
+		"table":     d.table,
+		"name":      func(name string) string { return quoteIdent(d.opts.TablePrefix + name) },
+		"prefix":    func() string { return d.opts.TablePrefix },
+		"partition": func() string { return string(d.opts.Partitioning) },
+	}).Parse(script)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse migration script: %w", err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, nil); err != nil {
+		return "", fmt.Errorf("failed to render migration script: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/migrations"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationDriver_Version(t *testing.T) {
+	t.Run("applied version", func(t *testing.T) {
+		mock, err := pgxmock.NewConn()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations`).
+			WillReturnRows(pgxmock.NewRows([]string{"version", "dirty"}).AddRow(int64(3), false))
+
+		driver := &MigrationDriver{conn: mock}
+		version, dirty, err := driver.Version(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(3), version)
+		assert.False(t, dirty)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("empty table", func(t *testing.T) {
+		mock, err := pgxmock.NewConn()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations`).
+			WillReturnError(pgx.ErrNoRows)
+
+		driver := &MigrationDriver{conn: mock}
+		version, dirty, err := driver.Version(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), version)
+		assert.False(t, dirty)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing table", func(t *testing.T) {
+		mock, err := pgxmock.NewConn()
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT version, dirty FROM zenmoney.schema_migrations`).
+			WillReturnError(&pgconn.PgError{Code: "42P01", Message: `relation "zenmoney.schema_migrations" does not exist`})
+		mock.ExpectQuery(`SELECT version, dirty FROM zenmoney.schema_migrations`).
+			WillReturnError(&pgconn.PgError{Code: "42501", Message: "permission denied for table schema_migrations"})
+
+		driver := &MigrationDriver{conn: mock, opts: interfaces.NewStorageOptions(interfaces.WithSchema("zenmoney"))}
+		version, dirty, err := driver.Version(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), version)
+		assert.False(t, dirty)
+
+		_, _, err = driver.Version(context.Background())
+		assert.ErrorContains(t, err, "permission denied")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigrationDriver_Apply(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mock, err := pgxmock.NewConn()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE test`).WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+		mock.ExpectExec(`DELETE FROM schema_migrations`).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mock.ExpectExec(`INSERT INTO schema_migrations`).
+			WithArgs(int64(2), false).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		driver := &MigrationDriver{conn: mock}
+		err = driver.Apply(context.Background(), "CREATE TABLE test (id INT)", 2)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("revert to zero", func(t *testing.T) {
+		mock, err := pgxmock.NewConn()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DROP TABLE test`).WillReturnResult(pgxmock.NewResult("DROP TABLE", 0))
+		mock.ExpectExec(`DELETE FROM schema_migrations`).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		mock.ExpectCommit()
+
+		driver := &MigrationDriver{conn: mock}
+		err = driver.Apply(context.Background(), "DROP TABLE test", 0)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("script error", func(t *testing.T) {
+		mock, err := pgxmock.NewConn()
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE test`).WillReturnError(errors.New("syntax error"))
+		mock.ExpectRollback()
+
+		driver := &MigrationDriver{conn: mock}
+		err = driver.Apply(context.Background(), "CREATE TABLE test (id INT)", 2)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to execute migration script")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigrationDriver_Lock(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+
+	mock.ExpectExec(`SELECT pg_advisory_lock`).
+		WithArgs(migrationLockID).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).
+		WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).
+		WithArgs(migrationLockID).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+
+	driver := &MigrationDriver{conn: mock}
+	assert.NoError(t, driver.Lock(context.Background()))
+	assert.NoError(t, driver.Unlock(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrationDriver_Render(t *testing.T) {
+	driver := &MigrationDriver{opts: interfaces.NewStorageOptions(
+		interfaces.WithSchema("zenmoney"),
+		interfaces.WithTablePrefix("zm_"),
+	)}
+
+	script, err := driver.render(`CREATE TABLE {{ table "user" }} (id INT);
+CREATE INDEX {{ name "idx_user_id" }} ON {{ table "user" }} (id);
+SELECT '{{ prefix }}user_history';`)
+	require.NoError(t, err)
+	assert.Equal(t, `CREATE TABLE zenmoney.zm_user (id INT);
+CREATE INDEX zm_idx_user_id ON zenmoney.zm_user (id);
+SELECT 'zm_user_history';`, script)
+}
+
+func TestMigrationDriver_RenderEmbedded(t *testing.T) {
+	source, err := migrations.Source("postgres")
+	require.NoError(t, err)
+
+	scripts, err := fs.Glob(source, "*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, scripts)
+
+	for _, partitioning := range []interfaces.Partitioning{interfaces.PartitioningNone, interfaces.PartitioningMonth} {
+		driver := &MigrationDriver{opts: interfaces.NewStorageOptions(
+			interfaces.WithTablePrefix("zm_"),
+			interfaces.WithPartitioning(partitioning),
+		)}
+		for _, name := range scripts {
+			body, err := fs.ReadFile(source, name)
+			require.NoError(t, err)
+
+			script, err := driver.render(string(body))
+			assert.NoError(t, err, name)
+			assert.NotContains(t, script, "{{", name)
+		}
+	}
+}
+
+func TestMigrationDriver_RenderPartitioning(t *testing.T) {
+	source, err := migrations.Source("postgres")
+	require.NoError(t, err)
+
+	body, err := fs.ReadFile(source, "000007_partitioning.up.sql")
+	require.NoError(t, err)
+
+	script, err := (&MigrationDriver{}).render(string(body))
+	require.NoError(t, err)
+	assert.Contains(t, script, "CREATE OR REPLACE FUNCTION ensure_partition")
+	assert.NotContains(t, script, "SET merchant = NULL")
+
+	driver := &MigrationDriver{opts: interfaces.NewStorageOptions(interfaces.WithPartitioning(interfaces.PartitioningMonth))}
+	script, err = driver.render(string(body))
+	require.NoError(t, err)
+	assert.Contains(t, script, "SELECT repartition_table('transaction', 'month');")
+	assert.Contains(t, script, "SELECT repartition_table('reminder_marker', 'month');")
+}
+
+func TestMigrationDriver_ApplySchema(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TABLE zenmoney.account`).WillReturnResult(pgxmock.NewResult("CREATE TABLE", 0))
+	mock.ExpectExec(`DELETE FROM zenmoney.schema_migrations`).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectExec(`INSERT INTO zenmoney.schema_migrations`).
+		WithArgs(int64(1), false).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	driver := &MigrationDriver{conn: mock, opts: interfaces.NewStorageOptions(interfaces.WithSchema("zenmoney"))}
+	err = driver.Apply(context.Background(), `CREATE TABLE {{ table "account" }} (id UUID)`, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"regexp"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// plainIdent matches identifiers which can be used without quotes
+var plainIdent = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// reservedWords lists reserved words used as names in the schema
+var reservedWords = map[string]bool{
+	"user": true,
+}
+
+// table returns the name of a table or another schema object
+// qualified with the configured schema and prefixed with the table prefix
+func (s *DB) table(name string) string {
+	return qualify(s.opts.Schema, s.opts.TablePrefix+name)
+}
+
+// qualify returns the name qualified with the schema, the default schema is left implicit
+func qualify(schema, name string) string {
+	if schema == "" {
+		return quoteIdent(name)
+	}
+	return quoteIdent(schema) + "." + quoteIdent(name)
+}
+
+// quoteIdent quotes names which are reserved words or contain special characters
+func quoteIdent(name string) string {
+	if plainIdent.MatchString(name) && !reservedWords[name] {
+		return name
+	}
+	return pgx.Identifier{name}.Sanitize()
+}
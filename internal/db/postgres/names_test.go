@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTable(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   interfaces.StorageOptions
+		table  string
+		expect string
+	}{
+		{name: "default", table: "account", expect: "account"},
+		{name: "reserved word", table: "user", expect: `"user"`},
+		{name: "schema", opts: interfaces.StorageOptions{Schema: "zenmoney"}, table: "user", expect: `zenmoney."user"`},
+		{name: "prefix", opts: interfaces.StorageOptions{TablePrefix: "zm_"}, table: "user", expect: "zm_user"},
+		{
+			name:   "schema and prefix",
+			opts:   interfaces.StorageOptions{Schema: "Warehouse", TablePrefix: "zm_"},
+			table:  "transaction",
+			expect: `"Warehouse".zm_transaction`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &DB{opts: tt.opts}
+			assert.Equal(t, tt.expect, db.table(tt.table))
+		})
+	}
+}
+
+func TestGetMerchant_Schema(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock, opts: interfaces.NewStorageOptions(
+		interfaces.WithSchema("zenmoney"),
+		interfaces.WithTablePrefix("zm_"),
+	)}
+
+	rows := mock.NewRows([]string{"id", "user", "title", "changed"}).
+		AddRow("test-id", 1, "Test Merchant", 1234567890)
+
+	mock.ExpectQuery(`SELECT id, "user", title, changed FROM zenmoney.zm_merchant WHERE id = \$1`).
+		WithArgs("test-id").
+		WillReturnRows(rows)
+
+	merchant, err := db.GetMerchant(context.Background(), "test-id")
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Merchant", merchant.Title)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,302 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+)
+
+// reference is a reference between two entity tables
+type reference struct {
+	table  string
+	column string
+	parent string
+	// array columns hold several references, e.g. the tags of a transaction
+	array bool
+	// text columns hold UUIDs as TEXT and are compared with the parent id as text
+	text bool
+	// id identifies the rows of tables without an id column
+	id string
+}
+
+// references lists the foreign keys of the schema, parents are always referenced by id
+var references = []reference{
+	{table: "account", column: "instrument", parent: "instrument"},
+	{table: "account", column: "company", parent: "company"},
+	{table: "tag", column: "parent", parent: "tag"},
+	{table: "transaction", column: "income_account", parent: "account"},
+	{table: "transaction", column: "outcome_account", parent: "account"},
+	{table: "transaction", column: "income_instrument", parent: "instrument"},
+	{table: "transaction", column: "outcome_instrument", parent: "instrument"},
+	{table: "transaction", column: "merchant", parent: "merchant"},
+}
+
+// looseReferences lists references without a foreign key, which Save can't keep consistent
+var looseReferences = []reference{
+	{table: "user", column: "parent", parent: "user"},
+	{table: "budget", column: "tag", parent: "tag", id: `concat_ws(':', c."user", c.tag, c.date)`},
+	{table: "reminder", column: "income_account", parent: "account", text: true},
+	{table: "reminder", column: "outcome_account", parent: "account", text: true},
+	{table: "reminder", column: "income_instrument", parent: "instrument"},
+	{table: "reminder", column: "outcome_instrument", parent: "instrument"},
+	{table: "reminder", column: "merchant", parent: "merchant"},
+	{table: "reminder", column: "tag", parent: "tag", array: true},
+	{table: "reminder_marker", column: "reminder", parent: "reminder"},
+	{table: "reminder_marker", column: "income_account", parent: "account", text: true},
+	{table: "reminder_marker", column: "outcome_account", parent: "account", text: true},
+	{table: "reminder_marker", column: "income_instrument", parent: "instrument"},
+	{table: "reminder_marker", column: "outcome_instrument", parent: "instrument"},
+	{table: "reminder_marker", column: "merchant", parent: "merchant"},
+	{table: "reminder_marker", column: "tag", parent: "tag", array: true},
+	{table: "transaction", column: "tag", parent: "tag", array: true},
+	{table: "transaction", column: "reminder_marker", parent: "reminder_marker"},
+}
+
+// nilTag is the tag of budgets for expenses without a category
+const nilTag = "00000000-0000-0000-0000-000000000000"
+
+// FindOrphans returns references to objects which are missing in the database,
+// both of foreign keys and of references the schema doesn't enforce
+func (s *DB) FindOrphans(ctx context.Context) ([]interfaces.Orphan, error) {
+	return s.findOrphans(ctx, slices.Concat(references, looseReferences))
+}
+
+// findOrphans returns the dangling references of refs
+func (s *DB) findOrphans(ctx context.Context, refs []reference) ([]interfaces.Orphan, error) {
+	var orphans []interfaces.Orphan
+	for _, ref := range refs {
+		found, err := s.queryOrphans(ctx, ref, s.orphanQuery(ref))
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, found...)
+	}
+	return orphans, nil
+}
+
+// responseOrphans returns the dangling foreign keys the response can cause: held by the objects it saves,
+// or pointing to the objects it deletes. Only these rows are looked at, so the cost follows the response
+// rather than the size of the tables.
+func (s *DB) responseOrphans(ctx context.Context, response *models.Response) ([]interfaces.Orphan, error) {
+	saved := make(map[string][]string)
+	for _, a := range response.Account {
+		saved["account"] = append(saved["account"], a.ID)
+	}
+	for _, t := range response.Tag {
+		saved["tag"] = append(saved["tag"], t.ID)
+	}
+	for _, t := range response.Transaction {
+		saved["transaction"] = append(saved["transaction"], t.ID)
+	}
+	deleted := make(map[string][]string)
+	for _, d := range response.Deletion {
+		deleted[d.Object] = append(deleted[d.Object], d.ID)
+	}
+
+	var orphans []interfaces.Orphan
+	for _, ref := range references {
+		ids, parentIDs := saved[ref.table], deleted[ref.parent]
+		if len(ids) == 0 && len(parentIDs) == 0 {
+			continue
+		}
+		if ids == nil {
+			ids = []string{}
+		}
+		if parentIDs == nil {
+			parentIDs = []string{}
+		}
+
+		found, err := s.queryOrphans(ctx, ref, s.scopedOrphanQuery(ref), ids, parentIDs)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, found...)
+	}
+	return orphans, nil
+}
+
+// queryOrphans runs an orphan query of ref
+func (s *DB) queryOrphans(ctx context.Context, ref reference, query string, args ...any) ([]interfaces.Orphan, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find orphans in %s.%s: %w", ref.table, ref.column, err)
+	}
+	defer rows.Close()
+
+	var orphans []interfaces.Orphan
+	for rows.Next() {
+		orphan := interfaces.Orphan{
+			Entity:    ref.table,
+			Field:     ref.column,
+			Reference: ref.parent,
+		}
+		if err := rows.Scan(&orphan.ID, &orphan.MissingID); err != nil {
+			return nil, fmt.Errorf("failed to scan orphan: %w", err)
+		}
+		orphans = append(orphans, orphan)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating orphans: %w", err)
+	}
+	return orphans, nil
+}
+
+// orphanQuery selects the id of every row of ref.table and the value of ref.column it refers to
+// which has no row in ref.parent
+func (s *DB) orphanQuery(ref reference) string {
+	from, value, parentID := s.table(ref.table)+" c", "c."+ref.column, "p.id"
+	id := "c.id::text"
+	if ref.id != "" {
+		id = ref.id
+	}
+	if ref.array {
+		from += fmt.Sprintf(" CROSS JOIN LATERAL unnest(c.%s) AS r(value)", ref.column)
+		value = "r.value"
+	}
+	var extra string
+	switch {
+	case ref.text:
+		parentID = "p.id::text"
+		extra = fmt.Sprintf("\n          AND %s <> ''", value)
+	case ref.table == "budget" && ref.column == "tag":
+		extra = fmt.Sprintf("\n          AND %s <> '%s'", value, nilTag)
+	}
+
+	return fmt.Sprintf(`
+        SELECT %[6]s, %[2]s::text
+        FROM %[1]s
+        WHERE %[2]s IS NOT NULL%[5]s
+          AND NOT EXISTS (SELECT 1 FROM %[3]s p WHERE %[4]s = %[2]s)`,
+		from, value, s.table(ref.parent), parentID, extra, id)
+}
+
+// scopedOrphanQuery is orphanQuery limited to the rows of ref.table with an id of $1
+// and the rows referring to an id of $2, both given as text
+func (s *DB) scopedOrphanQuery(ref reference) string {
+	value := "c." + ref.column
+	if ref.array {
+		value = "r.value"
+	}
+	return s.orphanQuery(ref) + fmt.Sprintf(`
+          AND (c.id = ANY ($1::text[]::%s[]) OR %s = ANY ($2::text[]::%s[]))`,
+		idType(ref.table), value, idType(ref.parent))
+}
+
+// idType returns the SQL type of the id column of a table
+func idType(table string) string {
+	switch table {
+	case "instrument", "company", "country", "user":
+		return "integer"
+	default:
+		return "uuid"
+	}
+}
+
+// saveSkippingOrphans saves the response in a savepoint and looks for dangling foreign keys.
+// Objects of the response holding one, and deletions of objects still referenced, would fail the
+// deferred foreign keys on commit: they are logged and left out, and the rest is saved again.
+// Dangling references already in the database are logged and kept, audit can fetch the missing objects.
+func (s *DB) saveSkippingOrphans(ctx context.Context, response *models.Response) error {
+	for {
+		sp, err := s.pool.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to begin savepoint: %w", err)
+		}
+		spDB := s.withTx(sp)
+
+		if err := spDB.saveObjects(ctx, response); err != nil {
+			_ = sp.Rollback(ctx)
+			return err
+		}
+		orphans, err := spDB.responseOrphans(ctx, response)
+		if err != nil {
+			_ = sp.Rollback(ctx)
+			return fmt.Errorf("failed to report orphans: %w", err)
+		}
+
+		rest, skipped := withoutOrphans(response, orphans)
+		if skipped == 0 {
+			for _, o := range orphans {
+				slog.Warn("Dangling reference",
+					"entity", o.Entity, "id", o.ID, "field", o.Field,
+					"reference", o.Reference, "missing_id", o.MissingID,
+				)
+			}
+			if err := sp.Commit(ctx); err != nil {
+				return fmt.Errorf("failed to release savepoint: %w", err)
+			}
+			return nil
+		}
+
+		if err := sp.Rollback(ctx); err != nil {
+			return fmt.Errorf("failed to roll back to savepoint: %w", err)
+		}
+		response = rest
+	}
+}
+
+// withoutOrphans returns a copy of the response without its objects holding one of the orphans
+// and without the deletions of the objects the other orphans miss, along with the number of items left out
+func withoutOrphans(response *models.Response, orphans []interfaces.Orphan) (*models.Response, int) {
+	saved := make(map[string]bool)
+	for _, a := range response.Account {
+		saved["account:"+a.ID] = true
+	}
+	for _, t := range response.Tag {
+		saved["tag:"+t.ID] = true
+	}
+	for _, t := range response.Transaction {
+		saved["transaction:"+t.ID] = true
+	}
+
+	// objects are the orphans saved by the response, parents the objects missing for the rest
+	objects := make(map[string]interfaces.Orphan)
+	parents := make(map[string]interfaces.Orphan)
+	for _, o := range orphans {
+		if saved[o.Entity+":"+o.ID] {
+			objects[o.Entity+":"+o.ID] = o
+		} else {
+			parents[o.Reference+":"+o.MissingID] = o
+		}
+	}
+
+	skipped := 0
+	skip := func(entity, id string) bool {
+		o, ok := objects[entity+":"+id]
+		if ok {
+			slog.Warn("Skipped an object with a dangling reference",
+				"entity", entity, "id", id, "field", o.Field,
+				"reference", o.Reference, "missing_id", o.MissingID,
+			)
+			skipped++
+		}
+		return ok
+	}
+
+	rest := *response
+	rest.Account = slices.DeleteFunc(slices.Clone(response.Account), func(a models.Account) bool {
+		return skip("account", a.ID)
+	})
+	rest.Tag = slices.DeleteFunc(slices.Clone(response.Tag), func(t models.Tag) bool {
+		return skip("tag", t.ID)
+	})
+	rest.Transaction = slices.DeleteFunc(slices.Clone(response.Transaction), func(t models.Transaction) bool {
+		return skip("transaction", t.ID)
+	})
+	rest.Deletion = slices.DeleteFunc(slices.Clone(response.Deletion), func(d models.Deletion) bool {
+		o, ok := parents[d.Object+":"+d.ID]
+		if ok {
+			slog.Warn("Skipped the deletion of a referenced object",
+				"object", d.Object, "id", d.ID, "entity", o.Entity, "entity_id", o.ID, "field", o.Field,
+			)
+			skipped++
+		}
+		return ok
+	})
+
+	return &rest, skipped
+}
@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectOrphanQueries expects one orphan query per reference, the merchant reference returns a row
+func expectOrphanQueries(mock pgxmock.PgxPoolIface, refs []reference) {
+	for _, ref := range refs {
+		rows := pgxmock.NewRows([]string{"id", "missing_id"})
+		if ref.table == "transaction" && ref.column == "merchant" {
+			rows.AddRow("tx-1", "merchant-1")
+		}
+		mock.ExpectQuery(`FROM "?` + ref.table + `"? c`).WillReturnRows(rows)
+	}
+}
+
+func TestFindOrphans_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+	expectOrphanQueries(mock, slices.Concat(references, looseReferences))
+
+	orphans, err := db.FindOrphans(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []interfaces.Orphan{{
+		Entity:    "transaction",
+		ID:        "tx-1",
+		Field:     "merchant",
+		Reference: "merchant",
+		MissingID: "merchant-1",
+	}}, orphans)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindOrphans_QueryError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+	mock.ExpectQuery(`SELECT c.id::text`).WillReturnError(errors.New("query error"))
+
+	_, err = db.FindOrphans(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to find orphans in account.instrument")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithoutOrphans(t *testing.T) {
+	response := &models.Response{
+		Transaction: []models.Transaction{{ID: "tx-1"}, {ID: "tx-2"}},
+		Deletion: []models.Deletion{
+			{ID: "merchant-2", Object: "merchant"},
+			{ID: "tag-1", Object: "tag"},
+		},
+	}
+	orphans := []interfaces.Orphan{
+		// saved by the response
+		{Entity: "transaction", ID: "tx-1", Field: "merchant", Reference: "merchant", MissingID: "merchant-1"},
+		// left dangling by a deletion of the response
+		{Entity: "transaction", ID: "tx-3", Field: "merchant", Reference: "merchant", MissingID: "merchant-2"},
+		// already in the database
+		{Entity: "transaction", ID: "tx-4", Field: "merchant", Reference: "merchant", MissingID: "merchant-9"},
+	}
+
+	rest, skipped := withoutOrphans(response, orphans)
+
+	assert.Equal(t, 2, skipped)
+	assert.Equal(t, []models.Transaction{{ID: "tx-2"}}, rest.Transaction)
+	assert.Equal(t, []models.Deletion{{ID: "tag-1", Object: "tag"}}, rest.Deletion)
+	// the response itself is left as it was
+	assert.Len(t, response.Transaction, 2)
+	assert.Len(t, response.Deletion, 2)
+}
+
+func TestResponseOrphans(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+	response := &models.Response{
+		Account:  []models.Account{{ID: "acc-1"}},
+		Deletion: []models.Deletion{{ID: "10", Object: "instrument"}},
+	}
+
+	// account references are checked for the saved account, instrument references for the deleted instrument,
+	// the tag and transaction references to none of them are skipped
+	mock.ExpectQuery(`FROM "?account"? c .* c\.instrument = ANY \(\$2::text\[\]::integer\[\]\)`).
+		WithArgs([]string{"acc-1"}, []string{"10"}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "missing_id"}))
+	mock.ExpectQuery(`FROM "?account"? c .*c\.id = ANY \(\$1::text\[\]::uuid\[\]\) OR c\.company = ANY`).
+		WithArgs([]string{"acc-1"}, []string{}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "missing_id"}).AddRow("acc-1", "5"))
+	mock.ExpectQuery(`FROM "?transaction"? c .* OR c\.income_instrument = ANY`).
+		WithArgs([]string{}, []string{"10"}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "missing_id"}))
+	mock.ExpectQuery(`FROM "?transaction"? c .* OR c\.outcome_instrument = ANY`).
+		WithArgs([]string{}, []string{"10"}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "missing_id"}))
+
+	orphans, err := db.responseOrphans(context.Background(), response)
+	assert.NoError(t, err)
+	assert.Equal(t, []interfaces.Orphan{{
+		Entity:    "account",
+		ID:        "acc-1",
+		Field:     "company",
+		Reference: "company",
+		MissingID: "5",
+	}}, orphans)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOrphanQuery(t *testing.T) {
+	db := &DB{opts: interfaces.StorageOptions{TablePrefix: "zm_"}}
+
+	tests := []struct {
+		name string
+		ref  reference
+		want []string
+	}{
+		{
+			name: "foreign key",
+			ref:  references[0],
+			want: []string{
+				"SELECT c.id::text, c.instrument::text",
+				"FROM zm_account c",
+				"WHERE c.instrument IS NOT NULL\n",
+				"NOT EXISTS (SELECT 1 FROM zm_instrument p WHERE p.id = c.instrument)",
+			},
+		},
+		{
+			name: "array",
+			ref:  reference{table: "transaction", column: "tag", parent: "tag", array: true},
+			want: []string{
+				"SELECT c.id::text, r.value::text",
+				"FROM zm_transaction c CROSS JOIN LATERAL unnest(c.tag) AS r(value)",
+				"WHERE p.id = r.value)",
+			},
+		},
+		{
+			name: "text",
+			ref:  reference{table: "reminder", column: "income_account", parent: "account", text: true},
+			want: []string{
+				"AND c.income_account <> ''",
+				"WHERE p.id::text = c.income_account)",
+			},
+		},
+		{
+			name: "budget",
+			ref:  looseReferences[1],
+			want: []string{
+				`SELECT concat_ws(':', c."user", c.tag, c.date), c.tag::text`,
+				"AND c.tag <> '" + nilTag + "'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := db.orphanQuery(tt.ref)
+			for _, want := range tt.want {
+				assert.Contains(t, query, want)
+			}
+		})
+	}
+}
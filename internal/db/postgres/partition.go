@@ -0,0 +1,230 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+)
+
+// partitionedTables are the tables partitioned by date unless partitioning is none
+var partitionedTables = []string{"transaction", "reminder_marker"}
+
+// partitionBound matches the bound of a range partition, e.g. FOR VALUES FROM ('2024-01-01') TO ('2024-02-01')
+var partitionBound = regexp.MustCompile(`FROM \('(\d{4}-\d{2}-\d{2})'\) TO \('(\d{4}-\d{2}-\d{2})'\)`)
+
+// tableLayout is how a table is actually partitioned in the database
+type tableLayout struct {
+	exists      bool
+	partitioned bool
+	// granularity is empty for a partitioned table without range partitions, as it can't be told then
+	granularity interfaces.Partitioning
+}
+
+// matches reports whether the layout is the one the partitioning expects. A missing table is
+// created by the migrations in the configured layout, so it matches any partitioning.
+func (l tableLayout) matches(partitioning interfaces.Partitioning) bool {
+	switch {
+	case !l.exists:
+		return true
+	case !l.partitioned:
+		return partitioning == interfaces.PartitioningNone
+	default:
+		return partitioning != interfaces.PartitioningNone && (l.granularity == "" || l.granularity == partitioning)
+	}
+}
+
+func (l tableLayout) String() string {
+	switch {
+	case !l.partitioned:
+		return "not partitioned"
+	case l.granularity == "":
+		return "partitioned"
+	default:
+		return "partitioned by " + string(l.granularity)
+	}
+}
+
+// layout reads the partitioning of the table from the catalog,
+// the granularity is told by the span of one of its range partitions
+func (s *DB) layout(ctx context.Context, table string) (tableLayout, error) {
+	var layout tableLayout
+	var bound *string
+	err := s.pool.QueryRow(ctx, `
+		SELECT c.relkind = 'p',
+		       (SELECT pg_get_expr(p.relpartbound, p.oid)
+		        FROM pg_inherits i
+		                 JOIN pg_class p ON p.oid = i.inhrelid
+		        WHERE i.inhparent = c.oid
+		          AND pg_get_expr(p.relpartbound, p.oid) <> 'DEFAULT'
+		        LIMIT 1)
+		FROM pg_class c
+		WHERE c.oid = to_regclass($1)`, s.table(table)).Scan(&layout.partitioned, &bound)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return layout, nil
+	}
+	if err != nil {
+		return layout, fmt.Errorf("failed to read the layout of %s: %w", table, err)
+	}
+	layout.exists = true
+
+	if bound == nil {
+		return layout, nil
+	}
+	match := partitionBound.FindStringSubmatch(*bound)
+	if match == nil {
+		return layout, nil
+	}
+	from, err := time.Parse(time.DateOnly, match[1])
+	if err != nil {
+		return layout, nil
+	}
+	switch match[2] {
+	case from.AddDate(0, 1, 0).Format(time.DateOnly):
+		layout.granularity = interfaces.PartitioningMonth
+	case from.AddDate(1, 0, 0).Format(time.DateOnly):
+		layout.granularity = interfaces.PartitioningYear
+	}
+	return layout, nil
+}
+
+// CheckPartitioning fails when a table is partitioned differently from the partitioning setting.
+// The layout is set by the migrations, so saving with another setting would break the upserts.
+func (s *DB) CheckPartitioning(ctx context.Context) error {
+	for _, table := range partitionedTables {
+		layout, err := s.layout(ctx, table)
+		if err != nil {
+			return err
+		}
+		if !layout.matches(s.opts.Partitioning) {
+			return fmt.Errorf("%s is %s, but partitioning is %q: run `zenexport migrate repartition` "+
+				"to rebuild it, or change the setting", table, layout, s.opts.Partitioning)
+		}
+	}
+	return nil
+}
+
+// Repartition rebuilds the tables partitioned differently from the partitioning setting and returns their names.
+// Rows are kept as they are: a foreign key which fails to validate on a partitioned table fails the rebuild.
+func (s *DB) Repartition(ctx context.Context) ([]string, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	txDB := s.withTx(tx)
+
+	var granularity *string
+	if s.opts.Partitioning != interfaces.PartitioningNone {
+		value := string(s.opts.Partitioning)
+		granularity = &value
+	}
+
+	var rebuilt []string
+	for _, table := range partitionedTables {
+		layout, err := txDB.layout(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		if layout.matches(s.opts.Partitioning) {
+			continue
+		}
+
+		_, err = tx.Exec(ctx, `SELECT `+s.table("repartition_table")+`($1::regclass, $2)`, s.table(table), granularity)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, fmt.Errorf("failed to repartition %s, it holds dangling references: "+
+				"run `zenexport audit --fix` first: %w", table, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to repartition %s: %w", table, err)
+		}
+		rebuilt = append(rebuilt, table)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return rebuilt, nil
+}
+
+// partitioned reports whether the table is partitioned by date in the current partitioning mode
+func (s *DB) partitioned(table string) bool {
+	if s.opts.Partitioning == interfaces.PartitioningNone {
+		return false
+	}
+	return slices.Contains(partitionedTables, table)
+}
+
+// upsertKey returns the statement prefix and the conflict target of an upsert into the table.
+// A partitioned table is unique on (id, date) only, so a row whose date changed is moved
+// by deleting it from its old partition first. Rows without a date never conflict, so they are
+// replaced the same way. The id is $1 and the date is $3 of the upsert.
+func (s *DB) upsertKey(table string) (string, string) {
+	if !s.partitioned(table) {
+		return "", "(id)"
+	}
+	return `WITH moved AS (DELETE FROM ` + s.table(table) + ` WHERE id = $1 AND (date = $3::date) IS NOT TRUE) `, "(id, date)"
+}
+
+// ensurePartitions creates the missing partitions of the table for the given dates
+func (s *DB) ensurePartitions(ctx context.Context, table string, dates []string) error {
+	if !s.partitioned(table) {
+		return nil
+	}
+
+	periods, err := partitionPeriods(s.opts.Partitioning, dates)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT ` + s.table("ensure_partition") + `($1::regclass, $2, $3::date)`
+
+	batch := &pgx.Batch{}
+	for _, period := range periods {
+		batch.Queue(query, s.table(table), string(s.opts.Partitioning), period)
+	}
+
+	br := s.pool.SendBatch(ctx, batch)
+	defer br.Close()
+
+	for _, period := range periods {
+		if _, err := br.Exec(); err != nil {
+			return fmt.Errorf("failed to create %s partition for %s: %w", table, period, err)
+		}
+	}
+
+	return nil
+}
+
+// partitionPeriods returns the distinct first days of the periods holding the dates, in order of appearance
+func partitionPeriods(partitioning interfaces.Partitioning, dates []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var periods []string
+	for _, date := range dates {
+		day, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q: %w", date, err)
+		}
+
+		month := day.Month()
+		if partitioning == interfaces.PartitioningYear {
+			month = time.January
+		}
+		period := time.Date(day.Year(), month, 1, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)
+
+		if !seen[period] {
+			seen[period] = true
+			periods = append(periods, period)
+		}
+	}
+	return periods, nil
+}
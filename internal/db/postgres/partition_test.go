@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionPeriods(t *testing.T) {
+	dates := []string{"2024-02-25", "2024-02-01", "2023-12-31", "2024-03-10"}
+
+	periods, err := partitionPeriods(interfaces.PartitioningMonth, dates)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2024-02-01", "2023-12-01", "2024-03-01"}, periods)
+
+	periods, err = partitionPeriods(interfaces.PartitioningYear, dates)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2024-01-01", "2023-01-01"}, periods)
+
+	_, err = partitionPeriods(interfaces.PartitioningMonth, []string{""})
+	assert.Error(t, err)
+}
+
+func TestUpsertKey(t *testing.T) {
+	db := &DB{}
+	prefix, target := db.upsertKey("transaction")
+	assert.Empty(t, prefix)
+	assert.Equal(t, "(id)", target)
+
+	db = &DB{opts: interfaces.NewStorageOptions(interfaces.WithPartitioning(interfaces.PartitioningMonth))}
+	prefix, target = db.upsertKey("transaction")
+	assert.Equal(t, `WITH moved AS (DELETE FROM transaction WHERE id = $1 AND (date = $3::date) IS NOT TRUE) `, prefix)
+	assert.Equal(t, "(id, date)", target)
+
+	prefix, target = db.upsertKey("merchant")
+	assert.Empty(t, prefix)
+	assert.Equal(t, "(id)", target)
+}
+
+func TestSaveTransactions_Partitioned(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock, opts: interfaces.NewStorageOptions(interfaces.WithPartitioning(interfaces.PartitioningYear))}
+
+	transactions := []models.Transaction{
+		{ID: "txn-1", User: 1, Date: "2024-02-25"},
+		{ID: "txn-2", User: 1, Date: "2024-07-01"},
+		{ID: "txn-3", User: 1, Date: "2023-12-31"},
+	}
+
+	partitions := mock.ExpectBatch()
+	partitions.ExpectExec(`SELECT ensure_partition\(\$1::regclass, \$2, \$3::date\)`).
+		WithArgs("transaction", "year", "2024-01-01").
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	partitions.ExpectExec(`SELECT ensure_partition\(\$1::regclass, \$2, \$3::date\)`).
+		WithArgs("transaction", "year", "2023-01-01").
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+
+	upserts := mock.ExpectBatch()
+	for range transactions {
+		upserts.ExpectExec(`WITH moved AS \(DELETE FROM transaction WHERE id = \$1 AND \(date = \$3::date\) IS NOT TRUE\) INSERT INTO transaction .* ON CONFLICT \(id, date\) DO UPDATE SET`).
+			WithArgs(anyArgs(31)...).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	}
+
+	err = db.SaveTransactions(context.Background(), transactions)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateReminderMarker_Partitioned(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock, opts: interfaces.NewStorageOptions(interfaces.WithPartitioning(interfaces.PartitioningMonth))}
+
+	partitions := mock.ExpectBatch()
+	partitions.ExpectExec(`SELECT ensure_partition`).
+		WithArgs("reminder_marker", "month", "2024-02-01").
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	mock.ExpectExec(`INSERT INTO reminder_marker`).
+		WithArgs(anyArgs(18)...).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	err = db.CreateReminderMarker(context.Background(), &models.ReminderMarker{ID: "marker-1", Date: "2024-02-25"})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// anyArgs matches n arguments of any value
+func anyArgs(n int) []interface{} {
+	args := make([]interface{}, n)
+	for i := range args {
+		args[i] = pgxmock.AnyArg()
+	}
+	return args
+}
+
+func expectLayout(mock pgxmock.PgxPoolIface, table string, partitioned bool, bound *string) {
+	mock.ExpectQuery(`SELECT c.relkind = 'p'`).
+		WithArgs(table).
+		WillReturnRows(pgxmock.NewRows([]string{"partitioned", "bound"}).AddRow(partitioned, bound))
+}
+
+func TestLayout(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+	month := "FOR VALUES FROM ('2024-02-01') TO ('2024-03-01')"
+	year := "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')"
+
+	expectLayout(mock, "transaction", true, &month)
+	expectLayout(mock, "transaction", true, &year)
+	expectLayout(mock, "transaction", true, nil)
+	expectLayout(mock, "transaction", false, nil)
+	mock.ExpectQuery(`SELECT c.relkind = 'p'`).
+		WithArgs("transaction").
+		WillReturnRows(pgxmock.NewRows([]string{"partitioned", "bound"}))
+
+	for _, want := range []tableLayout{
+		{exists: true, partitioned: true, granularity: interfaces.PartitioningMonth},
+		{exists: true, partitioned: true, granularity: interfaces.PartitioningYear},
+		{exists: true, partitioned: true},
+		{exists: true},
+		{},
+	} {
+		layout, err := db.layout(context.Background(), "transaction")
+		require.NoError(t, err)
+		assert.Equal(t, want, layout)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTableLayout_Matches(t *testing.T) {
+	plain := tableLayout{exists: true}
+	byMonth := tableLayout{exists: true, partitioned: true, granularity: interfaces.PartitioningMonth}
+	unknown := tableLayout{exists: true, partitioned: true}
+
+	assert.True(t, tableLayout{}.matches(interfaces.PartitioningYear))
+	assert.True(t, plain.matches(interfaces.PartitioningNone))
+	assert.False(t, plain.matches(interfaces.PartitioningMonth))
+	assert.True(t, byMonth.matches(interfaces.PartitioningMonth))
+	assert.False(t, byMonth.matches(interfaces.PartitioningYear))
+	assert.False(t, byMonth.matches(interfaces.PartitioningNone))
+	assert.True(t, unknown.matches(interfaces.PartitioningYear))
+	assert.False(t, unknown.matches(interfaces.PartitioningNone))
+}
+
+func TestCheckPartitioning(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock, opts: interfaces.NewStorageOptions(interfaces.WithPartitioning(interfaces.PartitioningYear))}
+	year := "FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')"
+	month := "FOR VALUES FROM ('2024-02-01') TO ('2024-03-01')"
+
+	expectLayout(mock, "transaction", true, &year)
+	expectLayout(mock, "reminder_marker", true, nil)
+	assert.NoError(t, db.CheckPartitioning(context.Background()))
+
+	expectLayout(mock, "transaction", true, &month)
+	err = db.CheckPartitioning(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `transaction is partitioned by month, but partitioning is "year"`)
+	assert.Contains(t, err.Error(), "zenexport migrate repartition")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepartition(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock, opts: interfaces.NewStorageOptions(interfaces.WithPartitioning(interfaces.PartitioningMonth))}
+	month := "FOR VALUES FROM ('2024-02-01') TO ('2024-03-01')"
+
+	mock.ExpectBegin()
+	expectLayout(mock, "transaction", false, nil)
+	mock.ExpectExec(`SELECT repartition_table\(\$1::regclass, \$2\)`).
+		WithArgs("transaction", pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+	expectLayout(mock, "reminder_marker", true, &month)
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	tables, err := db.Repartition(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"transaction"}, tables)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// purgeOrder lists entity tables with children before their parents
+var purgeOrder = []string{
+	"transaction",
+	"reminder_marker",
+	"reminder",
+	"budget",
+	"merchant",
+	"tag",
+	"account",
+	"user",
+	"company",
+	"country",
+	"instrument",
+}
+
+// PurgeDeleted permanently removes tombstones deleted before the given time.
+// Tombstones still referenced by other rows are kept until the references are purged.
+func (s *DB) PurgeDeleted(ctx context.Context, before time.Time) (map[string]int64, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func(tx pgx.Tx, ctx context.Context) {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
+			slog.Error("failed to rollback purge transaction", "error", err)
+		}
+	}(tx, ctx)
+
+	purged := make(map[string]int64, len(purgeOrder))
+	for _, table := range purgeOrder {
+		conditions := []string{"t.deleted_at IS NOT NULL", "t.deleted_at < $1"}
+		for _, ref := range references {
+			if ref.parent == table {
+				conditions = append(conditions, fmt.Sprintf(
+					"NOT EXISTS (SELECT 1 FROM %s c WHERE c.%s = t.id)", s.table(ref.table), ref.column))
+			}
+		}
+
+		query := fmt.Sprintf(`DELETE FROM %s t WHERE %s`, s.table(table), strings.Join(conditions, " AND "))
+
+		commandTag, err := tx.Exec(ctx, query, before)
+		if err != nil {
+			return nil, fmt.Errorf("failed to purge %s: %w", table, err)
+		}
+		purged[table] = commandTag.RowsAffected()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit purge transaction: %w", err)
+	}
+
+	return purged, nil
+}
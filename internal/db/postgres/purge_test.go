@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeDeleted_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM transaction t WHERE t.deleted_at IS NOT NULL AND t.deleted_at < \$1$`).
+		WithArgs(before).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+	for _, table := range purgeOrder[1:] {
+		result := pgxmock.NewResult("DELETE", 0)
+		if table == "account" {
+			mock.ExpectExec(`DELETE FROM account t WHERE t.deleted_at IS NOT NULL AND t.deleted_at < \$1 ` +
+				`AND NOT EXISTS \(SELECT 1 FROM transaction c WHERE c.income_account = t.id\) ` +
+				`AND NOT EXISTS \(SELECT 1 FROM transaction c WHERE c.outcome_account = t.id\)`).
+				WithArgs(before).
+				WillReturnResult(pgxmock.NewResult("DELETE", 1))
+			continue
+		}
+		mock.ExpectExec(`DELETE FROM ` + quoteIdent(table) + ` t`).WithArgs(before).WillReturnResult(result)
+	}
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	purged, err := db.PurgeDeleted(context.Background(), before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged["transaction"])
+	assert.Equal(t, int64(1), purged["account"])
+	assert.Len(t, purged, len(purgeOrder))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeDeleted_ExecError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+	before := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM transaction t`).
+		WithArgs(before).
+		WillReturnError(errors.New("exec error"))
+	mock.ExpectRollback()
+
+	_, err = db.PurgeDeleted(context.Background(), before)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to purge transaction")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+)
+
+// rawTables lists the entity tables keeping the raw payload of their objects
+var rawTables = []string{
+	"instrument", "country", "company", "user", "account", "tag",
+	"merchant", "budget", "reminder", "reminder_marker", "transaction",
+}
+
+// rawPayloads serializes objects for the raw column
+func rawPayloads[T any](objects []T) ([][]byte, error) {
+	payloads := make([][]byte, len(objects))
+	for i, object := range objects {
+		payload, err := json.Marshal(object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode raw payload %d: %w", i, err)
+		}
+		payloads[i] = payload
+	}
+	return payloads, nil
+}
+
+// SchemaDrift returns keys of raw payloads which have no column of their own
+func (s *DB) SchemaDrift(ctx context.Context) ([]interfaces.Drift, error) {
+	drift := []interfaces.Drift{}
+	for _, table := range rawTables {
+		columns, err := s.columns(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := s.pool.Query(ctx, `
+            SELECT k.key, count(*)
+            FROM `+s.table(table)+` t
+                     CROSS JOIN LATERAL jsonb_object_keys(t.raw) AS k(key)
+            WHERE jsonb_typeof(t.raw) = 'object'
+            GROUP BY k.key
+            ORDER BY k.key`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s payload keys: %w", table, err)
+		}
+
+		for rows.Next() {
+			var key string
+			var count int64
+			if err := rows.Scan(&key, &count); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan %s payload key: %w", table, err)
+			}
+			if column := columnName(key); !columns[column] {
+				drift = append(drift, interfaces.Drift{Entity: table, Key: key, Column: column, Rows: count})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error iterating %s payload keys: %w", table, err)
+		}
+	}
+
+	return drift, nil
+}
+
+// columns returns the column names of a table
+func (s *DB) columns(ctx context.Context, table string) (map[string]bool, error) {
+	var schema *string
+	if s.opts.Schema != "" {
+		schema = &s.opts.Schema
+	}
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT column_name
+        FROM information_schema.columns
+        WHERE table_schema = coalesce($1, current_schema()) AND table_name = $2`,
+		schema, s.opts.TablePrefix+table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s columns: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("failed to scan %s column: %w", table, err)
+		}
+		columns[column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s columns: %w", table, err)
+	}
+
+	return columns, nil
+}
+
+// columnName converts a payload key to the column it is stored in, e.g. incomeBankID to income_bank_id
+func columnName(key string) string {
+	runes := []rune(key)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnName(t *testing.T) {
+	tests := map[string]string{
+		"id":               "id",
+		"user":             "user",
+		"incomeInstrument": "income_instrument",
+		"incomeBankID":     "income_bank_id",
+		"syncID":           "sync_id",
+		"enableSMS":        "enable_sms",
+		"qrCode":           "qr_code",
+		"monthStartDay":    "month_start_day",
+	}
+	for key, column := range tests {
+		assert.Equal(t, column, columnName(key), key)
+	}
+}
+
+func TestRawPayloads(t *testing.T) {
+	payloads, err := rawPayloads([]models.Merchant{{ID: "merchant-1", User: 1, Title: "Shop"}})
+	require.NoError(t, err)
+	require.Len(t, payloads, 1)
+	assert.JSONEq(t, `{"id":"merchant-1","user":1,"title":"Shop","changed":0}`, string(payloads[0]))
+}
+
+func TestSchemaDrift(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock, opts: interfaces.NewStorageOptions(interfaces.WithTablePrefix("zm_"))}
+
+	for _, table := range rawTables {
+		mock.ExpectQuery(`SELECT column_name FROM information_schema.columns`).
+			WithArgs((*string)(nil), "zm_"+table).
+			WillReturnRows(mock.NewRows([]string{"column_name"}).
+				AddRow("id").AddRow("title").AddRow("changed"))
+
+		keys := mock.NewRows([]string{"key", "count"})
+		if table == "merchant" {
+			keys.AddRow("changed", int64(3)).AddRow("id", int64(3)).AddRow("logoURL", int64(2))
+		}
+		mock.ExpectQuery(`SELECT k.key, count\(\*\) FROM zm_` + table + ` t CROSS JOIN LATERAL jsonb_object_keys\(t.raw\)`).
+			WillReturnRows(keys)
+	}
+
+	drift, err := db.SchemaDrift(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []interfaces.Drift{
+		{Entity: "merchant", Key: "logoURL", Column: "logo_url", Rows: 2},
+	}, drift)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -18,17 +18,17 @@ func (s *DB) GetReminderMarker(ctx context.Context, id string) (*models.Reminder
                income_instrument, outcome_instrument, state, is_forecast,
                reminder, income_account, outcome_account, comment,
                payee, merchant, notify, tag
-        FROM reminder_marker
-        WHERE id = $1`
+        FROM ` + s.table("reminder_marker") + `
+        WHERE id = $1 AND deleted_at IS NULL`
 
 	marker := &models.ReminderMarker{}
 	err := s.pool.QueryRow(ctx, query, id).Scan(
 		&marker.ID,
 		&marker.User,
-		&marker.Date,
+		scanDate(&marker.Date),
 		&marker.Income,
 		&marker.Outcome,
-		&marker.Changed,
+		scanUnix(&marker.Changed),
 		&marker.IncomeInstrument,
 		&marker.OutcomeInstrument,
 		&marker.State,
@@ -44,7 +44,7 @@ func (s *DB) GetReminderMarker(ctx context.Context, id string) (*models.Reminder
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("reminder marker not found: %s", id)
+			return nil, fmt.Errorf("reminder marker %w: %s", interfaces.ErrNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get reminder marker: %w", err)
 	}
@@ -79,17 +79,23 @@ func (s *DB) ListReminderMarkers(
 		argNum++
 	}
 
+	from, args := s.source("reminder_marker", filter, args)
+	argNum = len(args) + 1
+
 	query := `
         SELECT id, "user", date, income, outcome, changed,
                income_instrument, outcome_instrument, state, is_forecast,
                reminder, income_account, outcome_account, comment,
                payee, merchant, notify, tag
-        FROM reminder_marker`
+        FROM ` + from
+
+	conditions = notDeleted(conditions, filter)
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
+	query += ` ORDER BY id`
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argNum, argNum+1)
 	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
 
@@ -105,10 +111,10 @@ func (s *DB) ListReminderMarkers(
 		err := rows.Scan(
 			&marker.ID,
 			&marker.User,
-			&marker.Date,
+			scanDate(&marker.Date),
 			&marker.Income,
 			&marker.Outcome,
-			&marker.Changed,
+			scanUnix(&marker.Changed),
 			&marker.IncomeInstrument,
 			&marker.OutcomeInstrument,
 			&marker.State,
@@ -137,8 +143,12 @@ func (s *DB) ListReminderMarkers(
 
 // CreateReminderMarker creates a new reminder marker record
 func (s *DB) CreateReminderMarker(ctx context.Context, marker *models.ReminderMarker) error {
+	if err := s.ensurePartitions(ctx, "reminder_marker", []string{marker.Date}); err != nil {
+		return err
+	}
+
 	query := `
-        INSERT INTO reminder_marker (
+        INSERT INTO ` + s.table("reminder_marker") + ` (
             id, "user", date, income, outcome, changed,
             income_instrument, outcome_instrument, state, is_forecast,
             reminder, income_account, outcome_account, comment,
@@ -152,7 +162,7 @@ func (s *DB) CreateReminderMarker(ctx context.Context, marker *models.ReminderMa
 		marker.Date,
 		marker.Income,
 		marker.Outcome,
-		marker.Changed,
+		unixTime(marker.Changed),
 		marker.IncomeInstrument,
 		marker.OutcomeInstrument,
 		marker.State,
@@ -176,7 +186,7 @@ func (s *DB) CreateReminderMarker(ctx context.Context, marker *models.ReminderMa
 // UpdateReminderMarker updates an existing reminder marker record
 func (s *DB) UpdateReminderMarker(ctx context.Context, marker *models.ReminderMarker) error {
 	query := `
-        UPDATE reminder_marker SET
+        UPDATE ` + s.table("reminder_marker") + ` SET
             "user" = $2,
             date = $3,
             income = $4,
@@ -202,7 +212,7 @@ func (s *DB) UpdateReminderMarker(ctx context.Context, marker *models.ReminderMa
 		marker.Date,
 		marker.Income,
 		marker.Outcome,
-		marker.Changed,
+		unixTime(marker.Changed),
 		marker.IncomeInstrument,
 		marker.OutcomeInstrument,
 		marker.State,
@@ -221,7 +231,7 @@ func (s *DB) UpdateReminderMarker(ctx context.Context, marker *models.ReminderMa
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("reminder marker not found: %s", marker.ID)
+		return fmt.Errorf("reminder marker %w: %s", interfaces.ErrNotFound, marker.ID)
 	}
 
 	return nil
@@ -229,7 +239,7 @@ func (s *DB) UpdateReminderMarker(ctx context.Context, marker *models.ReminderMa
 
 // DeleteReminderMarker deletes a reminder marker by its ID
 func (s *DB) DeleteReminderMarker(ctx context.Context, id string) error {
-	query := `DELETE FROM reminder_marker WHERE id = $1`
+	query := s.deleteQuery("reminder_marker", `id = $1`, "now()")
 
 	commandTag, err := s.pool.Exec(ctx, query, id)
 	if err != nil {
@@ -237,7 +247,7 @@ func (s *DB) DeleteReminderMarker(ctx context.Context, id string) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("reminder marker not found: %s", id)
+		return fmt.Errorf("reminder marker %w: %s", interfaces.ErrNotFound, id)
 	}
 
 	return nil
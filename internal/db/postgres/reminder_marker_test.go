@@ -179,7 +179,7 @@ func TestListReminderMarkers_Success(t *testing.T) {
 		expectedMarker.Tag,
 	)
 
-	mock.ExpectQuery(`SELECT id, "user", date, income, outcome, changed, income_instrument, outcome_instrument, state, is_forecast, reminder, income_account, outcome_account, comment, payee, merchant, notify, tag FROM reminder_marker WHERE "user" = \$1 AND date >= \$2 AND date <= \$3 LIMIT \$4 OFFSET \$5`).
+	mock.ExpectQuery(`SELECT id, "user", date, income, outcome, changed, income_instrument, outcome_instrument, state, is_forecast, reminder, income_account, outcome_account, comment, payee, merchant, notify, tag FROM reminder_marker WHERE "user" = \$1 AND date >= \$2 AND date <= \$3 AND deleted_at IS NULL ORDER BY id LIMIT \$4 OFFSET \$5`).
 		WithArgs(1, "2025-01-01", "2025-02-01", 10, 0).
 		WillReturnRows(rows)
 
@@ -206,7 +206,7 @@ func TestListReminderMarkers_QueryError(t *testing.T) {
 		Page:      1,
 	}
 
-	mock.ExpectQuery(`SELECT id, "user", date, income, outcome, changed, income_instrument, outcome_instrument, state, is_forecast, reminder, income_account, outcome_account, comment, payee, merchant, notify, tag FROM reminder_marker WHERE "user" = \$1 AND date >= \$2 AND date <= \$3 LIMIT \$4 OFFSET \$5`).
+	mock.ExpectQuery(`SELECT id, "user", date, income, outcome, changed, income_instrument, outcome_instrument, state, is_forecast, reminder, income_account, outcome_account, comment, payee, merchant, notify, tag FROM reminder_marker WHERE "user" = \$1 AND date >= \$2 AND date <= \$3 AND deleted_at IS NULL ORDER BY id LIMIT \$4 OFFSET \$5`).
 		WithArgs(1, "2025-01-01", "2025-02-01", 10, 0).
 		WillReturnError(errors.New("database error"))
 
@@ -258,7 +258,7 @@ func TestCreateReminderMarker_Success(t *testing.T) {
 			marker.Date,
 			marker.Income,
 			marker.Outcome,
-			marker.Changed,
+			unixTime(marker.Changed),
 			marker.IncomeInstrument,
 			marker.OutcomeInstrument,
 			marker.State,
@@ -318,7 +318,7 @@ func TestCreateReminderMarker_QueryError(t *testing.T) {
 			marker.Date,
 			marker.Income,
 			marker.Outcome,
-			marker.Changed,
+			unixTime(marker.Changed),
 			marker.IncomeInstrument,
 			marker.OutcomeInstrument,
 			marker.State,
@@ -393,7 +393,7 @@ func TestUpdateReminderMarker_Success(t *testing.T) {
 			marker.Date,
 			marker.Income,
 			marker.Outcome,
-			marker.Changed,
+			unixTime(marker.Changed),
 			marker.IncomeInstrument,
 			marker.OutcomeInstrument,
 			marker.State,
@@ -466,7 +466,7 @@ func TestUpdateReminderMarker_QueryError(t *testing.T) {
 			marker.Date,
 			marker.Income,
 			marker.Outcome,
-			marker.Changed,
+			unixTime(marker.Changed),
 			marker.IncomeInstrument,
 			marker.OutcomeInstrument,
 			marker.State,
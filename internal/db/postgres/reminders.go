@@ -18,8 +18,8 @@ func (s *DB) GetReminder(ctx context.Context, id string) (*models.Reminder, erro
                outcome_instrument, step, points, tag, start_date, end_date,
                notify, interval, income_account, outcome_account, comment,
                payee, merchant
-        FROM reminder
-        WHERE id = $1`
+        FROM ` + s.table("reminder") + `
+        WHERE id = $1 AND deleted_at IS NULL`
 
 	reminder := &models.Reminder{}
 	err := s.pool.QueryRow(ctx, query, id).Scan(
@@ -27,7 +27,7 @@ func (s *DB) GetReminder(ctx context.Context, id string) (*models.Reminder, erro
 		&reminder.User,
 		&reminder.Income,
 		&reminder.Outcome,
-		&reminder.Changed,
+		scanUnix(&reminder.Changed),
 		&reminder.IncomeInstrument,
 		&reminder.OutcomeInstrument,
 		&reminder.Step,
@@ -45,7 +45,7 @@ func (s *DB) GetReminder(ctx context.Context, id string) (*models.Reminder, erro
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("reminder not found: %s", id)
+			return nil, fmt.Errorf("reminder %w: %s", interfaces.ErrNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get reminder: %w", err)
 	}
@@ -68,17 +68,23 @@ func (s *DB) ListReminders(
 		argNum++
 	}
 
+	from, args := s.source("reminder", filter, args)
+	argNum = len(args) + 1
+
 	query := `
         SELECT id, "user", income, outcome, changed, income_instrument,
                outcome_instrument, step, points, tag, start_date, end_date,
                notify, interval, income_account, outcome_account, comment,
                payee, merchant
-        FROM reminder`
+        FROM ` + from
+
+	conditions = notDeleted(conditions, filter)
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
+	query += ` ORDER BY id`
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argNum, argNum+1)
 	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
 
@@ -96,7 +102,7 @@ func (s *DB) ListReminders(
 			&reminder.User,
 			&reminder.Income,
 			&reminder.Outcome,
-			&reminder.Changed,
+			scanUnix(&reminder.Changed),
 			&reminder.IncomeInstrument,
 			&reminder.OutcomeInstrument,
 			&reminder.Step,
@@ -128,7 +134,7 @@ func (s *DB) ListReminders(
 // CreateReminder creates a new reminder record
 func (s *DB) CreateReminder(ctx context.Context, reminder *models.Reminder) error {
 	query := `
-        INSERT INTO reminder (
+        INSERT INTO ` + s.table("reminder") + ` (
             id, "user", income, outcome, changed, income_instrument,
             outcome_instrument, step, points, tag, start_date, end_date,
             notify, interval, income_account, outcome_account, comment,
@@ -141,7 +147,7 @@ func (s *DB) CreateReminder(ctx context.Context, reminder *models.Reminder) erro
 		reminder.User,
 		reminder.Income,
 		reminder.Outcome,
-		reminder.Changed,
+		unixTime(reminder.Changed),
 		reminder.IncomeInstrument,
 		reminder.OutcomeInstrument,
 		reminder.Step,
@@ -167,7 +173,7 @@ func (s *DB) CreateReminder(ctx context.Context, reminder *models.Reminder) erro
 // UpdateReminder updates an existing reminder record
 func (s *DB) UpdateReminder(ctx context.Context, reminder *models.Reminder) error {
 	query := `
-        UPDATE reminder SET
+        UPDATE ` + s.table("reminder") + ` SET
             "user" = $2,
             income = $3,
             outcome = $4,
@@ -193,7 +199,7 @@ func (s *DB) UpdateReminder(ctx context.Context, reminder *models.Reminder) erro
 		reminder.User,
 		reminder.Income,
 		reminder.Outcome,
-		reminder.Changed,
+		unixTime(reminder.Changed),
 		reminder.IncomeInstrument,
 		reminder.OutcomeInstrument,
 		reminder.Step,
@@ -214,7 +220,7 @@ func (s *DB) UpdateReminder(ctx context.Context, reminder *models.Reminder) erro
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("reminder not found: %s", reminder.ID)
+		return fmt.Errorf("reminder %w: %s", interfaces.ErrNotFound, reminder.ID)
 	}
 
 	return nil
@@ -222,7 +228,7 @@ func (s *DB) UpdateReminder(ctx context.Context, reminder *models.Reminder) erro
 
 // DeleteReminder deletes a reminder by its ID
 func (s *DB) DeleteReminder(ctx context.Context, id string) error {
-	query := `DELETE FROM reminder WHERE id = $1`
+	query := s.deleteQuery("reminder", `id = $1`, "now()")
 
 	commandTag, err := s.pool.Exec(ctx, query, id)
 	if err != nil {
@@ -230,7 +236,7 @@ func (s *DB) DeleteReminder(ctx context.Context, id string) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("reminder not found: %s", id)
+		return fmt.Errorf("reminder %w: %s", interfaces.ErrNotFound, id)
 	}
 
 	return nil
@@ -186,7 +186,7 @@ func TestListReminders_Success(t *testing.T) {
 		expectedReminder.Merchant,
 	)
 
-	mock.ExpectQuery(`SELECT id, "user", income, outcome, changed, income_instrument, outcome_instrument, step, points, tag, start_date, end_date, notify, interval, income_account, outcome_account, comment, payee, merchant FROM reminder WHERE "user" = \$1 LIMIT \$2 OFFSET \$3`).
+	mock.ExpectQuery(`SELECT id, "user", income, outcome, changed, income_instrument, outcome_instrument, step, points, tag, start_date, end_date, notify, interval, income_account, outcome_account, comment, payee, merchant FROM reminder WHERE "user" = \$1 AND deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
 		WithArgs(1, 10, 0).
 		WillReturnRows(rows)
 
@@ -211,7 +211,7 @@ func TestListReminders_QueryError(t *testing.T) {
 		Page:   1,
 	}
 
-	mock.ExpectQuery(`SELECT id, "user", income, outcome, changed, income_instrument, outcome_instrument, step, points, tag, start_date, end_date, notify, interval, income_account, outcome_account, comment, payee, merchant FROM reminder WHERE "user" = \$1 LIMIT \$2 OFFSET \$3`).
+	mock.ExpectQuery(`SELECT id, "user", income, outcome, changed, income_instrument, outcome_instrument, step, points, tag, start_date, end_date, notify, interval, income_account, outcome_account, comment, payee, merchant FROM reminder WHERE "user" = \$1 AND deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
 		WithArgs(1, 10, 0).
 		WillReturnError(errors.New("database error"))
 
@@ -263,7 +263,7 @@ func TestCreateReminder_Success(t *testing.T) {
 			reminder.User,
 			reminder.Income,
 			reminder.Outcome,
-			reminder.Changed,
+			unixTime(reminder.Changed),
 			reminder.IncomeInstrument,
 			reminder.OutcomeInstrument,
 			reminder.Step,
@@ -325,7 +325,7 @@ func TestCreateReminder_QueryError(t *testing.T) {
 			reminder.User,
 			reminder.Income,
 			reminder.Outcome,
-			reminder.Changed,
+			unixTime(reminder.Changed),
 			reminder.IncomeInstrument,
 			reminder.OutcomeInstrument,
 			reminder.Step,
@@ -403,7 +403,7 @@ func TestUpdateReminder_Success(t *testing.T) {
 			reminder.User,
 			reminder.Income,
 			reminder.Outcome,
-			reminder.Changed,
+			unixTime(reminder.Changed),
 			reminder.IncomeInstrument,
 			reminder.OutcomeInstrument,
 			reminder.Step,
@@ -480,7 +480,7 @@ func TestUpdateReminder_NotFound(t *testing.T) {
 			reminder.User,
 			reminder.Income,
 			reminder.Outcome,
-			reminder.Changed,
+			unixTime(reminder.Changed),
 			reminder.IncomeInstrument,
 			reminder.OutcomeInstrument,
 			reminder.Step,
@@ -558,7 +558,7 @@ func TestUpdateReminder_QueryError(t *testing.T) {
 			reminder.User,
 			reminder.Income,
 			reminder.Outcome,
-			reminder.Changed,
+			unixTime(reminder.Changed),
 			reminder.IncomeInstrument,
 			reminder.OutcomeInstrument,
 			reminder.Step,
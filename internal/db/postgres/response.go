@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
@@ -11,7 +12,10 @@ import (
 	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
 )
 
-// Save saves the entire API response to database
+// Save saves the entire API response to database in a single transaction.
+// Entities are upserted parents first (instruments, companies, users, accounts, tags...),
+// so references resolve in order; foreign keys are deferred and checked on commit.
+// In history mode the previous versions of changed objects are kept in history tables.
 func (s *DB) Save(ctx context.Context, response *models.Response) error {
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
@@ -19,11 +23,13 @@ func (s *DB) Save(ctx context.Context, response *models.Response) error {
 	}
 	defer func(tx pgx.Tx, ctx context.Context) {
 		err := tx.Rollback(ctx)
-		if err != nil {
+		if err != nil && !errors.Is(err, pgx.ErrTxClosed) {
 			slog.Error("failed to rollback transaction", "error", err)
 		}
 	}(tx, ctx)
 
+	txDB := s.withTx(tx)
+
 	status := interfaces.SyncStatus{
 		StartedAt:        time.Now(),
 		FinishedAt:       nil,
@@ -51,82 +57,105 @@ func (s *DB) Save(ctx context.Context, response *models.Response) error {
 		}
 	}()
 
+	if s.opts.History {
+		if status.ID, err = txDB.reserveSyncRunID(ctx); err != nil {
+			return err
+		}
+		if err = txDB.enableHistory(ctx, status.ID); err != nil {
+			return err
+		}
+	}
+
+	if s.opts.OrphanMode == interfaces.OrphanModeReport {
+		err = txDB.saveSkippingOrphans(ctx, response)
+	} else {
+		err = txDB.saveObjects(ctx, response)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// saveObjects upserts the objects of the response parents first and processes its deletions
+func (s *DB) saveObjects(ctx context.Context, response *models.Response) error {
 	if len(response.Instrument) > 0 {
-		if err = s.SaveInstruments(ctx, response.Instrument); err != nil {
+		if err := s.SaveInstruments(ctx, response.Instrument); err != nil {
 			return fmt.Errorf("failed to save instruments: %w", err)
 		}
 	}
 
 	if len(response.Country) > 0 {
-		if err = s.SaveCountries(ctx, response.Country); err != nil {
+		if err := s.SaveCountries(ctx, response.Country); err != nil {
 			return fmt.Errorf("failed to save countries: %w", err)
 		}
 	}
 
 	if len(response.Company) > 0 {
-		if err = s.SaveCompanies(ctx, response.Company); err != nil {
+		if err := s.SaveCompanies(ctx, response.Company); err != nil {
 			return fmt.Errorf("failed to save companies: %w", err)
 		}
 	}
 
 	if len(response.User) > 0 {
-		if err = s.SaveUsers(ctx, response.User); err != nil {
+		if err := s.SaveUsers(ctx, response.User); err != nil {
 			return fmt.Errorf("failed to save users: %w", err)
 		}
 	}
 
 	if len(response.Account) > 0 {
-		if err = s.SaveAccounts(ctx, response.Account); err != nil {
+		if err := s.SaveAccounts(ctx, response.Account); err != nil {
 			return fmt.Errorf("failed to save accounts: %w", err)
 		}
 	}
 
 	if len(response.Tag) > 0 {
-		if err = s.SaveTags(ctx, response.Tag); err != nil {
+		if err := s.SaveTags(ctx, parentTagsFirst(response.Tag)); err != nil {
 			return fmt.Errorf("failed to save tags: %w", err)
 		}
 	}
 
 	if len(response.Merchant) > 0 {
-		if err = s.SaveMerchants(ctx, response.Merchant); err != nil {
+		if err := s.SaveMerchants(ctx, response.Merchant); err != nil {
 			return fmt.Errorf("failed to save merchants: %w", err)
 		}
 	}
 
 	if len(response.Budget) > 0 {
-		if err = s.SaveBudgets(ctx, response.Budget); err != nil {
+		if err := s.SaveBudgets(ctx, response.Budget); err != nil {
 			return fmt.Errorf("failed to save budgets: %w", err)
 		}
 	}
 
 	if len(response.Reminder) > 0 {
-		if err = s.SaveReminders(ctx, response.Reminder); err != nil {
+		if err := s.SaveReminders(ctx, response.Reminder); err != nil {
 			return fmt.Errorf("failed to save reminders: %w", err)
 		}
 	}
 
 	if len(response.ReminderMarker) > 0 {
-		if err = s.SaveReminderMarkers(ctx, response.ReminderMarker); err != nil {
+		if err := s.SaveReminderMarkers(ctx, response.ReminderMarker); err != nil {
 			return fmt.Errorf("failed to save reminder markers: %w", err)
 		}
 	}
 
 	if len(response.Transaction) > 0 {
-		if err = s.SaveTransactions(ctx, response.Transaction); err != nil {
+		if err := s.SaveTransactions(ctx, response.Transaction); err != nil {
 			return fmt.Errorf("failed to save transactions: %w", err)
 		}
 	}
 
 	if len(response.Deletion) > 0 {
-		if err = s.DeleteObjects(ctx, response.Deletion); err != nil {
+		if err := s.DeleteObjects(ctx, response.Deletion); err != nil {
 			return fmt.Errorf("failed to process deletions: %w", err)
 		}
 	}
 
-	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	return nil
 }
 
@@ -145,3 +174,19 @@ func (s *DB) countRecords(response *models.Response) int {
 		len(response.Transaction) +
 		len(response.Deletion)
 }
+
+// parentTagsFirst orders tags so that parent tags are saved before their children
+func parentTagsFirst(tags []models.Tag) []models.Tag {
+	ordered := make([]models.Tag, 0, len(tags))
+	for _, tag := range tags {
+		if tag.Parent == nil {
+			ordered = append(ordered, tag)
+		}
+	}
+	for _, tag := range tags {
+		if tag.Parent != nil {
+			ordered = append(ordered, tag)
+		}
+	}
+	return ordered
+}
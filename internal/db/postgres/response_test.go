@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSave_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	response := &models.Response{
+		ServerTimestamp: 1700000000,
+		Merchant:        []models.Merchant{{ID: "merchant-1", User: 1, Title: "Shop", Changed: 1700000000}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectBatch().ExpectExec("INSERT INTO merchant").
+		WithArgs("merchant-1", 1, "Shop", unixTime(1700000000), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`INSERT INTO sync_status`).
+		WithArgs(
+			pgxmock.AnyArg(), pgxmock.AnyArg(), "full", int64(1700000000), 1,
+			"completed", (*string)(nil), pgxmock.AnyArg(), pgxmock.AnyArg(),
+		).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(1)))
+	mock.ExpectRollback()
+
+	err = db.Save(context.Background(), response)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSave_CommitError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	mock.ExpectBegin()
+	mock.ExpectCommit().WillReturnError(errors.New("violates foreign key constraint"))
+	mock.ExpectQuery(`INSERT INTO sync_status`).
+		WithArgs(
+			pgxmock.AnyArg(), pgxmock.AnyArg(), "full", int64(0), 0,
+			"failed", pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+		).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(1)))
+	mock.ExpectRollback()
+
+	err = db.Save(context.Background(), &models.Response{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to commit transaction")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSave_ReportOrphans(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock, opts: interfaces.NewStorageOptions(interfaces.WithOrphanMode(interfaces.OrphanModeReport))}
+	response := &models.Response{
+		Deletion: []models.Deletion{{ID: "merchant-1", Object: "merchant", User: 1, Stamp: 1234567890}},
+	}
+
+	mock.ExpectBegin()
+	// the deletion leaves tx-1 with a dangling merchant, so it is rolled back
+	mock.ExpectBegin()
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM merchant WHERE id = \$1 AND "user" = \$2`).
+		WithArgs("merchant-1", 1).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectExec(`INSERT INTO deletion_history`).
+		WithArgs("merchant-1", "merchant", 1, 1234567890).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+	// only the references to the deleted merchant are looked at
+	mock.ExpectQuery(`FROM "?transaction"? c .* OR c\.merchant = ANY`).
+		WithArgs([]string{}, []string{"merchant-1"}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "missing_id"}).AddRow("tx-1", "merchant-1"))
+	mock.ExpectRollback()
+	// and the rest, now empty, is saved without it and without orphan queries
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectCommit()
+	mock.ExpectQuery(`INSERT INTO sync_status`).
+		WithArgs(
+			pgxmock.AnyArg(), pgxmock.AnyArg(), "full", int64(0), 1,
+			"completed", (*string)(nil), pgxmock.AnyArg(), pgxmock.AnyArg(),
+		).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(1)))
+	mock.ExpectRollback()
+
+	err = db.Save(context.Background(), response)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestParentTagsFirst(t *testing.T) {
+	tags := []models.Tag{
+		{ID: "child", Parent: new("parent")},
+		{ID: "parent"},
+	}
+
+	ordered := parentTagsFirst(tags)
+	assert.Equal(t, "parent", ordered[0].ID)
+	assert.Equal(t, "child", ordered[1].ID)
+}
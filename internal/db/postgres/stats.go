@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+)
+
+// statsTables lists the tables TableStats describes with their date and user columns, empty if they have none
+var statsTables = []struct {
+	table, date, user string
+}{
+	{"instrument", "", ""},
+	{"country", "", ""},
+	{"company", "", ""},
+	{"user", "", ""},
+	{"account", "", `"user"`},
+	{"tag", "", `"user"`},
+	{"merchant", "", `"user"`},
+	{"budget", "date", `"user"`},
+	{"reminder", "start_date", `"user"`},
+	{"reminder_marker", "date", `"user"`},
+	{"transaction", "date", `"user"`},
+	{"sync_status", "started_at", ""},
+	{"deletion_history", "deleted_at", "user_id"},
+	{"imported_record", "date", ""},
+}
+
+// TableStats returns the row counts, date ranges, rows per user and sizes of the tables.
+// Soft deleted rows are counted along with the live ones, history tables aren't described.
+func (s *DB) TableStats(ctx context.Context) ([]interfaces.TableStats, error) {
+	stats := make([]interfaces.TableStats, 0, len(statsTables))
+	for _, t := range statsTables {
+		table := s.table(t.table)
+		st := interfaces.TableStats{Table: t.table}
+
+		dates := "NULL::date, NULL::date"
+		if t.date != "" {
+			dates = fmt.Sprintf("min(%s)::date, max(%s)::date", t.date, t.date)
+		}
+		err := s.pool.QueryRow(ctx, `SELECT count(*), `+dates+` FROM `+table).Scan(&st.Rows, &st.From, &st.To)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", t.table, err)
+		}
+
+		// partitioned tables have no storage of their own, their size is the sum of their partitions
+		err = s.pool.QueryRow(ctx, `
+            SELECT COALESCE(sum(pg_total_relation_size(relid)), 0)::bigint
+            FROM pg_partition_tree($1::regclass)`, table).Scan(&st.Size)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the size of %s: %w", t.table, err)
+		}
+
+		if t.user != "" {
+			if st.Users, err = s.userCounts(ctx, table, t.user); err != nil {
+				return nil, fmt.Errorf("failed to count %s per user: %w", t.table, err)
+			}
+		}
+		stats = append(stats, st)
+	}
+	return stats, nil
+}
+
+// userCounts counts the rows of a table per user
+func (s *DB) userCounts(ctx context.Context, table, column string) (map[int]int64, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+column+`, count(*) FROM `+table+
+		` WHERE `+column+` IS NOT NULL GROUP BY `+column+` ORDER BY `+column)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int64)
+	for rows.Next() {
+		var user int
+		var count int64
+		if err := rows.Scan(&user, &count); err != nil {
+			return nil, err
+		}
+		counts[user] = count
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableStats_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock, opts: interfaces.StorageOptions{TablePrefix: "zen_"}}
+
+	from := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	for _, st := range statsTables {
+		table := db.table(st.table)
+		rows := pgxmock.NewRows([]string{"count", "min", "max"})
+		if st.table == "transaction" {
+			rows.AddRow(int64(42), &from, &to)
+		} else {
+			rows.AddRow(int64(0), (*time.Time)(nil), (*time.Time)(nil))
+		}
+		mock.ExpectQuery(`SELECT count\(\*\), .+ FROM ` + regexp.QuoteMeta(table) + `$`).WillReturnRows(rows)
+		mock.ExpectQuery(`pg_total_relation_size`).WithArgs(table).
+			WillReturnRows(pgxmock.NewRows([]string{"size"}).AddRow(int64(8192)))
+		if st.user != "" {
+			users := pgxmock.NewRows([]string{"user", "count"})
+			if st.table == "transaction" {
+				users.AddRow(1, int64(40)).AddRow(2, int64(2))
+			}
+			mock.ExpectQuery(`SELECT ` + regexp.QuoteMeta(st.user) + `, count\(\*\) FROM ` + regexp.QuoteMeta(table) +
+				` WHERE .+ GROUP BY`).WillReturnRows(users)
+		}
+	}
+
+	stats, err := db.TableStats(context.Background())
+	require.NoError(t, err)
+	require.Len(t, stats, len(statsTables))
+	assert.Equal(t, interfaces.TableStats{Table: "user", Size: 8192}, stats[3])
+	assert.Equal(t, interfaces.TableStats{
+		Table: "transaction", Rows: 42, Size: 8192, From: &from, To: &to, Users: map[int]int64{1: 40, 2: 2},
+	}, stats[10])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTableStats_Error(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	mock.ExpectQuery(`SELECT count\(\*\)`).WillReturnError(errors.New("relation does not exist"))
+
+	_, err = db.TableStats(context.Background())
+	assert.ErrorContains(t, err, "failed to count instrument")
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -12,13 +13,18 @@ import (
 )
 
 // SaveSyncStatus saves synchronization status to the database
-// It creates a new record in the sync_status table with the provided status information
+// It creates a new record in the sync_status table with the provided status information,
+// with status.ID when it is set, e.g. reserved for the history of the sync
 func (s *DB) SaveSyncStatus(ctx context.Context, status interfaces.SyncStatus) error {
+	id := "DEFAULT"
+	if status.ID != 0 {
+		id = strconv.FormatInt(status.ID, 10)
+	}
 	query := `
-        INSERT INTO sync_status (
-            started_at, finished_at, sync_type, server_timestamp,
+        INSERT INTO ` + s.table("sync_status") + ` (
+            id, started_at, finished_at, sync_type, server_timestamp,
             records_processed, status, error_message, created_at, updated_at
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        ) VALUES (` + id + `, $1, $2, $3, $4, $5, $6, $7, $8, $9)
         RETURNING id`
 
 	err := s.pool.QueryRow(ctx, query,
@@ -41,7 +47,7 @@ func (s *DB) GetLastSyncStatus(ctx context.Context) (interfaces.SyncStatus, erro
 	query := `
         SELECT id, started_at, finished_at, sync_type, server_timestamp,
                records_processed, status, error_message, created_at, updated_at
-        FROM sync_status
+        FROM ` + s.table("sync_status") + `
         ORDER BY id DESC
         LIMIT 1`
 
@@ -61,3 +67,62 @@ func (s *DB) GetLastSyncStatus(ctx context.Context) (interfaces.SyncStatus, erro
 
 	return status, nil
 }
+
+// ListSyncStatuses lists sync_status records ordered by ID, the order they were saved in
+func (s *DB) ListSyncStatuses(ctx context.Context, filter interfaces.Filter) ([]interfaces.SyncStatus, error) {
+	query := `
+        SELECT id, started_at, finished_at, sync_type, server_timestamp,
+               records_processed, status, error_message, created_at, updated_at
+        FROM ` + s.table("sync_status") + `
+        ORDER BY id
+        LIMIT $1 OFFSET $2`
+
+	rows, err := s.pool.Query(ctx, query, filter.Limit, (filter.Page-1)*filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sync statuses: %w", err)
+	}
+	return scanSyncStatuses(rows)
+}
+
+// ListRecentSyncStatuses lists the last sync statuses newest first, only the ones of the given status unless it's empty
+func (s *DB) ListRecentSyncStatuses(ctx context.Context, limit int, status string) ([]interfaces.SyncStatus, error) {
+	query := `
+        SELECT id, started_at, finished_at, sync_type, server_timestamp,
+               records_processed, status, error_message, created_at, updated_at
+        FROM ` + s.table("sync_status") + `
+        WHERE $1 = '' OR status = $1
+        ORDER BY id DESC
+        LIMIT $2`
+
+	rows, err := s.pool.Query(ctx, query, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sync statuses: %w", err)
+	}
+	return scanSyncStatuses(rows)
+}
+
+// scanSyncStatuses reads the sync statuses of a query and closes its rows
+func scanSyncStatuses(rows pgx.Rows) ([]interfaces.SyncStatus, error) {
+	defer rows.Close()
+
+	var statuses []interfaces.SyncStatus
+	for rows.Next() {
+		var status interfaces.SyncStatus
+		err := rows.Scan(
+			&status.ID, &status.StartedAt, &status.FinishedAt,
+			&status.SyncType, &status.ServerTimestamp,
+			&status.RecordsProcessed, &status.Status,
+			&status.ErrorMessage, &status.CreatedAt, &status.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sync status: %w", err)
+		}
+		statuses = append(statuses, status)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sync statuses: %w", err)
+	}
+
+	return statuses, nil
+}
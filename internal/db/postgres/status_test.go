@@ -153,3 +153,91 @@ func TestGetLastSyncStatus_Error(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestListSyncStatuses_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	now := time.Now()
+	rows := pgxmock.NewRows([]string{
+		"id", "started_at", "finished_at", "sync_type", "server_timestamp",
+		"records_processed", "status", "error_message", "created_at", "updated_at",
+	}).
+		AddRow(int64(1), now, &now, "full", int64(100), 10, "completed", nil, now, now).
+		AddRow(int64(2), now, &now, "incremental", int64(200), 2, "completed", nil, now, now)
+
+	mock.ExpectQuery(`SELECT id, started_at, finished_at, sync_type, server_timestamp,.+ORDER BY id\s+LIMIT \$1 OFFSET \$2`).
+		WithArgs(2, 2).
+		WillReturnRows(rows)
+
+	statuses, err := db.ListSyncStatuses(context.Background(), interfaces.Filter{Page: 2, Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, int64(1), statuses[0].ID)
+	assert.Equal(t, int64(200), statuses[1].ServerTimestamp)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListSyncStatuses_Error(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	mock.ExpectQuery(`SELECT id, started_at`).
+		WithArgs(10, 0).
+		WillReturnError(errors.New("query error"))
+
+	_, err = db.ListSyncStatuses(context.Background(), interfaces.Filter{Page: 1, Limit: 10})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to list sync statuses")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListRecentSyncStatuses_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	now := time.Now()
+	message := "timeout"
+	rows := pgxmock.NewRows([]string{
+		"id", "started_at", "finished_at", "sync_type", "server_timestamp",
+		"records_processed", "status", "error_message", "created_at", "updated_at",
+	}).
+		AddRow(int64(3), now, &now, "partial", int64(300), 0, "failed", &message, now, now).
+		AddRow(int64(2), now, &now, "partial", int64(200), 2, "completed", nil, now, now)
+
+	mock.ExpectQuery(`SELECT id, started_at, .+ FROM sync_status\s+WHERE \$1 = '' OR status = \$1\s+ORDER BY id DESC\s+LIMIT \$2`).
+		WithArgs("", 2).
+		WillReturnRows(rows)
+
+	statuses, err := db.ListRecentSyncStatuses(context.Background(), 2, "")
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, int64(3), statuses[0].ID)
+	assert.Equal(t, &message, statuses[0].ErrorMessage)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListRecentSyncStatuses_Error(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	db := &DB{pool: mock}
+
+	mock.ExpectQuery(`SELECT id, started_at`).
+		WithArgs("completed", 1).
+		WillReturnError(errors.New("query error"))
+
+	_, err = db.ListRecentSyncStatuses(context.Background(), 1, "completed")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to list sync statuses")
+}
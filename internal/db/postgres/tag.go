@@ -17,14 +17,14 @@ func (s *DB) GetTag(ctx context.Context, id string) (*models.Tag, error) {
         SELECT id, "user", changed, icon, budget_income, budget_outcome,
                required, color, picture, title, show_income, show_outcome,
                parent, static_id
-        FROM tag
-        WHERE id = $1`
+        FROM ` + s.table("tag") + `
+        WHERE id = $1 AND deleted_at IS NULL`
 
 	tag := &models.Tag{}
 	err := s.pool.QueryRow(ctx, query, id).Scan(
 		&tag.ID,
 		&tag.User,
-		&tag.Changed,
+		scanUnix(&tag.Changed),
 		&tag.Icon,
 		&tag.BudgetIncome,
 		&tag.BudgetOutcome,
@@ -39,7 +39,7 @@ func (s *DB) GetTag(ctx context.Context, id string) (*models.Tag, error) {
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("tag not found: %s", id)
+			return nil, fmt.Errorf("tag %w: %s", interfaces.ErrNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get tag: %w", err)
 	}
@@ -60,17 +60,23 @@ func (s *DB) ListTags(ctx context.Context, filter interfaces.Filter) ([]models.T
 		argNum++
 	}
 
+	from, args := s.source("tag", filter, args)
+	argNum = len(args) + 1
+
 	query := `
         SELECT id, "user", changed, icon, budget_income, budget_outcome,
                required, color, picture, title, show_income, show_outcome,
                parent, static_id
-        FROM tag`
+        FROM ` + from
+
+	conditions = notDeleted(conditions, filter)
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
 	// Add pagination
+	query += ` ORDER BY id`
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argNum, argNum+1)
 	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
 
@@ -86,7 +92,7 @@ func (s *DB) ListTags(ctx context.Context, filter interfaces.Filter) ([]models.T
 		err := rows.Scan(
 			&tag.ID,
 			&tag.User,
-			&tag.Changed,
+			scanUnix(&tag.Changed),
 			&tag.Icon,
 			&tag.BudgetIncome,
 			&tag.BudgetOutcome,
@@ -115,7 +121,7 @@ func (s *DB) ListTags(ctx context.Context, filter interfaces.Filter) ([]models.T
 // CreateTag creates a new tag record
 func (s *DB) CreateTag(ctx context.Context, tag *models.Tag) error {
 	query := `
-        INSERT INTO tag (
+        INSERT INTO ` + s.table("tag") + ` (
             id, "user", changed, icon, budget_income, budget_outcome,
             required, color, picture, title, show_income, show_outcome,
             parent, static_id
@@ -124,7 +130,7 @@ func (s *DB) CreateTag(ctx context.Context, tag *models.Tag) error {
 	_, err := s.pool.Exec(ctx, query,
 		tag.ID,
 		tag.User,
-		tag.Changed,
+		unixTime(tag.Changed),
 		tag.Icon,
 		tag.BudgetIncome,
 		tag.BudgetOutcome,
@@ -147,7 +153,7 @@ func (s *DB) CreateTag(ctx context.Context, tag *models.Tag) error {
 // UpdateTag updates an existing tag record
 func (s *DB) UpdateTag(ctx context.Context, tag *models.Tag) error {
 	query := `
-        UPDATE tag SET
+        UPDATE ` + s.table("tag") + ` SET
             "user" = $2,
             changed = $3,
             icon = $4,
@@ -166,7 +172,7 @@ func (s *DB) UpdateTag(ctx context.Context, tag *models.Tag) error {
 	commandTag, err := s.pool.Exec(ctx, query,
 		tag.ID,
 		tag.User,
-		tag.Changed,
+		unixTime(tag.Changed),
 		tag.Icon,
 		tag.BudgetIncome,
 		tag.BudgetOutcome,
@@ -184,7 +190,7 @@ func (s *DB) UpdateTag(ctx context.Context, tag *models.Tag) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("tag not found: %s", tag.ID)
+		return fmt.Errorf("tag %w: %s", interfaces.ErrNotFound, tag.ID)
 	}
 
 	return nil
@@ -192,7 +198,7 @@ func (s *DB) UpdateTag(ctx context.Context, tag *models.Tag) error {
 
 // DeleteTag deletes a tag by its ID
 func (s *DB) DeleteTag(ctx context.Context, id string) error {
-	query := `DELETE FROM tag WHERE id = $1`
+	query := s.deleteQuery("tag", `id = $1`, "now()")
 
 	commandTag, err := s.pool.Exec(ctx, query, id)
 	if err != nil {
@@ -200,7 +206,7 @@ func (s *DB) DeleteTag(ctx context.Context, id string) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("tag not found: %s", id)
+		return fmt.Errorf("tag %w: %s", interfaces.ErrNotFound, id)
 	}
 
 	return nil
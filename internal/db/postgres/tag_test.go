@@ -124,7 +124,7 @@ func TestListTags_Success(t *testing.T) {
 		ptr("parent-id"), "static-id",
 	)
 
-	mock.ExpectQuery(`SELECT id, "user", changed, icon, budget_income, budget_outcome, required, color, picture, title, show_income, show_outcome, parent, static_id FROM tag WHERE "user" = \$1 LIMIT \$2 OFFSET \$3`).
+	mock.ExpectQuery(`SELECT id, "user", changed, icon, budget_income, budget_outcome, required, color, picture, title, show_income, show_outcome, parent, static_id FROM tag WHERE "user" = \$1 AND deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
 		WithArgs(1, 10, 0).
 		WillReturnRows(rows)
 
@@ -149,7 +149,7 @@ func TestListTags_QueryError(t *testing.T) {
 		Page:   1,
 	}
 
-	mock.ExpectQuery(`SELECT id, "user", changed, icon, budget_income, budget_outcome, required, color, picture, title, show_income, show_outcome, parent, static_id FROM tag WHERE "user" = \$1 LIMIT \$2 OFFSET \$3`).
+	mock.ExpectQuery(`SELECT id, "user", changed, icon, budget_income, budget_outcome, required, color, picture, title, show_income, show_outcome, parent, static_id FROM tag WHERE "user" = \$1 AND deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
 		WithArgs(1, 10, 0).
 		WillReturnError(errors.New("query error"))
 
@@ -180,7 +180,7 @@ func TestListTags_NoResults(t *testing.T) {
 		"parent", "static_id",
 	})
 
-	mock.ExpectQuery(`SELECT id, "user", changed, icon, budget_income, budget_outcome, required, color, picture, title, show_income, show_outcome, parent, static_id FROM tag WHERE "user" = \$1 LIMIT \$2 OFFSET \$3`).
+	mock.ExpectQuery(`SELECT id, "user", changed, icon, budget_income, budget_outcome, required, color, picture, title, show_income, show_outcome, parent, static_id FROM tag WHERE "user" = \$1 AND deleted_at IS NULL ORDER BY id LIMIT \$2 OFFSET \$3`).
 		WithArgs(1, 10, 0).
 		WillReturnRows(rows)
 
@@ -217,7 +217,7 @@ func TestCreateTag_Success(t *testing.T) {
 
 	mock.ExpectExec(`INSERT INTO tag`).
 		WithArgs(
-			tag.ID, tag.User, tag.Changed, tag.Icon, tag.BudgetIncome, tag.BudgetOutcome,
+			tag.ID, tag.User, unixTime(tag.Changed), tag.Icon, tag.BudgetIncome, tag.BudgetOutcome,
 			tag.Required, tag.Color, tag.Picture, tag.Title, tag.ShowIncome, tag.ShowOutcome,
 			tag.Parent, tag.StaticID,
 		).
@@ -255,7 +255,7 @@ func TestCreateTag_QueryError(t *testing.T) {
 
 	mock.ExpectExec(`INSERT INTO tag`).
 		WithArgs(
-			tag.ID, tag.User, tag.Changed, tag.Icon, tag.BudgetIncome, tag.BudgetOutcome,
+			tag.ID, tag.User, unixTime(tag.Changed), tag.Icon, tag.BudgetIncome, tag.BudgetOutcome,
 			tag.Required, tag.Color, tag.Picture, tag.Title, tag.ShowIncome, tag.ShowOutcome,
 			tag.Parent, tag.StaticID,
 		).
@@ -294,7 +294,7 @@ func TestUpdateTag_Success(t *testing.T) {
 
 	mock.ExpectExec(`UPDATE tag SET`).
 		WithArgs(
-			tag.ID, tag.User, tag.Changed, tag.Icon, tag.BudgetIncome, tag.BudgetOutcome,
+			tag.ID, tag.User, unixTime(tag.Changed), tag.Icon, tag.BudgetIncome, tag.BudgetOutcome,
 			tag.Required, tag.Color, tag.Picture, tag.Title, tag.ShowIncome, tag.ShowOutcome,
 			tag.Parent, tag.StaticID,
 		).
@@ -332,7 +332,7 @@ func TestUpdateTag_NotFound(t *testing.T) {
 
 	mock.ExpectExec(`UPDATE tag SET`).
 		WithArgs(
-			tag.ID, tag.User, tag.Changed, tag.Icon, tag.BudgetIncome, tag.BudgetOutcome,
+			tag.ID, tag.User, unixTime(tag.Changed), tag.Icon, tag.BudgetIncome, tag.BudgetOutcome,
 			tag.Required, tag.Color, tag.Picture, tag.Title, tag.ShowIncome, tag.ShowOutcome,
 			tag.Parent, tag.StaticID,
 		).
@@ -371,7 +371,7 @@ func TestUpdateTag_QueryError(t *testing.T) {
 
 	mock.ExpectExec(`UPDATE tag SET`).
 		WithArgs(
-			tag.ID, tag.User, tag.Changed, tag.Icon, tag.BudgetIncome, tag.BudgetOutcome,
+			tag.ID, tag.User, unixTime(tag.Changed), tag.Icon, tag.BudgetIncome, tag.BudgetOutcome,
 			tag.Required, tag.Color, tag.Picture, tag.Title, tag.ShowIncome, tag.ShowOutcome,
 			tag.Parent, tag.StaticID,
 		).
@@ -20,20 +20,20 @@ func (s *DB) GetTransaction(ctx context.Context, id string) (*models.Transaction
                comment, payee, op_income, op_outcome, op_income_instrument,
                op_outcome_instrument, latitude, longitude, merchant,
                income_bank_id, outcome_bank_id, reminder_marker
-        FROM transaction
-        WHERE id = $1`
+        FROM ` + s.table("transaction") + `
+        WHERE id = $1 AND deleted_at IS NULL`
 
 	tx := &models.Transaction{}
 	err := s.pool.QueryRow(ctx, query, id).Scan(
 		&tx.ID,
 		&tx.User,
-		&tx.Date,
+		scanDate(&tx.Date),
 		&tx.Income,
 		&tx.Outcome,
-		&tx.Changed,
+		scanUnix(&tx.Changed),
 		&tx.IncomeInstrument,
 		&tx.OutcomeInstrument,
-		&tx.Created,
+		scanUnix(&tx.Created),
 		&tx.OriginalPayee,
 		&tx.Deleted,
 		&tx.Viewed,
@@ -58,7 +58,7 @@ func (s *DB) GetTransaction(ctx context.Context, id string) (*models.Transaction
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("transaction not found: %s", id)
+			return nil, fmt.Errorf("transaction %w: %s", interfaces.ErrNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get transaction: %w", err)
 	}
@@ -93,6 +93,9 @@ func (s *DB) ListTransactions(
 		argNum++
 	}
 
+	from, args := s.source("transaction", filter, args)
+	argNum = len(args) + 1
+
 	query := `
         SELECT id, "user", date, income, outcome, changed, income_instrument,
                outcome_instrument, created, original_payee, deleted, viewed,
@@ -100,13 +103,15 @@ func (s *DB) ListTransactions(
                comment, payee, op_income, op_outcome, op_income_instrument,
                op_outcome_instrument, latitude, longitude, merchant,
                income_bank_id, outcome_bank_id, reminder_marker
-        FROM transaction`
+        FROM ` + from
+
+	conditions = notDeleted(conditions, filter)
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	query += ` ORDER BY date DESC, created DESC`
+	query += ` ORDER BY date DESC, created DESC, id`
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argNum, argNum+1)
 	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
 
@@ -122,13 +127,13 @@ func (s *DB) ListTransactions(
 		err := rows.Scan(
 			&tx.ID,
 			&tx.User,
-			&tx.Date,
+			scanDate(&tx.Date),
 			&tx.Income,
 			&tx.Outcome,
-			&tx.Changed,
+			scanUnix(&tx.Changed),
 			&tx.IncomeInstrument,
 			&tx.OutcomeInstrument,
-			&tx.Created,
+			scanUnix(&tx.Created),
 			&tx.OriginalPayee,
 			&tx.Deleted,
 			&tx.Viewed,
@@ -166,8 +171,12 @@ func (s *DB) ListTransactions(
 
 // CreateTransaction creates a new transaction record
 func (s *DB) CreateTransaction(ctx context.Context, tx *models.Transaction) error {
+	if err := s.ensurePartitions(ctx, "transaction", []string{tx.Date}); err != nil {
+		return err
+	}
+
 	query := `
-        INSERT INTO transaction (
+        INSERT INTO ` + s.table("transaction") + ` (
             id, "user", date, income, outcome, changed, income_instrument,
             outcome_instrument, created, original_payee, deleted, viewed,
             hold, qr_code, source, income_account, outcome_account, tag,
@@ -184,10 +193,10 @@ func (s *DB) CreateTransaction(ctx context.Context, tx *models.Transaction) erro
 		tx.Date,
 		tx.Income,
 		tx.Outcome,
-		tx.Changed,
+		unixTime(tx.Changed),
 		tx.IncomeInstrument,
 		tx.OutcomeInstrument,
-		tx.Created,
+		unixTime(tx.Created),
 		tx.OriginalPayee,
 		tx.Deleted,
 		tx.Viewed,
@@ -220,7 +229,7 @@ func (s *DB) CreateTransaction(ctx context.Context, tx *models.Transaction) erro
 // UpdateTransaction updates an existing transaction record
 func (s *DB) UpdateTransaction(ctx context.Context, tx *models.Transaction) error {
 	query := `
-        UPDATE transaction SET
+        UPDATE ` + s.table("transaction") + ` SET
             "user" = $2,
             date = $3,
             income = $4,
@@ -258,10 +267,10 @@ func (s *DB) UpdateTransaction(ctx context.Context, tx *models.Transaction) erro
 		tx.Date,
 		tx.Income,
 		tx.Outcome,
-		tx.Changed,
+		unixTime(tx.Changed),
 		tx.IncomeInstrument,
 		tx.OutcomeInstrument,
-		tx.Created,
+		unixTime(tx.Created),
 		tx.OriginalPayee,
 		tx.Deleted,
 		tx.Viewed,
@@ -289,7 +298,7 @@ func (s *DB) UpdateTransaction(ctx context.Context, tx *models.Transaction) erro
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("transaction not found: %s", tx.ID)
+		return fmt.Errorf("transaction %w: %s", interfaces.ErrNotFound, tx.ID)
 	}
 
 	return nil
@@ -297,7 +306,7 @@ func (s *DB) UpdateTransaction(ctx context.Context, tx *models.Transaction) erro
 
 // DeleteTransaction deletes a transaction by its ID
 func (s *DB) DeleteTransaction(ctx context.Context, id string) error {
-	query := `DELETE FROM transaction WHERE id = $1`
+	query := s.deleteQuery("transaction", `id = $1`, "now()")
 
 	commandTag, err := s.pool.Exec(ctx, query, id)
 	if err != nil {
@@ -305,7 +314,7 @@ func (s *DB) DeleteTransaction(ctx context.Context, id string) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("transaction not found: %s", id)
+		return fmt.Errorf("transaction %w: %s", interfaces.ErrNotFound, id)
 	}
 
 	return nil
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+)
+
+// dateLayout is the format of dates in ZenMoney models
+const dateLayout = "2006-01-02"
+
+// unixTime converts a unix timestamp of a model to a TIMESTAMPTZ value
+func unixTime(ts int) time.Time {
+	return time.Unix(int64(ts), 0).UTC()
+}
+
+// unixScanner scans a TIMESTAMPTZ column into a unix timestamp field of a model
+type unixScanner struct {
+	dst *int
+}
+
+func scanUnix(dst *int) *unixScanner {
+	return &unixScanner{dst: dst}
+}
+
+// Scan implements sql.Scanner
+func (s *unixScanner) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s.dst = 0
+	case time.Time:
+		*s.dst = int(v.Unix())
+	case int64:
+		*s.dst = int(v)
+	case int32:
+		*s.dst = int(v)
+	case int:
+		*s.dst = v
+	default:
+		return fmt.Errorf("cannot scan %T into unix timestamp", src)
+	}
+	return nil
+}
+
+// dateScanner scans a DATE column into a 'yyyy-MM-dd' field of a model
+type dateScanner struct {
+	dst *string
+}
+
+func scanDate(dst *string) *dateScanner {
+	return &dateScanner{dst: dst}
+}
+
+// Scan implements sql.Scanner
+func (s *dateScanner) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s.dst = ""
+	case time.Time:
+		*s.dst = v.Format(dateLayout)
+	case string:
+		*s.dst = v
+	default:
+		return fmt.Errorf("cannot scan %T into date", src)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnixTime(t *testing.T) {
+	ts := unixTime(1700000000)
+	assert.Equal(t, int64(1700000000), ts.Unix())
+	assert.Equal(t, time.UTC, ts.Location())
+}
+
+func TestScanUnix(t *testing.T) {
+	var changed int
+
+	assert.NoError(t, scanUnix(&changed).Scan(time.Unix(1700000000, 0)))
+	assert.Equal(t, 1700000000, changed)
+
+	assert.NoError(t, scanUnix(&changed).Scan(int64(42)))
+	assert.Equal(t, 42, changed)
+
+	assert.NoError(t, scanUnix(&changed).Scan(nil))
+	assert.Equal(t, 0, changed)
+
+	assert.Error(t, scanUnix(&changed).Scan("2024-01-01"))
+}
+
+func TestScanDate(t *testing.T) {
+	var date string
+
+	assert.NoError(t, scanDate(&date).Scan(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2024-03-01", date)
+
+	assert.NoError(t, scanDate(&date).Scan("2024-04-01"))
+	assert.Equal(t, "2024-04-01", date)
+
+	assert.NoError(t, scanDate(&date).Scan(nil))
+	assert.Equal(t, "", date)
+
+	assert.Error(t, scanDate(&date).Scan(42))
+}
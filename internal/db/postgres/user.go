@@ -18,8 +18,8 @@ func (s *DB) GetUser(ctx context.Context, id int) (*models.User, error) {
                changed, currency, paid_till, month_start_day,
                is_forecast_enabled, plan_balance_mode, plan_settings,
                subscription, subscription_renewal_date
-        FROM "user"
-        WHERE id = $1`
+        FROM ` + s.table("user") + `
+        WHERE id = $1 AND deleted_at IS NULL`
 
 	user := &models.User{}
 	err := s.pool.QueryRow(ctx, query, id).Scan(
@@ -29,7 +29,7 @@ func (s *DB) GetUser(ctx context.Context, id int) (*models.User, error) {
 		&user.Parent,
 		&user.CountryCode,
 		&user.Email,
-		&user.Changed,
+		scanUnix(&user.Changed),
 		&user.Currency,
 		&user.PaidTill,
 		&user.MonthStartDay,
@@ -41,7 +41,7 @@ func (s *DB) GetUser(ctx context.Context, id int) (*models.User, error) {
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, fmt.Errorf("user not found: %d", id)
+			return nil, fmt.Errorf("user %w: %d", interfaces.ErrNotFound, id)
 		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -62,18 +62,24 @@ func (s *DB) ListUsers(ctx context.Context, filter interfaces.Filter) ([]models.
 		argNum++
 	}
 
+	from, args := s.source("user", filter, args)
+	argNum = len(args) + 1
+
 	query := `
         SELECT id, country, login, parent, country_code, email,
                changed, currency, paid_till, month_start_day,
                is_forecast_enabled, plan_balance_mode, plan_settings,
                subscription, subscription_renewal_date
-        FROM "user"`
+        FROM ` + from
+
+	conditions = notDeleted(conditions, filter)
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
 	// Add pagination
+	query += ` ORDER BY id`
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argNum, argNum+1)
 	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
 
@@ -93,7 +99,7 @@ func (s *DB) ListUsers(ctx context.Context, filter interfaces.Filter) ([]models.
 			&user.Parent,
 			&user.CountryCode,
 			&user.Email,
-			&user.Changed,
+			scanUnix(&user.Changed),
 			&user.Currency,
 			&user.PaidTill,
 			&user.MonthStartDay,
@@ -119,7 +125,7 @@ func (s *DB) ListUsers(ctx context.Context, filter interfaces.Filter) ([]models.
 // CreateUser creates a new user record
 func (s *DB) CreateUser(ctx context.Context, user *models.User) error {
 	query := `
-        INSERT INTO "user" (
+        INSERT INTO ` + s.table("user") + ` (
             id, country, login, parent, country_code, email,
             changed, currency, paid_till, month_start_day,
             is_forecast_enabled, plan_balance_mode, plan_settings,
@@ -133,7 +139,7 @@ func (s *DB) CreateUser(ctx context.Context, user *models.User) error {
 		user.Parent,
 		user.CountryCode,
 		user.Email,
-		user.Changed,
+		unixTime(user.Changed),
 		user.Currency,
 		user.PaidTill,
 		user.MonthStartDay,
@@ -153,7 +159,7 @@ func (s *DB) CreateUser(ctx context.Context, user *models.User) error {
 // UpdateUser updates an existing user record
 func (s *DB) UpdateUser(ctx context.Context, user *models.User) error {
 	query := `
-        UPDATE "user"
+        UPDATE ` + s.table("user") + `
         SET country = $2, login = $3, parent = $4, country_code = $5,
             email = $6, changed = $7, currency = $8, paid_till = $9,
             month_start_day = $10, is_forecast_enabled = $11,
@@ -168,7 +174,7 @@ func (s *DB) UpdateUser(ctx context.Context, user *models.User) error {
 		user.Parent,
 		user.CountryCode,
 		user.Email,
-		user.Changed,
+		unixTime(user.Changed),
 		user.Currency,
 		user.PaidTill,
 		user.MonthStartDay,
@@ -183,7 +189,7 @@ func (s *DB) UpdateUser(ctx context.Context, user *models.User) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("user not found: %d", user.ID)
+		return fmt.Errorf("user %w: %d", interfaces.ErrNotFound, user.ID)
 	}
 
 	return nil
@@ -191,7 +197,7 @@ func (s *DB) UpdateUser(ctx context.Context, user *models.User) error {
 
 // DeleteUser deletes a user by their ID
 func (s *DB) DeleteUser(ctx context.Context, id int) error {
-	query := `DELETE FROM "user" WHERE id = $1`
+	query := s.deleteQuery("user", `id = $1`, "now()")
 
 	commandTag, err := s.pool.Exec(ctx, query, id)
 	if err != nil {
@@ -199,7 +205,7 @@ func (s *DB) DeleteUser(ctx context.Context, id int) error {
 	}
 
 	if commandTag.RowsAffected() == 0 {
-		return fmt.Errorf("user not found: %d", id)
+		return fmt.Errorf("user %w: %d", interfaces.ErrNotFound, id)
 	}
 
 	return nil
@@ -193,7 +193,7 @@ func TestCreateUser_Success(t *testing.T) {
 	mock.ExpectExec(`INSERT INTO "user"`).
 		WithArgs(
 			user.ID, user.Country, user.Login, user.Parent, user.CountryCode, user.Email,
-			user.Changed, user.Currency, user.PaidTill, user.MonthStartDay,
+			unixTime(user.Changed), user.Currency, user.PaidTill, user.MonthStartDay,
 			user.IsForecastEnabled, user.PlanBalanceMode, user.PlanSettings,
 			user.Subscription, user.SubscriptionRenewalDate,
 		).
@@ -233,7 +233,7 @@ func TestCreateUser_QueryError(t *testing.T) {
 	mock.ExpectExec(`INSERT INTO "user"`).
 		WithArgs(
 			user.ID, user.Country, user.Login, user.Parent, user.CountryCode, user.Email,
-			user.Changed, user.Currency, user.PaidTill, user.MonthStartDay,
+			unixTime(user.Changed), user.Currency, user.PaidTill, user.MonthStartDay,
 			user.IsForecastEnabled, user.PlanBalanceMode, user.PlanSettings,
 			user.Subscription, user.SubscriptionRenewalDate,
 		).
@@ -274,7 +274,7 @@ func TestUpdateUser_Success(t *testing.T) {
 	mock.ExpectExec(`UPDATE "user" SET`).
 		WithArgs(
 			user.ID, user.Country, user.Login, user.Parent, user.CountryCode, user.Email,
-			user.Changed, user.Currency, user.PaidTill, user.MonthStartDay,
+			unixTime(user.Changed), user.Currency, user.PaidTill, user.MonthStartDay,
 			user.IsForecastEnabled, user.PlanBalanceMode, user.PlanSettings,
 			user.Subscription, user.SubscriptionRenewalDate,
 		).
@@ -314,7 +314,7 @@ func TestUpdateUser_NotFound(t *testing.T) {
 	mock.ExpectExec(`UPDATE "user" SET`).
 		WithArgs(
 			user.ID, user.Country, user.Login, user.Parent, user.CountryCode, user.Email,
-			user.Changed, user.Currency, user.PaidTill, user.MonthStartDay,
+			unixTime(user.Changed), user.Currency, user.PaidTill, user.MonthStartDay,
 			user.IsForecastEnabled, user.PlanBalanceMode, user.PlanSettings,
 			user.Subscription, user.SubscriptionRenewalDate,
 		).
@@ -355,7 +355,7 @@ func TestUpdateUser_QueryError(t *testing.T) {
 	mock.ExpectExec(`UPDATE "user" SET`).
 		WithArgs(
 			user.ID, user.Country, user.Login, user.Parent, user.CountryCode, user.Email,
-			user.Changed, user.Currency, user.PaidTill, user.MonthStartDay,
+			unixTime(user.Changed), user.Currency, user.PaidTill, user.MonthStartDay,
 			user.IsForecastEnabled, user.PlanBalanceMode, user.PlanSettings,
 			user.Subscription, user.SubscriptionRenewalDate,
 		).
@@ -0,0 +1,231 @@
+// Package encrypt encrypts exports and backups with age (https://age-encryption.org), either to the
+// public keys of recipients or with a passphrase. Passphrases and private keys are read from files or
+// the environment only, so they don't show up in shell history and process lists.
+package encrypt
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+const (
+	// Extension is appended to the names of encrypted files the exporter chooses
+	Extension = ".age"
+	// PassphraseEnv holds the passphrase if no passphrase file is given
+	PassphraseEnv = "ZENEXPORT_PASSPHRASE"
+	// IdentityEnv holds age private keys, one per line, if no identity file is given
+	IdentityEnv = "ZENEXPORT_AGE_IDENTITY"
+)
+
+// magic starts every binary age file
+var magic = []byte("age-encryption.org/v1\n")
+
+// Encrypter encrypts output. A nil Encrypter leaves output as it is.
+type Encrypter struct {
+	recipients []age.Recipient
+}
+
+// NewEncrypter encrypts to the given age recipients and the recipients listed in recipientsFile.
+// Without recipients it encrypts with the passphrase of passphraseFile or PassphraseEnv.
+func NewEncrypter(recipients []string, recipientsFile, passphraseFile string) (*Encrypter, error) {
+	var parsed []age.Recipient
+	for _, s := range recipients {
+		r, err := age.ParseRecipients(strings.NewReader(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", s, err)
+		}
+		parsed = append(parsed, r...)
+	}
+	if recipientsFile != "" {
+		data, err := os.ReadFile(recipientsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read recipients file: %w", err)
+		}
+		r, err := age.ParseRecipients(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipients file: %w", err)
+		}
+		parsed = append(parsed, r...)
+	}
+
+	passphrase, err := readPassphrase(passphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(parsed) > 0 && passphraseFile != "":
+		// age encrypts with a passphrase only when it's the single recipient
+		return nil, errors.New("encrypt either to recipients or with a passphrase, not both")
+	case len(parsed) > 0:
+		return &Encrypter{recipients: parsed}, nil
+	case passphrase != "":
+		r, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return &Encrypter{recipients: []age.Recipient{r}}, nil
+	default:
+		return nil, fmt.Errorf("encryption needs --recipient, --recipients-file, --passphrase-file or %s", PassphraseEnv)
+	}
+}
+
+// Extension returns the file extension suffix of encrypted files, empty if e is nil
+func (e *Encrypter) Extension() string {
+	if e == nil {
+		return ""
+	}
+	return Extension
+}
+
+// Wrap returns a writer encrypting into w. Closing it writes the end of the encrypted stream but doesn't close w.
+func (e *Encrypter) Wrap(w io.Writer) (io.WriteCloser, error) {
+	if e == nil {
+		return nopCloser{w}, nil
+	}
+	ew, err := age.Encrypt(w, e.recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt: %w", err)
+	}
+	return ew, nil
+}
+
+// Decrypter decrypts input encrypted by an Encrypter and passes other input through
+type Decrypter struct {
+	identities []age.Identity
+}
+
+// NewDecrypter decrypts with the private keys of identityFile or IdentityEnv and the passphrase of
+// passphraseFile or PassphraseEnv. Without any of them it still reads unencrypted input.
+func NewDecrypter(identityFile, passphraseFile string) (*Decrypter, error) {
+	d := &Decrypter{}
+
+	keys := os.Getenv(IdentityEnv)
+	if identityFile != "" {
+		data, err := os.ReadFile(identityFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read identity file: %w", err)
+		}
+		keys = string(data)
+	}
+	if strings.TrimSpace(keys) != "" {
+		identities, err := age.ParseIdentities(strings.NewReader(keys))
+		if err != nil {
+			return nil, fmt.Errorf("invalid identity: %w", err)
+		}
+		d.identities = append(d.identities, identities...)
+	}
+
+	passphrase, err := readPassphrase(passphraseFile)
+	if err != nil {
+		return nil, err
+	}
+	if passphrase != "" {
+		identity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		d.identities = append(d.identities, identity)
+	}
+	return d, nil
+}
+
+// Reader returns a reader of the decrypted content of r, or of r itself if it isn't encrypted
+func (d *Decrypter) Reader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if head, err := br.Peek(len(magic)); err != nil || !bytes.Equal(head, magic) {
+		return br, nil
+	}
+
+	if d == nil || len(d.identities) == 0 {
+		return nil, fmt.Errorf("the input is encrypted, decryption needs --identity, --passphrase-file, %s or %s",
+			IdentityEnv, PassphraseEnv)
+	}
+	dr, err := age.Decrypt(br, d.identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return dr, nil
+}
+
+// readPassphrase reads the passphrase from the first line of path, or from PassphraseEnv if path is empty
+func readPassphrase(path string) (string, error) {
+	if path == "" {
+		return os.Getenv(PassphraseEnv), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase file: %w", err)
+	}
+	passphrase, _, _ := strings.Cut(string(data), "\n")
+	passphrase = strings.TrimSuffix(passphrase, "\r")
+	if passphrase == "" {
+		return "", errors.New("the passphrase file is empty")
+	}
+	return passphrase, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// Spool is a temporary file encrypted to a key which only lives in memory, so the data spooled
+// through it can't be read from the disk, even if the process dies before the file is removed
+type Spool struct {
+	file     *os.File
+	identity *age.X25519Identity
+	w        io.WriteCloser
+}
+
+// NewSpool creates a spool in the temporary directory, its file is named by pattern as with os.CreateTemp
+func NewSpool(pattern string) (*Spool, error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate spool key: %w", err)
+	}
+	file, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	w, err := age.Encrypt(file, identity.Recipient())
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, fmt.Errorf("failed to encrypt: %w", err)
+	}
+	return &Spool{file: file, identity: identity, w: w}, nil
+}
+
+func (s *Spool) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+// Reader ends writing and returns a reader of the spooled data, it can be called once
+func (s *Spool) Reader() (io.Reader, error) {
+	if err := s.w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read temporary file: %w", err)
+	}
+	r, err := age.Decrypt(bufio.NewReader(s.file), s.identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return r, nil
+}
+
+// Close closes and removes the file of the spool
+func (s *Spool) Close() error {
+	return errors.Join(s.file.Close(), os.Remove(s.file.Name()))
+}
@@ -0,0 +1,165 @@
+package encrypt_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/nemirlev/zenmoney-export/v2/internal/encrypt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seal encrypts data with the encrypter
+func seal(t *testing.T, e *encrypt.Encrypter, data string) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	w, err := e.Wrap(&out)
+	require.NoError(t, err)
+	_, err = io.WriteString(w, data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return out.Bytes()
+}
+
+// open decrypts data with the decrypter
+func open(t *testing.T, d *encrypt.Decrypter, data []byte) (string, error) {
+	t.Helper()
+	r, err := d.Reader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	plain, err := io.ReadAll(r)
+	return string(plain), err
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestRecipients(t *testing.T) {
+	t.Setenv(encrypt.IdentityEnv, "")
+	t.Setenv(encrypt.PassphraseEnv, "")
+
+	first, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	second, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	recipientsFile := writeFile(t, "# backup key\n"+second.Recipient().String()+"\n")
+	e, err := encrypt.NewEncrypter([]string{first.Recipient().String()}, recipientsFile, "")
+	require.NoError(t, err)
+	assert.Equal(t, ".age", e.Extension())
+	sealed := seal(t, e, "secret data")
+	assert.NotContains(t, string(sealed), "secret data")
+
+	// either key decrypts, from a file or the environment
+	d, err := encrypt.NewDecrypter(writeFile(t, first.String()+"\n"), "")
+	require.NoError(t, err)
+	plain, err := open(t, d, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "secret data", plain)
+
+	t.Setenv(encrypt.IdentityEnv, second.String())
+	d, err = encrypt.NewDecrypter("", "")
+	require.NoError(t, err)
+	plain, err = open(t, d, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "secret data", plain)
+
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	d, err = encrypt.NewDecrypter(writeFile(t, other.String()), "")
+	require.NoError(t, err)
+	_, err = open(t, d, sealed)
+	assert.ErrorContains(t, err, "failed to decrypt")
+}
+
+func TestPassphrase(t *testing.T) {
+	t.Setenv(encrypt.IdentityEnv, "")
+	t.Setenv(encrypt.PassphraseEnv, "correct horse")
+
+	e, err := encrypt.NewEncrypter(nil, "", "")
+	require.NoError(t, err)
+	sealed := seal(t, e, "secret data")
+
+	// the first line of the file, without its line break
+	d, err := encrypt.NewDecrypter("", writeFile(t, "correct horse\r\nignored\n"))
+	require.NoError(t, err)
+	plain, err := open(t, d, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "secret data", plain)
+
+	t.Setenv(encrypt.PassphraseEnv, "")
+	d, err = encrypt.NewDecrypter("", "")
+	require.NoError(t, err)
+	_, err = open(t, d, sealed)
+	assert.ErrorContains(t, err, "the input is encrypted")
+}
+
+func TestNewEncrypter_Errors(t *testing.T) {
+	t.Setenv(encrypt.PassphraseEnv, "")
+
+	_, err := encrypt.NewEncrypter(nil, "", "")
+	assert.ErrorContains(t, err, "encryption needs")
+
+	_, err = encrypt.NewEncrypter([]string{"age1invalid"}, "", "")
+	assert.ErrorContains(t, err, "invalid recipient")
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	_, err = encrypt.NewEncrypter([]string{identity.Recipient().String()}, "", writeFile(t, "secret"))
+	assert.ErrorContains(t, err, "not both")
+
+	_, err = encrypt.NewEncrypter(nil, "", writeFile(t, "\n"))
+	assert.ErrorContains(t, err, "the passphrase file is empty")
+}
+
+func TestNil(t *testing.T) {
+	t.Setenv(encrypt.IdentityEnv, "")
+	t.Setenv(encrypt.PassphraseEnv, "")
+
+	var e *encrypt.Encrypter
+	assert.Empty(t, e.Extension())
+	assert.Equal(t, []byte("plain"), seal(t, e, "plain"))
+
+	// unencrypted input passes through a decrypter without keys
+	d, err := encrypt.NewDecrypter("", "")
+	require.NoError(t, err)
+	plain, err := open(t, d, []byte("plain"))
+	require.NoError(t, err)
+	assert.Equal(t, "plain", plain)
+}
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	spool, err := encrypt.NewSpool("spool-*")
+	require.NoError(t, err)
+	_, err = io.WriteString(spool, "secret transactions")
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "spool-*"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	r, err := spool.Reader()
+	require.NoError(t, err)
+	onDisk, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.NotContains(t, string(onDisk), "secret")
+
+	plain, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "secret transactions", string(plain))
+
+	require.NoError(t, spool.Close())
+	assert.NoFileExists(t, files[0])
+}
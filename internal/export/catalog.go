@@ -0,0 +1,89 @@
+package export
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+)
+
+// catalog holds the accounts, tags, instruments and merchants transactions refer to
+type catalog struct {
+	accounts    map[string]models.Account
+	tags        map[string]models.Tag
+	instruments map[int]models.Instrument
+	merchants   map[string]models.Merchant
+}
+
+// loadCatalog reads all accounts, tags, instruments and merchants
+func (e *Exporter) loadCatalog(ctx context.Context) (*catalog, error) {
+	c := &catalog{
+		accounts:    make(map[string]models.Account),
+		tags:        make(map[string]models.Tag),
+		instruments: make(map[int]models.Instrument),
+		merchants:   make(map[string]models.Merchant),
+	}
+
+	for _, entity := range []Entity{accountsEntity, tagsEntity, instrumentsEntity, merchantsEntity} {
+		_, err := e.each(ctx, entity, func(record any) error {
+			switch v := record.(type) {
+			case models.Account:
+				c.accounts[v.ID] = v
+			case models.Tag:
+				c.tags[v.ID] = v
+			case models.Instrument:
+				c.instruments[v.ID] = v
+			case models.Merchant:
+				c.merchants[v.ID] = v
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// tagPath returns the title of the first tag with its parent, e.g. "Food:Cafe", empty without tags
+func (c *catalog) tagPath(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	tag, ok := c.tags[tags[0]]
+	if !ok {
+		return tags[0]
+	}
+	if tag.Parent != nil {
+		if parent, ok := c.tags[*tag.Parent]; ok {
+			return parent.Title + ":" + tag.Title
+		}
+	}
+	return tag.Title
+}
+
+// accountTitle returns the title of an account, or its ID if it isn't known
+func (c *catalog) accountTitle(id string) string {
+	if account, ok := c.accounts[id]; ok {
+		return account.Title
+	}
+	return id
+}
+
+// currency returns the short title of an instrument, or its ID if it isn't known
+func (c *catalog) currency(id int) string {
+	if instrument, ok := c.instruments[id]; ok {
+		return instrument.ShortTitle
+	}
+	return strconv.Itoa(id)
+}
+
+// payee returns the title of the merchant of a transaction, or its payee as written
+func (c *catalog) payee(tx models.Transaction) string {
+	if tx.Merchant != nil {
+		if merchant, ok := c.merchants[*tx.Merchant]; ok && merchant.Title != "" {
+			return merchant.Title
+		}
+	}
+	return tx.Payee
+}
@@ -0,0 +1,113 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+)
+
+// Entity is a kind of objects which can be exported
+type Entity struct {
+	Name string
+	// Dated entities are filtered by the export date range
+	Dated bool
+	// Columns are the json names of the model fields, in declaration order
+	Columns []string
+
+	list func(ctx context.Context, storage interfaces.Storage, filter interfaces.Filter) ([]any, error)
+}
+
+// entity describes an entity listed by a Storage method
+func entity[T any](
+	name string,
+	dated bool,
+	list func(interfaces.Storage, context.Context, interfaces.Filter) ([]T, error),
+) Entity {
+	return Entity{
+		Name:    name,
+		Dated:   dated,
+		Columns: jsonColumns(reflect.TypeFor[T]()),
+		list: func(ctx context.Context, storage interfaces.Storage, filter interfaces.Filter) ([]any, error) {
+			objects, err := list(storage, ctx, filter)
+			if err != nil {
+				return nil, err
+			}
+			records := make([]any, len(objects))
+			for i := range objects {
+				records[i] = objects[i]
+			}
+			return records, nil
+		},
+	}
+}
+
+// Entities the exporters read on their own, they are in Entities as well
+var (
+	instrumentsEntity  = entity[models.Instrument]("instruments", false, interfaces.Storage.ListInstruments)
+	accountsEntity     = entity[models.Account]("accounts", false, interfaces.Storage.ListAccounts)
+	tagsEntity         = entity[models.Tag]("tags", false, interfaces.Storage.ListTags)
+	merchantsEntity    = entity[models.Merchant]("merchants", false, interfaces.Storage.ListMerchants)
+	transactionsEntity = entity[models.Transaction]("transactions", true, interfaces.Storage.ListTransactions)
+)
+
+// Entities lists exportable entities in the order they are exported
+var Entities = []Entity{
+	instrumentsEntity,
+	entity[models.Country]("countries", false, interfaces.Storage.ListCountries),
+	entity[models.Company]("companies", false, interfaces.Storage.ListCompanies),
+	entity[models.User]("users", false, interfaces.Storage.ListUsers),
+	accountsEntity,
+	tagsEntity,
+	merchantsEntity,
+	entity[models.Budget]("budgets", true, interfaces.Storage.ListBudgets),
+	entity[models.Reminder]("reminders", false, interfaces.Storage.ListReminders),
+	entity[models.ReminderMarker]("reminder_markers", true, interfaces.Storage.ListReminderMarkers),
+	transactionsEntity,
+}
+
+// ParseEntities resolves a comma-separated list of entity names, "all" selects every entity
+func ParseEntities(list string) ([]Entity, error) {
+	if list == "" || list == "all" {
+		return Entities, nil
+	}
+
+	var selected []Entity
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, e := range Entities {
+			if e.Name == name {
+				selected = append(selected, e)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown entity: %s", name)
+		}
+	}
+	return selected, nil
+}
+
+// jsonColumns returns the json field names of a struct type
+func jsonColumns(t reflect.Type) []string {
+	var columns []string
+	for field := range t.Fields() {
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		columns = append(columns, name)
+	}
+	return columns
+}
@@ -0,0 +1,255 @@
+// Package export writes objects of the storage to files in portable formats
+package export
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/encrypt"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+)
+
+// DefaultPageSize is the number of objects read from the storage at once
+const DefaultPageSize = 1000
+
+// Options configure an export
+type Options struct {
+	Format      Format
+	Compression Compression
+	// From and To limit dated entities (budgets, reminder markers, transactions) to a date range
+	From *time.Time
+	To   *time.Time
+	// PageSize is the number of objects read from the storage at once
+	PageSize int
+	// Mapping names the accounts and commodities of journal formats
+	Mapping Mapping
+	// Preset writes transactions as CSV in the layout of another app instead of the format
+	Preset *Preset
+	// Template is a built-in template (markdown, html) or a template file the entities are written through
+	Template string
+	// Encrypter encrypts the output after compression, nil writes it in the clear
+	Encrypter *encrypt.Encrypter
+}
+
+// Exporter pages through the List methods of a storage, so memory use doesn't grow with the data
+type Exporter struct {
+	storage interfaces.Storage
+	opts    Options
+}
+
+// NewExporter creates an exporter reading from the storage
+func NewExporter(storage interfaces.Storage, opts Options) *Exporter {
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultPageSize
+	}
+	return &Exporter{storage: storage, opts: opts}
+}
+
+// FileName returns the name of the export file of the entity, e.g. transactions.csv.gz
+func (e *Exporter) FileName(entity Entity) string {
+	return entity.Name + "." + e.opts.Format.Extension() + e.opts.Compression.Extension() + e.opts.Encrypter.Extension()
+}
+
+// Export writes all objects of the entity to w and returns their number
+func (e *Exporter) Export(ctx context.Context, entity Entity, w io.Writer) (int, error) {
+	cw, err := e.opts.Compression.Wrap(w)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s compressor: %w", e.opts.Compression, err)
+	}
+
+	rw, err := NewRecordWriter(e.opts.Format, cw, entity.Columns)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := e.each(ctx, entity, func(record any) error {
+		if err := rw.Write(record); err != nil {
+			return fmt.Errorf("failed to write %s: %w", entity.Name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if err := rw.Flush(); err != nil {
+		return count, fmt.Errorf("failed to write %s: %w", entity.Name, err)
+	}
+	if err := cw.Close(); err != nil {
+		return count, fmt.Errorf("failed to compress %s: %w", entity.Name, err)
+	}
+	return count, nil
+}
+
+// each calls fn for every object of the entity, page by page, and returns their number
+func (e *Exporter) each(ctx context.Context, entity Entity, fn func(record any) error) (int, error) {
+	filter := interfaces.Filter{Limit: e.opts.PageSize}
+	if entity.Dated {
+		filter.StartDate = e.opts.From
+		filter.EndDate = e.opts.To
+	}
+
+	count := 0
+	for page := 1; ; page++ {
+		filter.Page = page
+		records, err := entity.list(ctx, e.storage, filter)
+		if err != nil {
+			return count, fmt.Errorf("failed to list %s: %w", entity.Name, err)
+		}
+
+		for _, record := range records {
+			if err := fn(record); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if len(records) < e.opts.PageSize {
+			return count, nil
+		}
+	}
+}
+
+// ExportTo exports the entities and returns the number of objects per entity.
+// A single entity is written to output, or to stdout if output is empty or "-".
+// Several entities need output to be a directory, each goes into a file named by FileName.
+// The xlsx format writes all entities into one workbook instead, journal formats write
+// accounts and transactions into one journal regardless of entities, and statement formats
+// (qif, ofx) write the transactions of every account, see exportStatementsTo. A preset replaces
+// the format, see exportPresetTo, and so does a template, see ExportTemplate.
+// With an Encrypter every file is encrypted, and so is the output written to stdout.
+func (e *Exporter) ExportTo(ctx context.Context, entities []Entity, output string, stdout io.Writer) (counts map[string]int, err error) {
+	if e.opts.Encrypter != nil && (output == "" || output == "-") {
+		ew, err := e.opts.Encrypter.Wrap(stdout)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if cerr := ew.Close(); cerr != nil && err == nil {
+				err = fmt.Errorf("failed to encrypt export: %w", cerr)
+			}
+		}()
+		stdout = ew
+	}
+
+	return e.exportTo(ctx, entities, output, stdout)
+}
+
+// exportTo dispatches the export by its options, see ExportTo
+func (e *Exporter) exportTo(ctx context.Context, entities []Entity, output string, stdout io.Writer) (map[string]int, error) {
+	if e.opts.Template != "" {
+		return e.exportTemplateTo(ctx, entities, output, stdout)
+	}
+	if e.opts.Preset != nil {
+		return e.exportPresetTo(ctx, output, stdout)
+	}
+	if e.opts.Format == FormatXLSX {
+		return e.exportWorkbookTo(ctx, entities, output, stdout)
+	}
+	if e.opts.Format.Journal() {
+		return e.exportJournalTo(ctx, output, stdout)
+	}
+	if e.opts.Format.Statement() {
+		return e.exportStatementsTo(ctx, output, stdout)
+	}
+
+	counts := make(map[string]int, len(entities))
+
+	if len(entities) == 1 && (output == "" || output == "-") {
+		bw := bufio.NewWriter(stdout)
+		n, err := e.Export(ctx, entities[0], bw)
+		if err != nil {
+			return nil, err
+		}
+		counts[entities[0].Name] = n
+		return counts, bw.Flush()
+	}
+
+	if output == "" || output == "-" {
+		return nil, errors.New("exporting several entities requires --output to be a directory")
+	}
+
+	if len(entities) == 1 {
+		if info, err := os.Stat(output); err != nil || !info.IsDir() {
+			n, err := e.exportFile(ctx, entities[0], output)
+			if err != nil {
+				return nil, err
+			}
+			counts[entities[0].Name] = n
+			return counts, nil
+		}
+	}
+
+	if err := os.MkdirAll(output, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	for _, entity := range entities {
+		n, err := e.exportFile(ctx, entity, filepath.Join(output, e.FileName(entity)))
+		if err != nil {
+			return nil, err
+		}
+		counts[entity.Name] = n
+	}
+	return counts, nil
+}
+
+// exportFile exports the entity into a new file at path
+func (e *Exporter) exportFile(ctx context.Context, entity Entity, path string) (n int, err error) {
+	f, err := e.create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close export file: %w", cerr)
+		}
+	}()
+
+	bw := bufio.NewWriter(f)
+	if n, err = e.Export(ctx, entity, bw); err != nil {
+		return n, err
+	}
+	if err = bw.Flush(); err != nil {
+		return n, fmt.Errorf("failed to write export file: %w", err)
+	}
+	return n, nil
+}
+
+// create creates an export file at path, encrypted if the options ask for it.
+// Closing the file finishes the encryption first.
+func (e *Exporter) create(path string) (io.WriteCloser, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export file: %w", err)
+	}
+	if e.opts.Encrypter == nil {
+		return f, nil
+	}
+
+	ew, err := e.opts.Encrypter.Wrap(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &encryptedFile{WriteCloser: ew, file: f}, nil
+}
+
+// encryptedFile is a file written through an encrypting writer
+type encryptedFile struct {
+	io.WriteCloser
+	file *os.File
+}
+
+func (f *encryptedFile) Close() error {
+	err := f.WriteCloser.Close()
+	if cerr := f.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
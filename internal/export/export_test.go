@@ -0,0 +1,255 @@
+package export_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"github.com/klauspost/compress/zstd"
+	"github.com/nemirlev/zenmoney-export/v2/internal/encrypt"
+	"github.com/nemirlev/zenmoney-export/v2/internal/export"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/mocks"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func entities(t *testing.T, list string) []export.Entity {
+	t.Helper()
+	selected, err := export.ParseEntities(list)
+	require.NoError(t, err)
+	return selected
+}
+
+// testData are the rows of the storage of an export test
+type testData struct {
+	accounts     []models.Account
+	tags         []models.Tag
+	instruments  []models.Instrument
+	merchants    []models.Merchant
+	transactions []models.Transaction
+}
+
+// newTestData returns the catalog shared by the export tests: a card in RUB and a wallet in USD,
+// a food category with a subcategory, a salary category and a merchant.
+// Tests add their transactions and the fields they need.
+func newTestData(transactions ...models.Transaction) *testData {
+	return &testData{
+		accounts: []models.Account{
+			{ID: "acc-1", Title: "Tinkoff Black", Type: "ccard", Instrument: ptr(int32(2)), StartBalance: ptr(1000.0)},
+			{ID: "acc-2", Title: "Wallet", Type: "cash", Instrument: ptr(int32(1))},
+		},
+		tags: []models.Tag{
+			{ID: "tag-1", Title: "Food"},
+			{ID: "tag-2", Title: "Cafe & bars", Parent: ptr("tag-1")},
+			{ID: "tag-3", Title: "Salary"},
+		},
+		instruments: []models.Instrument{
+			{ID: 1, ShortTitle: "USD", Symbol: "$"},
+			{ID: 2, ShortTitle: "RUB", Symbol: "₽"},
+		},
+		merchants:    []models.Merchant{{ID: "m-1", Title: "Acme Corp"}},
+		transactions: transactions,
+	}
+}
+
+// storage returns a storage mock listing the rows and getting them by ID, objects missing in the rows aren't found
+func (d *testData) storage(t *testing.T) *mocks.Storage {
+	storage := mocks.NewStorage(t)
+	storage.On("ListAccounts", mock.Anything, mock.Anything).Return(d.accounts, nil).Maybe()
+	storage.On("ListTags", mock.Anything, mock.Anything).Return(d.tags, nil).Maybe()
+	storage.On("ListInstruments", mock.Anything, mock.Anything).Return(d.instruments, nil).Maybe()
+	storage.On("ListMerchants", mock.Anything, mock.Anything).Return(d.merchants, nil).Maybe()
+	storage.On("ListTransactions", mock.Anything, mock.Anything).Return(d.transactions, nil).Maybe()
+
+	storage.On("GetAccount", mock.Anything, mock.Anything).Return(
+		func(_ context.Context, id string) (*models.Account, error) {
+			return find(d.accounts, "account", id, func(a models.Account) string { return a.ID })
+		}).Maybe()
+	storage.On("GetTag", mock.Anything, mock.Anything).Return(
+		func(_ context.Context, id string) (*models.Tag, error) {
+			return find(d.tags, "tag", id, func(t models.Tag) string { return t.ID })
+		}).Maybe()
+	storage.On("GetInstrument", mock.Anything, mock.Anything).Return(
+		func(_ context.Context, id int) (*models.Instrument, error) {
+			return find(d.instruments, "instrument", id, func(i models.Instrument) int { return i.ID })
+		}).Maybe()
+	storage.On("GetMerchant", mock.Anything, mock.Anything).Return(
+		func(_ context.Context, id string) (*models.Merchant, error) {
+			return find(d.merchants, "merchant", id, func(m models.Merchant) string { return m.ID })
+		}).Maybe()
+	return storage
+}
+
+// find returns the row with an ID the way the storage gets it
+func find[T any, ID comparable](rows []T, entity string, id ID, key func(T) ID) (*T, error) {
+	for i := range rows {
+		if key(rows[i]) == id {
+			return &rows[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s %w: %v", entity, interfaces.ErrNotFound, id)
+}
+
+func TestParseEntities(t *testing.T) {
+	assert.Len(t, entities(t, "all"), len(export.Entities))
+
+	selected := entities(t, "transactions, tags")
+	require.Len(t, selected, 2)
+	assert.Equal(t, "transactions", selected[0].Name)
+	assert.True(t, selected[0].Dated)
+	assert.Equal(t, "tags", selected[1].Name)
+
+	_, err := export.ParseEntities("transactions,unknown")
+	assert.EqualError(t, err, "unknown entity: unknown")
+}
+
+func TestExport_CSVPages(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListMerchants", mock.Anything, interfaces.Filter{Page: 1, Limit: 2}).
+		Return([]models.Merchant{
+			{ID: "m-1", User: 1, Title: "Shop", Changed: 1700000000},
+			{ID: "m-2", User: 1, Title: "Cafe, Bar", Changed: 1700000001},
+		}, nil)
+	storage.On("ListMerchants", mock.Anything, interfaces.Filter{Page: 2, Limit: 2}).
+		Return([]models.Merchant{{ID: "m-3", User: 2, Title: "Fuel"}}, nil)
+
+	exporter := export.NewExporter(storage, export.Options{Format: export.FormatCSV, PageSize: 2})
+
+	var out bytes.Buffer
+	n, err := exporter.Export(context.Background(), entities(t, "merchants")[0], &out)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "id,user,title,changed\n"+
+		"m-1,1,Shop,1700000000\n"+
+		"m-2,1,\"Cafe, Bar\",1700000001\n"+
+		"m-3,2,Fuel,0\n", out.String())
+}
+
+func TestExport_JSONDateRange(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	storage := mocks.NewStorage(t)
+	storage.On("ListTransactions", mock.Anything, interfaces.Filter{StartDate: &from, EndDate: &to, Page: 1, Limit: 10}).
+		Return([]models.Transaction{{ID: "tx-1", Date: "2024-01-15", Tag: []string{"tag-1"}}}, nil)
+
+	exporter := export.NewExporter(storage, export.Options{Format: export.FormatJSON, From: &from, To: &to, PageSize: 10})
+
+	var out bytes.Buffer
+	n, err := exporter.Export(context.Background(), entities(t, "transactions")[0], &out)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	require.Len(t, lines, 1)
+	assert.Contains(t, string(lines[0]), `"id":"tx-1"`)
+	assert.Contains(t, string(lines[0]), `"tag":["tag-1"]`)
+}
+
+func TestExport_EmptyCSVHasHeader(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListCountries", mock.Anything, mock.Anything).Return([]models.Country{}, nil)
+
+	exporter := export.NewExporter(storage, export.Options{Format: export.FormatCSV})
+
+	var out bytes.Buffer
+	n, err := exporter.Export(context.Background(), entities(t, "countries")[0], &out)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, "id,title,currency,domain\n", out.String())
+}
+
+func TestExportTo_Directory(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListTags", mock.Anything, mock.Anything).Return([]models.Tag{{ID: "tag-1", Title: "Food"}}, nil)
+	storage.On("ListMerchants", mock.Anything, mock.Anything).Return([]models.Merchant{{ID: "m-1"}}, nil)
+
+	dir := t.TempDir()
+	exporter := export.NewExporter(storage, export.Options{Format: export.FormatJSON, Compression: export.CompressionGzip})
+
+	counts, err := exporter.ExportTo(context.Background(), entities(t, "tags,merchants"), dir, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"tags": 1, "merchants": 1}, counts)
+
+	f, err := os.Open(filepath.Join(dir, "tags.ndjson.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"title":"Food"`)
+
+	assert.FileExists(t, filepath.Join(dir, "merchants.ndjson.gz"))
+}
+
+func TestExportTo_StdoutZstd(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListCountries", mock.Anything, mock.Anything).Return([]models.Country{{ID: 1, Title: "Russia"}}, nil)
+
+	exporter := export.NewExporter(storage, export.Options{Format: export.FormatCSV, Compression: export.CompressionZstd})
+
+	var out bytes.Buffer
+	_, err := exporter.ExportTo(context.Background(), entities(t, "countries"), "", &out)
+	require.NoError(t, err)
+
+	zr, err := zstd.NewReader(&out)
+	require.NoError(t, err)
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "1,Russia,")
+
+	_, err = exporter.ExportTo(context.Background(), entities(t, "countries,tags"), "-", &out)
+	assert.Error(t, err)
+}
+
+func TestExportTo_Encrypted(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListTags", mock.Anything, mock.Anything).Return([]models.Tag{{ID: "tag-1", Title: "Food"}}, nil)
+	storage.On("ListMerchants", mock.Anything, mock.Anything).Return([]models.Merchant{{ID: "m-1"}}, nil)
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	encrypter, err := encrypt.NewEncrypter([]string{identity.Recipient().String()}, "", "")
+	require.NoError(t, err)
+	exporter := export.NewExporter(storage, export.Options{
+		Format:      export.FormatJSON,
+		Compression: export.CompressionGzip,
+		Encrypter:   encrypter,
+	})
+
+	decrypt := func(r io.Reader) string {
+		dr, err := age.Decrypt(r, identity)
+		require.NoError(t, err)
+		zr, err := gzip.NewReader(dr)
+		require.NoError(t, err)
+		data, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	dir := t.TempDir()
+	_, err = exporter.ExportTo(context.Background(), entities(t, "tags,merchants"), dir, io.Discard)
+	require.NoError(t, err)
+	f, err := os.Open(filepath.Join(dir, "tags.ndjson.gz.age"))
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Contains(t, decrypt(f), `"title":"Food"`)
+	assert.FileExists(t, filepath.Join(dir, "merchants.ndjson.gz.age"))
+
+	var out bytes.Buffer
+	_, err = exporter.ExportTo(context.Background(), entities(t, "tags"), "-", &out)
+	require.NoError(t, err)
+	assert.Contains(t, decrypt(&out), `"title":"Food"`)
+}
@@ -0,0 +1,149 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Format is the output format of an export
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+	// FormatXLSX writes all entities into one workbook, see Exporter.ExportWorkbook
+	FormatXLSX Format = "xlsx"
+)
+
+// Extension returns the file extension of the format
+func (f Format) Extension() string {
+	switch f {
+	case FormatJSON:
+		return "ndjson"
+	default:
+		return string(f)
+	}
+}
+
+// RecordWriter writes exported objects
+type RecordWriter interface {
+	Write(record any) error
+	// Flush writes buffered data to the underlying writer
+	Flush() error
+}
+
+// NewRecordWriter creates a writer of the format for records with the given columns
+func NewRecordWriter(format Format, w io.Writer, columns []string) (RecordWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns), nil
+	case FormatJSON:
+		return &jsonWriter{enc: json.NewEncoder(w)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// jsonWriter writes newline-delimited JSON, one object per line
+type jsonWriter struct {
+	enc *json.Encoder
+}
+
+func (w *jsonWriter) Write(record any) error {
+	return w.enc.Encode(record)
+}
+
+func (w *jsonWriter) Flush() error {
+	return nil
+}
+
+// csvWriter writes a header row followed by a row per record.
+// Values are taken from the JSON encoding of the record, so CSV and NDJSON exports match.
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+	header  bool
+}
+
+func newCSVWriter(w io.Writer, columns []string) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w), columns: columns}
+}
+
+func (w *csvWriter) Write(record any) error {
+	if !w.header {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	fields, err := jsonFields(record)
+	if err != nil {
+		return err
+	}
+
+	row := make([]string, len(w.columns))
+	for i, column := range w.columns {
+		row[i], err = csvValue(fields[column])
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", column, err)
+		}
+	}
+	return w.w.Write(row)
+}
+
+// Flush writes the header of an empty export and flushes buffered rows
+func (w *csvWriter) Flush() error {
+	if !w.header {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvWriter) writeHeader() error {
+	w.header = true
+	return w.w.Write(w.columns)
+}
+
+// jsonFields returns the JSON fields of a record, numbers are kept as written
+func jsonFields(record any) (map[string]any, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("failed to decode record: %w", err)
+	}
+	return fields, nil
+}
+
+// csvValue formats a JSON value for a CSV cell, nested values are written as JSON
+func csvValue(v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
@@ -0,0 +1,416 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+)
+
+const (
+	FormatBeancount Format = "beancount"
+	FormatLedger    Format = "ledger"
+	FormatHledger   Format = "hledger"
+)
+
+// Journal reports whether the format is a plain-text accounting journal
+func (f Format) Journal() bool {
+	return f == FormatBeancount || f == FormatLedger || f == FormatHledger
+}
+
+// journalKey matches the zenmoney_id written with every journal entry
+var journalKey = regexp.MustCompile(`zenmoney_id:\s*"?([^"\s]+)"?`)
+
+// exportJournalTo writes a journal to output, or to stdout if output is empty or "-".
+// An existing output file is appended to, skipping entries it already has.
+func (e *Exporter) exportJournalTo(ctx context.Context, output string, stdout io.Writer) (counts map[string]int, err error) {
+	if e.opts.Compression != CompressionNone {
+		return nil, errors.New("journals can't be appended to when compressed, --compress is not supported")
+	}
+
+	if output == "" || output == "-" {
+		return e.ExportJournal(ctx, stdout, nil)
+	}
+	if e.opts.Encrypter != nil {
+		return nil, errors.New("journals can't be appended to when encrypted, --encrypt needs the journal on stdout")
+	}
+
+	known, err := journalKeys(output)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(output, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close journal: %w", cerr)
+		}
+	}()
+
+	return e.ExportJournal(ctx, f, known)
+}
+
+// journalKeys returns the zenmoney_id keys of the entries of an existing journal
+func journalKeys(path string) (map[string]bool, error) {
+	known := make(map[string]bool)
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return known, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := journalKey.FindStringSubmatch(scanner.Text()); m != nil {
+			known[m[1]] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	return known, nil
+}
+
+// ExportJournal writes accounts, opening balances and transactions as a plain-text accounting journal
+// and returns the number of written entries per entity. Entries whose zenmoney_id is in known are skipped,
+// so a journal can be extended by exporting into it again.
+func (e *Exporter) ExportJournal(ctx context.Context, w io.Writer, known map[string]bool) (map[string]int, error) {
+	if known == nil {
+		known = make(map[string]bool)
+	}
+
+	j := &journal{
+		format:  e.opts.Format,
+		mapping: e.opts.Mapping,
+		known:   known,
+		opened:  make(map[string]string),
+		w:       bufio.NewWriter(w),
+	}
+	if j.mapping.OpeningDate == "" {
+		j.mapping = DefaultMapping()
+	}
+
+	var err error
+	if j.catalog, err = e.loadCatalog(ctx); err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int{"accounts": 0, "transactions": 0}
+	accountIDs := make([]string, 0, len(j.accounts))
+	for id := range j.accounts {
+		accountIDs = append(accountIDs, id)
+	}
+	sort.Strings(accountIDs)
+	for _, id := range accountIDs {
+		written, err := j.writeOpening(j.accounts[id])
+		if err != nil {
+			return nil, err
+		}
+		if written {
+			counts["accounts"]++
+		}
+	}
+
+	_, err = e.each(ctx, transactionsEntity, func(record any) error {
+		written, err := j.writeTransaction(record.(models.Transaction))
+		if written {
+			counts["transactions"]++
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := j.writeOpens(); err != nil {
+		return nil, err
+	}
+	if err := j.w.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write journal: %w", err)
+	}
+	return counts, nil
+}
+
+// journal is a journal being written
+type journal struct {
+	format  Format
+	mapping Mapping
+	// known are zenmoney_id keys of entries written already
+	known map[string]bool
+	// opened maps accounts used by postings to the date they are used first in the run
+	opened map[string]string
+	w      *bufio.Writer
+
+	*catalog
+}
+
+// account returns the journal account of a ZenMoney account
+func (j *journal) account(id string) string {
+	account, ok := j.accounts[id]
+	if !ok {
+		return j.mapping.AccountTypes["cash"] + ":" + accountSegment(id)
+	}
+	if name, ok := lookup(j.mapping.Accounts, account.Title, account.ID); ok {
+		return name
+	}
+	parent, ok := j.mapping.AccountTypes[account.Type]
+	if !ok {
+		parent = "Assets:" + accountSegment(account.Type)
+	}
+	return parent + ":" + accountSegment(account.Title)
+}
+
+// category returns the expense or income account of the first tag of a transaction
+func (j *journal) category(tags []string, income bool) string {
+	root := j.mapping.Expenses
+	if income {
+		root = j.mapping.Income
+	}
+	if len(tags) == 0 {
+		return root + ":" + accountSegment(j.mapping.Uncategorized)
+	}
+
+	tag, ok := j.tags[tags[0]]
+	if !ok {
+		return root + ":" + accountSegment(tags[0])
+	}
+	if name, ok := lookup(j.mapping.Tags, tag.Title, tag.ID); ok {
+		return name
+	}
+
+	path := accountSegment(tag.Title)
+	if tag.Parent != nil {
+		if parent, ok := j.tags[*tag.Parent]; ok {
+			path = accountSegment(parent.Title) + ":" + path
+		}
+	}
+	return root + ":" + path
+}
+
+// commodity returns the commodity of an instrument
+func (j *journal) commodity(id int) string {
+	instrument, ok := j.instruments[id]
+	if !ok {
+		return commodityName("CUR" + strconv.Itoa(id))
+	}
+	if name, ok := j.mapping.Commodities[instrument.ShortTitle]; ok {
+		return name
+	}
+	return commodityName(instrument.ShortTitle)
+}
+
+// posting is a line of a journal entry
+type posting struct {
+	account string
+	amount  float64
+	// commodity of the amount
+	commodity string
+	// price is the total cost in priceCommodity, for transfers between currencies
+	price          float64
+	priceCommodity string
+}
+
+// writeOpening writes the opening balance of an account from its start balance
+func (j *journal) writeOpening(account models.Account) (bool, error) {
+	if account.StartBalance == nil || *account.StartBalance == 0 || account.Instrument == nil {
+		return false, nil
+	}
+
+	key := "opening:" + account.ID
+	if j.known[key] {
+		return false, nil
+	}
+
+	date := j.mapping.OpeningDate
+	if account.StartDate != nil && *account.StartDate != "" {
+		date = *account.StartDate
+	}
+
+	commodity := j.commodity(int(*account.Instrument))
+	return true, j.writeEntry(key, date, "Opening balance", account.Title, []posting{
+		{account: j.account(account.ID), amount: *account.StartBalance, commodity: commodity},
+		{account: j.mapping.OpeningBalances, amount: -*account.StartBalance, commodity: commodity},
+	})
+}
+
+// writeTransaction writes a transaction as balanced postings: an expense moves money from
+// the account to the category of its first tag, an income from the category to the account
+// and a transfer between the accounts, priced in the income currency if they differ
+// or balanced against the transfer fees account if the amounts differ in one currency
+func (j *journal) writeTransaction(tx models.Transaction) (bool, error) {
+	if tx.Deleted || j.known[tx.ID] {
+		return false, nil
+	}
+
+	outcomeAccount := tx.IncomeAccount
+	if tx.OutcomeAccount != nil {
+		outcomeAccount = *tx.OutcomeAccount
+	}
+	incomeCommodity := j.commodity(tx.IncomeInstrument)
+	outcomeCommodity := j.commodity(tx.OutcomeInstrument)
+
+	var postings []posting
+	switch {
+	case tx.Income > 0 && tx.Outcome == 0:
+		postings = []posting{
+			{account: j.account(tx.IncomeAccount), amount: tx.Income, commodity: incomeCommodity},
+			{account: j.category(tx.Tag, true), amount: -tx.Income, commodity: incomeCommodity},
+		}
+	case tx.Outcome > 0 && tx.Income == 0:
+		postings = []posting{
+			{account: j.category(tx.Tag, false), amount: tx.Outcome, commodity: outcomeCommodity},
+			{account: j.account(outcomeAccount), amount: -tx.Outcome, commodity: outcomeCommodity},
+		}
+	case tx.Income > 0 && tx.Outcome > 0:
+		out := posting{account: j.account(outcomeAccount), amount: -tx.Outcome, commodity: outcomeCommodity}
+		if outcomeCommodity != incomeCommodity {
+			out.price = tx.Income
+			out.priceCommodity = incomeCommodity
+		}
+		postings = []posting{
+			{account: j.account(tx.IncomeAccount), amount: tx.Income, commodity: incomeCommodity},
+			out,
+		}
+		// in one currency the difference is a fee taken on the way, or a gain if it's negative
+		if fee := roundAmount(tx.Outcome - tx.Income); outcomeCommodity == incomeCommodity && fee != 0 {
+			postings = append(postings, posting{account: j.mapping.TransferFees, amount: fee, commodity: outcomeCommodity})
+		}
+	default:
+		return false, nil
+	}
+
+	narration := ""
+	if tx.Comment != nil {
+		narration = *tx.Comment
+	}
+	return true, j.writeEntry(tx.ID, tx.Date, j.payee(tx), narration, postings)
+}
+
+// writeEntry writes a transaction entry tagged with its zenmoney_id
+func (j *journal) writeEntry(key, date, payee, narration string, postings []posting) error {
+	for _, p := range postings {
+		if first, ok := j.opened[p.account]; !ok || date < first {
+			j.opened[p.account] = date
+		}
+	}
+	j.known[key] = true
+
+	var b strings.Builder
+	indent := "    "
+	switch j.format {
+	case FormatBeancount:
+		indent = "  "
+		fmt.Fprintf(&b, "%s * %s %s\n", date, quote(payee), quote(narration))
+		fmt.Fprintf(&b, "%szenmoney_id: %s\n", indent, quote(key))
+	case FormatHledger:
+		description := payee
+		if narration != "" {
+			description = payee + " | " + narration
+		}
+		fmt.Fprintf(&b, "%s %s\n", date, strings.TrimSpace(description))
+		fmt.Fprintf(&b, "%s; zenmoney_id: %s\n", indent, key)
+	default:
+		description := payee
+		if description == "" {
+			description = narration
+		}
+		fmt.Fprintf(&b, "%s %s\n", date, strings.TrimSpace(description))
+		fmt.Fprintf(&b, "%s; zenmoney_id: %s\n", indent, key)
+		if narration != "" && narration != description {
+			fmt.Fprintf(&b, "%s; %s\n", indent, narration)
+		}
+	}
+
+	for _, p := range postings {
+		fmt.Fprintf(&b, "%s%s  %s %s", indent, p.account, formatAmount(p.amount), p.commodity)
+		if p.priceCommodity != "" {
+			fmt.Fprintf(&b, " @@ %s %s", formatAmount(p.price), p.priceCommodity)
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+
+	_, err := j.w.WriteString(b.String())
+	return err
+}
+
+// writeOpens declares the accounts used by the written entries, unless the journal declares them already.
+// Accounts are opened on the start date of their ZenMoney account or on the opening date of the mapping,
+// so the date doesn't depend on the transactions of the run, unless a posting is earlier than that.
+func (j *journal) writeOpens() error {
+	starts := make(map[string]string)
+	for id, account := range j.accounts {
+		if account.StartDate == nil || *account.StartDate == "" {
+			continue
+		}
+		name := j.account(id)
+		if start, ok := starts[name]; !ok || *account.StartDate < start {
+			starts[name] = *account.StartDate
+		}
+	}
+
+	accounts := make([]string, 0, len(j.opened))
+	for account := range j.opened {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	for _, account := range accounts {
+		key := "account:" + account
+		if j.known[key] {
+			continue
+		}
+		j.known[key] = true
+
+		date, ok := starts[account]
+		if !ok {
+			date = j.mapping.OpeningDate
+		}
+		if first := j.opened[account]; first < date {
+			date = first
+		}
+
+		var err error
+		switch j.format {
+		case FormatBeancount:
+			_, err = fmt.Fprintf(j.w, "%s open %s\n  zenmoney_id: %s\n\n", date, account, quote(key))
+		default:
+			_, err = fmt.Fprintf(j.w, "; zenmoney_id: %s\naccount %s\n\n", key, account)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatAmount formats an amount without exponent and trailing zeros
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+// roundAmount drops the float error of a difference of amounts, e.g. 100.1 - 100
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*1e9) / 1e9
+}
+
+// quote quotes a beancount string
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ").Replace(s) + `"`
+}
@@ -0,0 +1,167 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/export"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// journalData are three transactions of the card: a transfer to the wallet, an expense and an income,
+// and a deleted one
+func journalData() *testData {
+	data := newTestData(
+		models.Transaction{ID: "tx-3", Date: "2024-01-20", Income: 10, Outcome: 900, IncomeInstrument: 1, OutcomeInstrument: 2,
+			IncomeAccount: "acc-2", OutcomeAccount: ptr("acc-1")},
+		models.Transaction{ID: "tx-2", Date: "2024-01-15", Payee: "Coffee \"Bean\"", Comment: ptr("latte"), Outcome: 250.5,
+			IncomeInstrument: 2, OutcomeInstrument: 2, IncomeAccount: "acc-1", OutcomeAccount: ptr("acc-1"),
+			Tag: []string{"tag-2"}},
+		models.Transaction{ID: "tx-1", Date: "2024-01-10", Income: 5000, IncomeInstrument: 2, OutcomeInstrument: 2,
+			IncomeAccount: "acc-1", OutcomeAccount: ptr("acc-1"), Tag: []string{"tag-3"}},
+		models.Transaction{ID: "tx-0", Date: "2024-01-09", Outcome: 1, Deleted: true, IncomeAccount: "acc-1"},
+	)
+	data.accounts[0].StartDate = ptr("2024-01-01")
+	return data
+}
+
+func TestExportJournal_Beancount(t *testing.T) {
+	exporter := export.NewExporter(journalData().storage(t), export.Options{
+		Format:  export.FormatBeancount,
+		Mapping: export.DefaultMapping(),
+	})
+
+	var out bytes.Buffer
+	counts, err := exporter.ExportTo(context.Background(), nil, "-", &out)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"accounts": 1, "transactions": 3}, counts)
+
+	assert.Equal(t, `2024-01-01 * "Opening balance" "Tinkoff Black"
+  zenmoney_id: "opening:acc-1"
+  Assets:Card:Tinkoff-Black  1000 RUB
+  Equity:Opening-Balances  -1000 RUB
+
+2024-01-20 * "" ""
+  zenmoney_id: "tx-3"
+  Assets:Cash:Wallet  10 USD
+  Assets:Card:Tinkoff-Black  -900 RUB @@ 10 USD
+
+2024-01-15 * "Coffee \"Bean\"" "latte"
+  zenmoney_id: "tx-2"
+  Expenses:Food:Cafe-bars  250.5 RUB
+  Assets:Card:Tinkoff-Black  -250.5 RUB
+
+2024-01-10 * "" ""
+  zenmoney_id: "tx-1"
+  Assets:Card:Tinkoff-Black  5000 RUB
+  Income:Salary  -5000 RUB
+
+2024-01-01 open Assets:Card:Tinkoff-Black
+  zenmoney_id: "account:Assets:Card:Tinkoff-Black"
+
+1970-01-01 open Assets:Cash:Wallet
+  zenmoney_id: "account:Assets:Cash:Wallet"
+
+1970-01-01 open Equity:Opening-Balances
+  zenmoney_id: "account:Equity:Opening-Balances"
+
+1970-01-01 open Expenses:Food:Cafe-bars
+  zenmoney_id: "account:Expenses:Food:Cafe-bars"
+
+1970-01-01 open Income:Salary
+  zenmoney_id: "account:Income:Salary"
+
+`, out.String())
+}
+
+func TestExportJournal_LedgerAppend(t *testing.T) {
+	mappingFile := filepath.Join(t.TempDir(), "mapping.yaml")
+	require.NoError(t, os.WriteFile(mappingFile, []byte(`
+accounts:
+  Wallet: Assets:Pocket
+tags:
+  tag-3: Income:Job
+`), 0o644))
+	mapping, err := export.LoadMapping(mappingFile)
+	require.NoError(t, err)
+	assert.Equal(t, "Expenses", mapping.Expenses)
+
+	journal := filepath.Join(t.TempDir(), "main.ledger")
+	exporter := export.NewExporter(journalData().storage(t), export.Options{Format: export.FormatLedger, Mapping: mapping})
+
+	counts, err := exporter.ExportTo(context.Background(), nil, journal, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"accounts": 1, "transactions": 3}, counts)
+
+	data, err := os.ReadFile(journal)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `2024-01-15 Coffee "Bean"
+    ; zenmoney_id: tx-2
+    ; latte
+    Expenses:Food:Cafe-bars  250.5 RUB
+    Assets:Card:Tinkoff-Black  -250.5 RUB
+`)
+	assert.Contains(t, string(data), "    Assets:Pocket  10 USD\n")
+	assert.Contains(t, string(data), "    Income:Job  -5000 RUB\n")
+	assert.Contains(t, string(data), "; zenmoney_id: account:Income:Job\naccount Income:Job\n")
+
+	counts, err = exporter.ExportTo(context.Background(), nil, journal, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"accounts": 0, "transactions": 0}, counts)
+
+	again, err := os.ReadFile(journal)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(again))
+}
+
+func TestExportJournal_Hledger(t *testing.T) {
+	exporter := export.NewExporter(journalData().storage(t), export.Options{Format: export.FormatHledger})
+
+	var out bytes.Buffer
+	_, err := exporter.ExportTo(context.Background(), nil, "", &out)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "2024-01-15 Coffee \"Bean\" | latte\n    ; zenmoney_id: tx-2\n")
+	assert.Contains(t, out.String(), "    Expenses:Food:Cafe-bars  250.5 RUB\n")
+}
+
+func TestExportJournal_TransferFee(t *testing.T) {
+	storage := newTestData(
+		models.Transaction{ID: "tx-1", Date: "2024-01-20", Payee: "Top-up", Income: 100, Outcome: 100.1, IncomeInstrument: 2, OutcomeInstrument: 2,
+			IncomeAccount: "acc-2", OutcomeAccount: ptr("acc-1")},
+		models.Transaction{ID: "tx-2", Date: "2024-01-21", Payee: "Withdrawal", Income: 50, Outcome: 50, IncomeInstrument: 2, OutcomeInstrument: 2,
+			IncomeAccount: "acc-1", OutcomeAccount: ptr("acc-2")},
+	).storage(t)
+	exporter := export.NewExporter(storage, export.Options{Format: export.FormatLedger, Mapping: export.DefaultMapping()})
+
+	var out bytes.Buffer
+	_, err := exporter.ExportTo(context.Background(), nil, "-", &out)
+	require.NoError(t, err)
+
+	assert.Contains(t, out.String(), `2024-01-20 Top-up
+    ; zenmoney_id: tx-1
+    Assets:Cash:Wallet  100 RUB
+    Assets:Card:Tinkoff-Black  -100.1 RUB
+    Expenses:Fees  0.1 RUB
+`)
+	assert.Contains(t, out.String(), `2024-01-21 Withdrawal
+    ; zenmoney_id: tx-2
+    Assets:Card:Tinkoff-Black  50 RUB
+    Assets:Cash:Wallet  -50 RUB
+
+`)
+}
+
+func TestLoadMapping_Defaults(t *testing.T) {
+	_, err := export.LoadMapping(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+
+	mapping, err := export.LoadMapping("")
+	require.NoError(t, err)
+	assert.Equal(t, export.DefaultMapping(), mapping)
+}
@@ -0,0 +1,147 @@
+package export
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Mapping controls the account and commodity names of journal exports.
+// Accounts and tags are matched by ZenMoney title or ID.
+type Mapping struct {
+	// Accounts maps ZenMoney accounts to journal accounts, e.g. "Tinkoff Black": Assets:Bank:Tinkoff
+	Accounts map[string]string `yaml:"accounts"`
+	// Tags maps ZenMoney tags to expense or income accounts, e.g. Food: Expenses:Groceries
+	Tags map[string]string `yaml:"tags"`
+	// Commodities maps instrument short titles to commodities, e.g. RUR: RUB
+	Commodities map[string]string `yaml:"commodities"`
+	// AccountTypes maps ZenMoney account types (cash, ccard, checking, deposit, emoney, loan, debt)
+	// to the parent of accounts without an explicit mapping
+	AccountTypes map[string]string `yaml:"account_types"`
+	// Expenses and Income are the parents of tag accounts
+	Expenses string `yaml:"expenses"`
+	Income   string `yaml:"income"`
+	// Uncategorized is the category of transactions without tags, under Expenses or Income
+	Uncategorized string `yaml:"uncategorized"`
+	// OpeningBalances is the equity account opening balances are booked against
+	OpeningBalances string `yaml:"opening_balances"`
+	// TransferFees balances transfers in one currency whose accounts receive less or more than is sent
+	TransferFees string `yaml:"transfer_fees"`
+	// OpeningDate is the date of accounts without a start date, YYYY-MM-DD
+	OpeningDate string `yaml:"opening_date"`
+}
+
+// DefaultMapping returns the account naming used when no mapping file is given
+func DefaultMapping() Mapping {
+	return Mapping{
+		AccountTypes: map[string]string{
+			"cash":     "Assets:Cash",
+			"ccard":    "Assets:Card",
+			"checking": "Assets:Bank",
+			"deposit":  "Assets:Deposit",
+			"emoney":   "Assets:EMoney",
+			"loan":     "Liabilities:Loan",
+			"debt":     "Liabilities:Debt",
+		},
+		Expenses:        "Expenses",
+		Income:          "Income",
+		Uncategorized:   "Uncategorized",
+		OpeningBalances: "Equity:Opening-Balances",
+		TransferFees:    "Expenses:Fees",
+		OpeningDate:     "1970-01-01",
+	}
+}
+
+// LoadMapping reads a YAML mapping file on top of DefaultMapping
+func LoadMapping(path string) (Mapping, error) {
+	mapping := DefaultMapping()
+	if path == "" {
+		return mapping, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return mapping, fmt.Errorf("failed to read mapping file: %w", err)
+	}
+
+	var file Mapping
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return mapping, fmt.Errorf("failed to parse mapping file: %w", err)
+	}
+
+	mapping.Accounts = file.Accounts
+	mapping.Tags = file.Tags
+	mapping.Commodities = file.Commodities
+	for accountType, parent := range file.AccountTypes {
+		mapping.AccountTypes[accountType] = parent
+	}
+	for _, v := range []struct {
+		dst *string
+		src string
+	}{
+		{&mapping.Expenses, file.Expenses},
+		{&mapping.Income, file.Income},
+		{&mapping.Uncategorized, file.Uncategorized},
+		{&mapping.OpeningBalances, file.OpeningBalances},
+		{&mapping.TransferFees, file.TransferFees},
+		{&mapping.OpeningDate, file.OpeningDate},
+	} {
+		if v.src != "" {
+			*v.dst = v.src
+		}
+	}
+
+	return mapping, nil
+}
+
+// lookup returns the mapped name of an object by its title or ID
+func lookup(names map[string]string, title, id string) (string, bool) {
+	if name, ok := names[title]; ok {
+		return name, true
+	}
+	name, ok := names[id]
+	return name, ok
+}
+
+// accountSegment turns a title into an account name component: letters and digits,
+// other characters replaced with dashes, starting with a capital letter or a digit
+func accountSegment(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.TrimSpace(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			if b.Len() == 0 {
+				r = unicode.ToUpper(r)
+			}
+			b.WriteRune(r)
+			continue
+		}
+		dash = true
+	}
+	if b.Len() == 0 {
+		return "Unnamed"
+	}
+	return b.String()
+}
+
+// commodityName turns an instrument short title into a commodity: capital letters and digits,
+// starting with a letter
+func commodityName(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(title) {
+		if (r >= 'A' && r <= 'Z') || (b.Len() > 0 && r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() < 2 {
+		return "CUR" + b.String()
+	}
+	return b.String()
+}
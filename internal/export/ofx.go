@@ -0,0 +1,162 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ofxFormat writes OFX 1.0.2 bank statements, the SGML version most importers read
+type ofxFormat struct{}
+
+// ofxAccountTypes maps ZenMoney account types to OFX account types, CHECKING by default
+var ofxAccountTypes = map[string]string{
+	"deposit": "SAVINGS",
+	"loan":    "CREDITLINE",
+	"debt":    "CREDITLINE",
+}
+
+// ofxNameLength is the maximum length of the NAME element
+const ofxNameLength = 32
+
+func (ofxFormat) header(w io.Writer, statements []*statement) error {
+	server := time.Now().Format(time.DateOnly)
+	if len(statements) > 0 {
+		server = ""
+		for _, st := range statements {
+			server = max(server, st.last)
+		}
+	}
+
+	_, err := fmt.Fprintf(w, `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+SECURITY:NONE
+ENCODING:UTF-8
+CHARSET:NONE
+COMPRESSION:NONE
+OLDFILEUID:NONE
+NEWFILEUID:NONE
+
+<OFX>
+<SIGNONMSGSRSV1>
+<SONRS>
+<STATUS>
+<CODE>0
+<SEVERITY>INFO
+</STATUS>
+<DTSERVER>%s
+<LANGUAGE>ENG
+</SONRS>
+</SIGNONMSGSRSV1>
+<BANKMSGSRSV1>
+`, ofxDate(server))
+	return err
+}
+
+func (ofxFormat) footer(w io.Writer) error {
+	_, err := io.WriteString(w, "</BANKMSGSRSV1>\n</OFX>\n")
+	return err
+}
+
+func (ofxFormat) begin(w io.Writer, st *statement) error {
+	accountType, ok := ofxAccountTypes[st.account.Type]
+	if !ok {
+		accountType = "CHECKING"
+	}
+
+	_, err := fmt.Fprintf(w, `<STMTTRNRS>
+<TRNUID>%s
+<STATUS>
+<CODE>0
+<SEVERITY>INFO
+</STATUS>
+<STMTRS>
+<CURDEF>%s
+<BANKACCTFROM>
+<BANKID>ZENMONEY
+<ACCTID>%s
+<ACCTTYPE>%s
+</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>%s
+<DTEND>%s
+`, ofxText(st.account.ID), ofxText(st.currency), ofxText(st.account.ID), accountType, ofxDate(st.first), ofxDate(st.last))
+	return err
+}
+
+// end closes the statement with the current balance of the account
+func (ofxFormat) end(w io.Writer, st *statement) error {
+	balance := 0.0
+	if st.account.Balance != nil {
+		balance = *st.account.Balance
+	}
+
+	_, err := fmt.Fprintf(w, `</BANKTRANLIST>
+<LEDGERBAL>
+<BALAMT>%s
+<DTASOF>%s
+</LEDGERBAL>
+</STMTRS>
+</STMTTRNRS>
+`, formatAmount(balance), ofxDate(st.last))
+	return err
+}
+
+// line writes a transaction. Amounts in a foreign currency keep it as ORIGCURRENCY
+// with the rate they were converted at. OFX 1.0.2 has no pending state, so the memo of
+// a transaction on hold starts with "Pending".
+func (ofxFormat) line(w io.Writer, l statementLine) error {
+	trnType := "DEBIT"
+	switch {
+	case l.transfer:
+		trnType = "XFER"
+	case l.amount > 0:
+		trnType = "CREDIT"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<STMTTRN>\n<TRNTYPE>%s\n<DTPOSTED>%s\n<TRNAMT>%s\n<FITID>%s\n",
+		trnType, ofxDate(l.date), formatAmount(l.amount), ofxText(l.id))
+
+	name := l.payee
+	if l.transfer && name == "" {
+		name = l.category
+	}
+	name = strings.Join(strings.Fields(name), " ")
+	if runes := []rune(name); len(runes) > ofxNameLength {
+		name = string(runes[:ofxNameLength])
+	}
+	if name != "" {
+		fmt.Fprintf(&b, "<NAME>%s\n", ofxText(name))
+	}
+	memo := ofxText(l.memo)
+	if !l.cleared {
+		memo = strings.TrimSuffix("Pending: "+memo, ": ")
+	}
+	if memo != "" {
+		fmt.Fprintf(&b, "<MEMO>%s\n", memo)
+	}
+	if l.opCurrency != "" && l.opAmount != 0 {
+		rate := strconv.FormatFloat(l.amount/l.opAmount, 'f', 6, 64)
+		rate = strings.TrimRight(strings.TrimRight(rate, "0"), ".")
+		fmt.Fprintf(&b, "<ORIGCURRENCY>\n<CURRATE>%s\n<CURSYM>%s\n</ORIGCURRENCY>\n", rate, ofxText(l.opCurrency))
+	}
+	b.WriteString("</STMTTRN>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ofxDate formats a 'yyyy-MM-dd' date as YYYYMMDD
+func ofxDate(date string) string {
+	return strings.ReplaceAll(date, "-", "")
+}
+
+// ofxText escapes a value for an SGML element on a single line
+func ofxText(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
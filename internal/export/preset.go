@@ -0,0 +1,322 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+)
+
+// Preset is a CSV layout of transactions another budget app imports directly
+type Preset struct {
+	Name    string
+	Columns []string
+	// PerAccount presets write a file per account, for apps importing a file into one account at a time
+	PerAccount bool
+
+	rows func(c *catalog, tx models.Transaction) []presetRow
+}
+
+// presetRow is a CSV row with the account whose file it goes into, if the preset writes a file per account
+type presetRow struct {
+	account string
+	values  []string
+}
+
+// Presets lists the supported export presets
+var Presets = []Preset{
+	{
+		// Firefly III data importer: a row per transaction, signed from the asset account,
+		// transfers have another asset account as the opposing account
+		Name: "firefly",
+		Columns: []string{
+			"external_id", "date", "description", "amount", "currency_code", "foreign_amount",
+			"foreign_currency_code", "asset_account", "opposing_account", "category", "tags", "notes",
+		},
+		rows: fireflyRows,
+	},
+	{
+		// Actual Budget: a file per account with signed amounts and ISO dates
+		Name:       "actual",
+		Columns:    []string{"Date", "Payee", "Notes", "Category", "Amount"},
+		PerAccount: true,
+		rows: func(c *catalog, tx models.Transaction) []presetRow {
+			return lineRows(c, tx, func(l statementLine) []string {
+				payee, category := l.payee, l.category
+				if l.transfer {
+					// the payee of a transfer is the other account, Actual has no category for transfers
+					payee, category = l.category, ""
+				}
+				return []string{l.date, payee, lineMemo(l), category, formatAmount(l.amount)}
+			})
+		},
+	},
+	{
+		// YNAB: a file per account with US dates and separate outflow and inflow columns,
+		// transfers use the "Transfer : Account" payee YNAB links to the other account
+		Name:       "ynab",
+		Columns:    []string{"Date", "Payee", "Memo", "Outflow", "Inflow"},
+		PerAccount: true,
+		rows: func(c *catalog, tx models.Transaction) []presetRow {
+			return lineRows(c, tx, func(l statementLine) []string {
+				payee := l.payee
+				if l.transfer {
+					payee = "Transfer : " + l.category
+				}
+				outflow, inflow := "", ""
+				if l.amount < 0 {
+					outflow = strconv.FormatFloat(-l.amount, 'f', 2, 64)
+				} else {
+					inflow = strconv.FormatFloat(l.amount, 'f', 2, 64)
+				}
+				return []string{qifDate(l.date), payee, lineMemo(l), outflow, inflow}
+			})
+		},
+	},
+}
+
+// ParsePreset returns the preset of the given name
+func ParsePreset(name string) (*Preset, error) {
+	for i := range Presets {
+		if Presets[i].Name == name {
+			return &Presets[i], nil
+		}
+	}
+	names := make([]string, len(Presets))
+	for i, preset := range Presets {
+		names[i] = preset.Name
+	}
+	return nil, fmt.Errorf("unknown export preset: %s (supported: %s)", name, strings.Join(names, ", "))
+}
+
+// lineRows turns the statement lines of a transaction into rows of the accounts' files
+func lineRows(c *catalog, tx models.Transaction, row func(l statementLine) []string) []presetRow {
+	lines := c.statementLines(tx)
+	rows := make([]presetRow, len(lines))
+	for i, l := range lines {
+		rows[i] = presetRow{account: l.account, values: row(l)}
+	}
+	return rows
+}
+
+// fireflyRows writes a transaction as a Firefly III row. The foreign amount is the original amount of
+// an operation in another currency, or the received amount of a transfer between currencies.
+func fireflyRows(c *catalog, tx models.Transaction) []presetRow {
+	outcomeAccount := tx.IncomeAccount
+	if tx.OutcomeAccount != nil {
+		outcomeAccount = *tx.OutcomeAccount
+	}
+
+	var (
+		asset, opposing   string
+		amount            float64
+		instrument        int
+		foreignAmount     float64
+		foreignInstrument *int
+		category, comment string
+	)
+	payee := c.payee(tx)
+	description := payee
+	tags := make([]string, 0, len(tx.Tag))
+	if tx.Comment != nil {
+		comment = *tx.Comment
+	}
+	for _, id := range tx.Tag {
+		if tag, ok := c.tags[id]; ok {
+			tags = append(tags, tag.Title)
+		}
+	}
+
+	switch {
+	case tx.Income > 0 && tx.Outcome > 0:
+		asset, opposing = outcomeAccount, c.accountTitle(tx.IncomeAccount)
+		amount, instrument = -tx.Outcome, tx.OutcomeInstrument
+		if tx.IncomeInstrument != tx.OutcomeInstrument {
+			foreignAmount, foreignInstrument = tx.Income, &tx.IncomeInstrument
+		}
+		if description == "" {
+			description = "Transfer to " + opposing
+		}
+	case tx.Income > 0:
+		asset, opposing = tx.IncomeAccount, payee
+		amount, instrument = tx.Income, tx.IncomeInstrument
+		if tx.OpIncome > 0 && tx.OpIncomeInstrument != nil && *tx.OpIncomeInstrument != instrument {
+			foreignAmount, foreignInstrument = tx.OpIncome, tx.OpIncomeInstrument
+		}
+		category = c.tagPath(tx.Tag)
+	case tx.Outcome > 0:
+		asset, opposing = outcomeAccount, payee
+		amount, instrument = -tx.Outcome, tx.OutcomeInstrument
+		if tx.OpOutcome > 0 && tx.OpOutcomeInstrument != nil && *tx.OpOutcomeInstrument != instrument {
+			foreignAmount, foreignInstrument = tx.OpOutcome, tx.OpOutcomeInstrument
+		}
+		category = c.tagPath(tx.Tag)
+	default:
+		return nil
+	}
+
+	if description == "" {
+		description = comment
+	}
+	if description == "" {
+		description = category
+	}
+	if description == "" {
+		description = "(no description)"
+	}
+
+	foreign, foreignCurrency := "", ""
+	if foreignInstrument != nil {
+		foreign = formatAmount(foreignAmount)
+		foreignCurrency = c.currency(*foreignInstrument)
+	}
+
+	return []presetRow{{values: []string{
+		tx.ID, tx.Date, description, formatAmount(amount), c.currency(instrument), foreign, foreignCurrency,
+		c.accountTitle(asset), opposing, category, strings.Join(tags, ","), comment,
+	}}}
+}
+
+// exportPresetTo writes the transactions in the layout of the preset: into output, or to stdout if output
+// is empty or "-". Presets writing a file per account need output to be a directory.
+func (e *Exporter) exportPresetTo(ctx context.Context, output string, stdout io.Writer) (counts map[string]int, err error) {
+	preset := e.opts.Preset
+	if e.opts.Compression != CompressionNone {
+		return nil, errors.New("export presets are meant for import into other apps, --compress is not supported")
+	}
+	if preset.PerAccount && (output == "" || output == "-") {
+		return nil, fmt.Errorf("the %s preset writes a file per account, --output must be a directory", preset.Name)
+	}
+
+	c, err := e.loadCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	files := &presetFiles{exporter: e, preset: preset, catalog: c, dir: output, writers: make(map[string]*csv.Writer)}
+	defer func() {
+		if cerr := files.close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	if !preset.PerAccount {
+		w := stdout
+		if output != "" && output != "-" {
+			if info, err := os.Stat(output); err == nil && info.IsDir() {
+				output = filepath.Join(output, preset.Name+".csv"+e.opts.Encrypter.Extension())
+			}
+			f, err := e.create(output)
+			if err != nil {
+				return nil, err
+			}
+			files.files = append(files.files, f)
+			w = f
+		}
+		files.writers[""] = csv.NewWriter(w)
+		if err := files.writers[""].Write(preset.Columns); err != nil {
+			return nil, fmt.Errorf("failed to write export file: %w", err)
+		}
+	} else if err := os.MkdirAll(output, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	counts = map[string]int{"transactions": 0}
+	_, err = e.each(ctx, transactionsEntity, func(record any) error {
+		tx := record.(models.Transaction)
+		if tx.Deleted {
+			return nil
+		}
+
+		rows := preset.rows(c, tx)
+		for _, row := range rows {
+			w, err := files.writer(row.account)
+			if err != nil {
+				return err
+			}
+			if err := w.Write(row.values); err != nil {
+				return fmt.Errorf("failed to write export file: %w", err)
+			}
+		}
+		if len(rows) > 0 {
+			counts["transactions"]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if preset.PerAccount {
+		counts["accounts"] = len(files.writers)
+	}
+	return counts, nil
+}
+
+// presetFiles are the CSV files of a preset export, opened as rows of their accounts come up
+type presetFiles struct {
+	exporter *Exporter
+	preset   *Preset
+	catalog  *catalog
+	dir      string
+	// writers are keyed by account, a single writer has an empty key
+	writers map[string]*csv.Writer
+	files   []io.WriteCloser
+	names   map[string]bool
+}
+
+// writer returns the writer of an account, creating its file with a header row
+func (p *presetFiles) writer(account string) (*csv.Writer, error) {
+	if !p.preset.PerAccount {
+		return p.writers[""], nil
+	}
+	if w, ok := p.writers[account]; ok {
+		return w, nil
+	}
+
+	if p.names == nil {
+		p.names = make(map[string]bool)
+	}
+	name := accountSegment(p.catalog.accountTitle(account))
+	if p.names[name] {
+		name += "-" + accountSegment(account)
+	}
+	p.names[name] = true
+
+	f, err := p.exporter.create(filepath.Join(p.dir, name+".csv"+p.exporter.opts.Encrypter.Extension()))
+	if err != nil {
+		return nil, err
+	}
+	p.files = append(p.files, f)
+
+	w := csv.NewWriter(f)
+	if err := w.Write(p.preset.Columns); err != nil {
+		return nil, fmt.Errorf("failed to write export file: %w", err)
+	}
+	p.writers[account] = w
+	return w, nil
+}
+
+// close flushes the writers and closes the files
+func (p *presetFiles) close() error {
+	var errs []error
+	for _, w := range p.writers {
+		w.Flush()
+		if err := w.Error(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to write export file: %w", err))
+		}
+	}
+	for _, f := range p.files {
+		if err := f.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close export file: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
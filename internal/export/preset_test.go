@@ -0,0 +1,105 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/export"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePreset(t *testing.T) {
+	preset, err := export.ParsePreset("ynab")
+	require.NoError(t, err)
+	assert.True(t, preset.PerAccount)
+
+	_, err = export.ParsePreset("mint")
+	assert.EqualError(t, err, "unknown export preset: mint (supported: firefly, actual, ynab)")
+}
+
+func TestExportPreset_Firefly(t *testing.T) {
+	preset, err := export.ParsePreset("firefly")
+	require.NoError(t, err)
+	exporter := export.NewExporter(statementData().storage(t), export.Options{Preset: preset})
+
+	var out bytes.Buffer
+	counts, err := exporter.ExportTo(context.Background(), nil, "", &out)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"transactions": 4}, counts)
+
+	assert.Equal(t, `external_id,date,description,amount,currency_code,foreign_amount,foreign_currency_code,asset_account,opposing_account,category,tags,notes
+tx-4,2024-01-21,Taxi,-300,RUB,,,Tinkoff Black,Taxi,,,
+tx-3,2024-01-20,Transfer to Wallet,-900,RUB,10,USD,Tinkoff Black,Wallet,,,
+tx-2,2024-01-15,Cafe & Bar,-1800,RUB,20,USD,Tinkoff Black,Cafe & Bar,Food:Cafe & bars,Cafe & bars,latte
+tx-1,2024-01-10,Acme Corp,5000,RUB,,,Tinkoff Black,Acme Corp,,,
+`, out.String())
+}
+
+func TestExportPreset_PerAccount(t *testing.T) {
+	tests := []struct {
+		preset string
+		files  map[string]string
+	}{
+		{
+			preset: "actual",
+			files: map[string]string{
+				"Tinkoff-Black.csv": `Date,Payee,Notes,Category,Amount
+2024-01-21,Taxi,,,-300
+2024-01-20,Wallet,,,-900
+2024-01-15,Cafe & Bar,latte (-20 USD),Food:Cafe & bars,-1800
+2024-01-10,Acme Corp,,,5000
+`,
+				"Wallet.csv": `Date,Payee,Notes,Category,Amount
+2024-01-20,Tinkoff Black,,,10
+`,
+			},
+		},
+		{
+			preset: "ynab",
+			files: map[string]string{
+				"Tinkoff-Black.csv": `Date,Payee,Memo,Outflow,Inflow
+01/21/2024,Taxi,,300.00,
+01/20/2024,Transfer : Wallet,,900.00,
+01/15/2024,Cafe & Bar,latte (-20 USD),1800.00,
+01/10/2024,Acme Corp,,,5000.00
+`,
+				"Wallet.csv": `Date,Payee,Memo,Outflow,Inflow
+01/20/2024,Transfer : Tinkoff Black,,,10.00
+`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			preset, err := export.ParsePreset(tt.preset)
+			require.NoError(t, err)
+			exporter := export.NewExporter(statementData().storage(t), export.Options{Preset: preset})
+
+			dir := filepath.Join(t.TempDir(), tt.preset)
+			counts, err := exporter.ExportTo(context.Background(), nil, dir, io.Discard)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]int{"accounts": 2, "transactions": 4}, counts)
+
+			for name, expected := range tt.files {
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				require.NoError(t, err)
+				assert.Equal(t, expected, string(data), name)
+			}
+		})
+	}
+}
+
+func TestExportPreset_PerAccountNeedsDirectory(t *testing.T) {
+	preset, err := export.ParsePreset("actual")
+	require.NoError(t, err)
+	exporter := export.NewExporter(nil, export.Options{Preset: preset})
+
+	_, err = exporter.ExportTo(context.Background(), nil, "-", io.Discard)
+	assert.EqualError(t, err, "the actual preset writes a file per account, --output must be a directory")
+}
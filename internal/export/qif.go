@@ -0,0 +1,97 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// qifFormat writes Quicken Interchange Format statements. Every statement starts with
+// an !Account block, so a file with several accounts imports into the right ones.
+type qifFormat struct{}
+
+// qifTypes maps ZenMoney account types to QIF account types, Bank by default
+var qifTypes = map[string]string{
+	"cash": "Cash",
+	"loan": "Oth L",
+	"debt": "Oth L",
+}
+
+func (qifFormat) header(io.Writer, []*statement) error {
+	return nil
+}
+
+func (qifFormat) footer(io.Writer) error {
+	return nil
+}
+
+// begin writes the account and its start balance as the opening balance transaction QIF importers expect
+func (qifFormat) begin(w io.Writer, st *statement) error {
+	accountType, ok := qifTypes[st.account.Type]
+	if !ok {
+		accountType = "Bank"
+	}
+	title := qifText(st.account.Title)
+
+	if _, err := fmt.Fprintf(w, "!Account\nN%s\nT%s\n^\n!Type:%s\n", title, accountType, accountType); err != nil {
+		return err
+	}
+
+	if st.account.StartBalance == nil || *st.account.StartBalance == 0 {
+		return nil
+	}
+	date := st.first
+	if st.account.StartDate != nil && *st.account.StartDate != "" {
+		date = *st.account.StartDate
+	}
+	_, err := fmt.Fprintf(w, "D%s\nT%s\nPOpening Balance\nL[%s]\nC*\n^\n",
+		qifDate(date), formatAmount(*st.account.StartBalance), title)
+	return err
+}
+
+func (qifFormat) end(io.Writer, *statement) error {
+	return nil
+}
+
+// line writes a transaction: the category is the tag path, or the other account in brackets
+// for transfers, and transactions not on hold are marked cleared
+func (qifFormat) line(w io.Writer, l statementLine) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "D%s\nT%s\n", qifDate(l.date), formatAmount(l.amount))
+	if l.payee != "" {
+		fmt.Fprintf(&b, "P%s\n", qifText(l.payee))
+	}
+
+	if memo := lineMemo(l); memo != "" {
+		fmt.Fprintf(&b, "M%s\n", qifText(memo))
+	}
+
+	switch {
+	case l.transfer:
+		fmt.Fprintf(&b, "L[%s]\n", qifText(l.category))
+	case l.category != "":
+		fmt.Fprintf(&b, "L%s\n", qifText(l.category))
+	}
+	if l.cleared {
+		b.WriteString("C*\n")
+	}
+	b.WriteString("^\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// qifDate formats a 'yyyy-MM-dd' date as MM/DD/YYYY, the date format of most QIF importers
+func qifDate(date string) string {
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return date
+	}
+	return t.Format("01/02/2006")
+}
+
+// qifText puts a value on a single line
+func qifText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
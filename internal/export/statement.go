@@ -0,0 +1,296 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/encrypt"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+)
+
+const (
+	FormatQIF Format = "qif"
+	FormatOFX Format = "ofx"
+)
+
+// Statement reports whether the format writes a statement per account
+func (f Format) Statement() bool {
+	return f == FormatQIF || f == FormatOFX
+}
+
+// statementLine is a transaction as seen from one account
+type statementLine struct {
+	account string
+	id      string
+	date    string
+	amount  float64
+	payee   string
+	memo    string
+	// category is the tag path of expenses and income, or the title of the other account of a transfer
+	category string
+	transfer bool
+	// cleared is false for transactions on hold
+	cleared bool
+	// opAmount and opCurrency are the original amount and currency of an operation in a foreign currency
+	opAmount   float64
+	opCurrency string
+}
+
+// statementFormat writes statements of a format. Lines are written ahead of the statement
+// around them, which needs the dates of the lines.
+type statementFormat interface {
+	// header and footer surround the statements of a file
+	header(w io.Writer, statements []*statement) error
+	footer(w io.Writer) error
+	// begin and end surround the lines of a statement
+	begin(w io.Writer, st *statement) error
+	end(w io.Writer, st *statement) error
+	line(w io.Writer, l statementLine) error
+}
+
+// statement collects the lines of an account in a temporary file
+type statement struct {
+	account  models.Account
+	currency string
+	spool    *encrypt.Spool
+	w        *bufio.Writer
+	// first and last are the dates of the earliest and the latest line
+	first, last string
+}
+
+// exportStatementsTo writes a statement per account: into a file per account if output is a directory,
+// otherwise all statements into output, or to stdout if output is empty or "-"
+func (e *Exporter) exportStatementsTo(ctx context.Context, output string, stdout io.Writer) (counts map[string]int, err error) {
+	if e.opts.Compression != CompressionNone {
+		return nil, errors.New("statements are meant for import into other apps, --compress is not supported")
+	}
+
+	c, err := e.loadCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sf := e.statementFormat()
+	statements := make(map[string]*statement)
+	defer func() {
+		for _, st := range statements {
+			_ = st.spool.Close()
+		}
+	}()
+
+	counts = map[string]int{"accounts": 0, "transactions": 0}
+	_, err = e.each(ctx, transactionsEntity, func(record any) error {
+		tx := record.(models.Transaction)
+		if tx.Deleted {
+			return nil
+		}
+
+		lines := c.statementLines(tx)
+		for _, l := range lines {
+			st, ok := statements[l.account]
+			if !ok {
+				var err error
+				if st, err = c.newStatement(l.account); err != nil {
+					return err
+				}
+				statements[l.account] = st
+			}
+			if err := st.add(sf, l); err != nil {
+				return err
+			}
+		}
+		if len(lines) > 0 {
+			counts["transactions"]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([]*statement, 0, len(statements))
+	for _, st := range statements {
+		if err := st.w.Flush(); err != nil {
+			return nil, fmt.Errorf("failed to write statement: %w", err)
+		}
+		ordered = append(ordered, st)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].account.Title != ordered[j].account.Title {
+			return ordered[i].account.Title < ordered[j].account.Title
+		}
+		return ordered[i].account.ID < ordered[j].account.ID
+	})
+	counts["accounts"] = len(ordered)
+
+	if output == "" || output == "-" {
+		return counts, writeStatements(sf, stdout, ordered)
+	}
+	if info, err := os.Stat(output); err != nil || !info.IsDir() {
+		return counts, e.writeStatementFile(sf, output, ordered)
+	}
+
+	names := make(map[string]bool)
+	for _, st := range ordered {
+		name := accountSegment(st.account.Title)
+		if names[name] {
+			name += "-" + accountSegment(st.account.ID)
+		}
+		names[name] = true
+
+		path := filepath.Join(output, name+"."+e.opts.Format.Extension()+e.opts.Encrypter.Extension())
+		if err := e.writeStatementFile(sf, path, []*statement{st}); err != nil {
+			return nil, err
+		}
+	}
+	return counts, nil
+}
+
+// statementFormat returns the writer of the export format
+func (e *Exporter) statementFormat() statementFormat {
+	if e.opts.Format == FormatOFX {
+		return ofxFormat{}
+	}
+	return qifFormat{}
+}
+
+// statementLines splits a transaction into the lines of the accounts it touches: an expense or an income
+// is a line of its account, a transfer a line of each of both accounts
+func (c *catalog) statementLines(tx models.Transaction) []statementLine {
+	outcomeAccount := tx.IncomeAccount
+	if tx.OutcomeAccount != nil {
+		outcomeAccount = *tx.OutcomeAccount
+	}
+
+	base := statementLine{id: tx.ID, date: tx.Date, payee: c.payee(tx), cleared: !tx.Hold}
+	if tx.Comment != nil {
+		base.memo = *tx.Comment
+	}
+
+	income := base
+	income.account = tx.IncomeAccount
+	income.amount = tx.Income
+	if tx.OpIncome > 0 && tx.OpIncomeInstrument != nil && *tx.OpIncomeInstrument != tx.IncomeInstrument {
+		income.opAmount = tx.OpIncome
+		income.opCurrency = c.currency(*tx.OpIncomeInstrument)
+	}
+
+	outcome := base
+	outcome.account = outcomeAccount
+	outcome.amount = -tx.Outcome
+	if tx.OpOutcome > 0 && tx.OpOutcomeInstrument != nil && *tx.OpOutcomeInstrument != tx.OutcomeInstrument {
+		outcome.opAmount = -tx.OpOutcome
+		outcome.opCurrency = c.currency(*tx.OpOutcomeInstrument)
+	}
+
+	switch {
+	case tx.Income > 0 && tx.Outcome == 0:
+		income.category = c.tagPath(tx.Tag)
+		return []statementLine{income}
+	case tx.Outcome > 0 && tx.Income == 0:
+		outcome.category = c.tagPath(tx.Tag)
+		return []statementLine{outcome}
+	case tx.Income > 0 && tx.Outcome > 0:
+		income.transfer, outcome.transfer = true, true
+		income.category = c.accountTitle(outcomeAccount)
+		outcome.category = c.accountTitle(tx.IncomeAccount)
+		if tx.IncomeAccount == outcomeAccount {
+			// both sides of an exchange within one account need their own IDs
+			income.id += ":income"
+			outcome.id += ":outcome"
+		}
+		return []statementLine{outcome, income}
+	default:
+		return nil
+	}
+}
+
+// newStatement creates the statement of an account with an encrypted temporary file for its lines
+func (c *catalog) newStatement(id string) (*statement, error) {
+	account, ok := c.accounts[id]
+	if !ok {
+		account = models.Account{ID: id, Title: id}
+	}
+
+	spool, err := encrypt.NewSpool("zenexport-statement-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create statement file: %w", err)
+	}
+
+	st := &statement{account: account, spool: spool, w: bufio.NewWriter(spool)}
+	if account.Instrument != nil {
+		st.currency = c.currency(int(*account.Instrument))
+	}
+	return st, nil
+}
+
+// add writes a line of the statement
+func (st *statement) add(sf statementFormat, l statementLine) error {
+	if st.first == "" || l.date < st.first {
+		st.first = l.date
+	}
+	if l.date > st.last {
+		st.last = l.date
+	}
+	if err := sf.line(st.w, l); err != nil {
+		return fmt.Errorf("failed to write statement: %w", err)
+	}
+	return nil
+}
+
+// writeStatementFile writes the statements into a new file at path
+func (e *Exporter) writeStatementFile(sf statementFormat, path string, statements []*statement) (err error) {
+	f, err := e.create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close export file: %w", cerr)
+		}
+	}()
+
+	return writeStatements(sf, f, statements)
+}
+
+// writeStatements writes the statements with the lines collected in their temporary files
+func writeStatements(sf statementFormat, w io.Writer, statements []*statement) error {
+	bw := bufio.NewWriter(w)
+	if err := sf.header(bw, statements); err != nil {
+		return fmt.Errorf("failed to write statement: %w", err)
+	}
+	for _, st := range statements {
+		if err := sf.begin(bw, st); err != nil {
+			return fmt.Errorf("failed to write statement: %w", err)
+		}
+		r, err := st.spool.Reader()
+		if err != nil {
+			return fmt.Errorf("failed to read statement: %w", err)
+		}
+		if _, err := io.Copy(bw, r); err != nil {
+			return fmt.Errorf("failed to write statement: %w", err)
+		}
+		if err := sf.end(bw, st); err != nil {
+			return fmt.Errorf("failed to write statement: %w", err)
+		}
+	}
+	if err := sf.footer(bw); err != nil {
+		return fmt.Errorf("failed to write statement: %w", err)
+	}
+	return bw.Flush()
+}
+
+// lineMemo returns the comment of a line with the original amount of a foreign currency operation
+func lineMemo(l statementLine) string {
+	if l.opCurrency == "" {
+		return l.memo
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s (%s %s)", l.memo, formatAmount(l.opAmount), l.opCurrency))
+}
@@ -0,0 +1,128 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/export"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statementData are transactions of the card: one on hold, a transfer to the wallet,
+// an expense paid in another currency, an income from the merchant, and a deleted one
+func statementData() *testData {
+	data := newTestData(
+		models.Transaction{ID: "tx-4", Date: "2024-01-21", Payee: "Taxi", Outcome: 300, Hold: true,
+			IncomeInstrument: 2, OutcomeInstrument: 2, IncomeAccount: "acc-1", OutcomeAccount: ptr("acc-1")},
+		models.Transaction{ID: "tx-3", Date: "2024-01-20", Income: 10, Outcome: 900, IncomeInstrument: 1, OutcomeInstrument: 2,
+			IncomeAccount: "acc-2", OutcomeAccount: ptr("acc-1")},
+		models.Transaction{ID: "tx-2", Date: "2024-01-15", Payee: "Cafe & Bar", Comment: ptr("latte"), Outcome: 1800,
+			OpOutcome: 20, OpOutcomeInstrument: ptr(1),
+			IncomeInstrument: 2, OutcomeInstrument: 2, IncomeAccount: "acc-1", OutcomeAccount: ptr("acc-1"),
+			Tag: []string{"tag-2"}},
+		models.Transaction{ID: "tx-1", Date: "2024-01-10", Payee: "ACME CORP LLC", Merchant: ptr("m-1"), Income: 5000,
+			IncomeInstrument: 2, OutcomeInstrument: 2, IncomeAccount: "acc-1", OutcomeAccount: ptr("acc-1")},
+		models.Transaction{ID: "tx-0", Date: "2024-01-09", Outcome: 1, Deleted: true, IncomeAccount: "acc-1"},
+	)
+	data.accounts[0].Balance = ptr(4000.0)
+	data.accounts[1].Balance = ptr(10.0)
+	return data
+}
+
+func TestExportStatements_QIF(t *testing.T) {
+	exporter := export.NewExporter(statementData().storage(t), export.Options{Format: export.FormatQIF})
+
+	var out bytes.Buffer
+	counts, err := exporter.ExportTo(context.Background(), nil, "-", &out)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"accounts": 2, "transactions": 4}, counts)
+
+	assert.Equal(t, `!Account
+NTinkoff Black
+TBank
+^
+!Type:Bank
+D01/10/2024
+T1000
+POpening Balance
+L[Tinkoff Black]
+C*
+^
+D01/21/2024
+T-300
+PTaxi
+^
+D01/20/2024
+T-900
+L[Wallet]
+C*
+^
+D01/15/2024
+T-1800
+PCafe & Bar
+Mlatte (-20 USD)
+LFood:Cafe & bars
+C*
+^
+D01/10/2024
+T5000
+PAcme Corp
+C*
+^
+!Account
+NWallet
+TCash
+^
+!Type:Cash
+D01/20/2024
+T10
+L[Tinkoff Black]
+C*
+^
+`, out.String())
+}
+
+func TestExportStatements_OFXPerAccount(t *testing.T) {
+	exporter := export.NewExporter(statementData().storage(t), export.Options{Format: export.FormatOFX})
+
+	dir := t.TempDir()
+	counts, err := exporter.ExportTo(context.Background(), nil, dir, io.Discard)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"accounts": 2, "transactions": 4}, counts)
+
+	data, err := os.ReadFile(filepath.Join(dir, "Tinkoff-Black.ofx"))
+	require.NoError(t, err)
+	ofx := string(data)
+	assert.Contains(t, ofx, "<DTSERVER>20240121\n")
+	assert.Contains(t, ofx, "<CURDEF>RUB\n<BANKACCTFROM>\n<BANKID>ZENMONEY\n<ACCTID>acc-1\n<ACCTTYPE>CHECKING\n")
+	assert.Contains(t, ofx, "<DTSTART>20240110\n<DTEND>20240121\n")
+	assert.Contains(t, ofx, `<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240115
+<TRNAMT>-1800
+<FITID>tx-2
+<NAME>Cafe &amp; Bar
+<MEMO>latte
+<ORIGCURRENCY>
+<CURRATE>90
+<CURSYM>USD
+</ORIGCURRENCY>
+</STMTTRN>
+`)
+	assert.Contains(t, ofx, "<TRNTYPE>XFER\n<DTPOSTED>20240120\n<TRNAMT>-900\n<FITID>tx-3\n<NAME>Wallet\n")
+	assert.Contains(t, ofx, "<TRNTYPE>CREDIT\n<DTPOSTED>20240110\n<TRNAMT>5000\n")
+	assert.Contains(t, ofx, "<TRNTYPE>DEBIT\n<DTPOSTED>20240121\n<TRNAMT>-300\n<FITID>tx-4\n<NAME>Taxi\n<MEMO>Pending\n")
+	assert.Contains(t, ofx, "<LEDGERBAL>\n<BALAMT>4000\n<DTASOF>20240121\n</LEDGERBAL>\n")
+	assert.Contains(t, ofx, "</BANKMSGSRSV1>\n</OFX>\n")
+
+	wallet, err := os.ReadFile(filepath.Join(dir, "Wallet.ofx"))
+	require.NoError(t, err)
+	assert.Contains(t, string(wallet), "<CURDEF>USD\n")
+	assert.Contains(t, string(wallet), "<TRNTYPE>XFER\n<DTPOSTED>20240120\n<TRNAMT>10\n<FITID>tx-3\n<NAME>Tinkoff Black\n")
+}
@@ -0,0 +1,367 @@
+package export
+
+import (
+	"bufio"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// builtinExtensions maps the built-in templates to the extension of their output
+var builtinExtensions = map[string]string{
+	"markdown": "md",
+	"html":     "html",
+}
+
+// errStopRows stops reading rows when a template leaves a range early
+var errStopRows = errors.New("stop rows")
+
+// TemplateData is the data export templates are executed with
+type TemplateData struct {
+	// Entities are the exported entities in export order
+	Entities []TemplateEntity
+	// From and To are the export date range, nil if unbounded
+	From *time.Time
+	To   *time.Time
+}
+
+// TemplateEntity is an exported entity. Rows are read from the storage page by page while
+// a template ranges over them, each row maps the column names of the entity to their values.
+type TemplateEntity struct {
+	Name    string
+	Columns []string
+	Rows    iter.Seq[map[string]any]
+}
+
+// templateRun is the state of a template execution
+type templateRun struct {
+	ctx      context.Context
+	exporter *Exporter
+	resolver *resolver
+	// err is the first error reading rows, iterators can't return it to the template
+	err error
+}
+
+// exportTemplateTo writes the entities through the template into output, into a file named after
+// the template if output is a directory, or to stdout if output is empty or "-"
+func (e *Exporter) exportTemplateTo(ctx context.Context, entities []Entity, output string, stdout io.Writer) (counts map[string]int, err error) {
+	if output == "" || output == "-" {
+		return e.ExportTemplate(ctx, entities, stdout)
+	}
+
+	if info, err := os.Stat(output); err == nil && info.IsDir() {
+		output = filepath.Join(output, templateFileName(e.opts.Template)+e.opts.Compression.Extension()+
+			e.opts.Encrypter.Extension())
+	}
+
+	f, err := e.create(output)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close export file: %w", cerr)
+		}
+	}()
+
+	return e.ExportTemplate(ctx, entities, f)
+}
+
+// templateFileName returns the output file name of a template: zenmoney.md for the markdown
+// template, report.txt for report.txt.tmpl
+func templateFileName(name string) string {
+	if ext, ok := builtinExtensions[name]; ok {
+		return "zenmoney." + ext
+	}
+	return strings.TrimSuffix(filepath.Base(name), ".tmpl")
+}
+
+// ExportTemplate executes the template of the export options, a built-in template name
+// or a template file, and writes its output to w. The counts are the rows the template read.
+func (e *Exporter) ExportTemplate(ctx context.Context, entities []Entity, w io.Writer) (map[string]int, error) {
+	run := &templateRun{ctx: ctx, exporter: e, resolver: newResolver(ctx, e.storage)}
+	counts := make(map[string]int, len(entities))
+
+	tmpl, err := run.parse(e.opts.Template, counts)
+	if err != nil {
+		return nil, err
+	}
+
+	data := TemplateData{From: e.opts.From, To: e.opts.To}
+	for _, entity := range entities {
+		counts[entity.Name] = 0
+		data.Entities = append(data.Entities, run.entity(entity, counts))
+	}
+
+	cw, err := e.opts.Compression.Wrap(w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s compressor: %w", e.opts.Compression, err)
+	}
+	bw := bufio.NewWriter(cw)
+
+	err = tmpl.Execute(bw, data)
+	if run.err != nil {
+		return nil, run.err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	if err := bw.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to write template output: %w", err)
+	}
+	if err := cw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress template output: %w", err)
+	}
+	return counts, nil
+}
+
+// parse reads a built-in template or a template file
+func (run *templateRun) parse(name string, counts map[string]int) (*template.Template, error) {
+	var text []byte
+	var err error
+	if _, ok := builtinExtensions[name]; ok {
+		text, err = builtinTemplates.ReadFile("templates/" + name + ".tmpl")
+	} else {
+		text, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(name)).Funcs(run.funcs(counts)).Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// entity returns the template view of an entity, counting the rows read into counts
+func (run *templateRun) entity(entity Entity, counts map[string]int) TemplateEntity {
+	return TemplateEntity{
+		Name:    entity.Name,
+		Columns: entity.Columns,
+		Rows: func(yield func(map[string]any) bool) {
+			if run.err != nil {
+				return
+			}
+			_, err := run.exporter.each(run.ctx, entity, func(record any) error {
+				fields, err := jsonFields(record)
+				if err != nil {
+					return err
+				}
+				counts[entity.Name]++
+				if !yield(fields) {
+					return errStopRows
+				}
+				return nil
+			})
+			if err != nil && !errors.Is(err, errStopRows) {
+				run.err = err
+			}
+		},
+	}
+}
+
+// funcs are the helper functions of templates
+func (run *templateRun) funcs(counts map[string]int) template.FuncMap {
+	return template.FuncMap{
+		// entity returns any entity by name, e.g. {{ range (entity "tags").Rows }}
+		"entity": func(name string) (TemplateEntity, error) {
+			entities, err := ParseEntities(name)
+			if err != nil {
+				return TemplateEntity{}, err
+			}
+			if len(entities) != 1 {
+				return TemplateEntity{}, fmt.Errorf("entity expects a single name, got %q", name)
+			}
+			return run.entity(entities[0], counts), nil
+		},
+		"account":    func(id any) (string, error) { return run.title(refAccount, id) },
+		"tag":        func(id any) (string, error) { return run.title(refTag, id) },
+		"instrument": func(id any) (string, error) { return run.title(refInstrument, id) },
+		"merchant":   run.merchant,
+		"amount":     run.amount,
+		"cell":       run.cell,
+		"markdown": func(s string) string {
+			return strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(s)
+		},
+		"join": func(sep string, v any) string {
+			if list, ok := v.([]any); ok {
+				items := make([]string, len(list))
+				for i, item := range list {
+					items[i] = fmt.Sprint(item)
+				}
+				return strings.Join(items, sep)
+			}
+			return fmt.Sprint(v)
+		},
+		"add": func(a, b any) (float64, error) {
+			x, err := toFloat(a)
+			if err != nil {
+				return 0, err
+			}
+			y, err := toFloat(b)
+			return x + y, err
+		},
+		"sub": func(a, b any) (float64, error) {
+			x, err := toFloat(a)
+			if err != nil {
+				return 0, err
+			}
+			y, err := toFloat(b)
+			return x - y, err
+		},
+		"date":       toTime,
+		"now":        time.Now,
+		"formatDate": func(layout string, t time.Time) string { return t.Format(layout) },
+		"addDays":    func(days int, t time.Time) time.Time { return t.AddDate(0, 0, days) },
+		"addMonths":  func(months int, t time.Time) time.Time { return t.AddDate(0, months, 0) },
+		"daysBetween": func(from, to time.Time) int {
+			return int(math.Round(to.Sub(from).Hours() / 24))
+		},
+	}
+}
+
+// title returns the title of a referenced object, empty for a missing reference
+func (run *templateRun) title(kind refKind, id any) (string, error) {
+	if id == nil {
+		return "", nil
+	}
+	title, err := run.resolver.resolve(kind, id)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(title), nil
+}
+
+// merchant returns the title of a merchant, or its ID if it's missing
+func (run *templateRun) merchant(id any) (string, error) {
+	s, ok := id.(string)
+	if !ok || s == "" {
+		return "", nil
+	}
+	return run.resolver.merchant(s)
+}
+
+// amount formats an amount in the currency of an instrument, e.g. 1,250.50 ₽
+func (run *templateRun) amount(value, instrument any) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	amount, err := toFloat(value)
+	if err != nil {
+		return "", err
+	}
+
+	symbol := ""
+	if id, err := toFloat(instrument); err == nil && instrument != nil {
+		v, err := run.resolver.instrument(int(id))
+		if err != nil {
+			return "", err
+		}
+		if v != nil {
+			symbol = v.Symbol
+		}
+	}
+	return formatMoney(amount, symbol), nil
+}
+
+// cell formats a column of a row the way the built-in templates show it: references resolved
+// to titles and amounts formatted in their currency
+func (run *templateRun) cell(entity, column string, row map[string]any) (string, error) {
+	value := row[column]
+	if value == nil {
+		return "", nil
+	}
+	if kind, ok := references[entity][column]; ok {
+		return run.title(kind, value)
+	}
+	if instrumentColumn, ok := amounts[entity][column]; ok {
+		return run.amount(value, row[instrumentColumn])
+	}
+	return csvValue(value)
+}
+
+// formatMoney formats an amount with two decimals, thousands separators and a currency symbol
+func formatMoney(amount float64, symbol string) string {
+	s := strconv.FormatFloat(math.Abs(amount), 'f', 2, 64)
+	whole, fraction := s[:len(s)-3], s[len(s)-3:]
+
+	var b strings.Builder
+	if amount < 0 && s != "0.00" {
+		b.WriteByte('-')
+	}
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteString(fraction)
+	if symbol != "" {
+		b.WriteString(" " + symbol)
+	}
+	return b.String()
+}
+
+// toFloat converts a number of a row to float64
+func toFloat(v any) (float64, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return v.Float64()
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case nil:
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("not a number: %v", v)
+	}
+}
+
+// toTime converts a date of a row to time: a 'yyyy-MM-dd' date, an RFC 3339 time,
+// a unix timestamp like changed and created, or a time
+func toTime(v any) (time.Time, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, nil
+		}
+		return *v, nil
+	case string:
+		if t, err := time.Parse(time.DateOnly, v); err == nil {
+			return t, nil
+		}
+		return time.Parse(time.RFC3339, v)
+	case nil:
+		return time.Time{}, nil
+	default:
+		seconds, err := toFloat(v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("not a date: %v", v)
+		}
+		return time.Unix(int64(seconds), 0).UTC(), nil
+	}
+}
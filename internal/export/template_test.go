@@ -0,0 +1,136 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/export"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/mocks"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// templateData are an expense of the card at the merchant and an income
+func templateData() *testData {
+	return newTestData(
+		models.Transaction{ID: "tx-1", Date: "2024-01-15", Outcome: 1250.5, OutcomeInstrument: 2, IncomeInstrument: 2,
+			OutcomeAccount: ptr("acc-1"), IncomeAccount: "acc-1", Tag: []string{"tag-1"},
+			Merchant: ptr("m-1"), Comment: ptr("lunch | dinner")},
+		models.Transaction{ID: "tx-2", Date: "2024-01-20", Income: 5000, IncomeInstrument: 2, OutcomeInstrument: 2,
+			IncomeAccount: "acc-1", OutcomeAccount: ptr("acc-1")},
+	)
+}
+
+func TestExportTemplate_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.txt.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(`
+{{- $total := 0.0 -}}
+{{- range $e := .Entities }}{{ range $e.Rows -}}
+{{ .date }} +7d={{ date .date | addDays 7 | formatDate "2006-01-02" }} {{ account .incomeAccount }} {{ tag .tag }} {{ merchant .merchant }} {{ amount .outcome .outcomeInstrument }}
+{{ $total = add $total .income -}}
+{{ end }}{{ end -}}
+income {{ amount $total 2 }}
+{{ range (entity "transactions").Rows }}first {{ .id }}{{ break }}{{ end }}
+days {{ daysBetween (date "2024-01-15") (date "2024-03-01") }}
+`), 0o644))
+
+	exporter := export.NewExporter(templateData().storage(t), export.Options{Template: path})
+
+	var out bytes.Buffer
+	counts, err := exporter.ExportTo(context.Background(), entities(t, "transactions"), "-", &out)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"transactions": 3}, counts)
+
+	assert.Equal(t, `2024-01-15 +7d=2024-01-22 Tinkoff Black Food Acme Corp 1,250.50 ₽
+2024-01-20 +7d=2024-01-27 Tinkoff Black   0.00 ₽
+income 5,000.00 ₽
+first tx-1
+days 46
+`, out.String())
+}
+
+func TestExportTemplate_MerchantCached(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "merchants.txt.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(
+		`{{ range $e := .Entities }}{{ range $e.Rows }}{{ merchant "m-1" }} {{ merchant "m-2" }};{{ end }}{{ end }}`), 0o644))
+
+	storage := mocks.NewStorage(t)
+	storage.On("ListTransactions", mock.Anything, mock.Anything).Return([]models.Transaction{
+		{ID: "tx-1", Date: "2024-01-15"},
+		{ID: "tx-2", Date: "2024-01-20"},
+	}, nil)
+	storage.On("GetMerchant", mock.Anything, "m-1").Return(&models.Merchant{ID: "m-1", Title: "Cafe"}, nil).Once()
+	storage.On("GetMerchant", mock.Anything, "m-2").Return(nil, fmt.Errorf("merchant %w: m-2", interfaces.ErrNotFound)).Once()
+	exporter := export.NewExporter(storage, export.Options{Template: path})
+
+	var out bytes.Buffer
+	_, err := exporter.ExportTo(context.Background(), entities(t, "transactions"), "-", &out)
+	require.NoError(t, err)
+	assert.Equal(t, "Cafe m-2;Cafe m-2;", out.String())
+}
+
+func TestExportTemplate_Builtin(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		contains []string
+	}{
+		{
+			name: "markdown",
+			file: "zenmoney.md",
+			contains: []string{
+				"# ZenMoney export\n\n## transactions\n\n| id | user | date |",
+				"| --- | --- | --- |",
+				"| tx-1 | 0 | 2024-01-15 | 0.00 ₽ | 1,250.50 ₽ |",
+				`| Tinkoff Black | Tinkoff Black | Food | lunch \| dinner |`,
+			},
+		},
+		{
+			name: "html",
+			file: "zenmoney.html",
+			contains: []string{
+				"<h2>transactions</h2>",
+				"<tr><th>id</th><th>user</th><th>date</th>",
+				"<tr><td>tx-1</td><td>0</td><td>2024-01-15</td><td>0.00 ₽</td><td>1,250.50 ₽</td>",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := export.NewExporter(templateData().storage(t), export.Options{Template: tt.name})
+
+			dir := t.TempDir()
+			counts, err := exporter.ExportTo(context.Background(), entities(t, "transactions"), dir, nil)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]int{"transactions": 2}, counts)
+
+			data, err := os.ReadFile(filepath.Join(dir, tt.file))
+			require.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, string(data), s)
+			}
+		})
+	}
+}
+
+func TestExportTemplate_Errors(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListTags", mock.Anything, mock.Anything).Return(nil, errors.New("connection lost"))
+	exporter := export.NewExporter(storage, export.Options{Template: "markdown"})
+
+	_, err := exporter.ExportTo(context.Background(), entities(t, "tags"), "-", &bytes.Buffer{})
+	assert.EqualError(t, err, "failed to list tags: connection lost")
+
+	exporter = export.NewExporter(mocks.NewStorage(t), export.Options{Template: filepath.Join(t.TempDir(), "missing.tmpl")})
+	_, err = exporter.ExportTo(context.Background(), entities(t, "tags"), "-", &bytes.Buffer{})
+	assert.ErrorContains(t, err, "failed to read template")
+}
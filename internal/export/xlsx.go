@@ -0,0 +1,526 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// WorkbookName is the file name of a workbook written into an output directory
+const WorkbookName = "zenmoney.xlsx"
+
+// summarySheet is the sheet with monthly totals of transactions
+const summarySheet = "Summary"
+
+// refKind is the kind of object a column references
+type refKind int
+
+const (
+	refAccount refKind = iota + 1
+	refTag
+	refInstrument
+	refMerchant
+	refCompany
+)
+
+// references maps the reference columns of entities to the kind of object they point to.
+// The workbook shows titles of referenced objects instead of their IDs.
+var references = map[string]map[string]refKind{
+	"countries": {"currency": refInstrument},
+	"users":     {"currency": refInstrument},
+	"accounts":  {"instrument": refInstrument, "company": refCompany},
+	"tags":      {"parent": refTag},
+	"budgets":   {"tag": refTag},
+	"reminders": {
+		"incomeInstrument": refInstrument, "outcomeInstrument": refInstrument,
+		"incomeAccount": refAccount, "outcomeAccount": refAccount, "tag": refTag, "merchant": refMerchant,
+	},
+	"reminder_markers": {
+		"incomeInstrument": refInstrument, "outcomeInstrument": refInstrument,
+		"incomeAccount": refAccount, "outcomeAccount": refAccount, "tag": refTag, "merchant": refMerchant,
+	},
+	"transactions": {
+		"incomeInstrument": refInstrument, "outcomeInstrument": refInstrument,
+		"opIncomeInstrument": refInstrument, "opOutcomeInstrument": refInstrument,
+		"incomeAccount": refAccount, "outcomeAccount": refAccount, "tag": refTag, "merchant": refMerchant,
+	},
+}
+
+// amounts maps the amount columns of entities to the instrument column of their currency,
+// empty if the currency isn't known
+var amounts = map[string]map[string]string{
+	"accounts":         {"balance": "instrument", "startBalance": "instrument", "creditLimit": "instrument"},
+	"budgets":          {"income": "", "outcome": ""},
+	"reminders":        {"income": "incomeInstrument", "outcome": "outcomeInstrument"},
+	"reminder_markers": {"income": "incomeInstrument", "outcome": "outcomeInstrument"},
+	"transactions": {
+		"income": "incomeInstrument", "outcome": "outcomeInstrument",
+		"opIncome": "opIncomeInstrument", "opOutcome": "opOutcomeInstrument",
+	},
+}
+
+// dateColumns hold 'yyyy-MM-dd' dates, written as date cells
+var dateColumns = map[string]bool{"date": true, "startDate": true, "endDate": true}
+
+// exportWorkbookTo writes the entities into a single workbook at output,
+// into WorkbookName if output is a directory, or to stdout if output is empty or "-"
+func (e *Exporter) exportWorkbookTo(ctx context.Context, entities []Entity, output string, stdout io.Writer) (counts map[string]int, err error) {
+	if e.opts.Compression != CompressionNone {
+		return nil, errors.New("xlsx workbooks are compressed already, --compress is not supported")
+	}
+
+	if output == "" || output == "-" {
+		return e.ExportWorkbook(ctx, entities, stdout)
+	}
+
+	if info, err := os.Stat(output); err == nil && info.IsDir() {
+		output = filepath.Join(output, WorkbookName+e.opts.Encrypter.Extension())
+	}
+
+	f, err := e.create(output)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close export file: %w", cerr)
+		}
+	}()
+
+	return e.ExportWorkbook(ctx, entities, f)
+}
+
+// ExportWorkbook writes the entities as sheets of an XLSX workbook and returns the number of objects per entity.
+// References are resolved to titles, amounts are formatted in the currency of their instrument
+// and exporting transactions adds a sheet with monthly totals.
+func (e *Exporter) ExportWorkbook(ctx context.Context, entities []Entity, w io.Writer) (map[string]int, error) {
+	wb := &workbook{
+		file:     excelize.NewFile(),
+		resolver: newResolver(ctx, e.storage),
+		styles:   make(map[string]int),
+		totals:   make(map[monthKey]*monthTotals),
+	}
+	defer func() {
+		_ = wb.file.Close()
+	}()
+
+	counts := make(map[string]int, len(entities))
+	for _, entity := range entities {
+		n, err := wb.writeSheet(entity, func(fn func(record any) error) (int, error) {
+			return e.each(ctx, entity, fn)
+		})
+		if err != nil {
+			return nil, err
+		}
+		counts[entity.Name] = n
+
+		if entity.Name == "transactions" {
+			if err := wb.writeSummary(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// excelize creates Sheet1 with the file
+	if err := wb.file.DeleteSheet("Sheet1"); err != nil {
+		return nil, fmt.Errorf("failed to remove default sheet: %w", err)
+	}
+	if index, err := wb.file.GetSheetIndex(summarySheet); err == nil && index >= 0 {
+		wb.file.SetActiveSheet(index)
+	}
+
+	if err := wb.file.Write(w); err != nil {
+		return nil, fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return counts, nil
+}
+
+// workbook is an XLSX workbook being written
+type workbook struct {
+	file     *excelize.File
+	resolver *resolver
+	// styles maps number formats to style IDs
+	styles map[string]int
+	totals map[monthKey]*monthTotals
+}
+
+// monthKey identifies a row of the summary sheet
+type monthKey struct {
+	Month    string
+	Currency string
+}
+
+// monthTotals are the income and expense of a month in one currency, transfers aren't counted
+type monthTotals struct {
+	Income  float64
+	Expense float64
+}
+
+// writeSheet writes a sheet of the entity with a header row and a row per object
+func (wb *workbook) writeSheet(entity Entity, each func(fn func(record any) error) (int, error)) (int, error) {
+	if _, err := wb.file.NewSheet(entity.Name); err != nil {
+		return 0, fmt.Errorf("failed to create %s sheet: %w", entity.Name, err)
+	}
+	sw, err := wb.file.NewStreamWriter(entity.Name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s sheet: %w", entity.Name, err)
+	}
+
+	header := make([]any, len(entity.Columns))
+	for i, column := range entity.Columns {
+		header[i] = column
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return 0, fmt.Errorf("failed to write %s header: %w", entity.Name, err)
+	}
+
+	row := 1
+	count, err := each(func(record any) error {
+		fields, err := jsonFields(record)
+		if err != nil {
+			return err
+		}
+
+		cells := make([]any, len(entity.Columns))
+		for i, column := range entity.Columns {
+			if cells[i], err = wb.cell(entity.Name, column, fields); err != nil {
+				return fmt.Errorf("failed to write %s %s: %w", entity.Name, column, err)
+			}
+		}
+
+		if tx, ok := record.(models.Transaction); ok {
+			if err := wb.addTotals(tx); err != nil {
+				return err
+			}
+		}
+
+		row++
+		cell, err := excelize.CoordinatesToCellName(1, row)
+		if err != nil {
+			return err
+		}
+		return sw.SetRow(cell, cells)
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if err := sw.Flush(); err != nil {
+		return count, fmt.Errorf("failed to write %s sheet: %w", entity.Name, err)
+	}
+	return count, nil
+}
+
+// cell returns the value of a column for the stream writer
+func (wb *workbook) cell(entity, column string, fields map[string]any) (any, error) {
+	value := fields[column]
+	if value == nil {
+		return nil, nil
+	}
+
+	if kind, ok := references[entity][column]; ok {
+		return wb.resolver.resolve(kind, value)
+	}
+
+	if instrumentColumn, ok := amounts[entity][column]; ok {
+		number, ok := value.(json.Number)
+		if !ok {
+			return value, nil
+		}
+		amount, err := number.Float64()
+		if err != nil {
+			return nil, err
+		}
+
+		symbol := ""
+		if id, ok := fields[instrumentColumn].(json.Number); ok {
+			if n, err := id.Int64(); err == nil {
+				instrument, err := wb.resolver.instrument(int(n))
+				if err != nil {
+					return nil, err
+				}
+				if instrument != nil {
+					symbol = instrument.Symbol
+				}
+			}
+		}
+
+		style, err := wb.style(currencyFormat(symbol))
+		if err != nil {
+			return nil, err
+		}
+		return excelize.Cell{StyleID: style, Value: amount}, nil
+	}
+
+	if s, ok := value.(string); ok && dateColumns[column] {
+		date, err := time.Parse(time.DateOnly, s)
+		if err != nil {
+			return s, nil
+		}
+		style, err := wb.style("yyyy-mm-dd")
+		if err != nil {
+			return nil, err
+		}
+		return excelize.Cell{StyleID: style, Value: date}, nil
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		return v.Float64()
+	case string, bool:
+		return v, nil
+	default:
+		return csvValue(v)
+	}
+}
+
+// style returns the ID of a style with the number format
+func (wb *workbook) style(format string) (int, error) {
+	if id, ok := wb.styles[format]; ok {
+		return id, nil
+	}
+	id, err := wb.file.NewStyle(&excelize.Style{CustomNumFmt: &format})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create style: %w", err)
+	}
+	wb.styles[format] = id
+	return id, nil
+}
+
+// currencyFormat returns a number format with the currency symbol
+func currencyFormat(symbol string) string {
+	if symbol == "" {
+		return "#,##0.00"
+	}
+	return `#,##0.00 "` + strings.ReplaceAll(symbol, `"`, `""`) + `"`
+}
+
+// addTotals counts an income or expense transaction into the monthly totals
+func (wb *workbook) addTotals(tx models.Transaction) error {
+	if tx.Deleted || len(tx.Date) < 7 {
+		return nil
+	}
+
+	var instrument int
+	var income, expense float64
+	switch {
+	case tx.Income > 0 && tx.Outcome == 0:
+		instrument, income = tx.IncomeInstrument, tx.Income
+	case tx.Outcome > 0 && tx.Income == 0:
+		instrument, expense = tx.OutcomeInstrument, tx.Outcome
+	default:
+		return nil
+	}
+	currency, err := wb.resolver.currency(instrument)
+	if err != nil {
+		return err
+	}
+	key := monthKey{Month: tx.Date[:7], Currency: currency}
+
+	totals, ok := wb.totals[key]
+	if !ok {
+		totals = &monthTotals{}
+		wb.totals[key] = totals
+	}
+	totals.Income += income
+	totals.Expense += expense
+	return nil
+}
+
+// writeSummary writes the monthly totals of the exported transactions
+func (wb *workbook) writeSummary() error {
+	if _, err := wb.file.NewSheet(summarySheet); err != nil {
+		return fmt.Errorf("failed to create summary sheet: %w", err)
+	}
+	sw, err := wb.file.NewStreamWriter(summarySheet)
+	if err != nil {
+		return fmt.Errorf("failed to create summary sheet: %w", err)
+	}
+
+	if err := sw.SetRow("A1", []any{"month", "currency", "income", "expense", "net"}); err != nil {
+		return fmt.Errorf("failed to write summary header: %w", err)
+	}
+
+	keys := make([]monthKey, 0, len(wb.totals))
+	for key := range wb.totals {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Month != keys[j].Month {
+			return keys[i].Month < keys[j].Month
+		}
+		return keys[i].Currency < keys[j].Currency
+	})
+
+	style, err := wb.style(currencyFormat(""))
+	if err != nil {
+		return err
+	}
+	for i, key := range keys {
+		totals := wb.totals[key]
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return err
+		}
+		err = sw.SetRow(cell, []any{
+			key.Month,
+			key.Currency,
+			excelize.Cell{StyleID: style, Value: totals.Income},
+			excelize.Cell{StyleID: style, Value: totals.Expense},
+			excelize.Cell{StyleID: style, Value: totals.Income - totals.Expense},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write summary: %w", err)
+		}
+	}
+
+	if err := sw.Flush(); err != nil {
+		return fmt.Errorf("failed to write summary sheet: %w", err)
+	}
+	return nil
+}
+
+// resolver looks up titles of referenced objects, caching them for the export.
+// Objects missing in the storage keep their IDs, other errors of the storage fail the export.
+type resolver struct {
+	ctx         context.Context
+	storage     interfaces.Storage
+	accounts    map[string]string
+	tags        map[string]string
+	merchants   map[string]string
+	companies   map[int]string
+	instruments map[int]*models.Instrument
+}
+
+func newResolver(ctx context.Context, storage interfaces.Storage) *resolver {
+	return &resolver{
+		ctx:         ctx,
+		storage:     storage,
+		accounts:    make(map[string]string),
+		tags:        make(map[string]string),
+		merchants:   make(map[string]string),
+		companies:   make(map[int]string),
+		instruments: make(map[int]*models.Instrument),
+	}
+}
+
+// resolve returns the title of a referenced object, lists of references are joined with commas
+func (r *resolver) resolve(kind refKind, value any) (any, error) {
+	switch v := value.(type) {
+	case []any:
+		titles := make([]string, 0, len(v))
+		for _, item := range v {
+			title, err := r.resolve(kind, item)
+			if err != nil {
+				return nil, err
+			}
+			titles = append(titles, fmt.Sprint(title))
+		}
+		return strings.Join(titles, ", "), nil
+	case json.Number:
+		id, err := v.Int64()
+		if err != nil {
+			return v.String(), nil
+		}
+		switch kind {
+		case refInstrument:
+			return r.currency(int(id))
+		case refCompany:
+			return r.company(int(id))
+		}
+		return v.String(), nil
+	case string:
+		switch kind {
+		case refAccount:
+			return r.account(v)
+		case refTag:
+			return r.tag(v)
+		case refMerchant:
+			return r.merchant(v)
+		}
+		return v, nil
+	default:
+		return value, nil
+	}
+}
+
+// title returns the cached title of an object or gets it from the storage,
+// the ID of the object if it's missing
+func title[ID comparable, T any](cache map[ID]string, id ID, get func() (*T, error), titleOf func(*T) string) (string, error) {
+	if t, ok := cache[id]; ok {
+		return t, nil
+	}
+	t := fmt.Sprint(id)
+	object, err := get()
+	switch {
+	case errors.Is(err, interfaces.ErrNotFound):
+	case err != nil:
+		return "", err
+	case object != nil:
+		t = titleOf(object)
+	}
+	cache[id] = t
+	return t, nil
+}
+
+func (r *resolver) account(id string) (string, error) {
+	return title(r.accounts, id, func() (*models.Account, error) { return r.storage.GetAccount(r.ctx, id) },
+		func(a *models.Account) string { return a.Title })
+}
+
+func (r *resolver) tag(id string) (string, error) {
+	return title(r.tags, id, func() (*models.Tag, error) { return r.storage.GetTag(r.ctx, id) },
+		func(t *models.Tag) string { return t.Title })
+}
+
+func (r *resolver) merchant(id string) (string, error) {
+	return title(r.merchants, id, func() (*models.Merchant, error) { return r.storage.GetMerchant(r.ctx, id) },
+		func(m *models.Merchant) string { return m.Title })
+}
+
+func (r *resolver) company(id int) (string, error) {
+	return title(r.companies, id, func() (*models.Company, error) { return r.storage.GetCompany(r.ctx, id) },
+		func(c *models.Company) string { return c.Title })
+}
+
+// instrument returns an instrument, nil if it's missing
+func (r *resolver) instrument(id int) (*models.Instrument, error) {
+	if instrument, ok := r.instruments[id]; ok {
+		return instrument, nil
+	}
+	instrument, err := r.storage.GetInstrument(r.ctx, id)
+	if errors.Is(err, interfaces.ErrNotFound) {
+		instrument, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.instruments[id] = instrument
+	return instrument, nil
+}
+
+// currency returns the short title of an instrument, e.g. RUB, or its ID if it's missing
+func (r *resolver) currency(id int) (string, error) {
+	instrument, err := r.instrument(id)
+	if err != nil {
+		return "", err
+	}
+	if instrument != nil {
+		return instrument.ShortTitle, nil
+	}
+	return fmt.Sprint(id), nil
+}
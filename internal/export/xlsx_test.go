@@ -0,0 +1,145 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/export"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/mocks"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExportWorkbook(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListTransactions", mock.Anything, mock.Anything).Return([]models.Transaction{
+		{ID: "tx-1", Date: "2024-01-15", Outcome: 1250.5, OutcomeInstrument: 2, IncomeInstrument: 2,
+			OutcomeAccount: ptr("acc-1"), IncomeAccount: "acc-1", Tag: []string{"tag-1", "tag-2"}, Merchant: ptr("m-1")},
+		{ID: "tx-2", Date: "2024-01-20", Income: 5000, IncomeInstrument: 2, OutcomeInstrument: 2,
+			IncomeAccount: "acc-1", OutcomeAccount: ptr("acc-1")},
+		{ID: "tx-3", Date: "2024-02-01", Income: 100, Outcome: 100, IncomeInstrument: 2, OutcomeInstrument: 2,
+			IncomeAccount: "acc-2", OutcomeAccount: ptr("acc-1")},
+	}, nil)
+	storage.On("GetAccount", mock.Anything, "acc-1").Return(&models.Account{ID: "acc-1", Title: "Card"}, nil).Once()
+	storage.On("GetAccount", mock.Anything, "acc-2").
+		Return(nil, fmt.Errorf("account %w: acc-2", interfaces.ErrNotFound)).Once()
+	storage.On("GetTag", mock.Anything, "tag-1").Return(&models.Tag{ID: "tag-1", Title: "Food"}, nil).Once()
+	storage.On("GetTag", mock.Anything, "tag-2").Return(&models.Tag{ID: "tag-2", Title: "Cafe"}, nil).Once()
+	storage.On("GetInstrument", mock.Anything, 2).
+		Return(&models.Instrument{ID: 2, ShortTitle: "RUB", Symbol: "₽"}, nil).Once()
+	storage.On("GetMerchant", mock.Anything, "m-1").Return(&models.Merchant{ID: "m-1", Title: "Cafe"}, nil).Once()
+
+	exporter := export.NewExporter(storage, export.Options{Format: export.FormatXLSX})
+
+	var out bytes.Buffer
+	counts, err := exporter.ExportTo(context.Background(), entities(t, "transactions"), "", &out)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"transactions": 3}, counts)
+
+	f, err := excelize.OpenReader(&out)
+	require.NoError(t, err)
+	defer f.Close()
+
+	assert.Equal(t, []string{"transactions", "Summary"}, f.GetSheetList())
+
+	rows, err := f.GetRows("transactions", excelize.Options{RawCellValue: true})
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	header := rows[0]
+	value := func(row int, column string) string {
+		for i, name := range header {
+			if name == column && i < len(rows[row]) {
+				return rows[row][i]
+			}
+		}
+		return ""
+	}
+	assert.Equal(t, "Card", value(1, "incomeAccount"))
+	assert.Equal(t, "Food, Cafe", value(1, "tag"))
+	assert.Equal(t, "RUB", value(1, "outcomeInstrument"))
+	assert.Equal(t, "Cafe", value(1, "merchant"))
+	assert.Equal(t, "1250.5", value(1, "outcome"))
+	assert.Equal(t, "acc-2", value(3, "incomeAccount"))
+
+	outcomeCell, err := excelize.CoordinatesToCellName(indexOf(header, "outcome")+1, 2)
+	require.NoError(t, err)
+	formatted, err := f.GetCellValue("transactions", outcomeCell)
+	require.NoError(t, err)
+	assert.Equal(t, "1,250.50 ₽", formatted)
+
+	summary, err := f.GetRows("Summary", excelize.Options{RawCellValue: true})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"month", "currency", "income", "expense", "net"},
+		{"2024-01", "RUB", "5000", "1250.5", "3749.5"},
+	}, summary)
+}
+
+func TestExportWorkbook_Company(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListAccounts", mock.Anything, mock.Anything).Return([]models.Account{
+		{ID: "acc-1", Title: "Card", Company: ptr(int32(4))},
+		{ID: "acc-2", Title: "Cash", Company: ptr(int32(5))},
+	}, nil)
+	storage.On("GetCompany", mock.Anything, 4).Return(&models.Company{ID: 4, Title: "Tinkoff"}, nil).Once()
+	storage.On("GetCompany", mock.Anything, 5).Return(nil, fmt.Errorf("company %w: 5", interfaces.ErrNotFound)).Once()
+	exporter := export.NewExporter(storage, export.Options{Format: export.FormatXLSX})
+
+	var out bytes.Buffer
+	_, err := exporter.ExportTo(context.Background(), entities(t, "accounts"), "", &out)
+	require.NoError(t, err)
+
+	f, err := excelize.OpenReader(&out)
+	require.NoError(t, err)
+	defer f.Close()
+
+	rows, err := f.GetRows("accounts", excelize.Options{RawCellValue: true})
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	column := indexOf(rows[0], "company")
+	require.NotEqual(t, -1, column)
+	assert.Equal(t, "Tinkoff", rows[1][column])
+	assert.Equal(t, "5", rows[2][column])
+}
+
+func TestExportWorkbook_StorageError(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListTransactions", mock.Anything, mock.Anything).Return([]models.Transaction{
+		{ID: "tx-1", Date: "2024-01-15", IncomeInstrument: 2, IncomeAccount: "acc-1"},
+	}, nil)
+	storage.On("GetInstrument", mock.Anything, 2).Return(nil, errors.New("connection refused")).Once()
+	exporter := export.NewExporter(storage, export.Options{Format: export.FormatXLSX})
+
+	_, err := exporter.ExportTo(context.Background(), entities(t, "transactions"), "", &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connection refused")
+}
+
+func TestExportWorkbook_NoCompression(t *testing.T) {
+	exporter := export.NewExporter(mocks.NewStorage(t), export.Options{
+		Format:      export.FormatXLSX,
+		Compression: export.CompressionGzip,
+	})
+	_, err := exporter.ExportTo(context.Background(), entities(t, "tags"), "", &bytes.Buffer{})
+	assert.Error(t, err)
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,224 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CSVMapping describes the layout of a bank's CSV statements. Columns are given by their header
+// or by their 1-based position.
+type CSVMapping struct {
+	// Delimiter separates the fields, a comma by default
+	Delimiter string `yaml:"delimiter"`
+	// Skip is the number of lines before the header row, blank ones included
+	Skip int `yaml:"skip"`
+	// DateFormat is the Go layout of dates, 2006-01-02 by default, e.g. 02.01.2006
+	DateFormat string `yaml:"date_format"`
+	// DecimalComma is set for amounts like 1.234,56
+	DecimalComma bool `yaml:"decimal_comma"`
+	// Columns maps the fields of a record to the columns holding them
+	Columns CSVColumns `yaml:"columns"`
+
+	// optional is set while Columns are the ones of DefaultCSVMapping, whose payee, memo, currency
+	// and id columns a statement may lack. Columns given explicitly have to be in the statement.
+	optional bool
+}
+
+// CSVColumns are the columns of the record fields. Amount is the signed amount; statements with
+// separate columns for money leaving and entering the account set Debit and Credit instead.
+type CSVColumns struct {
+	Date     string `yaml:"date"`
+	Amount   string `yaml:"amount"`
+	Debit    string `yaml:"debit"`
+	Credit   string `yaml:"credit"`
+	Payee    string `yaml:"payee"`
+	Memo     string `yaml:"memo"`
+	Currency string `yaml:"currency"`
+	ID       string `yaml:"id"`
+}
+
+// DefaultCSVMapping returns the mapping of CSV statements without a mapping file:
+// comma separated with date, amount, payee, memo, currency and id headers
+func DefaultCSVMapping() CSVMapping {
+	return CSVMapping{
+		Delimiter:  ",",
+		DateFormat: time.DateOnly,
+		Columns: CSVColumns{
+			Date: "date", Amount: "amount", Payee: "payee", Memo: "memo", Currency: "currency", ID: "id",
+		},
+		optional: true,
+	}
+}
+
+// LoadCSVMapping reads a YAML mapping file on top of DefaultCSVMapping, the columns of the file
+// replace the default columns
+func LoadCSVMapping(path string) (CSVMapping, error) {
+	mapping := DefaultCSVMapping()
+	if path == "" {
+		return mapping, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return mapping, fmt.Errorf("failed to read mapping file: %w", err)
+	}
+
+	var file CSVMapping
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return mapping, fmt.Errorf("failed to parse mapping file: %w", err)
+	}
+	if file.Delimiter != "" {
+		mapping.Delimiter = file.Delimiter
+	}
+	if file.DateFormat != "" {
+		mapping.DateFormat = file.DateFormat
+	}
+	mapping.Skip = file.Skip
+	mapping.DecimalComma = file.DecimalComma
+	if file.Columns != (CSVColumns{}) {
+		mapping.Columns = file.Columns
+		mapping.optional = false
+	}
+
+	if mapping.Columns.Date == "" {
+		return mapping, errors.New("invalid mapping file: the date column is missing")
+	}
+	if mapping.Columns.Amount == "" && mapping.Columns.Debit == "" && mapping.Columns.Credit == "" {
+		return mapping, errors.New("invalid mapping file: an amount or debit and credit columns are needed")
+	}
+	return mapping, nil
+}
+
+// parseCSV reads a CSV statement with a header row
+func parseCSV(r io.Reader, mapping CSVMapping) ([]Record, error) {
+	// the lines before the header are skipped as they are, the CSV reader would leave out blank ones
+	br := bufio.NewReader(r)
+	for i := 0; i < mapping.Skip; i++ {
+		if _, err := br.ReadString('\n'); err != nil {
+			return nil, fmt.Errorf("failed to read statement: %w", err)
+		}
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	if delimiter := []rune(mapping.Delimiter); len(delimiter) == 1 {
+		cr.Comma = delimiter[0]
+	} else if mapping.Delimiter == `\t` {
+		cr.Comma = '\t'
+	}
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement header: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	index := func(column string) (int, error) {
+		if column == "" {
+			return -1, nil
+		}
+		for i, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				return i, nil
+			}
+		}
+		if n, err := strconv.Atoi(column); err == nil && n > 0 {
+			return n - 1, nil
+		}
+		return -1, fmt.Errorf("the statement has no %q column", column)
+	}
+
+	columns := mapping.Columns
+	var idx struct{ date, amount, debit, credit, payee, memo, currency, id int }
+	for _, c := range []struct {
+		dst      *int
+		column   string
+		required bool
+	}{
+		{&idx.date, columns.Date, true},
+		{&idx.amount, columns.Amount, !mapping.optional || columns.Debit == "" && columns.Credit == ""},
+		{&idx.debit, columns.Debit, !mapping.optional},
+		{&idx.credit, columns.Credit, !mapping.optional},
+		{&idx.payee, columns.Payee, !mapping.optional},
+		{&idx.memo, columns.Memo, !mapping.optional},
+		{&idx.currency, columns.Currency, !mapping.optional},
+		{&idx.id, columns.ID, !mapping.optional},
+	} {
+		i, err := index(c.column)
+		if err != nil && c.required {
+			return nil, err
+		}
+		// only the optional default columns may be missing from a statement
+		*c.dst = i
+	}
+
+	var records []Record
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read statement: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		line += mapping.Skip
+		field := func(i int) string {
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		if field(idx.date) == "" {
+			// blank lines and totals at the end of a statement
+			continue
+		}
+
+		record := Record{
+			ID: field(idx.id), Payee: field(idx.payee), Memo: field(idx.memo), Currency: field(idx.currency),
+		}
+		if record.Date, err = time.Parse(mapping.DateFormat, field(idx.date)); err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q, expected the layout %s", line, field(idx.date), mapping.DateFormat)
+		}
+		if record.Amount, err = csvAmount(field(idx.amount), field(idx.debit), field(idx.credit), mapping.DecimalComma); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+}
+
+// csvAmount returns the signed amount of a line, either the amount or the credit less the debit
+func csvAmount(amount, debit, credit string, decimalComma bool) (float64, error) {
+	if amount != "" {
+		return parseAmount(amount, decimalComma)
+	}
+	var total float64
+	if credit != "" {
+		v, err := parseAmount(credit, decimalComma)
+		if err != nil {
+			return 0, err
+		}
+		total += v
+	}
+	if debit != "" {
+		v, err := parseAmount(debit, decimalComma)
+		if err != nil {
+			return 0, err
+		}
+		// debits are written positive or negative depending on the bank
+		total -= math.Abs(v)
+	}
+	return total, nil
+}
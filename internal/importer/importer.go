@@ -0,0 +1,130 @@
+// Package importer reads bank statements of accounts ZenMoney isn't connected to, matches their lines
+// against synced transactions and records the lines, creating transactions for new ones if asked to
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the format of a bank statement
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatOFX   Format = "ofx"
+	FormatMT940 Format = "mt940"
+)
+
+// Record is a line of a bank statement
+type Record struct {
+	// ID is the bank's ID of the line, empty if the statement has none
+	ID   string    `json:"id,omitempty"`
+	Date time.Time `json:"date"`
+	// Amount is signed, negative for money leaving the account
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency,omitempty"`
+	Payee    string  `json:"payee,omitempty"`
+	Memo     string  `json:"memo,omitempty"`
+}
+
+// ParseFormat parses the --format value, an empty value detects the format by the extension of path
+func ParseFormat(s, path string) (Format, error) {
+	if s == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv", ".txt":
+			return FormatCSV, nil
+		case ".ofx", ".qfx":
+			return FormatOFX, nil
+		case ".sta", ".mt940", ".940":
+			return FormatMT940, nil
+		default:
+			return "", fmt.Errorf("can't tell the statement format of %s, set --statement-format", path)
+		}
+	}
+
+	switch Format(s) {
+	case FormatCSV, FormatOFX, FormatMT940:
+		return Format(s), nil
+	default:
+		return "", fmt.Errorf("unsupported statement format: %s", s)
+	}
+}
+
+// Parse reads the records of a statement, the mapping is used by CSV statements only
+func Parse(format Format, r io.Reader, mapping CSVMapping) ([]Record, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r, mapping)
+	case FormatOFX:
+		return parseOFX(r)
+	case FormatMT940:
+		return parseMT940(r)
+	default:
+		return nil, fmt.Errorf("unsupported statement format: %s", format)
+	}
+}
+
+// externalIDs returns the IDs records are imported under: the bank's ID, or a hash of the line.
+// Identical lines without an ID, like two coffees of the same price on a day, are told apart by their order.
+func externalIDs(records []Record) []string {
+	ids := make([]string, len(records))
+	seen := make(map[string]int)
+	for i, record := range records {
+		if record.ID != "" {
+			ids[i] = record.ID
+			continue
+		}
+		key := strings.Join([]string{
+			record.Date.Format(time.DateOnly), formatAmount(record.Amount), record.Currency, record.Payee, record.Memo,
+		}, "\x00")
+		seen[key]++
+		sum := sha256.Sum256([]byte(key + "\x00" + strconv.Itoa(seen[key])))
+		ids[i] = "sha256:" + hex.EncodeToString(sum[:12])
+	}
+	return ids
+}
+
+// parseAmount parses an amount like -1 234,56 or (12.00). With a decimal comma, dots separate thousands.
+func parseAmount(s string, decimalComma bool) (float64, error) {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\u00a0', '\u202f', '\'':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(s))
+
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative, s = true, s[1:len(s)-1]
+	}
+	if decimalComma {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	} else {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	s = strings.Replace(s, "\u2212", "-", 1)
+
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// formatAmount formats an amount with the digits it needs, up to cents
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(math.Round(amount*100)/100, 'f', -1, 64)
+}
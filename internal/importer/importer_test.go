@@ -0,0 +1,198 @@
+package importer_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/importer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(time.DateOnly, s)
+	return t
+}
+
+func TestParseFormat(t *testing.T) {
+	for path, want := range map[string]importer.Format{
+		"bank.csv":        importer.FormatCSV,
+		"statement.OFX":   importer.FormatOFX,
+		"statement.qfx":   importer.FormatOFX,
+		"statement.sta":   importer.FormatMT940,
+		"statement.mt940": importer.FormatMT940,
+	} {
+		got, err := importer.ParseFormat("", path)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, got, path)
+	}
+
+	got, err := importer.ParseFormat("mt940", "-")
+	require.NoError(t, err)
+	assert.Equal(t, importer.FormatMT940, got)
+
+	_, err = importer.ParseFormat("", "statement.pdf")
+	assert.ErrorContains(t, err, "set --statement-format")
+	_, err = importer.ParseFormat("qif", "statement.qif")
+	assert.ErrorContains(t, err, "unsupported statement format: qif")
+}
+
+func TestParse_CSV(t *testing.T) {
+	statement := "\ufeffDate,Amount,Payee,Memo,Currency,ID\n" +
+		"2024-01-10,-4.50,Coffee House,latte,EUR,b-1\n" +
+		"2024-01-11,\"1,250.00\",ACME Corp,salary,EUR,\n" +
+		",,,Total,,\n"
+
+	records, err := importer.Parse(importer.FormatCSV, strings.NewReader(statement), importer.DefaultCSVMapping())
+	require.NoError(t, err)
+	assert.Equal(t, []importer.Record{
+		{ID: "b-1", Date: date("2024-01-10"), Amount: -4.5, Currency: "EUR", Payee: "Coffee House", Memo: "latte"},
+		{Date: date("2024-01-11"), Amount: 1250, Currency: "EUR", Payee: "ACME Corp", Memo: "salary"},
+	}, records)
+}
+
+func TestParse_CSVMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+delimiter: ";"
+skip: 2
+date_format: "02.01.2006"
+decimal_comma: true
+columns:
+  date: Buchungstag
+  debit: Soll
+  credit: "5"
+  payee: Empfänger
+`), 0o644))
+
+	mapping, err := importer.LoadCSVMapping(path)
+	require.NoError(t, err)
+
+	statement := "Konto;DE00 1234\n\n" +
+		"Buchungstag;Empfänger;Verwendungszweck;Soll;Haben\n" +
+		"10.01.2024;Bäckerei;Brötchen;3,20;\n" +
+		"31.01.2024;ACME GmbH;Gehalt;;1.250,00\n"
+
+	records, err := importer.Parse(importer.FormatCSV, strings.NewReader(statement), mapping)
+	require.NoError(t, err)
+	assert.Equal(t, []importer.Record{
+		{Date: date("2024-01-10"), Amount: -3.2, Payee: "Bäckerei"},
+		{Date: date("2024-01-31"), Amount: 1250, Payee: "ACME GmbH"},
+	}, records)
+}
+
+func TestParse_CSVErrors(t *testing.T) {
+	_, err := importer.Parse(importer.FormatCSV, strings.NewReader("Day,Amount\n"), importer.DefaultCSVMapping())
+	assert.ErrorContains(t, err, `no "date" column`)
+
+	_, err = importer.Parse(importer.FormatCSV, strings.NewReader("date,amount\n10/01/2024,1\n"), importer.DefaultCSVMapping())
+	assert.ErrorContains(t, err, "line 2: invalid date")
+
+	_, err = importer.Parse(importer.FormatCSV, strings.NewReader("date,amount\n2024-01-10,abc\n"), importer.DefaultCSVMapping())
+	assert.ErrorContains(t, err, `line 2: invalid amount "abc"`)
+}
+
+func TestParse_CSVMappedColumnMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("columns:\n  date: Date\n  amount: Amount\n  payee: Counterparty\n"), 0o644))
+	mapping, err := importer.LoadCSVMapping(path)
+	require.NoError(t, err)
+
+	_, err = importer.Parse(importer.FormatCSV, strings.NewReader("Date,Amount,Payee\n2024-01-10,1,Shop\n"), mapping)
+	assert.ErrorContains(t, err, `no "Counterparty" column`)
+
+	// the default payee, memo, currency and id columns may be missing
+	records, err := importer.Parse(importer.FormatCSV, strings.NewReader("date,amount\n2024-01-10,1\n"),
+		importer.DefaultCSVMapping())
+	require.NoError(t, err)
+	assert.Equal(t, []importer.Record{{Date: date("2024-01-10"), Amount: 1}}, records)
+}
+
+func TestLoadCSVMapping_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bank.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("columns:\n  payee: Name\n"), 0o644))
+
+	_, err := importer.LoadCSVMapping(path)
+	assert.ErrorContains(t, err, "the date column is missing")
+}
+
+func TestParse_OFX(t *testing.T) {
+	sgml := `OFXHEADER:100
+DATA:OFXSGML
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>EUR
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240110120000[+1:CET]
+<TRNAMT>-4.50
+<FITID>fit-1
+<NAME>COFFEE HOUSE
+<MEMO>Card payment
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240112
+<TRNAMT>-20.00
+<FITID>fit-2
+<NAME>BOOKS &amp; MORE
+<CURRENCY><CURRATE>0.9<CURSYM>USD</CURRENCY>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+	records, err := importer.Parse(importer.FormatOFX, strings.NewReader(sgml), importer.CSVMapping{})
+	require.NoError(t, err)
+	assert.Equal(t, []importer.Record{
+		{ID: "fit-1", Date: date("2024-01-10"), Amount: -4.5, Currency: "EUR", Payee: "COFFEE HOUSE", Memo: "Card payment"},
+		{ID: "fit-2", Date: date("2024-01-12"), Amount: -20, Currency: "USD", Payee: "BOOKS & MORE"},
+	}, records)
+
+	xml := `<?xml version="1.0"?><OFX><CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS>
+<CURDEF>GBP</CURDEF>
+<BANKTRANLIST><STMTTRN><DTPOSTED>20240201</DTPOSTED><TRNAMT>100</TRNAMT><FITID>cc-1</FITID>
+<PAYEE><NAME>Refund Ltd</NAME><ADDR1>1 Street</ADDR1></PAYEE><NAME>REFUND</NAME></STMTTRN></BANKTRANLIST>
+</CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1></OFX>`
+	records, err = importer.Parse(importer.FormatOFX, strings.NewReader(xml), importer.CSVMapping{})
+	require.NoError(t, err)
+	assert.Equal(t, []importer.Record{
+		{ID: "cc-1", Date: date("2024-02-01"), Amount: 100, Currency: "GBP", Payee: "Refund Ltd"},
+	}, records)
+}
+
+func TestParse_OFXMissingDate(t *testing.T) {
+	_, err := importer.Parse(importer.FormatOFX, strings.NewReader("<STMTTRN><TRNAMT>1<FITID>x</STMTTRN>"), importer.CSVMapping{})
+	assert.ErrorContains(t, err, `transaction "x" has no DTPOSTED`)
+}
+
+func TestParse_MT940(t *testing.T) {
+	statement := `{1:F01BANKDEFFXXXX0000000000}{2:I940BANKDEFFXXXXN}{4:
+:20:STARTUMSE
+:25:10020030/1234567
+:28C:00001/001
+:60F:C240109EUR1000,00
+:61:2401100110DR4,50NDDTNONREF//B1-0001
+:86:106?00KARTENZAHLUNG?20Latte?21Croissant?32COFFEE HOUSE
+?33BERLIN
+:61:240111C1250,NTRFSALARY-JAN
+:86:Salary January ACME
+:61:240112D20,00NMSCNONREF
+:62F:C240112EUR2225,50
+-}`
+	records, err := importer.Parse(importer.FormatMT940, strings.NewReader(statement), importer.CSVMapping{})
+	require.NoError(t, err)
+	assert.Equal(t, []importer.Record{
+		{ID: "B1-0001", Date: date("2024-01-10"), Amount: -4.5, Currency: "EUR", Payee: "COFFEE HOUSEBERLIN", Memo: "Latte Croissant"},
+		{ID: "SALARY-JAN", Date: date("2024-01-11"), Amount: 1250, Currency: "EUR", Payee: "Salary January ACME"},
+		{Date: date("2024-01-12"), Amount: -20, Currency: "EUR"},
+	}, records)
+}
+
+func TestParse_MT940Invalid(t *testing.T) {
+	_, err := importer.Parse(importer.FormatMT940, strings.NewReader(":61:garbage\n"), importer.CSVMapping{})
+	assert.ErrorContains(t, err, "invalid statement line :61:garbage")
+}
@@ -0,0 +1,367 @@
+package importer
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+)
+
+// pageSize is the number of objects read from the storage at once
+const pageSize = 1000
+
+// Status is the outcome of importing a record
+type Status string
+
+const (
+	// StatusMatched records match a synced transaction
+	StatusMatched Status = "matched"
+	// StatusNew records match no transaction and are kept for review
+	StatusNew Status = "new"
+	// StatusCreated records match no transaction and were created as one
+	StatusCreated Status = "created"
+	// StatusDuplicate records were imported into the account and linked to a transaction before
+	StatusDuplicate Status = "duplicate"
+)
+
+// Options configure an import
+type Options struct {
+	Format  Format
+	Mapping CSVMapping
+	// Account is the ID or the title of the account the statement belongs to
+	Account string
+	// Window is the number of days the date of a matching transaction may differ by
+	Window int
+	// StrictPayee matches only transactions whose payee resembles the record's,
+	// otherwise the payee decides between several transactions of the same amount
+	StrictPayee bool
+	// Create creates transactions for new records instead of keeping them for review
+	Create bool
+	// DryRun reports the outcome without saving anything
+	DryRun bool
+}
+
+// Line is an imported record with its outcome
+type Line struct {
+	Record
+	ExternalID    string `json:"external_id"`
+	Status        Status `json:"status"`
+	TransactionID string `json:"transaction_id,omitempty"`
+}
+
+// Result is the outcome of an import
+type Result struct {
+	Account string         `json:"account"`
+	Counts  map[Status]int `json:"counts"`
+	Lines   []Line         `json:"lines"`
+}
+
+// candidate is a transaction as seen from the imported account
+type candidate struct {
+	tx     models.Transaction
+	date   time.Time
+	amount float64
+}
+
+// Import reads a statement, matches its records against the transactions of the account by amount,
+// date and payee and saves them as imported records. Each transaction matches one record at most,
+// also across imports.
+func Import(ctx context.Context, storage interfaces.Storage, r io.Reader, opts Options) (*Result, error) {
+	records, err := Parse(opts.Format, r, opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	account, err := findAccount(ctx, storage, opts.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Account: account.Title, Counts: make(map[Status]int), Lines: make([]Line, len(records))}
+	if len(records) == 0 {
+		return result, nil
+	}
+
+	ids := externalIDs(records)
+	order := make([]int, len(records))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return records[order[a]].Date.Before(records[order[b]].Date) })
+	window := time.Duration(opts.Window) * 24 * time.Hour
+	from, to := records[order[0]].Date.Add(-window), records[order[len(order)-1]].Date.Add(window)
+
+	imported, used, err := importedRecords(ctx, storage, account.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := accountTransactions(ctx, storage, account.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var currency string
+	if opts.Create {
+		if currency, err = accountCurrency(ctx, storage, account); err != nil {
+			return nil, err
+		}
+	}
+
+	var saved []interfaces.ImportedRecord
+	// a failed creation stops creating, the remaining new records are kept for review
+	var createErr error
+	for _, i := range order {
+		record := records[i]
+		line := Line{Record: record, ExternalID: ids[i], Status: StatusNew}
+
+		if imported[ids[i]] {
+			line.Status = StatusDuplicate
+		} else if c := match(candidates, used, record, window, opts.StrictPayee); c != nil {
+			used[c.tx.ID] = true
+			line.Status, line.TransactionID = StatusMatched, c.tx.ID
+		} else if opts.Create && createErr == nil && (record.Currency == "" || strings.EqualFold(record.Currency, currency)) {
+			line.TransactionID, createErr = createTransaction(ctx, storage, account, record, opts.DryRun)
+			if createErr == nil {
+				line.Status = StatusCreated
+			}
+		}
+
+		result.Lines[i] = line
+		result.Counts[line.Status]++
+		if line.Status != StatusDuplicate {
+			saved = append(saved, importedRecord(account.ID, opts.Format, line))
+		}
+	}
+
+	if !opts.DryRun {
+		if err := storage.SaveImportedRecords(ctx, saved); err != nil {
+			return nil, err
+		}
+	}
+	if createErr != nil {
+		return result, createErr
+	}
+	return result, nil
+}
+
+// findAccount returns the account of the given ID or title
+func findAccount(ctx context.Context, storage interfaces.Storage, account string) (models.Account, error) {
+	var found []models.Account
+	for page := 1; ; page++ {
+		accounts, err := storage.ListAccounts(ctx, interfaces.Filter{Page: page, Limit: pageSize})
+		if err != nil {
+			return models.Account{}, fmt.Errorf("failed to list accounts: %w", err)
+		}
+		for _, a := range accounts {
+			if a.ID == account {
+				return a, nil
+			}
+			if strings.EqualFold(a.Title, account) {
+				found = append(found, a)
+			}
+		}
+		if len(accounts) < pageSize {
+			break
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return models.Account{}, fmt.Errorf("account not found: %s", account)
+	case 1:
+		return found[0], nil
+	default:
+		return models.Account{}, fmt.Errorf("several accounts are titled %s, pass the account ID instead", account)
+	}
+}
+
+// importedRecords returns the external IDs of records imported into the account before, true for
+// the ones linked to a transaction, and the transactions records of any account are linked to.
+// Records kept for review are matched again, so importing a statement again after a sync links them.
+func importedRecords(ctx context.Context, storage interfaces.Storage, account string, from, to time.Time) (map[string]bool, map[string]bool, error) {
+	imported, linked := make(map[string]bool), make(map[string]bool)
+	for page := 1; ; page++ {
+		records, err := storage.ListImportedRecords(ctx, interfaces.Filter{StartDate: &from, EndDate: &to, Page: page, Limit: pageSize})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, record := range records {
+			if record.Account == account {
+				imported[record.ExternalID] = record.TransactionID != nil
+			}
+			if record.TransactionID != nil {
+				linked[*record.TransactionID] = true
+			}
+		}
+		if len(records) < pageSize {
+			return imported, linked, nil
+		}
+	}
+}
+
+// accountTransactions returns the transactions of the account in the date range, a transfer within
+// the account once for each side
+func accountTransactions(ctx context.Context, storage interfaces.Storage, account string, from, to time.Time) ([]*candidate, error) {
+	var candidates []*candidate
+	for page := 1; ; page++ {
+		txs, err := storage.ListTransactions(ctx, interfaces.Filter{StartDate: &from, EndDate: &to, Page: page, Limit: pageSize})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list transactions: %w", err)
+		}
+		for _, tx := range txs {
+			if tx.Deleted {
+				continue
+			}
+			date, err := time.Parse(time.DateOnly, tx.Date)
+			if err != nil {
+				continue
+			}
+			outcomeAccount := tx.IncomeAccount
+			if tx.OutcomeAccount != nil {
+				outcomeAccount = *tx.OutcomeAccount
+			}
+			if tx.IncomeAccount == account && tx.Income > 0 {
+				candidates = append(candidates, &candidate{tx: tx, date: date, amount: tx.Income})
+			}
+			if outcomeAccount == account && tx.Outcome > 0 {
+				candidates = append(candidates, &candidate{tx: tx, date: date, amount: -tx.Outcome})
+			}
+		}
+		if len(txs) < pageSize {
+			return candidates, nil
+		}
+	}
+}
+
+// match returns the best candidate for the record among the transactions not used yet: one of the same
+// amount within the window, with a resembling payee first, then the closest date
+func match(candidates []*candidate, used map[string]bool, record Record, window time.Duration, strictPayee bool) *candidate {
+	var best *candidate
+	bestSimilar, bestDistance := false, time.Duration(0)
+	for _, c := range candidates {
+		if used[c.tx.ID] || math.Abs(c.amount-record.Amount) >= 0.005 {
+			continue
+		}
+		distance := c.date.Sub(record.Date).Abs()
+		if distance > window {
+			continue
+		}
+		similar := record.Payee != "" && (similarPayee(record.Payee, c.tx.Payee) ||
+			similarPayee(record.Payee, c.tx.OriginalPayee) || (c.tx.Comment != nil && similarPayee(record.Payee, *c.tx.Comment)))
+		if strictPayee && record.Payee != "" && !similar {
+			continue
+		}
+		if best == nil || (similar && !bestSimilar) || (similar == bestSimilar && distance < bestDistance) {
+			best, bestSimilar, bestDistance = c, similar, distance
+		}
+	}
+	return best
+}
+
+// similarPayee reports whether two payees share a word of three or more letters, ignoring case
+// and punctuation, e.g. "COFFEE HOUSE MOSCOW RU" and "Coffee House"
+func similarPayee(a, b string) bool {
+	words := func(s string) []string {
+		return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+	}
+	seen := make(map[string]bool)
+	for _, w := range words(a) {
+		if len([]rune(w)) >= 3 {
+			seen[w] = true
+		}
+	}
+	for _, w := range words(b) {
+		if seen[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// accountCurrency returns the short title of the account's instrument, created transactions are in it
+func accountCurrency(ctx context.Context, storage interfaces.Storage, account models.Account) (string, error) {
+	if account.Instrument == nil {
+		return "", fmt.Errorf("account %s has no currency, transactions can't be created in it", account.Title)
+	}
+	instrument, err := storage.GetInstrument(ctx, int(*account.Instrument))
+	if err != nil {
+		return "", err
+	}
+	if instrument == nil {
+		return "", nil
+	}
+	return instrument.ShortTitle, nil
+}
+
+// createTransaction creates an expense or an income of the account for the record
+// and returns its ID. A dry run only makes up the ID.
+func createTransaction(ctx context.Context, storage interfaces.Storage, account models.Account, record Record, dryRun bool) (string, error) {
+	now := int(time.Now().Unix())
+	instrument := int(*account.Instrument)
+	tx := &models.Transaction{
+		ID:                newID(),
+		User:              account.User,
+		Date:              record.Date.Format(time.DateOnly),
+		Changed:           now,
+		Created:           now,
+		IncomeInstrument:  instrument,
+		OutcomeInstrument: instrument,
+		IncomeAccount:     account.ID,
+		OutcomeAccount:    &account.ID,
+		Payee:             record.Payee,
+		OriginalPayee:     record.Payee,
+		Source:            "import",
+	}
+	if record.Amount >= 0 {
+		tx.Income = record.Amount
+	} else {
+		tx.Outcome = -record.Amount
+	}
+	if record.Memo != "" {
+		tx.Comment = &record.Memo
+	}
+
+	if !dryRun {
+		if err := storage.CreateTransaction(ctx, tx); err != nil {
+			return "", fmt.Errorf("failed to create transaction: %w", err)
+		}
+	}
+	return tx.ID, nil
+}
+
+// importedRecord returns the record of a line saved in the storage
+func importedRecord(account string, format Format, line Line) interfaces.ImportedRecord {
+	record := interfaces.ImportedRecord{
+		Account:    account,
+		ExternalID: line.ExternalID,
+		Date:       line.Date,
+		Amount:     line.Amount,
+		Currency:   line.Currency,
+		Payee:      line.Payee,
+		Memo:       line.Memo,
+		Source:     string(format),
+	}
+	if line.TransactionID != "" {
+		id := line.TransactionID
+		record.TransactionID = &id
+	}
+	return record
+}
+
+// newID returns a random UUID, the ID format of ZenMoney objects
+func newID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
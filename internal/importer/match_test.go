@@ -0,0 +1,213 @@
+package importer_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/importer"
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/mocks"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	eur      = int32(2)
+	checking = "acc-1"
+	savings  = "acc-2"
+	accounts = []models.Account{
+		{ID: checking, User: 7, Title: "Checking", Instrument: &eur},
+		{ID: savings, User: 7, Title: "Savings", Instrument: &eur},
+	}
+	coffee = "coffee house"
+	txs    = []models.Transaction{
+		{ID: "tx-1", Date: "2024-01-10", Outcome: 4.5, IncomeAccount: checking, OutcomeAccount: &checking, Payee: "Bakery"},
+		{ID: "tx-2", Date: "2024-01-11", Outcome: 4.5, IncomeAccount: checking, OutcomeAccount: &checking, Comment: &coffee},
+		{ID: "tx-3", Date: "2024-01-14", Income: 1250, Outcome: 1250, IncomeAccount: checking, OutcomeAccount: &savings},
+		{ID: "tx-4", Date: "2024-01-10", Outcome: 30, IncomeAccount: checking, OutcomeAccount: &checking},
+		{ID: "tx-5", Date: "2024-01-20", Outcome: 9.99, IncomeAccount: checking, OutcomeAccount: &checking, Deleted: true},
+		{ID: "tx-6", Date: "2024-01-20", Outcome: 30, IncomeAccount: checking, OutcomeAccount: &checking},
+		{ID: "tx-7", Date: "2024-01-20", Outcome: 30, IncomeAccount: savings, OutcomeAccount: &savings},
+	}
+	matched, linked = "tx-0", "tx-6"
+	// existing records: b-5 of the checking account matched before, tx-6 is linked to another account's record
+	existing = []interfaces.ImportedRecord{
+		{Account: checking, ExternalID: "b-5", Source: "csv", TransactionID: &matched},
+		{Account: savings, ExternalID: "s-1", Source: "csv", TransactionID: &linked},
+	}
+)
+
+const statement = `date,amount,payee,id
+2024-01-10,-4.50,COFFEE HOUSE BERLIN,b-1
+2024-01-10,-4.50,BAKERY,b-2
+2024-01-15,1250,ACME,b-3
+2024-01-20,-9.99,STREAMING,b-4
+2024-01-21,-4.50,BAKERY,b-5
+2024-01-20,-30.00,,b-6
+`
+
+// storage returns a storage with the accounts, transactions and imported records above
+func storage(t *testing.T) *mocks.Storage {
+	storage := mocks.NewStorage(t)
+	storage.On("ListAccounts", mock.Anything, interfaces.Filter{Page: 1, Limit: 1000}).Return(accounts, nil)
+	storage.On("ListImportedRecords", mock.Anything, mock.Anything).Return(existing, nil).Once()
+	storage.On("ListTransactions", mock.Anything, mock.Anything).Return(txs, nil).Once()
+	return storage
+}
+
+// saved captures the records saved by an import
+func saved(storage *mocks.Storage) *[]interfaces.ImportedRecord {
+	var records []interfaces.ImportedRecord
+	storage.On("SaveImportedRecords", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		records = args.Get(1).([]interfaces.ImportedRecord)
+	}).Once()
+	return &records
+}
+
+func statuses(result *importer.Result) map[string]importer.Status {
+	got := make(map[string]importer.Status)
+	for _, line := range result.Lines {
+		got[line.ExternalID] = line.Status
+	}
+	return got
+}
+
+func TestImport(t *testing.T) {
+	s := storage(t)
+	records := saved(s)
+
+	result, err := importer.Import(context.Background(), s, strings.NewReader(statement), importer.Options{
+		Format: importer.FormatCSV, Mapping: importer.DefaultCSVMapping(), Account: "checking", Window: 3,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Checking", result.Account)
+	assert.Equal(t, map[string]importer.Status{
+		"b-1": importer.StatusMatched, "b-2": importer.StatusMatched, "b-3": importer.StatusMatched,
+		"b-4": importer.StatusNew, "b-5": importer.StatusDuplicate, "b-6": importer.StatusNew,
+	}, statuses(result))
+	assert.Equal(t, map[importer.Status]int{
+		importer.StatusMatched: 3, importer.StatusNew: 2, importer.StatusDuplicate: 1,
+	}, result.Counts)
+
+	// the payee decides between the transactions of the same amount
+	assert.Equal(t, "tx-2", result.Lines[0].TransactionID)
+	assert.Equal(t, "tx-1", result.Lines[1].TransactionID)
+	assert.Equal(t, "tx-3", result.Lines[2].TransactionID)
+
+	require.Len(t, *records, 5)
+	for _, record := range *records {
+		assert.Equal(t, checking, record.Account)
+		assert.Equal(t, "csv", record.Source)
+		assert.NotEqual(t, "b-5", record.ExternalID)
+	}
+	assert.Equal(t, "b-4", (*records)[3].ExternalID)
+	assert.Nil(t, (*records)[3].TransactionID)
+}
+
+func TestImport_StrictPayee(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		s := storage(t)
+		saved(s)
+
+		result, err := importer.Import(context.Background(), s,
+			strings.NewReader("date,amount,payee\n2024-01-10,-4.50,NEWSSTAND\n"), importer.Options{
+				Format: importer.FormatCSV, Mapping: importer.DefaultCSVMapping(), Account: checking,
+				Window: 3, StrictPayee: strict,
+			})
+		require.NoError(t, err)
+		if strict {
+			assert.Equal(t, importer.StatusNew, result.Lines[0].Status)
+		} else {
+			assert.Equal(t, importer.StatusMatched, result.Lines[0].Status)
+		}
+	}
+}
+
+func TestImport_Create(t *testing.T) {
+	s := storage(t)
+	records := saved(s)
+	s.On("GetInstrument", mock.Anything, 2).Return(&models.Instrument{ID: 2, ShortTitle: "EUR"}, nil)
+
+	var created *models.Transaction
+	s.On("CreateTransaction", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*models.Transaction)
+	}).Once()
+
+	result, err := importer.Import(context.Background(), s,
+		strings.NewReader("date,amount,payee,memo,currency\n2024-01-20,-9.99,STREAMING,monthly,EUR\n2024-01-20,-5,SHOP,,USD\n"),
+		importer.Options{
+			Format: importer.FormatCSV, Mapping: importer.DefaultCSVMapping(), Account: checking, Window: 3, Create: true,
+		})
+	require.NoError(t, err)
+
+	require.NotNil(t, created)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, created.ID)
+	assert.Equal(t, 7, created.User)
+	assert.Equal(t, "2024-01-20", created.Date)
+	assert.Equal(t, 9.99, created.Outcome)
+	assert.Zero(t, created.Income)
+	assert.Equal(t, checking, created.IncomeAccount)
+	assert.Equal(t, checking, *created.OutcomeAccount)
+	assert.Equal(t, 2, created.OutcomeInstrument)
+	assert.Equal(t, "STREAMING", created.Payee)
+	assert.Equal(t, "monthly", *created.Comment)
+
+	assert.Equal(t, importer.StatusCreated, result.Lines[0].Status)
+	assert.Equal(t, created.ID, result.Lines[0].TransactionID)
+	// a record in another currency than the account's is kept for review
+	assert.Equal(t, importer.StatusNew, result.Lines[1].Status)
+	assert.Equal(t, created.ID, *(*records)[0].TransactionID)
+}
+
+func TestImport_DryRun(t *testing.T) {
+	s := storage(t)
+	s.On("GetInstrument", mock.Anything, 2).Return(&models.Instrument{ID: 2, ShortTitle: "EUR"}, nil)
+
+	result, err := importer.Import(context.Background(), s, strings.NewReader(statement), importer.Options{
+		Format: importer.FormatCSV, Mapping: importer.DefaultCSVMapping(), Account: checking,
+		Window: 3, Create: true, DryRun: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Counts[importer.StatusCreated])
+	s.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+	s.AssertNotCalled(t, "SaveImportedRecords", mock.Anything, mock.Anything)
+}
+
+func TestImport_CreateError(t *testing.T) {
+	s := storage(t)
+	records := saved(s)
+	s.On("GetInstrument", mock.Anything, 2).Return(&models.Instrument{ID: 2, ShortTitle: "EUR"}, nil)
+	s.On("CreateTransaction", mock.Anything, mock.Anything).Return(assert.AnError).Once()
+
+	result, err := importer.Import(context.Background(), s, strings.NewReader(statement), importer.Options{
+		Format: importer.FormatCSV, Mapping: importer.DefaultCSVMapping(), Account: checking, Window: 3, Create: true,
+	})
+	assert.ErrorContains(t, err, "failed to create transaction")
+	// the records are saved with the failed and the remaining ones kept for review
+	require.NotNil(t, result)
+	assert.Equal(t, 2, result.Counts[importer.StatusNew])
+	assert.Len(t, *records, 5)
+}
+
+func TestImport_Account(t *testing.T) {
+	s := mocks.NewStorage(t)
+	s.On("ListAccounts", mock.Anything, mock.Anything).Return(accounts, nil)
+
+	_, err := importer.Import(context.Background(), s, strings.NewReader(statement), importer.Options{
+		Format: importer.FormatCSV, Mapping: importer.DefaultCSVMapping(), Account: "Credit card",
+	})
+	assert.ErrorContains(t, err, "account not found: Credit card")
+
+	twins := []models.Account{{ID: "a", Title: "Cash"}, {ID: "b", Title: "cash"}}
+	s = mocks.NewStorage(t)
+	s.On("ListAccounts", mock.Anything, mock.Anything).Return(twins, nil)
+
+	_, err = importer.Import(context.Background(), s, strings.NewReader(statement), importer.Options{
+		Format: importer.FormatCSV, Mapping: importer.DefaultCSVMapping(), Account: "Cash",
+	})
+	assert.ErrorContains(t, err, "several accounts are titled Cash")
+}
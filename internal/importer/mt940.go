@@ -0,0 +1,132 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// mt940Line matches a :61: statement line: value date, optional entry date, debit or credit mark
+	// (R for reversals), optional funds code, amount, transaction type, reference and bank reference
+	mt940Line = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[CD])([A-Z])?(\d+,\d*)([NSF][A-Z0-9]{3})([^/]*)(?://(.*))?$`)
+	// mt940Balance matches an opening balance, the currency follows the mark and the date
+	mt940Balance = regexp.MustCompile(`^[CD]\d{6}([A-Z]{3})`)
+	// mt940Field matches a subfield of structured :86: information, e.g. ?20 or ?32
+	mt940Field = regexp.MustCompile(`\?(\d{2})`)
+)
+
+// parseMT940 reads the statement lines of a SWIFT MT940 statement with their :86: information
+func parseMT940(r io.Reader) ([]Record, error) {
+	var (
+		records  []Record
+		currency string
+		tag      string
+		value    strings.Builder
+	)
+
+	flush := func() error {
+		defer value.Reset()
+		text := value.String()
+		switch tag {
+		case "60F", "60M":
+			if m := mt940Balance.FindStringSubmatch(text); m != nil {
+				currency = m[1]
+			}
+		case "61":
+			line, _, _ := strings.Cut(text, "\n")
+			m := mt940Line.FindStringSubmatch(strings.TrimSpace(line))
+			if m == nil {
+				return fmt.Errorf("invalid statement line :61:%s", line)
+			}
+			date, err := time.Parse("060102", m[1])
+			if err != nil {
+				return fmt.Errorf("invalid statement line :61:%s", line)
+			}
+			amount, err := parseAmount(m[5], true)
+			if err != nil {
+				return err
+			}
+			// debits and reversed credits leave the account
+			if m[3] == "D" || m[3] == "RC" {
+				amount = -amount
+			}
+
+			record := Record{Date: date, Amount: amount, Currency: currency}
+			if bankRef := strings.TrimSpace(m[8]); bankRef != "" {
+				record.ID = bankRef
+			} else if ref := strings.TrimSpace(m[7]); ref != "" && ref != "NONREF" {
+				record.ID = ref
+			}
+			records = append(records, record)
+		case "86":
+			if len(records) > 0 {
+				last := &records[len(records)-1]
+				last.Payee, last.Memo = mt940Details(text)
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// SWIFT blocks wrap the message text, e.g. {4: ... -}
+		if i := strings.Index(line, "{4:"); i >= 0 {
+			line = line[i+3:]
+		}
+		if line == "-" || line == "-}" || strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			if end := strings.Index(line[1:], ":"); end > 0 {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+				tag = line[1 : end+1]
+				value.WriteString(line[end+2:])
+				continue
+			}
+		}
+		// continuation lines of the current field
+		value.WriteString("\n" + line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read statement: %w", err)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// mt940Details splits :86: information into the payee and the memo. Structured information
+// (e.g. 166?00...?20...?32...) has the purpose in ?20-?29 and the payee in ?32 and ?33,
+// free text is kept as the payee.
+func mt940Details(text string) (payee, memo string) {
+	text = strings.ReplaceAll(text, "\n", "")
+	locs := mt940Field.FindAllStringSubmatchIndex(text, -1)
+	if len(locs) == 0 {
+		return strings.TrimSpace(text), ""
+	}
+
+	var purpose, name []string
+	for i, loc := range locs {
+		end := len(text)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		code, value := text[loc[2]:loc[3]], strings.TrimSpace(text[loc[1]:end])
+		switch {
+		case code >= "20" && code <= "29", code >= "60" && code <= "63":
+			purpose = append(purpose, value)
+		case code == "32" || code == "33":
+			name = append(name, value)
+		}
+	}
+	return strings.Join(name, ""), strings.Join(purpose, " ")
+}
@@ -0,0 +1,86 @@
+package importer
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ofxToken matches an OFX tag with the value following it. OFX 1.x is SGML, where elements
+// aren't closed, OFX 2.x is XML; both are read as a flat sequence of tags.
+var ofxToken = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)>([^<]*)`)
+
+// parseOFX reads the bank and credit card transactions of an OFX statement
+func parseOFX(r io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement: %w", err)
+	}
+
+	var (
+		records  []Record
+		current  *Record
+		currency string
+		// inPayee and inOrig are set within the PAYEE and ORIGCURRENCY aggregates of a transaction
+		inPayee, inOrig bool
+	)
+	for _, m := range ofxToken.FindAllStringSubmatch(string(data), -1) {
+		closing, tag, value := m[1] == "/", strings.ToUpper(m[2]), html.UnescapeString(strings.TrimSpace(m[3]))
+
+		switch {
+		case tag == "STMTTRN" && closing:
+			if current != nil {
+				records = append(records, *current)
+			}
+			current = nil
+		case tag == "STMTTRN":
+			if current != nil {
+				// a transaction left open by a sloppy SGML statement
+				records = append(records, *current)
+			}
+			current = &Record{Currency: currency}
+		case tag == "PAYEE":
+			inPayee = !closing
+		case tag == "ORIGCURRENCY":
+			inOrig = !closing
+		case closing:
+		case tag == "CURDEF":
+			currency = value
+		case current == nil || inOrig:
+		case tag == "FITID":
+			current.ID = value
+		case tag == "DTPOSTED":
+			if len(value) < 8 {
+				return nil, fmt.Errorf("invalid DTPOSTED %q", value)
+			}
+			if current.Date, err = time.Parse("20060102", value[:8]); err != nil {
+				return nil, fmt.Errorf("invalid DTPOSTED %q", value)
+			}
+		case tag == "TRNAMT":
+			if current.Amount, err = parseAmount(value, strings.Contains(value, ",") && !strings.Contains(value, ".")); err != nil {
+				return nil, err
+			}
+		case tag == "NAME" && (inPayee || current.Payee == ""):
+			current.Payee = value
+		case tag == "MEMO":
+			current.Memo = value
+		case tag == "CURSYM":
+			// the CURRENCY aggregate of a transaction in another currency than the statement's
+			current.Currency = value
+		}
+	}
+
+	if current != nil {
+		// SGML statements may leave the last transaction open
+		records = append(records, *current)
+	}
+	for _, record := range records {
+		if record.Date.IsZero() {
+			return nil, fmt.Errorf("transaction %q has no DTPOSTED", record.ID)
+		}
+	}
+	return records, nil
+}
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
@@ -19,13 +20,26 @@ const (
 	InMemoryStorage   StorageType = "memory"
 )
 
+// ErrNotFound is wrapped by the errors of the storage for objects which don't exist
+var ErrNotFound = errors.New("not found")
+
 // Storage is an interface for working with the database
 type Storage interface {
 	Close(ctx context.Context) error
 	Ping(ctx context.Context) error
+	// CheckWrite writes into the storage and reverts the write, failing if the storage can't be written to
+	CheckWrite(ctx context.Context) error
+	// CheckPartitioning fails when the tables are partitioned differently from the partitioning option
+	CheckPartitioning(ctx context.Context) error
+	// Repartition rebuilds the tables partitioned differently from the partitioning option and returns their names
+	Repartition(ctx context.Context) ([]string, error)
 
 	SaveSyncStatus(ctx context.Context, status SyncStatus) error
 	GetLastSyncStatus(ctx context.Context) (SyncStatus, error)
+	// ListSyncStatuses lists sync statuses in the order they were saved
+	ListSyncStatuses(ctx context.Context, filter Filter) ([]SyncStatus, error)
+	// ListRecentSyncStatuses lists the last sync statuses newest first, only the ones of the given status unless it's empty
+	ListRecentSyncStatuses(ctx context.Context, limit int, status string) ([]SyncStatus, error)
 
 	Save(ctx context.Context, response *models.Response) error
 
@@ -42,6 +56,30 @@ type Storage interface {
 	SaveTransactions(ctx context.Context, transactions []models.Transaction) error
 
 	DeleteObjects(ctx context.Context, deletions []models.Deletion) error
+	// ListDeletionHistory lists the deletions recorded by DeleteObjects in the order they were recorded
+	ListDeletionHistory(ctx context.Context, filter Filter) ([]DeletionRecord, error)
+	// SaveDeletionHistory records deletions without removing any objects, e.g. when restoring a backup
+	SaveDeletionHistory(ctx context.Context, records []DeletionRecord) error
+	// PurgeDeleted permanently removes tombstones deleted before the given time
+	// and returns the number of purged rows per entity
+	PurgeDeleted(ctx context.Context, before time.Time) (map[string]int64, error)
+	// FindOrphans returns references to objects which are missing in the storage
+	FindOrphans(ctx context.Context) ([]Orphan, error)
+	// FindDuplicates returns groups of rows which store the same object more than once,
+	// rows with the same content but different ids are marked as warnings
+	FindDuplicates(ctx context.Context) ([]Duplicate, error)
+	// SchemaDrift returns keys of the stored raw payloads which aren't mapped to columns
+	SchemaDrift(ctx context.Context) ([]Drift, error)
+	// TableStats returns the row counts, date ranges, rows per user and sizes of the tables
+	TableStats(ctx context.Context) ([]TableStats, error)
+
+	// ListImportedRecords lists imported bank statement lines, by date within the filter's date range
+	ListImportedRecords(ctx context.Context, filter Filter) ([]ImportedRecord, error)
+	// SaveImportedRecords saves imported bank statement lines, replacing lines of the same account and external ID
+	SaveImportedRecords(ctx context.Context, records []ImportedRecord) error
+
+	// Get methods read the current version of an object, to read one as of a time
+	// list its entity with Filter.AsOf
 
 	GetInstrument(ctx context.Context, id int) (*models.Instrument, error)
 	ListInstruments(ctx context.Context, filter Filter) ([]models.Instrument, error)
@@ -85,7 +123,8 @@ type Storage interface {
 	UpdateMerchant(ctx context.Context, merchant *models.Merchant) error
 	DeleteMerchant(ctx context.Context, id string) error
 
-	GetBudget(ctx context.Context, userID int, tagID string, date time.Time) (*models.Budget, error)
+	// GetBudget returns the budget of a tag in the month starting at date, the total budget of the month for a nil tagID
+	GetBudget(ctx context.Context, userID int, tagID *string, date time.Time) (*models.Budget, error)
 	ListBudgets(ctx context.Context, filter Filter) ([]models.Budget, error)
 	CreateBudget(ctx context.Context, budget *models.Budget) error
 	UpdateBudget(ctx context.Context, budget *models.Budget) error
@@ -117,6 +156,11 @@ type Filter struct {
 	EndDate   *time.Time `json:"endDate,omitempty"`
 	Page      int        `json:"page"`
 	Limit     int        `json:"limit"`
+	// IncludeDeleted lists soft deleted objects along with the live ones
+	IncludeDeleted bool `json:"includeDeleted,omitempty"`
+	// AsOf lists objects as they were at the given time, it requires the history mode.
+	// Only List methods take a filter, Get methods always read the current version
+	AsOf *time.Time `json:"asOf,omitempty"`
 }
 
 // SyncStatus is a status of the synchronization process
@@ -132,3 +176,44 @@ type SyncStatus struct {
 	CreatedAt        time.Time
 	UpdatedAt        time.Time
 }
+
+// TableStats describes the contents of a storage table
+type TableStats struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+	// Size is the size of the table with its indexes and partitions in bytes
+	Size int64 `json:"size_bytes"`
+	// From and To are the range of the table's date column, nil if it has no dates
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+	// Users counts the rows per user of tables with a user column
+	Users map[int]int64 `json:"users,omitempty"`
+}
+
+// ImportedRecord is a line of a bank statement imported into an account
+type ImportedRecord struct {
+	Account string
+	// ExternalID identifies the line within the account: the bank's ID, or a hash of the line without one
+	ExternalID string
+	Date       time.Time
+	// Amount is signed, negative for money leaving the account
+	Amount   float64
+	Currency string
+	Payee    string
+	Memo     string
+	// Source is the statement format, e.g. csv, ofx or mt940
+	Source string
+	// TransactionID is the transaction the line matched or was created as, nil if it's kept for review
+	TransactionID *string
+	ImportedAt    time.Time
+}
+
+// DeletionRecord is a deletion of an object recorded in the deletion history
+type DeletionRecord struct {
+	ID         int64
+	ObjectID   string
+	ObjectType string
+	UserID     int
+	DeletedAt  time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,29 @@
+package interfaces
+
+import "context"
+
+// MigrationDriver is an interface for applying schema migrations to a storage backend.
+// The driver keeps track of the applied version in a version table of the backend.
+type MigrationDriver interface {
+	// Lock acquires an exclusive migration lock, so concurrent runs don't interleave
+	Lock(ctx context.Context) error
+	// Unlock releases the lock acquired with Lock
+	Unlock(ctx context.Context) error
+
+	// Version returns the current schema version. Zero means no migrations were applied.
+	// Dirty is true when a previous migration failed halfway and needs manual fixing.
+	Version(ctx context.Context) (version uint64, dirty bool, err error)
+	// SetVersion overwrites the recorded schema version without running any script
+	SetVersion(ctx context.Context, version uint64, dirty bool) error
+	// Apply runs the migration script and records the resulting version
+	Apply(ctx context.Context, script string, version uint64) error
+
+	Close(ctx context.Context) error
+}
+
+// MigrationStatus is a state of a single migration
+type MigrationStatus struct {
+	Version uint64 `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
@@ -0,0 +1,138 @@
+package interfaces
+
+// OrphanMode defines how Save handles references to objects missing in the storage
+type OrphanMode string
+
+const (
+	// OrphanModeFail makes Save fail on a dangling reference
+	OrphanModeFail OrphanMode = "fail"
+	// OrphanModeReport logs dangling references and leaves out the objects of the sync which add them,
+	// so the rest of the sync is saved and no stored reference is changed
+	OrphanModeReport OrphanMode = "report"
+)
+
+// DeleteMode defines how deleted objects are removed from the storage
+type DeleteMode string
+
+const (
+	// DeleteModeHard removes rows of deleted objects
+	DeleteModeHard DeleteMode = "hard"
+	// DeleteModeSoft keeps rows of deleted objects as tombstones marked with deleted_at
+	DeleteModeSoft DeleteMode = "soft"
+)
+
+// Partitioning defines how the transaction and reminder_marker tables are partitioned by date
+type Partitioning string
+
+const (
+	// PartitioningNone keeps plain tables
+	PartitioningNone Partitioning = ""
+	// PartitioningMonth keeps a partition per month
+	PartitioningMonth Partitioning = "month"
+	// PartitioningYear keeps a partition per year
+	PartitioningYear Partitioning = "year"
+)
+
+// StorageOptions are optional settings of a storage
+type StorageOptions struct {
+	OrphanMode OrphanMode
+	DeleteMode DeleteMode
+	// History keeps previous versions of changed and deleted objects in history tables
+	History bool
+	// Schema is the database schema of the tables, empty for the default one
+	Schema string
+	// TablePrefix is prepended to the names of all tables
+	TablePrefix string
+	// Partitioning is the partition granularity of transaction and reminder_marker
+	Partitioning Partitioning
+}
+
+// StorageOption configures StorageOptions
+type StorageOption func(*StorageOptions)
+
+// NewStorageOptions applies options on top of the defaults
+func NewStorageOptions(opts ...StorageOption) StorageOptions {
+	options := StorageOptions{
+		OrphanMode: OrphanModeFail,
+		DeleteMode: DeleteModeHard,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithOrphanMode sets how Save handles dangling references
+func WithOrphanMode(mode OrphanMode) StorageOption {
+	return func(o *StorageOptions) {
+		if mode != "" {
+			o.OrphanMode = mode
+		}
+	}
+}
+
+// WithDeleteMode sets how deleted objects are removed
+func WithDeleteMode(mode DeleteMode) StorageOption {
+	return func(o *StorageOptions) {
+		if mode != "" {
+			o.DeleteMode = mode
+		}
+	}
+}
+
+// WithHistory enables the change history of synced objects
+func WithHistory(enabled bool) StorageOption {
+	return func(o *StorageOptions) {
+		o.History = enabled
+	}
+}
+
+// WithSchema sets the database schema of the tables
+func WithSchema(schema string) StorageOption {
+	return func(o *StorageOptions) {
+		o.Schema = schema
+	}
+}
+
+// WithTablePrefix sets the prefix of the table names
+func WithTablePrefix(prefix string) StorageOption {
+	return func(o *StorageOptions) {
+		o.TablePrefix = prefix
+	}
+}
+
+// WithPartitioning sets the partition granularity of transaction and reminder_marker
+func WithPartitioning(partitioning Partitioning) StorageOption {
+	return func(o *StorageOptions) {
+		o.Partitioning = partitioning
+	}
+}
+
+// Orphan is a reference to an object missing in the storage
+type Orphan struct {
+	Entity    string `json:"entity"`
+	ID        string `json:"id"`
+	Field     string `json:"field"`
+	Reference string `json:"reference"`
+	MissingID string `json:"missingId"`
+}
+
+// Duplicate is a group of rows which store the same object
+type Duplicate struct {
+	Entity string `json:"entity"`
+	Reason string `json:"reason"`
+	// IDs are the ids of the rows, a single one if the same id is stored several times
+	IDs  []string `json:"ids"`
+	Rows int64    `json:"rows"`
+	// Warning marks rows with the same content but different ids, which may as well be distinct objects,
+	// e.g. two equal purchases on one day
+	Warning bool `json:"warning"`
+}
+
+// Drift is a key of raw payloads without a column of its own
+type Drift struct {
+	Entity string `json:"entity"`
+	Key    string `json:"key"`
+	Column string `json:"column"`
+	Rows   int64  `json:"rows"`
+}
@@ -0,0 +1,388 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+)
+
+// ErrNoChange is returned when there are no migrations to apply
+var ErrNoChange = errors.New("no change")
+
+// fileNamePattern matches migration file names like 000001_init.up.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// VersionMismatchError is returned when the storage schema doesn't match the migrations known to the binary
+type VersionMismatchError struct {
+	Expected uint64
+	Actual   uint64
+	Dirty    bool
+}
+
+func (e *VersionMismatchError) Error() string {
+	switch {
+	case e.Dirty:
+		return fmt.Sprintf(
+			"database schema is dirty at version %d (expected %d): fix the schema and run `zenexport migrate force`",
+			e.Actual, e.Expected)
+	case e.Actual > e.Expected:
+		return fmt.Sprintf(
+			"database schema version %d is newer than expected %d: upgrade zenexport",
+			e.Actual, e.Expected)
+	default:
+		return fmt.Sprintf(
+			"database schema version %d is older than expected %d: run `zenexport migrate up` or set migrate_mode to auto",
+			e.Actual, e.Expected)
+	}
+}
+
+// Migration is a single schema migration with its up and down scripts
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Runner applies migrations from a source to a storage through a driver
+type Runner struct {
+	driver     interfaces.MigrationDriver
+	migrations []Migration
+}
+
+// NewRunner creates a new runner with migrations loaded from the source file system
+func NewRunner(driver interfaces.MigrationDriver, source fs.FS) (*Runner, error) {
+	migrations, err := Load(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Runner{
+		driver:     driver,
+		migrations: migrations,
+	}, nil
+}
+
+// Load reads migration files from the root of the file system and returns them sorted by version
+func Load(source fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+
+		body, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest returns the version of the newest known migration
+func (r *Runner) Latest() uint64 {
+	if len(r.migrations) == 0 {
+		return 0
+	}
+	return r.migrations[len(r.migrations)-1].Version
+}
+
+// Version returns the current schema version of the storage
+func (r *Runner) Version(ctx context.Context) (uint64, bool, error) {
+	return r.driver.Version(ctx)
+}
+
+// Check returns VersionMismatchError if the storage schema is not at the latest version
+func (r *Runner) Check(ctx context.Context) error {
+	current, dirty, err := r.driver.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if dirty || current != r.Latest() {
+		return &VersionMismatchError{Expected: r.Latest(), Actual: current, Dirty: dirty}
+	}
+	return nil
+}
+
+// Status returns the list of known migrations with their applied state
+func (r *Runner) Status(ctx context.Context) ([]interfaces.MigrationStatus, error) {
+	current, _, err := r.driver.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]interfaces.MigrationStatus, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		status = append(status, interfaces.MigrationStatus{
+			Version: m.Version,
+			Name:    m.Name,
+			Applied: m.Version <= current,
+		})
+	}
+
+	return status, nil
+}
+
+// Up applies n pending migrations. If n is zero or less, all pending migrations are applied.
+func (r *Runner) Up(ctx context.Context, n int) error {
+	return r.locked(ctx, func(current uint64) error {
+		pending := r.pending(current)
+		if len(pending) == 0 {
+			return ErrNoChange
+		}
+		if n > 0 && n < len(pending) {
+			pending = pending[:n]
+		}
+
+		for _, m := range pending {
+			if err := r.apply(ctx, m.Up, m.Version, "up", m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down reverts n applied migrations. If n is zero or less, all applied migrations are reverted.
+func (r *Runner) Down(ctx context.Context, n int) error {
+	return r.locked(ctx, func(current uint64) error {
+		applied := r.applied(current)
+		if len(applied) == 0 {
+			return ErrNoChange
+		}
+		if n > 0 && n < len(applied) {
+			applied = applied[:n]
+		}
+
+		for _, m := range applied {
+			if err := r.apply(ctx, m.Down, r.previous(m.Version), "down", m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Goto migrates the storage up or down to the given version
+func (r *Runner) Goto(ctx context.Context, version uint64) error {
+	return r.goTo(ctx, version, "")
+}
+
+// UpTo applies the pending migrations up to the given version, it fails if the version is below the current one
+func (r *Runner) UpTo(ctx context.Context, version uint64) error {
+	return r.goTo(ctx, version, "up")
+}
+
+// DownTo reverts the applied migrations down to the given version, it fails if the version is above the current one
+func (r *Runner) DownTo(ctx context.Context, version uint64) error {
+	return r.goTo(ctx, version, "down")
+}
+
+// goTo migrates to the given version, only in the given direction unless it's empty
+func (r *Runner) goTo(ctx context.Context, version uint64, direction string) error {
+	if version != 0 && r.find(version) == nil {
+		return fmt.Errorf("migration %d not found", version)
+	}
+
+	return r.locked(ctx, func(current uint64) error {
+		switch {
+		case version == current:
+			return ErrNoChange
+		case direction == "up" && version < current:
+			return fmt.Errorf("version %d is below the current version %d: use `migrate down` to revert migrations",
+				version, current)
+		case direction == "down" && version > current:
+			return fmt.Errorf("version %d is above the current version %d: use `migrate up` to apply migrations",
+				version, current)
+		case version > current:
+			for _, m := range r.pending(current) {
+				if m.Version > version {
+					break
+				}
+				if err := r.apply(ctx, m.Up, m.Version, "up", m); err != nil {
+					return err
+				}
+			}
+		default:
+			for _, m := range r.applied(current) {
+				if m.Version <= version {
+					break
+				}
+				if err := r.apply(ctx, m.Down, r.previous(m.Version), "down", m); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Force sets the schema version without running migrations and clears the dirty flag
+func (r *Runner) Force(ctx context.Context, version uint64) error {
+	if version != 0 && r.find(version) == nil {
+		return fmt.Errorf("migration %d not found", version)
+	}
+
+	if err := r.driver.Lock(ctx); err != nil {
+		return err
+	}
+	defer r.unlock(ctx)
+
+	return r.driver.SetVersion(ctx, version, false)
+}
+
+// locked runs fn under the migration lock with the current clean schema version
+func (r *Runner) locked(ctx context.Context, fn func(current uint64) error) error {
+	if err := r.driver.Lock(ctx); err != nil {
+		return err
+	}
+	defer r.unlock(ctx)
+
+	current, dirty, err := r.driver.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("database is dirty at version %d: fix the schema manually and run `migrate force`", current)
+	}
+
+	return fn(current)
+}
+
+func (r *Runner) unlock(ctx context.Context) {
+	if err := r.driver.Unlock(ctx); err != nil {
+		slog.Error("failed to release migration lock", "error", err)
+	}
+}
+
+func (r *Runner) apply(ctx context.Context, script string, version uint64, direction string, m Migration) error {
+	slog.Info("Applying migration", "version", m.Version, "name", m.Name, "direction", direction)
+
+	if err := r.driver.Apply(ctx, script, version); err != nil {
+		return fmt.Errorf("failed to apply migration %d_%s (%s): %w", m.Version, m.Name, direction, err)
+	}
+	return nil
+}
+
+// pending returns migrations newer than current in ascending order
+func (r *Runner) pending(current uint64) []Migration {
+	var pending []Migration
+	for _, m := range r.migrations {
+		if m.Version > current {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// applied returns migrations up to current in descending order
+func (r *Runner) applied(current uint64) []Migration {
+	var applied []Migration
+	for i := len(r.migrations) - 1; i >= 0; i-- {
+		if r.migrations[i].Version <= current {
+			applied = append(applied, r.migrations[i])
+		}
+	}
+	return applied
+}
+
+// previous returns the version that precedes the given one, or zero for the first migration
+func (r *Runner) previous(version uint64) uint64 {
+	var prev uint64
+	for _, m := range r.migrations {
+		if m.Version >= version {
+			break
+		}
+		prev = m.Version
+	}
+	return prev
+}
+
+func (r *Runner) find(version uint64) *Migration {
+	for i := range r.migrations {
+		if r.migrations[i].Version == version {
+			return &r.migrations[i]
+		}
+	}
+	return nil
+}
+
+// Create writes an empty pair of up and down migration files with the next version into dir
+func Create(dir string, name string) (string, string, error) {
+	if !regexp.MustCompile(`^[a-z0-9_]+$`).MatchString(name) {
+		return "", "", fmt.Errorf("invalid migration name %q: use lowercase letters, digits and underscores", name)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", fmt.Errorf("failed to create migrations directory: %w", err)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	var version uint64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%06d_%s", version, name))
+	up, down := base+".up.sql", base+".down.sql"
+	for _, path := range []string{up, down} {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			return "", "", fmt.Errorf("failed to create migration file: %w", err)
+		}
+	}
+
+	return up, down, nil
+}
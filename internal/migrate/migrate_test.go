@@ -0,0 +1,266 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDriver records applied scripts in memory
+type fakeDriver struct {
+	version  uint64
+	dirty    bool
+	locked   bool
+	scripts  []string
+	applyErr error
+}
+
+func (d *fakeDriver) Lock(ctx context.Context) error {
+	d.locked = true
+	return nil
+}
+
+func (d *fakeDriver) Unlock(ctx context.Context) error {
+	d.locked = false
+	return nil
+}
+
+func (d *fakeDriver) Version(ctx context.Context) (uint64, bool, error) {
+	return d.version, d.dirty, nil
+}
+
+func (d *fakeDriver) SetVersion(ctx context.Context, version uint64, dirty bool) error {
+	d.version, d.dirty = version, dirty
+	return nil
+}
+
+func (d *fakeDriver) Apply(ctx context.Context, script string, version uint64) error {
+	if d.applyErr != nil {
+		return d.applyErr
+	}
+	d.scripts = append(d.scripts, script)
+	d.version = version
+	return nil
+}
+
+func (d *fakeDriver) Close(ctx context.Context) error {
+	return nil
+}
+
+func testSource() fstest.MapFS {
+	return fstest.MapFS{
+		"000001_init.up.sql":      {Data: []byte("up1")},
+		"000001_init.down.sql":    {Data: []byte("down1")},
+		"000002_budget.up.sql":    {Data: []byte("up2")},
+		"000002_budget.down.sql":  {Data: []byte("down2")},
+		"000010_indexes.up.sql":   {Data: []byte("up10")},
+		"000010_indexes.down.sql": {Data: []byte("down10")},
+		"README.md":               {Data: []byte("not a migration")},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testSource())
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+
+	assert.Equal(t, Migration{Version: 1, Name: "init", Up: "up1", Down: "down1"}, migrations[0])
+	assert.Equal(t, uint64(2), migrations[1].Version)
+	assert.Equal(t, uint64(10), migrations[2].Version)
+}
+
+func TestLoad_NameMismatch(t *testing.T) {
+	_, err := Load(fstest.MapFS{
+		"000001_init.up.sql":    {Data: []byte("up")},
+		"000001_other.down.sql": {Data: []byte("down")},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "different names")
+}
+
+func TestRunner_Up(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("all pending", func(t *testing.T) {
+		driver := &fakeDriver{version: 1}
+		r, err := NewRunner(driver, testSource())
+		require.NoError(t, err)
+
+		require.NoError(t, r.Up(ctx, 0))
+		assert.Equal(t, []string{"up2", "up10"}, driver.scripts)
+		assert.Equal(t, uint64(10), driver.version)
+		assert.False(t, driver.locked)
+	})
+
+	t.Run("n steps", func(t *testing.T) {
+		driver := &fakeDriver{}
+		r, err := NewRunner(driver, testSource())
+		require.NoError(t, err)
+
+		require.NoError(t, r.Up(ctx, 2))
+		assert.Equal(t, []string{"up1", "up2"}, driver.scripts)
+		assert.Equal(t, uint64(2), driver.version)
+	})
+
+	t.Run("no change", func(t *testing.T) {
+		driver := &fakeDriver{version: 10}
+		r, err := NewRunner(driver, testSource())
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, r.Up(ctx, 0), ErrNoChange)
+	})
+
+	t.Run("dirty database", func(t *testing.T) {
+		driver := &fakeDriver{version: 2, dirty: true}
+		r, err := NewRunner(driver, testSource())
+		require.NoError(t, err)
+
+		err = r.Up(ctx, 0)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "dirty")
+		assert.Empty(t, driver.scripts)
+	})
+
+	t.Run("apply error", func(t *testing.T) {
+		driver := &fakeDriver{applyErr: errors.New("syntax error")}
+		r, err := NewRunner(driver, testSource())
+		require.NoError(t, err)
+
+		err = r.Up(ctx, 0)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to apply migration 1_init (up)")
+		assert.False(t, driver.locked)
+	})
+}
+
+func TestRunner_Down(t *testing.T) {
+	ctx := context.Background()
+
+	driver := &fakeDriver{version: 10}
+	r, err := NewRunner(driver, testSource())
+	require.NoError(t, err)
+
+	require.NoError(t, r.Down(ctx, 1))
+	assert.Equal(t, []string{"down10"}, driver.scripts)
+	assert.Equal(t, uint64(2), driver.version)
+
+	require.NoError(t, r.Down(ctx, 0))
+	assert.Equal(t, []string{"down10", "down2", "down1"}, driver.scripts)
+	assert.Equal(t, uint64(0), driver.version)
+}
+
+func TestRunner_Goto(t *testing.T) {
+	ctx := context.Background()
+
+	driver := &fakeDriver{}
+	r, err := NewRunner(driver, testSource())
+	require.NoError(t, err)
+
+	require.NoError(t, r.Goto(ctx, 2))
+	assert.Equal(t, uint64(2), driver.version)
+
+	require.NoError(t, r.Goto(ctx, 1))
+	assert.Equal(t, []string{"up1", "up2", "down2"}, driver.scripts)
+	assert.Equal(t, uint64(1), driver.version)
+
+	assert.ErrorIs(t, r.Goto(ctx, 1), ErrNoChange)
+	assert.Error(t, r.Goto(ctx, 5))
+}
+
+func TestRunner_UpTo(t *testing.T) {
+	ctx := context.Background()
+
+	driver := &fakeDriver{version: 2}
+	r, err := NewRunner(driver, testSource())
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, r.UpTo(ctx, 0), "below the current version 2")
+	assert.ErrorContains(t, r.UpTo(ctx, 1), "below the current version 2")
+	assert.Empty(t, driver.scripts)
+	assert.Equal(t, uint64(2), driver.version)
+
+	assert.ErrorIs(t, r.UpTo(ctx, 2), ErrNoChange)
+	require.NoError(t, r.UpTo(ctx, 10))
+	assert.Equal(t, []string{"up10"}, driver.scripts)
+	assert.Equal(t, uint64(10), driver.version)
+}
+
+func TestRunner_DownTo(t *testing.T) {
+	ctx := context.Background()
+
+	driver := &fakeDriver{version: 2}
+	r, err := NewRunner(driver, testSource())
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, r.DownTo(ctx, 10), "above the current version 2")
+	assert.Empty(t, driver.scripts)
+	assert.Equal(t, uint64(2), driver.version)
+
+	require.NoError(t, r.DownTo(ctx, 0))
+	assert.Equal(t, []string{"down2", "down1"}, driver.scripts)
+	assert.Equal(t, uint64(0), driver.version)
+}
+
+func TestRunner_Status(t *testing.T) {
+	driver := &fakeDriver{version: 2}
+	r, err := NewRunner(driver, testSource())
+	require.NoError(t, err)
+
+	status, err := r.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, status, 3)
+	assert.True(t, status[0].Applied)
+	assert.True(t, status[1].Applied)
+	assert.False(t, status[2].Applied)
+	assert.Equal(t, uint64(10), r.Latest())
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	up, down, err := Create(dir, "add_index")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "000001_add_index.up.sql"), up)
+	assert.Equal(t, filepath.Join(dir, "000001_add_index.down.sql"), down)
+
+	up, _, err = Create(dir, "next")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "000002_next.up.sql"), up)
+
+	_, err = os.Stat(up)
+	assert.NoError(t, err)
+
+	_, _, err = Create(dir, "Bad Name")
+	assert.Error(t, err)
+}
+
+func TestRunner_Check(t *testing.T) {
+	ctx := context.Background()
+
+	r, err := NewRunner(&fakeDriver{version: 10}, testSource())
+	require.NoError(t, err)
+	assert.NoError(t, r.Check(ctx))
+
+	r, err = NewRunner(&fakeDriver{version: 2}, testSource())
+	require.NoError(t, err)
+	err = r.Check(ctx)
+	var mismatch *VersionMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.Equal(t, uint64(10), mismatch.Expected)
+	assert.Equal(t, uint64(2), mismatch.Actual)
+	assert.Contains(t, err.Error(), "older than expected 10")
+
+	r, err = NewRunner(&fakeDriver{version: 11}, testSource())
+	require.NoError(t, err)
+	assert.ErrorContains(t, r.Check(ctx), "newer than expected")
+
+	r, err = NewRunner(&fakeDriver{version: 10, dirty: true}, testSource())
+	require.NoError(t, err)
+	assert.ErrorContains(t, r.Check(ctx), "dirty")
+}
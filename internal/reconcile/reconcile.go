@@ -0,0 +1,349 @@
+// Package reconcile recomputes account balances from the start balance and the transactions
+// and compares them with the balances ZenMoney reports
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+)
+
+// Status is the outcome of the reconciliation of an account
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDiverged Status = "diverged"
+	// StatusCorrected is a difference on an account ZenMoney adjusts to the bank's balance on its own
+	StatusCorrected Status = "corrected"
+	// StatusSkipped is an account whose balance isn't the sum of its transactions
+	StatusSkipped Status = "skipped"
+)
+
+// tolerance is the largest difference taken for rounding
+const tolerance = 0.005
+
+const pageSize = 1000
+
+// Options select the accounts to reconcile
+type Options struct {
+	// Account is an account ID or title, all accounts if empty
+	Account string
+	// History looks for the sync after which a balance diverged, it requires the history mode
+	History bool
+}
+
+// Divergence is the first sync after which the computed balance of an account differed from the reported one
+type Divergence struct {
+	SyncID   int64     `json:"sync_id"`
+	SyncedAt time.Time `json:"synced_at"`
+	// Difference is the difference of the balances right after the sync
+	Difference float64 `json:"difference"`
+	// Date is the earliest date of the account's transactions the sync added, changed or removed,
+	// nil if the balance changed without any of them, e.g. for a transaction which never arrived
+	Date *time.Time `json:"date,omitempty"`
+}
+
+// Result is the reconciliation of an account
+type Result struct {
+	Account        string  `json:"account"`
+	Title          string  `json:"title"`
+	Type           string  `json:"type"`
+	CorrectionType string  `json:"balance_correction_type,omitempty"`
+	StartBalance   float64 `json:"start_balance"`
+	Reported       float64 `json:"reported_balance"`
+	Computed       float64 `json:"computed_balance"`
+	// Difference is the reported balance less the computed one
+	Difference   float64     `json:"difference"`
+	Transactions int         `json:"transactions"`
+	Status       Status      `json:"status"`
+	Note         string      `json:"note,omitempty"`
+	DivergedAt   *Divergence `json:"diverged_at,omitempty"`
+}
+
+// entry is what a transaction adds to the balance of an account
+type entry struct {
+	date   string
+	amount float64
+}
+
+// snapshot holds the accounts and their transactions at a point in time
+type snapshot struct {
+	accounts map[string]models.Account
+	// entries maps account IDs to the entries of their transactions by transaction ID
+	entries map[string]map[string]entry
+}
+
+// computed returns start_balance plus the entries of the account
+func (s *snapshot) computed(id string) float64 {
+	balance := value(s.accounts[id].StartBalance)
+	for _, e := range s.entries[id] {
+		balance += e.amount
+	}
+	return balance
+}
+
+// difference returns the reported balance of the account less the computed one, zero if it didn't exist
+func (s *snapshot) difference(id string) float64 {
+	account, ok := s.accounts[id]
+	if !ok {
+		return 0
+	}
+	return value(account.Balance) - s.computed(id)
+}
+
+// Reconcile compares the reported and the computed balances of the accounts ordered by title
+func Reconcile(ctx context.Context, storage interfaces.Storage, opts Options) ([]Result, error) {
+	current, err := load(ctx, storage, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []models.Account
+	for _, account := range current.accounts {
+		if opts.Account == "" || account.ID == opts.Account || strings.EqualFold(account.Title, opts.Account) {
+			accounts = append(accounts, account)
+		}
+	}
+	if len(accounts) == 0 && opts.Account != "" {
+		return nil, fmt.Errorf("account not found: %s", opts.Account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Title != accounts[j].Title {
+			return accounts[i].Title < accounts[j].Title
+		}
+		return accounts[i].ID < accounts[j].ID
+	})
+
+	var search *searcher
+	if opts.History {
+		if search, err = newSearcher(ctx, storage); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]Result, 0, len(accounts))
+	for _, account := range accounts {
+		result := Result{
+			Account:        account.ID,
+			Title:          account.Title,
+			Type:           account.Type,
+			CorrectionType: account.BalanceCorrectionType,
+			StartBalance:   round(value(account.StartBalance)),
+			Reported:       round(value(account.Balance)),
+			Computed:       round(current.computed(account.ID)),
+			Difference:     round(current.difference(account.ID)),
+			Transactions:   len(current.entries[account.ID]),
+		}
+
+		switch {
+		case account.Type == "debt" || account.Type == "loan":
+			result.Status = StatusSkipped
+			result.Note = "ZenMoney doesn't keep the balance of " + account.Type + " accounts as the sum of their transactions"
+		case math.Abs(current.difference(account.ID)) < tolerance:
+			result.Status = StatusOK
+		case account.EnableCorrection && account.BalanceCorrectionType != "request":
+			result.Status = StatusCorrected
+			result.Note = "ZenMoney adjusts the balance to the bank's without a transaction, balance_correction_type " +
+				account.BalanceCorrectionType
+		default:
+			result.Status = StatusDiverged
+			if search == nil {
+				result.Note = "no date can be given for the divergence without the history mode, see HISTORY"
+				break
+			}
+			result.DivergedAt, result.Note, err = search.find(ctx, account.ID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// load reads the accounts and the transactions, as they were at asOf unless it's nil
+func load(ctx context.Context, storage interfaces.Storage, asOf *time.Time) (*snapshot, error) {
+	s := &snapshot{
+		accounts: make(map[string]models.Account),
+		entries:  make(map[string]map[string]entry),
+	}
+
+	for page := 1; ; page++ {
+		accounts, err := storage.ListAccounts(ctx, interfaces.Filter{Page: page, Limit: pageSize, AsOf: asOf})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list accounts: %w", err)
+		}
+		for _, account := range accounts {
+			s.accounts[account.ID] = account
+		}
+		if len(accounts) < pageSize {
+			break
+		}
+	}
+
+	add := func(account, tx string, e entry) {
+		if s.entries[account] == nil {
+			s.entries[account] = make(map[string]entry)
+		}
+		// a transaction with both sides in one account adds its income and outcome
+		prev := s.entries[account][tx]
+		s.entries[account][tx] = entry{date: e.date, amount: prev.amount + e.amount}
+	}
+	for page := 1; ; page++ {
+		txs, err := storage.ListTransactions(ctx, interfaces.Filter{Page: page, Limit: pageSize, AsOf: asOf})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list transactions: %w", err)
+		}
+		for _, tx := range txs {
+			if tx.Deleted {
+				continue
+			}
+			outcomeAccount := tx.IncomeAccount
+			if tx.OutcomeAccount != nil {
+				outcomeAccount = *tx.OutcomeAccount
+			}
+			if tx.IncomeAccount != "" {
+				add(tx.IncomeAccount, tx.ID, entry{date: tx.Date, amount: tx.Income})
+			}
+			if outcomeAccount != "" {
+				add(outcomeAccount, tx.ID, entry{date: tx.Date, amount: -tx.Outcome})
+			}
+		}
+		if len(txs) < pageSize {
+			break
+		}
+	}
+
+	return s, nil
+}
+
+// searcher finds the first completed sync after which the balance of an account diverged.
+// Snapshots are loaded as of the server time of the syncs and shared between accounts.
+type searcher struct {
+	storage   interfaces.Storage
+	syncs     []interfaces.SyncStatus
+	snapshots map[int]*snapshot
+}
+
+func newSearcher(ctx context.Context, storage interfaces.Storage) (*searcher, error) {
+	s := &searcher{storage: storage, snapshots: make(map[int]*snapshot)}
+	for page := 1; ; page++ {
+		statuses, err := storage.ListSyncStatuses(ctx, interfaces.Filter{Page: page, Limit: pageSize})
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range statuses {
+			if status.Status == "completed" && status.ServerTimestamp > 0 {
+				s.syncs = append(s.syncs, status)
+			}
+		}
+		if len(statuses) < pageSize {
+			break
+		}
+	}
+	sort.SliceStable(s.syncs, func(i, j int) bool {
+		return s.syncs[i].ServerTimestamp < s.syncs[j].ServerTimestamp
+	})
+	return s, nil
+}
+
+// snapshot returns the state right after the i-th sync, an empty one before the first sync
+func (s *searcher) snapshot(ctx context.Context, i int) (*snapshot, error) {
+	if i < 0 {
+		return &snapshot{}, nil
+	}
+	if snap, ok := s.snapshots[i]; ok {
+		return snap, nil
+	}
+	asOf := time.Unix(s.syncs[i].ServerTimestamp, 0)
+	snap, err := load(ctx, s.storage, &asOf)
+	if err != nil {
+		return nil, err
+	}
+	s.snapshots[i] = snap
+	return snap, nil
+}
+
+// find returns the first sync after which the balances of the account differed, or a note why there is none.
+// The syncs are scanned from the oldest, as balances may differ and match again, e.g. when a lost transaction
+// arrives with a later sync.
+func (s *searcher) find(ctx context.Context, account string) (*Divergence, string, error) {
+	if len(s.syncs) == 0 {
+		return nil, "no completed sync is recorded", nil
+	}
+
+	lo := 0
+	for ; lo < len(s.syncs); lo++ {
+		snap, err := s.snapshot(ctx, lo)
+		if err != nil {
+			return nil, "", err
+		}
+		if math.Abs(snap.difference(account)) >= tolerance {
+			break
+		}
+	}
+	if lo == len(s.syncs) {
+		return nil, "the balances matched after the last sync, they differ by changes made since", nil
+	}
+
+	after, err := s.snapshot(ctx, lo)
+	if err != nil {
+		return nil, "", err
+	}
+	before, err := s.snapshot(ctx, lo-1)
+	if err != nil {
+		return nil, "", err
+	}
+
+	divergence := &Divergence{
+		SyncID:     s.syncs[lo].ID,
+		SyncedAt:   time.Unix(s.syncs[lo].ServerTimestamp, 0).UTC(),
+		Difference: round(after.difference(account)),
+	}
+	var first string
+	for id, e := range after.entries[account] {
+		if prev, ok := before.entries[account][id]; !ok || prev != e {
+			first = earliest(first, e.date)
+			if ok {
+				first = earliest(first, prev.date)
+			}
+		}
+	}
+	for id, e := range before.entries[account] {
+		if _, ok := after.entries[account][id]; !ok {
+			first = earliest(first, e.date)
+		}
+	}
+	if date, err := time.Parse(time.DateOnly, first); err == nil {
+		divergence.Date = &date
+	}
+	return divergence, "", nil
+}
+
+func earliest(a, b string) string {
+	if a == "" || (b != "" && b < a) {
+		return b
+	}
+	return a
+}
+
+func value(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// round rounds an amount to cents, so sums don't show floating-point noise
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
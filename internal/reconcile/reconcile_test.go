@@ -0,0 +1,219 @@
+package reconcile_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/internal/reconcile"
+	"github.com/nemirlev/zenmoney-export/v2/mocks"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func account(id, title, typ string, start, balance float64) models.Account {
+	return models.Account{ID: id, Title: title, Type: typ, StartBalance: &start, Balance: &balance}
+}
+
+func expense(id, date, acc string, amount float64) models.Transaction {
+	return models.Transaction{ID: id, Date: date, IncomeAccount: acc, OutcomeAccount: &acc, Outcome: amount}
+}
+
+func income(id, date, acc string, amount float64) models.Transaction {
+	return models.Transaction{ID: id, Date: date, IncomeAccount: acc, OutcomeAccount: &acc, Income: amount}
+}
+
+func transfer(id, date, from, to string, amount float64) models.Transaction {
+	return models.Transaction{ID: id, Date: date, IncomeAccount: to, OutcomeAccount: &from, Income: amount, Outcome: amount}
+}
+
+// current matches filters without AsOf
+var current = mock.MatchedBy(func(f interfaces.Filter) bool { return f.AsOf == nil })
+
+// asOf matches filters of the state at the server timestamp ts
+func asOf(ts int64) any {
+	return mock.MatchedBy(func(f interfaces.Filter) bool { return f.AsOf != nil && f.AsOf.Unix() == ts })
+}
+
+func TestReconcile(t *testing.T) {
+	corrected := account("acc-4", "Bank", "ccard", 0, 50)
+	corrected.EnableCorrection, corrected.BalanceCorrectionType = true, "auto"
+
+	storage := mocks.NewStorage(t)
+	storage.On("ListAccounts", mock.Anything, current).Return([]models.Account{
+		account("acc-1", "Wallet", "cash", 100, 70.1),
+		account("acc-2", "Card", "ccard", 0, 10),
+		account("acc-3", "Loan", "loan", 1000, -900),
+		corrected,
+	}, nil)
+	deleted := expense("tx-5", "2024-01-05", "acc-1", 1000)
+	deleted.Deleted = true
+	storage.On("ListTransactions", mock.Anything, current).Return([]models.Transaction{
+		expense("tx-1", "2024-01-01", "acc-1", 30.3),
+		income("tx-2", "2024-01-02", "acc-1", 20.4),
+		transfer("tx-3", "2024-01-03", "acc-1", "acc-2", 20),
+		expense("tx-4", "2024-01-04", "acc-2", 15),
+		deleted,
+	}, nil)
+
+	results, err := reconcile.Reconcile(context.Background(), storage, reconcile.Options{})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	byID := make(map[string]reconcile.Result)
+	for _, r := range results {
+		byID[r.Account] = r
+	}
+	assert.Equal(t, []string{"Bank", "Card", "Loan", "Wallet"},
+		[]string{results[0].Title, results[1].Title, results[2].Title, results[3].Title})
+
+	assert.Equal(t, reconcile.StatusOK, byID["acc-1"].Status)
+	assert.Equal(t, 70.1, byID["acc-1"].Computed)
+	assert.Equal(t, 3, byID["acc-1"].Transactions)
+
+	assert.Equal(t, reconcile.StatusDiverged, byID["acc-2"].Status)
+	assert.Equal(t, 5.0, byID["acc-2"].Computed)
+	assert.Equal(t, 5.0, byID["acc-2"].Difference)
+	assert.Nil(t, byID["acc-2"].DivergedAt)
+	assert.Contains(t, byID["acc-2"].Note, "no date can be given")
+
+	assert.Equal(t, reconcile.StatusSkipped, byID["acc-3"].Status)
+	assert.Equal(t, reconcile.StatusCorrected, byID["acc-4"].Status)
+	assert.Equal(t, "auto", byID["acc-4"].CorrectionType)
+}
+
+func TestReconcile_Account(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListAccounts", mock.Anything, current).Return([]models.Account{
+		account("acc-1", "Wallet", "cash", 0, 0),
+		account("acc-2", "Card", "ccard", 0, 0),
+	}, nil)
+	storage.On("ListTransactions", mock.Anything, current).Return(nil, nil)
+
+	results, err := reconcile.Reconcile(context.Background(), storage, reconcile.Options{Account: "card"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "acc-2", results[0].Account)
+
+	_, err = reconcile.Reconcile(context.Background(), storage, reconcile.Options{Account: "Savings"})
+	assert.EqualError(t, err, "account not found: Savings")
+}
+
+func TestReconcile_Divergence(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListSyncStatuses", mock.Anything, mock.Anything).Return([]interfaces.SyncStatus{
+		{ID: 1, Status: "completed", ServerTimestamp: 100},
+		{ID: 2, Status: "failed", ServerTimestamp: 150},
+		{ID: 3, Status: "completed", ServerTimestamp: 200},
+		{ID: 4, Status: "completed", ServerTimestamp: 300},
+		{ID: 5, Status: "completed", ServerTimestamp: 400},
+	}, nil)
+
+	// the sync at 300 saved tx-3 twice under different IDs
+	states := []struct {
+		ts      int64
+		balance float64
+		txs     []models.Transaction
+	}{
+		{100, 90, []models.Transaction{expense("tx-1", "2024-01-10", "acc-1", 10)}},
+		{200, 80, []models.Transaction{expense("tx-1", "2024-01-10", "acc-1", 10), expense("tx-2", "2024-01-12", "acc-1", 10)}},
+		{300, 70, []models.Transaction{
+			expense("tx-1", "2024-01-10", "acc-1", 10), expense("tx-2", "2024-01-12", "acc-1", 10),
+			expense("tx-3", "2024-01-11", "acc-1", 10), expense("tx-3b", "2024-01-11", "acc-1", 10),
+		}},
+		{400, 70, nil},
+	}
+	states[3].txs = states[2].txs
+	// the syncs after the first divergence aren't looked at
+	for _, state := range states {
+		storage.On("ListAccounts", mock.Anything, asOf(state.ts)).
+			Return([]models.Account{account("acc-1", "Wallet", "cash", 100, state.balance)}, nil).Maybe()
+		storage.On("ListTransactions", mock.Anything, asOf(state.ts)).Return(state.txs, nil).Maybe()
+	}
+	storage.On("ListAccounts", mock.Anything, current).
+		Return([]models.Account{account("acc-1", "Wallet", "cash", 100, 70)}, nil)
+	storage.On("ListTransactions", mock.Anything, current).Return(states[2].txs, nil)
+
+	results, err := reconcile.Reconcile(context.Background(), storage, reconcile.Options{History: true})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, reconcile.StatusDiverged, results[0].Status)
+	assert.Equal(t, 10.0, results[0].Difference)
+
+	date := time.Date(2024, 1, 11, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, &reconcile.Divergence{
+		SyncID:     4,
+		SyncedAt:   time.Unix(300, 0).UTC(),
+		Difference: 10,
+		Date:       &date,
+	}, results[0].DivergedAt)
+}
+
+func TestReconcile_FirstDivergence(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListSyncStatuses", mock.Anything, mock.Anything).Return([]interfaces.SyncStatus{
+		{ID: 1, Status: "completed", ServerTimestamp: 100},
+		{ID: 2, Status: "completed", ServerTimestamp: 200},
+		{ID: 3, Status: "completed", ServerTimestamp: 300},
+		{ID: 4, Status: "completed", ServerTimestamp: 400},
+	}, nil)
+
+	// the balances differ after the sync at 200, match again once tx-2 arrives at 300 and differ since 400
+	tx1, tx2 := expense("tx-1", "2024-01-10", "acc-1", 10), expense("tx-2", "2024-01-12", "acc-1", 10)
+	states := []struct {
+		ts      int64
+		balance float64
+		txs     []models.Transaction
+	}{
+		{100, 90, []models.Transaction{tx1}},
+		{200, 80, []models.Transaction{tx1}},
+		{300, 80, []models.Transaction{tx1, tx2}},
+		{400, 70, []models.Transaction{tx1, tx2}},
+	}
+	for _, state := range states[:2] {
+		storage.On("ListAccounts", mock.Anything, asOf(state.ts)).
+			Return([]models.Account{account("acc-1", "Wallet", "cash", 100, state.balance)}, nil)
+		storage.On("ListTransactions", mock.Anything, asOf(state.ts)).Return(state.txs, nil)
+	}
+	storage.On("ListAccounts", mock.Anything, current).
+		Return([]models.Account{account("acc-1", "Wallet", "cash", 100, 70)}, nil)
+	storage.On("ListTransactions", mock.Anything, current).Return(states[3].txs, nil)
+
+	results, err := reconcile.Reconcile(context.Background(), storage, reconcile.Options{History: true})
+	require.NoError(t, err)
+	require.NotNil(t, results[0].DivergedAt)
+	assert.Equal(t, int64(2), results[0].DivergedAt.SyncID)
+	assert.Equal(t, -10.0, results[0].DivergedAt.Difference)
+	assert.Nil(t, results[0].DivergedAt.Date)
+}
+
+func TestReconcile_DivergenceSinceLastSync(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListSyncStatuses", mock.Anything, mock.Anything).Return([]interfaces.SyncStatus{
+		{ID: 1, Status: "completed", ServerTimestamp: 100},
+	}, nil)
+	storage.On("ListAccounts", mock.Anything, asOf(100)).
+		Return([]models.Account{account("acc-1", "Wallet", "cash", 100, 100)}, nil)
+	storage.On("ListTransactions", mock.Anything, asOf(100)).Return(nil, nil)
+	storage.On("ListAccounts", mock.Anything, current).
+		Return([]models.Account{account("acc-1", "Wallet", "cash", 100, 100)}, nil)
+	storage.On("ListTransactions", mock.Anything, current).
+		Return([]models.Transaction{expense("tx-1", "2024-01-10", "acc-1", 10)}, nil)
+
+	results, err := reconcile.Reconcile(context.Background(), storage, reconcile.Options{History: true})
+	require.NoError(t, err)
+	assert.Nil(t, results[0].DivergedAt)
+	assert.Contains(t, results[0].Note, "matched after the last sync")
+}
+
+func TestReconcile_Error(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListAccounts", mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+
+	_, err := reconcile.Reconcile(context.Background(), storage, reconcile.Options{})
+	assert.EqualError(t, err, "failed to list accounts: connection refused")
+}
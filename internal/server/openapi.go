@@ -0,0 +1,182 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// openAPIVersion is the version of the OpenAPI specification the document follows
+const openAPIVersion = "3.0.3"
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// OpenAPI builds the OpenAPI document of the endpoints, with the schemas of the models they respond with
+func (s *Server) OpenAPI() map[string]any {
+	schemas := make(map[string]any)
+	paths := make(map[string]any)
+
+	for _, r := range s.routes {
+		name := r.schema.Name()
+		schemaOf(r.schema, schemas)
+
+		var content map[string]any
+		if r.list {
+			content = map[string]any{
+				"type":     "object",
+				"required": []string{"items", "page", "limit"},
+				"properties": map[string]any{
+					"items": map[string]any{"type": "array", "items": ref(name)},
+					"page":  map[string]any{"type": "integer"},
+					"limit": map[string]any{"type": "integer"},
+				},
+			}
+		} else {
+			content = ref(name)
+		}
+
+		params := make([]any, 0, len(r.params))
+		for _, p := range r.params {
+			schema := map[string]any{"type": p.typ}
+			if p.format != "" {
+				schema["format"] = p.format
+			}
+			params = append(params, map[string]any{
+				"name":        p.name,
+				"in":          p.in,
+				"required":    p.in == "path",
+				"description": p.description,
+				"schema":      schema,
+			})
+		}
+
+		headers := map[string]any{
+			"ETag": map[string]any{"schema": map[string]any{"type": "string"}},
+		}
+		if !r.list {
+			headers["Last-Modified"] = map[string]any{
+				"description": "changed time of the object",
+				"schema":      map[string]any{"type": "string"},
+			}
+		}
+
+		responses := map[string]any{
+			"200": map[string]any{
+				"description": "OK",
+				"headers":     headers,
+				"content":     map[string]any{"application/json": map[string]any{"schema": content}},
+			},
+			"304": map[string]any{"description": "Not modified since the ETag or the time in the request"},
+			"400": errorResponse("Invalid parameters"),
+			"500": errorResponse("Internal error"),
+		}
+		if !r.list {
+			responses["404"] = errorResponse("Not found")
+		}
+
+		paths[r.pattern] = map[string]any{
+			"get": map[string]any{
+				"summary":    r.summary,
+				"parameters": params,
+				"responses":  responses,
+			},
+		}
+	}
+
+	schemas["Error"] = map[string]any{
+		"type":       "object",
+		"required":   []string{"error"},
+		"properties": map[string]any{"error": map[string]any{"type": "string"}},
+	}
+
+	return map[string]any{
+		"openapi": openAPIVersion,
+		"info": map[string]any{
+			"title":       "zenexport API",
+			"description": "Read-only access to the ZenMoney data stored by zenexport",
+			"version":     "1",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, s.OpenAPI(), time.Time{})
+}
+
+func errorResponse(description string) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content":     map[string]any{"application/json": map[string]any{"schema": ref("Error")}},
+	}
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// schemaOf describes t by its JSON encoding, adding the named structs it refers to into schemas
+func schemaOf(t reflect.Type, schemas map[string]any) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		schema := schemaOf(t.Elem(), schemas)
+		if _, isRef := schema["$ref"]; isRef {
+			// siblings of $ref are ignored in OpenAPI 3.0
+			return map[string]any{"allOf": []any{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem(), schemas), "nullable": t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() != "" {
+			if _, ok := schemas[t.Name()]; !ok {
+				schemas[t.Name()] = nil // reserve the name so recursive types terminate
+				schemas[t.Name()] = structSchema(t, schemas)
+			}
+			return ref(t.Name())
+		}
+		return structSchema(t, schemas)
+	default:
+		return map[string]any{}
+	}
+}
+
+func structSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	properties := make(map[string]any)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n, _, _ := strings.Cut(tag, ","); n != "" {
+				name = n
+			}
+		}
+		properties[name] = schemaOf(field.Type, schemas)
+	}
+	return map[string]any{"type": "object", "properties": properties}
+}
@@ -0,0 +1,412 @@
+// Package server serves the stored data as a read-only JSON API
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+)
+
+const (
+	// Prefix is the path all the entity endpoints are served under
+	Prefix = "/api/v1"
+
+	defaultLimit = 100
+	maxLimit     = 1000
+
+	shutdownTimeout = 10 * time.Second
+)
+
+// uuidPattern matches a UUID in its canonical text form
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// filters are the query parameters a list endpoint accepts besides the pagination
+type filters struct {
+	user  bool
+	dates bool
+}
+
+// param is a parameter of an endpoint as documented in the OpenAPI document
+type param struct {
+	name        string
+	in          string
+	typ         string
+	format      string
+	description string
+}
+
+// route is an endpoint along with what the OpenAPI document says about it
+type route struct {
+	pattern string
+	summary string
+	params  []param
+	// list is set for endpoints responding with a page of items
+	list bool
+	// schema is the type of the item the endpoint responds with
+	schema reflect.Type
+}
+
+// Page is the response of a list endpoint
+type Page[T any] struct {
+	Items []T `json:"items"`
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+}
+
+// Server serves the objects of a storage over HTTP
+type Server struct {
+	storage interfaces.Storage
+	mux     *http.ServeMux
+	routes  []route
+}
+
+// New creates a server with list and get endpoints for every entity of the storage
+func New(storage interfaces.Storage) *Server {
+	s := &Server{storage: storage, mux: http.NewServeMux()}
+
+	addList(s, "instruments", "List instruments", filters{}, storage.ListInstruments)
+	addGet(s, "instruments", "Get an instrument", strconv.Atoi, storage.GetInstrument)
+	addList(s, "countries", "List countries", filters{}, storage.ListCountries)
+	addGet(s, "countries", "Get a country", strconv.Atoi, storage.GetCountry)
+	addList(s, "companies", "List companies", filters{}, storage.ListCompanies)
+	addGet(s, "companies", "Get a company", strconv.Atoi, storage.GetCompany)
+	addList(s, "users", "List users", filters{user: true}, storage.ListUsers)
+	addGet(s, "users", "Get a user", strconv.Atoi, storage.GetUser)
+	addList(s, "accounts", "List accounts", filters{user: true}, storage.ListAccounts)
+	addGet(s, "accounts", "Get an account", parseUUID, storage.GetAccount)
+	addList(s, "tags", "List tags", filters{user: true}, storage.ListTags)
+	addGet(s, "tags", "Get a tag", parseUUID, storage.GetTag)
+	addList(s, "merchants", "List merchants", filters{user: true}, storage.ListMerchants)
+	addGet(s, "merchants", "Get a merchant", parseUUID, storage.GetMerchant)
+	addList(s, "budgets", "List budgets", filters{user: true, dates: true}, storage.ListBudgets)
+	s.addBudget()
+	addList(s, "reminders", "List reminders", filters{user: true}, storage.ListReminders)
+	addGet(s, "reminders", "Get a reminder", parseUUID, storage.GetReminder)
+	addList(s, "reminder-markers", "List reminder markers", filters{user: true, dates: true}, storage.ListReminderMarkers)
+	addGet(s, "reminder-markers", "Get a reminder marker", parseUUID, storage.GetReminderMarker)
+	addList(s, "transactions", "List transactions", filters{user: true, dates: true}, storage.ListTransactions)
+	addGet(s, "transactions", "Get a transaction", parseUUID, storage.GetTransaction)
+
+	s.mux.HandleFunc("GET /openapi.json", s.handleOpenAPI)
+
+	return s
+}
+
+// Handler returns the handler serving the API
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// Serve listens on addr until ctx is done, then shuts down waiting for the requests in flight
+func (s *Server) Serve(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("Serving the API", "addr", addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) handle(r route, h http.HandlerFunc) {
+	s.routes = append(s.routes, r)
+	s.mux.HandleFunc("GET "+r.pattern, h)
+}
+
+// addList adds GET /api/v1/{name} listing the objects a page at a time
+func addList[T any](s *Server, name, summary string, f filters,
+	list func(context.Context, interfaces.Filter) ([]T, error)) {
+	s.handle(route{
+		pattern: Prefix + "/" + name,
+		summary: summary,
+		params:  f.params(),
+		list:    true,
+		schema:  reflect.TypeFor[T](),
+	}, func(w http.ResponseWriter, r *http.Request) {
+		filter, err := f.parse(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		items, err := list(r.Context(), filter)
+		if err != nil {
+			writeStorageError(w, r, err)
+			return
+		}
+		if items == nil {
+			items = []T{}
+		}
+
+		// no Last-Modified: the latest change of the items misses the ones deleted since, only the ETag does
+		writeJSON(w, r, Page[T]{Items: items, Page: filter.Page, Limit: filter.Limit}, time.Time{})
+	})
+}
+
+// addGet adds GET /api/v1/{name}/{id} returning the object of the given ID
+func addGet[T any, ID int | string](s *Server, name, summary string, parse func(string) (ID, error),
+	get func(context.Context, ID) (*T, error)) {
+	typ, format := "string", "uuid"
+	if _, ok := any(*new(ID)).(int); ok {
+		typ, format = "integer", ""
+	}
+
+	s.handle(route{
+		pattern: Prefix + "/" + name + "/{id}",
+		summary: summary,
+		params:  []param{{name: "id", in: "path", typ: typ, format: format, description: "ID of the object"}},
+		schema:  reflect.TypeFor[T](),
+	}, func(w http.ResponseWriter, r *http.Request) {
+		id, err := parse(r.PathValue("id"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid id: "+r.PathValue("id"))
+			return
+		}
+		object, err := get(r.Context(), id)
+		if err != nil {
+			writeStorageError(w, r, err)
+			return
+		}
+		writeJSON(w, r, object, lastChanged(*object))
+	})
+}
+
+// addBudget adds the get endpoints of budgets, which are identified by the user, the tag and the month.
+// The total budget of a month has no tag and is at /budgets/{user}/total/{date}.
+func (s *Server) addBudget() {
+	user := param{name: "user", in: "path", typ: "integer", description: "ID of the user"}
+	date := param{name: "date", in: "path", typ: "string", format: "date", description: "first day of the month"}
+
+	s.handle(route{
+		pattern: Prefix + "/budgets/{user}/{tag}/{date}",
+		summary: "Get a budget",
+		params: []param{
+			user,
+			{name: "tag", in: "path", typ: "string", format: "uuid", description: "ID of the tag, " +
+				"00000000-0000-0000-0000-000000000000 for the expenses without a category. " +
+				"The total budget of the month is at /budgets/{user}/total/{date}"},
+			date,
+		},
+		schema: reflect.TypeFor[models.Budget](),
+	}, func(w http.ResponseWriter, r *http.Request) {
+		tag, err := parseUUID(r.PathValue("tag"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid tag: "+r.PathValue("tag"))
+			return
+		}
+		s.getBudget(w, r, &tag)
+	})
+
+	s.handle(route{
+		pattern: Prefix + "/budgets/{user}/total/{date}",
+		summary: "Get the total budget of a month",
+		params:  []param{user, date},
+		schema:  reflect.TypeFor[models.Budget](),
+	}, func(w http.ResponseWriter, r *http.Request) {
+		s.getBudget(w, r, nil)
+	})
+}
+
+// getBudget writes the budget of the user and the date of the request path, the total budget for a nil tag
+func (s *Server) getBudget(w http.ResponseWriter, r *http.Request, tag *string) {
+	user, err := strconv.Atoi(r.PathValue("user"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user: "+r.PathValue("user"))
+		return
+	}
+	date, err := time.Parse(time.DateOnly, r.PathValue("date"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid date: "+r.PathValue("date"))
+		return
+	}
+	budget, err := s.storage.GetBudget(r.Context(), user, tag, date)
+	if err != nil {
+		writeStorageError(w, r, err)
+		return
+	}
+	writeJSON(w, r, budget, lastChanged(*budget))
+}
+
+func (f filters) params() []param {
+	params := []param{
+		{name: "page", in: "query", typ: "integer", description: "page number, starting from 1"},
+		{name: "limit", in: "query", typ: "integer",
+			description: fmt.Sprintf("number of items per page, %d by default, at most %d", defaultLimit, maxLimit)},
+	}
+	if f.user {
+		params = append(params, param{name: "user", in: "query", typ: "integer", description: "ID of the user"})
+	}
+	if f.dates {
+		params = append(params,
+			param{name: "from", in: "query", typ: "string", format: "date", description: "earliest date, inclusive"},
+			param{name: "to", in: "query", typ: "string", format: "date", description: "latest date, inclusive"},
+		)
+	}
+	return params
+}
+
+// parse reads the filter from the query of the request, rejecting the parameters the endpoint doesn't take
+func (f filters) parse(r *http.Request) (interfaces.Filter, error) {
+	query := r.URL.Query()
+	filter := interfaces.Filter{Page: 1, Limit: defaultLimit}
+
+	for key := range query {
+		switch {
+		case key == "page" || key == "limit":
+		case key == "user" && f.user:
+		case (key == "from" || key == "to") && f.dates:
+		default:
+			return filter, fmt.Errorf("unknown parameter: %s", key)
+		}
+	}
+
+	if v := query.Get("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return filter, fmt.Errorf("invalid page: %s", v)
+		}
+		filter.Page = page
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return filter, fmt.Errorf("invalid limit: %s, it must be between 1 and %d", v, maxLimit)
+		}
+		filter.Limit = limit
+	}
+	if v := query.Get("user"); v != "" {
+		user, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid user: %s", v)
+		}
+		filter.UserID = &user
+	}
+	for key, target := range map[string]**time.Time{"from": &filter.StartDate, "to": &filter.EndDate} {
+		if v := query.Get(key); v != "" {
+			date, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s: %s, expected YYYY-MM-DD", key, v)
+			}
+			*target = &date
+		}
+	}
+	if filter.StartDate != nil && filter.EndDate != nil && filter.EndDate.Before(*filter.StartDate) {
+		return filter, errors.New("to is before from")
+	}
+
+	return filter, nil
+}
+
+// writeJSON writes v with an ETag of the body and a Last-Modified of changed unless it's zero,
+// or 304 if the request's conditions show the client has it already
+func writeJSON(w http.ResponseWriter, r *http.Request, v any, changed time.Time) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("failed to encode response", "path", r.URL.Path, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "no-cache")
+	if !changed.IsZero() {
+		header.Set("Last-Modified", changed.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, changed) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(append(body, '\n')); err != nil {
+		slog.Debug("failed to write response", "path", r.URL.Path, "error", err)
+	}
+}
+
+// notModified evaluates If-None-Match, and If-Modified-Since only without it, as RFC 9110 requires
+func notModified(r *http.Request, etag string, changed time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if since := r.Header.Get("If-Modified-Since"); since != "" && !changed.IsZero() {
+		t, err := http.ParseTime(since)
+		return err == nil && !changed.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// writeStorageError responds 404 for objects which don't exist and 500 for anything else,
+// logging the error instead of showing it to the client
+func writeStorageError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, interfaces.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	slog.Error("request failed", "path", r.URL.Path, "error", err)
+	writeError(w, http.StatusInternalServerError, "internal error")
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": message}); err != nil {
+		slog.Debug("failed to write error", "error", err)
+	}
+}
+
+// lastChanged returns the time of the changed field of the object, zero if it has none, e.g. for countries
+func lastChanged(v any) time.Time {
+	field := reflect.Indirect(reflect.ValueOf(v)).FieldByName("Changed")
+	if !field.IsValid() || !field.CanInt() || field.Int() == 0 {
+		return time.Time{}
+	}
+	return time.Unix(field.Int(), 0)
+}
+
+// parseUUID validates the ID of an object ZenMoney identifies by UUID, a malformed one never reaches the storage
+func parseUUID(s string) (string, error) {
+	if !uuidPattern.MatchString(s) {
+		return "", fmt.Errorf("invalid UUID: %s", s)
+	}
+	return s, nil
+}
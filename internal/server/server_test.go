@@ -0,0 +1,220 @@
+package server_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nemirlev/zenmoney-export/v2/internal/interfaces"
+	"github.com/nemirlev/zenmoney-export/v2/internal/server"
+	"github.com/nemirlev/zenmoney-export/v2/mocks"
+	"github.com/nemirlev/zenmoney-go-sdk/v2/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, h http.Handler, path string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestListTransactions(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListTransactions", mock.Anything, mock.MatchedBy(func(f interfaces.Filter) bool {
+		return f.Page == 2 && f.Limit == 10 && f.UserID != nil && *f.UserID == 7 &&
+			f.StartDate.Format(time.DateOnly) == "2024-01-01" && f.EndDate.Format(time.DateOnly) == "2024-01-31"
+	})).Return([]models.Transaction{
+		{ID: "tx-1", User: 7, Date: "2024-01-02", Changed: 1704200000},
+		{ID: "tx-2", User: 7, Date: "2024-01-03", Changed: 1704300000},
+	}, nil)
+	h := server.New(storage).Handler()
+
+	rec := get(t, h, "/api/v1/transactions?page=2&limit=10&user=7&from=2024-01-01&to=2024-01-31", nil)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Empty(t, rec.Header().Get("Last-Modified"))
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	var page server.Page[models.Transaction]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+	assert.Equal(t, 2, page.Page)
+	assert.Equal(t, 10, page.Limit)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, "tx-1", page.Items[0].ID)
+
+	path := "/api/v1/transactions?page=2&limit=10&user=7&from=2024-01-01&to=2024-01-31"
+	rec = get(t, h, path, http.Header{"If-None-Match": {`"other", ` + etag}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	// a list may have lost items since, so it is never fresh by time
+	rec = get(t, h, path, http.Header{"If-Modified-Since": {time.Unix(1704300000, 0).UTC().Format(http.TimeFormat)}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestListDefaults(t *testing.T) {
+	storage := mocks.NewStorage(t)
+	storage.On("ListCountries", mock.Anything, interfaces.Filter{Page: 1, Limit: 100}).Return(nil, nil)
+
+	rec := get(t, server.New(storage).Handler(), "/api/v1/countries", nil)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"items":[],"page":1,"limit":100}`, rec.Body.String())
+	assert.Empty(t, rec.Header().Get("Last-Modified"))
+	assert.NotEmpty(t, rec.Header().Get("ETag"))
+}
+
+func TestListInvalidParameters(t *testing.T) {
+	h := server.New(mocks.NewStorage(t)).Handler()
+
+	tests := map[string]string{
+		"/api/v1/transactions?page=0":                                 "invalid page: 0",
+		"/api/v1/transactions?limit=1001":                             "invalid limit: 1001, it must be between 1 and 1000",
+		"/api/v1/transactions?user=me":                                "invalid user: me",
+		"/api/v1/transactions?from=01.02.2024":                        "invalid from: 01.02.2024, expected YYYY-MM-DD",
+		"/api/v1/transactions?from=2024-02-01&to=2024-01-01":          "to is before from",
+		"/api/v1/accounts?from=2024-01-01":                            "unknown parameter: from",
+		"/api/v1/instruments?user=1":                                  "unknown parameter: user",
+		"/api/v1/instruments/abc":                                     "invalid id: abc",
+		"/api/v1/accounts/foo":                                        "invalid id: foo",
+		"/api/v1/budgets/1/00000000-0000-0000-0000-000000000000/2024": "invalid date: 2024",
+		"/api/v1/budgets/1/food/2024-01-01":                           "invalid tag: food",
+	}
+	for path, message := range tests {
+		t.Run(path, func(t *testing.T) {
+			rec := get(t, h, path, nil)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.JSONEq(t, fmt.Sprintf(`{"error":%q}`, message), rec.Body.String())
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	const (
+		acc1 = "1b2e4c9a-5d0f-4a8e-9c3b-7f6a2d1e0b4c"
+		acc2 = "9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a"
+	)
+	storage := mocks.NewStorage(t)
+	storage.On("GetAccount", mock.Anything, acc1).Return(&models.Account{ID: acc1, Title: "Wallet", Changed: 1704200000}, nil)
+	storage.On("GetAccount", mock.Anything, acc2).Return(nil, fmt.Errorf("account %w: %s", interfaces.ErrNotFound, acc2))
+	storage.On("GetInstrument", mock.Anything, 1).Return(nil, errors.New("connection refused"))
+	h := server.New(storage).Handler()
+
+	rec := get(t, h, "/api/v1/accounts/"+acc1, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var account models.Account
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &account))
+	assert.Equal(t, "Wallet", account.Title)
+	lastModified := time.Unix(1704200000, 0).UTC().Format(http.TimeFormat)
+	assert.Equal(t, lastModified, rec.Header().Get("Last-Modified"))
+
+	rec = get(t, h, "/api/v1/accounts/"+acc1, http.Header{"If-Modified-Since": {lastModified}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	// If-Modified-Since is ignored along with If-None-Match
+	rec = get(t, h, "/api/v1/accounts/"+acc1, http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {lastModified}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = get(t, h, "/api/v1/accounts/"+acc2, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"error":"account not found: `+acc2+`"}`, rec.Body.String())
+
+	rec = get(t, h, "/api/v1/instruments/1", nil)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"error":"internal error"}`, rec.Body.String())
+}
+
+func TestGetBudget(t *testing.T) {
+	tag := "5c1d9e2a-3b4f-4d6e-8a7b-0c9d8e7f6a5b"
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storage := mocks.NewStorage(t)
+	storage.On("GetBudget", mock.Anything, 7, &tag, date).Return(&models.Budget{User: 7, Tag: &tag, Date: "2024-01-01", Outcome: 500}, nil)
+
+	rec := get(t, server.New(storage).Handler(), "/api/v1/budgets/7/"+tag+"/2024-01-01", nil)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var budget models.Budget
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &budget))
+	assert.Equal(t, 500.0, budget.Outcome)
+}
+
+func TestGetBudget_Total(t *testing.T) {
+	date := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	storage := mocks.NewStorage(t)
+	storage.On("GetBudget", mock.Anything, 7, (*string)(nil), date).
+		Return(&models.Budget{User: 7, Date: "2024-01-01", Outcome: 9000}, nil)
+
+	rec := get(t, server.New(storage).Handler(), "/api/v1/budgets/7/total/2024-01-01", nil)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var budget models.Budget
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &budget))
+	assert.Nil(t, budget.Tag)
+	assert.Equal(t, 9000.0, budget.Outcome)
+}
+
+func TestOpenAPI(t *testing.T) {
+	rec := get(t, server.New(mocks.NewStorage(t)).Handler(), "/openapi.json", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]struct {
+			Get struct {
+				Parameters []struct {
+					Name string `json:"name"`
+					In   string `json:"in"`
+				} `json:"parameters"`
+				Responses map[string]any `json:"responses"`
+			} `json:"get"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Type       string                    `json:"type"`
+				Properties map[string]map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Len(t, doc.Paths, 23)
+	for _, name := range []string{"instruments", "countries", "companies", "users", "accounts", "tags",
+		"merchants", "budgets", "reminders", "reminder-markers", "transactions"} {
+		assert.Contains(t, doc.Paths, "/api/v1/"+name)
+	}
+
+	transactions := doc.Paths["/api/v1/transactions"].Get
+	var params []string
+	for _, p := range transactions.Parameters {
+		params = append(params, p.In+":"+p.Name)
+	}
+	assert.Equal(t, []string{"query:page", "query:limit", "query:user", "query:from", "query:to"}, params)
+	assert.NotContains(t, transactions.Responses, "404")
+	assert.NotContains(t, transactions.Responses["200"].(map[string]any)["headers"], "Last-Modified")
+	assert.Contains(t, doc.Paths["/api/v1/transactions/{id}"].Get.Responses["200"].(map[string]any)["headers"], "Last-Modified")
+	assert.Contains(t, doc.Paths["/api/v1/transactions/{id}"].Get.Responses, "404")
+	assert.Contains(t, doc.Paths, "/api/v1/budgets/{user}/{tag}/{date}")
+	assert.Contains(t, doc.Paths, "/api/v1/budgets/{user}/total/{date}")
+
+	transaction := doc.Components.Schemas["Transaction"]
+	assert.Equal(t, "object", transaction.Type)
+	assert.Equal(t, map[string]any{"type": "integer"}, transaction.Properties["changed"])
+	assert.Equal(t, map[string]any{"type": "string", "nullable": true}, transaction.Properties["outcomeAccount"])
+	assert.Contains(t, doc.Components.Schemas, "Country")
+	assert.Contains(t, doc.Components.Schemas, "Error")
+}
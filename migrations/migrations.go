@@ -0,0 +1,26 @@
+// Package migrations embeds SQL migrations for every supported storage type,
+// so the zenexport binary can set up a database on its own.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+//go:embed postgres/*.sql
+var files embed.FS
+
+// Source returns migrations for the storage type, e.g. "postgres"
+func Source(storageType string) (fs.FS, error) {
+	source, err := fs.Sub(files, storageType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations for %s: %w", storageType, err)
+	}
+
+	if _, err := fs.ReadDir(source, "."); err != nil {
+		return nil, fmt.Errorf("no migrations for storage type: %s", storageType)
+	}
+
+	return source, nil
+}
@@ -1,13 +1,13 @@
-DROP TABLE IF EXISTS budget;
-DROP TABLE IF EXISTS transaction;
-DROP TABLE IF EXISTS reminder_marker;
-DROP TABLE IF EXISTS reminder;
-DROP TABLE IF EXISTS merchant;
-DROP TABLE IF EXISTS tag;
-DROP TABLE IF EXISTS account;
-DROP TABLE IF EXISTS country;
-DROP TABLE IF EXISTS "user";
-DROP TABLE IF EXISTS company;
-DROP TABLE IF EXISTS instrument;
-DROP TABLE IF EXISTS sync_status;
-DROP TABLE IF EXISTS deletion_history;
\ No newline at end of file
+DROP TABLE IF EXISTS {{ table "budget" }};
+DROP TABLE IF EXISTS {{ table "transaction" }};
+DROP TABLE IF EXISTS {{ table "reminder_marker" }};
+DROP TABLE IF EXISTS {{ table "reminder" }};
+DROP TABLE IF EXISTS {{ table "merchant" }};
+DROP TABLE IF EXISTS {{ table "tag" }};
+DROP TABLE IF EXISTS {{ table "account" }};
+DROP TABLE IF EXISTS {{ table "country" }};
+DROP TABLE IF EXISTS {{ table "user" }};
+DROP TABLE IF EXISTS {{ table "company" }};
+DROP TABLE IF EXISTS {{ table "instrument" }};
+DROP TABLE IF EXISTS {{ table "sync_status" }};
+DROP TABLE IF EXISTS {{ table "deletion_history" }};
\ No newline at end of file
@@ -1,4 +1,4 @@
-CREATE TABLE IF NOT EXISTS instrument
+CREATE TABLE IF NOT EXISTS {{ table "instrument" }}
 (
     id          INT,
     changed     INT,
@@ -9,7 +9,7 @@ CREATE TABLE IF NOT EXISTS instrument
     PRIMARY KEY (id)
 );
 
-CREATE TABLE IF NOT EXISTS company
+CREATE TABLE IF NOT EXISTS {{ table "company" }}
 (
     id           INT,
     changed      INT,
@@ -22,7 +22,7 @@ CREATE TABLE IF NOT EXISTS company
     PRIMARY KEY (id)
 );
 
-CREATE TABLE IF NOT EXISTS "user"
+CREATE TABLE IF NOT EXISTS {{ table "user" }}
 (
     id                        BIGINT,
     country                   INT           NULL,
@@ -42,7 +42,7 @@ CREATE TABLE IF NOT EXISTS "user"
     PRIMARY KEY (id)
 );
 
-CREATE TABLE IF NOT EXISTS country
+CREATE TABLE IF NOT EXISTS {{ table "country" }}
 (
     id       INT,
     title    TEXT,
@@ -51,7 +51,7 @@ CREATE TABLE IF NOT EXISTS country
     PRIMARY KEY (id)
 );
 
-CREATE TABLE IF NOT EXISTS account
+CREATE TABLE IF NOT EXISTS {{ table "account" }}
 (
     id                       UUID,
     changed                  INT,
@@ -82,7 +82,7 @@ CREATE TABLE IF NOT EXISTS account
     PRIMARY KEY (id)
 );
 
-CREATE TABLE IF NOT EXISTS tag
+CREATE TABLE IF NOT EXISTS {{ table "tag" }}
 (
     id             UUID,
     changed        INT,
@@ -101,7 +101,7 @@ CREATE TABLE IF NOT EXISTS tag
     PRIMARY KEY (id)
 );
 
-CREATE TABLE IF NOT EXISTS merchant
+CREATE TABLE IF NOT EXISTS {{ table "merchant" }}
 (
     id      UUID,
     changed INT,
@@ -110,7 +110,7 @@ CREATE TABLE IF NOT EXISTS merchant
     PRIMARY KEY (id)
 );
 
-CREATE TABLE IF NOT EXISTS reminder
+CREATE TABLE IF NOT EXISTS {{ table "reminder" }}
 (
     id                 UUID,
     changed            INT,
@@ -134,7 +134,7 @@ CREATE TABLE IF NOT EXISTS reminder
     PRIMARY KEY (id)
 );
 
-CREATE TABLE IF NOT EXISTS reminder_marker
+CREATE TABLE IF NOT EXISTS {{ table "reminder_marker" }}
 (
     id                 UUID,
     changed            INT,
@@ -157,7 +157,7 @@ CREATE TABLE IF NOT EXISTS reminder_marker
     PRIMARY KEY (id)
 );
 
-CREATE TABLE IF NOT EXISTS transaction
+CREATE TABLE IF NOT EXISTS {{ table "transaction" }}
 (
     id                    UUID,
     changed               INT,
@@ -193,7 +193,7 @@ CREATE TABLE IF NOT EXISTS transaction
     PRIMARY KEY (id)
 );
 
-CREATE TABLE IF NOT EXISTS budget
+CREATE TABLE IF NOT EXISTS {{ table "budget" }}
 (
     changed             INT,
     "user"              INT,
@@ -208,18 +208,18 @@ CREATE TABLE IF NOT EXISTS budget
 );
 
 -- Create sync type enum
-CREATE TYPE sync_type AS ENUM ('full', 'partial', 'force');
+CREATE TYPE {{ table "sync_type" }} AS ENUM ('full', 'partial', 'force');
 
 -- Create sync status enum
-CREATE TYPE status AS ENUM ('completed', 'failed');
+CREATE TYPE {{ table "status" }} AS ENUM ('completed', 'failed');
 
 -- Create sync status table
-CREATE TABLE IF NOT EXISTS sync_status
+CREATE TABLE IF NOT EXISTS {{ table "sync_status" }}
 (
     id                BIGSERIAL PRIMARY KEY,
     started_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
     finished_at       TIMESTAMP WITH TIME ZONE,
-    sync_type         sync_type                NOT NULL,
+    sync_type         {{ table "sync_type" }}                NOT NULL,
     server_timestamp  BIGINT                   NOT NULL,
     records_processed INTEGER                           DEFAULT 0,
     status            VARCHAR(20)              NOT NULL DEFAULT 'completed',
@@ -228,7 +228,7 @@ CREATE TABLE IF NOT EXISTS sync_status
     updated_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
 );
 
-CREATE TABLE IF NOT EXISTS deletion_history
+CREATE TABLE IF NOT EXISTS {{ table "deletion_history" }}
 (
     id          BIGSERIAL PRIMARY KEY,
     object_id   TEXT                     NOT NULL,
@@ -239,9 +239,9 @@ CREATE TABLE IF NOT EXISTS deletion_history
 );
 
 -- Создаем индексы для быстрого поиска
-CREATE INDEX IF NOT EXISTS idx_deletion_history_object
-    ON deletion_history (object_type, object_id);
-CREATE INDEX IF NOT EXISTS idx_deletion_history_user
-    ON deletion_history (user_id);
-CREATE INDEX IF NOT EXISTS idx_deletion_history_deleted_at
-    ON deletion_history (deleted_at);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS {{ name "idx_deletion_history_object" }}
+    ON {{ table "deletion_history" }} (object_type, object_id);
+CREATE INDEX IF NOT EXISTS {{ name "idx_deletion_history_user" }}
+    ON {{ table "deletion_history" }} (user_id);
+CREATE INDEX IF NOT EXISTS {{ name "idx_deletion_history_deleted_at" }}
+    ON {{ table "deletion_history" }} (deleted_at);
\ No newline at end of file
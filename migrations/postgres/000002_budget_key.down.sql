@@ -0,0 +1,6 @@
+ALTER TABLE {{ table "budget" }}
+    DROP CONSTRAINT IF EXISTS {{ name "budget_user_tag_date_key" }};
+
+ALTER TABLE {{ table "budget" }}
+    ALTER COLUMN "user" DROP NOT NULL,
+    ALTER COLUMN date DROP NOT NULL;
@@ -0,0 +1,31 @@
+-- The budget key needs UNIQUE NULLS NOT DISTINCT, stop with a clear message before changing anything
+DO
+$$
+    BEGIN
+        IF current_setting('server_version_num')::INT < 150000 THEN
+            RAISE EXCEPTION 'zenexport needs PostgreSQL 15 or later, the server runs %', current_setting('server_version');
+        END IF;
+    END
+$$;
+
+-- Budgets without a user or a month can't be identified and are dropped
+DELETE FROM {{ table "budget" }}
+WHERE "user" IS NULL
+   OR date IS NULL;
+
+-- Keep only the latest version of every (user, tag, date) budget
+DELETE FROM {{ table "budget" }} b
+    USING {{ table "budget" }} d
+WHERE b."user" = d."user"
+  AND b.tag IS NOT DISTINCT FROM d.tag
+  AND b.date = d.date
+  AND (COALESCE(b.changed, 0) < COALESCE(d.changed, 0)
+    OR (COALESCE(b.changed, 0) = COALESCE(d.changed, 0) AND b.ctid < d.ctid));
+
+ALTER TABLE {{ table "budget" }}
+    ALTER COLUMN "user" SET NOT NULL,
+    ALTER COLUMN date SET NOT NULL;
+
+-- A budget without a tag is the total budget of the month, so NULL tags must collide too
+ALTER TABLE {{ table "budget" }}
+    ADD CONSTRAINT {{ name "budget_user_tag_date_key" }} UNIQUE NULLS NOT DISTINCT ("user", tag, date);
@@ -0,0 +1,46 @@
+ALTER TABLE {{ table "instrument" }}
+    ALTER COLUMN changed TYPE INT USING EXTRACT(EPOCH FROM changed)::INT;
+
+ALTER TABLE {{ table "company" }}
+    ALTER COLUMN changed TYPE INT USING EXTRACT(EPOCH FROM changed)::INT;
+
+ALTER TABLE {{ table "user" }}
+    ALTER COLUMN changed TYPE BIGINT USING EXTRACT(EPOCH FROM changed)::BIGINT;
+
+ALTER TABLE {{ table "account" }}
+    ALTER COLUMN changed TYPE INT USING EXTRACT(EPOCH FROM changed)::INT,
+    ALTER COLUMN balance TYPE FLOAT USING balance::FLOAT,
+    ALTER COLUMN start_balance TYPE FLOAT USING start_balance::FLOAT,
+    ALTER COLUMN credit_limit TYPE FLOAT USING credit_limit::FLOAT;
+
+ALTER TABLE {{ table "tag" }}
+    ALTER COLUMN changed TYPE INT USING EXTRACT(EPOCH FROM changed)::INT;
+
+ALTER TABLE {{ table "merchant" }}
+    ALTER COLUMN changed TYPE INT USING EXTRACT(EPOCH FROM changed)::INT;
+
+ALTER TABLE {{ table "budget" }}
+    ALTER COLUMN date TYPE TEXT USING to_char(date, 'YYYY-MM-DD'),
+    ALTER COLUMN changed TYPE INT USING EXTRACT(EPOCH FROM changed)::INT,
+    ALTER COLUMN income TYPE FLOAT USING income::FLOAT,
+    ALTER COLUMN outcome TYPE FLOAT USING outcome::FLOAT;
+
+ALTER TABLE {{ table "reminder" }}
+    ALTER COLUMN changed TYPE INT USING EXTRACT(EPOCH FROM changed)::INT,
+    ALTER COLUMN income TYPE FLOAT USING income::FLOAT,
+    ALTER COLUMN outcome TYPE FLOAT USING outcome::FLOAT;
+
+ALTER TABLE {{ table "reminder_marker" }}
+    ALTER COLUMN date TYPE TEXT USING to_char(date, 'YYYY-MM-DD'),
+    ALTER COLUMN changed TYPE INT USING EXTRACT(EPOCH FROM changed)::INT,
+    ALTER COLUMN income TYPE FLOAT USING income::FLOAT,
+    ALTER COLUMN outcome TYPE FLOAT USING outcome::FLOAT;
+
+ALTER TABLE {{ table "transaction" }}
+    ALTER COLUMN date TYPE TEXT USING to_char(date, 'YYYY-MM-DD'),
+    ALTER COLUMN changed TYPE INT USING EXTRACT(EPOCH FROM changed)::INT,
+    ALTER COLUMN created TYPE INT USING EXTRACT(EPOCH FROM created)::INT,
+    ALTER COLUMN income TYPE FLOAT USING income::FLOAT,
+    ALTER COLUMN outcome TYPE FLOAT USING outcome::FLOAT,
+    ALTER COLUMN op_income TYPE FLOAT USING op_income::FLOAT,
+    ALTER COLUMN op_outcome TYPE FLOAT USING op_outcome::FLOAT;
@@ -0,0 +1,49 @@
+-- Dates become DATE, change and creation epochs become TIMESTAMPTZ and money amounts become NUMERIC,
+-- so range queries compare dates instead of strings and sums don't pick up floating-point drift
+
+ALTER TABLE {{ table "instrument" }}
+    ALTER COLUMN changed TYPE TIMESTAMPTZ USING to_timestamp(changed);
+
+ALTER TABLE {{ table "company" }}
+    ALTER COLUMN changed TYPE TIMESTAMPTZ USING to_timestamp(changed);
+
+ALTER TABLE {{ table "user" }}
+    ALTER COLUMN changed TYPE TIMESTAMPTZ USING to_timestamp(changed);
+
+ALTER TABLE {{ table "account" }}
+    ALTER COLUMN changed TYPE TIMESTAMPTZ USING to_timestamp(changed),
+    ALTER COLUMN balance TYPE NUMERIC USING balance::NUMERIC,
+    ALTER COLUMN start_balance TYPE NUMERIC USING start_balance::NUMERIC,
+    ALTER COLUMN credit_limit TYPE NUMERIC USING credit_limit::NUMERIC;
+
+ALTER TABLE {{ table "tag" }}
+    ALTER COLUMN changed TYPE TIMESTAMPTZ USING to_timestamp(changed);
+
+ALTER TABLE {{ table "merchant" }}
+    ALTER COLUMN changed TYPE TIMESTAMPTZ USING to_timestamp(changed);
+
+ALTER TABLE {{ table "budget" }}
+    ALTER COLUMN date TYPE DATE USING NULLIF(date, '')::DATE,
+    ALTER COLUMN changed TYPE TIMESTAMPTZ USING to_timestamp(changed),
+    ALTER COLUMN income TYPE NUMERIC USING income::NUMERIC,
+    ALTER COLUMN outcome TYPE NUMERIC USING outcome::NUMERIC;
+
+ALTER TABLE {{ table "reminder" }}
+    ALTER COLUMN changed TYPE TIMESTAMPTZ USING to_timestamp(changed),
+    ALTER COLUMN income TYPE NUMERIC USING income::NUMERIC,
+    ALTER COLUMN outcome TYPE NUMERIC USING outcome::NUMERIC;
+
+ALTER TABLE {{ table "reminder_marker" }}
+    ALTER COLUMN date TYPE DATE USING NULLIF(date, '')::DATE,
+    ALTER COLUMN changed TYPE TIMESTAMPTZ USING to_timestamp(changed),
+    ALTER COLUMN income TYPE NUMERIC USING income::NUMERIC,
+    ALTER COLUMN outcome TYPE NUMERIC USING outcome::NUMERIC;
+
+ALTER TABLE {{ table "transaction" }}
+    ALTER COLUMN date TYPE DATE USING NULLIF(date, '')::DATE,
+    ALTER COLUMN changed TYPE TIMESTAMPTZ USING to_timestamp(changed),
+    ALTER COLUMN created TYPE TIMESTAMPTZ USING to_timestamp(created),
+    ALTER COLUMN income TYPE NUMERIC USING income::NUMERIC,
+    ALTER COLUMN outcome TYPE NUMERIC USING outcome::NUMERIC,
+    ALTER COLUMN op_income TYPE NUMERIC USING op_income::NUMERIC,
+    ALTER COLUMN op_outcome TYPE NUMERIC USING op_outcome::NUMERIC;
@@ -0,0 +1,36 @@
+DROP INDEX IF EXISTS {{ table "idx_reminder_user" }};
+DROP INDEX IF EXISTS {{ table "idx_merchant_user" }};
+DROP INDEX IF EXISTS {{ table "idx_tag_parent" }};
+DROP INDEX IF EXISTS {{ table "idx_tag_user" }};
+DROP INDEX IF EXISTS {{ table "idx_account_company" }};
+DROP INDEX IF EXISTS {{ table "idx_account_instrument" }};
+DROP INDEX IF EXISTS {{ table "idx_account_user" }};
+DROP INDEX IF EXISTS {{ table "idx_budget_date" }};
+DROP INDEX IF EXISTS {{ table "idx_reminder_marker_reminder" }};
+DROP INDEX IF EXISTS {{ table "idx_reminder_marker_user_date" }};
+DROP INDEX IF EXISTS {{ table "idx_transaction_merchant" }};
+DROP INDEX IF EXISTS {{ table "idx_transaction_outcome_account" }};
+DROP INDEX IF EXISTS {{ table "idx_transaction_income_account" }};
+DROP INDEX IF EXISTS {{ table "idx_transaction_date" }};
+DROP INDEX IF EXISTS {{ table "idx_transaction_user_date" }};
+
+ALTER TABLE {{ table "transaction" }}
+    DROP CONSTRAINT IF EXISTS transaction_merchant_fkey,
+    DROP CONSTRAINT IF EXISTS transaction_outcome_instrument_fkey,
+    DROP CONSTRAINT IF EXISTS transaction_income_instrument_fkey,
+    DROP CONSTRAINT IF EXISTS transaction_outcome_account_fkey,
+    DROP CONSTRAINT IF EXISTS transaction_income_account_fkey;
+
+ALTER TABLE {{ table "tag" }}
+    DROP CONSTRAINT IF EXISTS tag_parent_fkey;
+
+ALTER TABLE {{ table "account" }}
+    DROP CONSTRAINT IF EXISTS account_company_fkey,
+    DROP CONSTRAINT IF EXISTS account_instrument_fkey;
+
+ALTER TABLE {{ table "tag" }}
+    ALTER COLUMN parent TYPE TEXT USING parent::TEXT;
+
+ALTER TABLE {{ table "transaction" }}
+    ALTER COLUMN outcome_account TYPE TEXT USING outcome_account::TEXT,
+    ALTER COLUMN income_account TYPE TEXT USING income_account::TEXT;
@@ -0,0 +1,59 @@
+-- References to accounts and parent tags are UUIDs like the ids they point to
+ALTER TABLE {{ table "transaction" }}
+    ALTER COLUMN income_account TYPE UUID USING NULLIF(income_account, '')::UUID,
+    ALTER COLUMN outcome_account TYPE UUID USING NULLIF(outcome_account, '')::UUID;
+
+ALTER TABLE {{ table "tag" }}
+    ALTER COLUMN parent TYPE UUID USING NULLIF(parent, '')::UUID;
+
+-- Foreign keys are deferred to the end of the sync transaction, so the upsert order inside
+-- a batch doesn't matter. NOT VALID skips the check of rows synced before this migration,
+-- a sync with orphan_mode report logs their dangling references and audit lists them.
+ALTER TABLE {{ table "account" }}
+    ADD CONSTRAINT account_instrument_fkey FOREIGN KEY (instrument) REFERENCES {{ table "instrument" }} (id)
+        DEFERRABLE INITIALLY DEFERRED NOT VALID,
+    ADD CONSTRAINT account_company_fkey FOREIGN KEY (company) REFERENCES {{ table "company" }} (id)
+        ON DELETE SET NULL DEFERRABLE INITIALLY DEFERRED NOT VALID;
+
+ALTER TABLE {{ table "tag" }}
+    ADD CONSTRAINT tag_parent_fkey FOREIGN KEY (parent) REFERENCES {{ table "tag" }} (id)
+        ON DELETE SET NULL DEFERRABLE INITIALLY DEFERRED NOT VALID;
+
+ALTER TABLE {{ table "transaction" }}
+    ADD CONSTRAINT transaction_income_account_fkey FOREIGN KEY (income_account) REFERENCES {{ table "account" }} (id)
+        DEFERRABLE INITIALLY DEFERRED NOT VALID,
+    ADD CONSTRAINT transaction_outcome_account_fkey FOREIGN KEY (outcome_account) REFERENCES {{ table "account" }} (id)
+        DEFERRABLE INITIALLY DEFERRED NOT VALID,
+    ADD CONSTRAINT transaction_income_instrument_fkey FOREIGN KEY (income_instrument) REFERENCES {{ table "instrument" }} (id)
+        DEFERRABLE INITIALLY DEFERRED NOT VALID,
+    ADD CONSTRAINT transaction_outcome_instrument_fkey FOREIGN KEY (outcome_instrument) REFERENCES {{ table "instrument" }} (id)
+        DEFERRABLE INITIALLY DEFERRED NOT VALID,
+    ADD CONSTRAINT transaction_merchant_fkey FOREIGN KEY (merchant) REFERENCES {{ table "merchant" }} (id)
+        ON DELETE SET NULL DEFERRABLE INITIALLY DEFERRED NOT VALID;
+
+-- Indexes for Filter (user, date range) and the ORDER BY of ListTransactions
+CREATE INDEX IF NOT EXISTS {{ name "idx_transaction_user_date" }}
+    ON {{ table "transaction" }} ("user", date DESC, created DESC);
+CREATE INDEX IF NOT EXISTS {{ name "idx_transaction_date" }}
+    ON {{ table "transaction" }} (date DESC, created DESC);
+CREATE INDEX IF NOT EXISTS {{ name "idx_transaction_income_account" }}
+    ON {{ table "transaction" }} (income_account);
+CREATE INDEX IF NOT EXISTS {{ name "idx_transaction_outcome_account" }}
+    ON {{ table "transaction" }} (outcome_account);
+CREATE INDEX IF NOT EXISTS {{ name "idx_transaction_merchant" }}
+    ON {{ table "transaction" }} (merchant);
+
+CREATE INDEX IF NOT EXISTS {{ name "idx_reminder_marker_user_date" }}
+    ON {{ table "reminder_marker" }} ("user", date);
+CREATE INDEX IF NOT EXISTS {{ name "idx_reminder_marker_reminder" }}
+    ON {{ table "reminder_marker" }} (reminder);
+CREATE INDEX IF NOT EXISTS {{ name "idx_budget_date" }}
+    ON {{ table "budget" }} (date);
+
+CREATE INDEX IF NOT EXISTS {{ name "idx_account_user" }} ON {{ table "account" }} ("user");
+CREATE INDEX IF NOT EXISTS {{ name "idx_account_instrument" }} ON {{ table "account" }} (instrument);
+CREATE INDEX IF NOT EXISTS {{ name "idx_account_company" }} ON {{ table "account" }} (company);
+CREATE INDEX IF NOT EXISTS {{ name "idx_tag_user" }} ON {{ table "tag" }} ("user");
+CREATE INDEX IF NOT EXISTS {{ name "idx_tag_parent" }} ON {{ table "tag" }} (parent);
+CREATE INDEX IF NOT EXISTS {{ name "idx_merchant_user" }} ON {{ table "merchant" }} ("user");
+CREATE INDEX IF NOT EXISTS {{ name "idx_reminder_user" }} ON {{ table "reminder" }} ("user");
@@ -0,0 +1,24 @@
+-- Tombstones are removed, the schema before soft deletes has no way to hide them
+DELETE FROM {{ table "transaction" }} WHERE deleted_at IS NOT NULL;
+DELETE FROM {{ table "reminder_marker" }} WHERE deleted_at IS NOT NULL;
+DELETE FROM {{ table "reminder" }} WHERE deleted_at IS NOT NULL;
+DELETE FROM {{ table "budget" }} WHERE deleted_at IS NOT NULL;
+DELETE FROM {{ table "merchant" }} WHERE deleted_at IS NOT NULL;
+DELETE FROM {{ table "tag" }} WHERE deleted_at IS NOT NULL;
+DELETE FROM {{ table "account" }} WHERE deleted_at IS NOT NULL;
+DELETE FROM {{ table "user" }} WHERE deleted_at IS NOT NULL;
+DELETE FROM {{ table "company" }} WHERE deleted_at IS NOT NULL;
+DELETE FROM {{ table "country" }} WHERE deleted_at IS NOT NULL;
+DELETE FROM {{ table "instrument" }} WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE {{ table "transaction" }} DROP COLUMN deleted_at;
+ALTER TABLE {{ table "reminder_marker" }} DROP COLUMN deleted_at;
+ALTER TABLE {{ table "reminder" }} DROP COLUMN deleted_at;
+ALTER TABLE {{ table "budget" }} DROP COLUMN deleted_at;
+ALTER TABLE {{ table "merchant" }} DROP COLUMN deleted_at;
+ALTER TABLE {{ table "tag" }} DROP COLUMN deleted_at;
+ALTER TABLE {{ table "account" }} DROP COLUMN deleted_at;
+ALTER TABLE {{ table "user" }} DROP COLUMN deleted_at;
+ALTER TABLE {{ table "company" }} DROP COLUMN deleted_at;
+ALTER TABLE {{ table "country" }} DROP COLUMN deleted_at;
+ALTER TABLE {{ table "instrument" }} DROP COLUMN deleted_at;
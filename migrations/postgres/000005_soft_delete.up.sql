@@ -0,0 +1,28 @@
+-- Tombstones of objects deleted in ZenMoney when delete_mode is soft
+ALTER TABLE {{ table "instrument" }} ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE {{ table "country" }} ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE {{ table "company" }} ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE {{ table "user" }} ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE {{ table "account" }} ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE {{ table "tag" }} ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE {{ table "merchant" }} ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE {{ table "budget" }} ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE {{ table "reminder" }} ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE {{ table "reminder_marker" }} ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE {{ table "transaction" }} ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Partial indexes for purge, live rows are not indexed
+CREATE INDEX IF NOT EXISTS {{ name "idx_account_deleted_at" }}
+    ON {{ table "account" }} (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS {{ name "idx_tag_deleted_at" }}
+    ON {{ table "tag" }} (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS {{ name "idx_merchant_deleted_at" }}
+    ON {{ table "merchant" }} (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS {{ name "idx_budget_deleted_at" }}
+    ON {{ table "budget" }} (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS {{ name "idx_reminder_deleted_at" }}
+    ON {{ table "reminder" }} (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS {{ name "idx_reminder_marker_deleted_at" }}
+    ON {{ table "reminder_marker" }} (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS {{ name "idx_transaction_deleted_at" }}
+    ON {{ table "transaction" }} (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,24 @@
+DROP TRIGGER IF EXISTS transaction_history ON {{ table "transaction" }};
+DROP TABLE IF EXISTS {{ table "transaction_history" }};
+DROP TRIGGER IF EXISTS reminder_marker_history ON {{ table "reminder_marker" }};
+DROP TABLE IF EXISTS {{ table "reminder_marker_history" }};
+DROP TRIGGER IF EXISTS reminder_history ON {{ table "reminder" }};
+DROP TABLE IF EXISTS {{ table "reminder_history" }};
+DROP TRIGGER IF EXISTS budget_history ON {{ table "budget" }};
+DROP TABLE IF EXISTS {{ table "budget_history" }};
+DROP TRIGGER IF EXISTS merchant_history ON {{ table "merchant" }};
+DROP TABLE IF EXISTS {{ table "merchant_history" }};
+DROP TRIGGER IF EXISTS tag_history ON {{ table "tag" }};
+DROP TABLE IF EXISTS {{ table "tag_history" }};
+DROP TRIGGER IF EXISTS account_history ON {{ table "account" }};
+DROP TABLE IF EXISTS {{ table "account_history" }};
+DROP TRIGGER IF EXISTS user_history ON {{ table "user" }};
+DROP TABLE IF EXISTS {{ table "user_history" }};
+DROP TRIGGER IF EXISTS company_history ON {{ table "company" }};
+DROP TABLE IF EXISTS {{ table "company_history" }};
+DROP TRIGGER IF EXISTS country_history ON {{ table "country" }};
+DROP TABLE IF EXISTS {{ table "country_history" }};
+DROP TRIGGER IF EXISTS instrument_history ON {{ table "instrument" }};
+DROP TABLE IF EXISTS {{ table "instrument_history" }};
+
+DROP FUNCTION IF EXISTS {{ table "record_history" }}();
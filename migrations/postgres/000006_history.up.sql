@@ -0,0 +1,188 @@
+-- History of entity versions (SCD type 2). Every update or delete of a row writes the
+-- previous version into <table>_history with the time range it was valid in.
+-- The triggers record history only when the sync enables it for its transaction:
+--   zenexport.history     = on
+--   zenexport.sync_run_id = id of the sync in sync_status
+CREATE OR REPLACE FUNCTION {{ table "record_history" }}() RETURNS trigger
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    valid_to TIMESTAMPTZ;
+BEGIN
+    IF coalesce(current_setting('zenexport.history', true), '') <> 'on' THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP = 'UPDATE' THEN
+        -- Nothing changed or the row was only marked as deleted: the row itself still describes the version
+        IF to_jsonb(NEW) - 'deleted_at' = to_jsonb(OLD) - 'deleted_at' THEN
+            RETURN NULL;
+        END IF;
+        valid_to := coalesce(OLD.deleted_at, (to_jsonb(NEW) ->> 'changed')::TIMESTAMPTZ, now());
+    ELSE
+        valid_to := coalesce(OLD.deleted_at, now());
+    END IF;
+
+    EXECUTE format('INSERT INTO %I.%I SELECT ($1).*, $2, $3, $4', TG_TABLE_SCHEMA, TG_ARGV[0])
+        USING OLD,
+            coalesce((to_jsonb(OLD) ->> 'changed')::TIMESTAMPTZ, '-infinity'),
+            valid_to,
+            nullif(current_setting('zenexport.sync_run_id', true), '')::BIGINT;
+
+    RETURN NULL;
+END
+$$;
+
+CREATE TABLE IF NOT EXISTS {{ table "instrument_history" }}
+(
+    LIKE {{ table "instrument" }},
+    valid_from  TIMESTAMPTZ NOT NULL,
+    valid_to    TIMESTAMPTZ NOT NULL,
+    sync_run_id BIGINT
+);
+CREATE INDEX IF NOT EXISTS {{ name "idx_instrument_history_key" }} ON {{ table "instrument_history" }} (id, valid_from);
+CREATE TRIGGER instrument_history
+    AFTER UPDATE OR DELETE
+    ON {{ table "instrument" }}
+    FOR EACH ROW
+EXECUTE FUNCTION {{ table "record_history" }}('{{ prefix }}instrument_history');
+
+CREATE TABLE IF NOT EXISTS {{ table "country_history" }}
+(
+    LIKE {{ table "country" }},
+    valid_from  TIMESTAMPTZ NOT NULL,
+    valid_to    TIMESTAMPTZ NOT NULL,
+    sync_run_id BIGINT
+);
+CREATE INDEX IF NOT EXISTS {{ name "idx_country_history_key" }} ON {{ table "country_history" }} (id, valid_from);
+CREATE TRIGGER country_history
+    AFTER UPDATE OR DELETE
+    ON {{ table "country" }}
+    FOR EACH ROW
+EXECUTE FUNCTION {{ table "record_history" }}('{{ prefix }}country_history');
+
+CREATE TABLE IF NOT EXISTS {{ table "company_history" }}
+(
+    LIKE {{ table "company" }},
+    valid_from  TIMESTAMPTZ NOT NULL,
+    valid_to    TIMESTAMPTZ NOT NULL,
+    sync_run_id BIGINT
+);
+CREATE INDEX IF NOT EXISTS {{ name "idx_company_history_key" }} ON {{ table "company_history" }} (id, valid_from);
+CREATE TRIGGER company_history
+    AFTER UPDATE OR DELETE
+    ON {{ table "company" }}
+    FOR EACH ROW
+EXECUTE FUNCTION {{ table "record_history" }}('{{ prefix }}company_history');
+
+CREATE TABLE IF NOT EXISTS {{ table "user_history" }}
+(
+    LIKE {{ table "user" }},
+    valid_from  TIMESTAMPTZ NOT NULL,
+    valid_to    TIMESTAMPTZ NOT NULL,
+    sync_run_id BIGINT
+);
+CREATE INDEX IF NOT EXISTS {{ name "idx_user_history_key" }} ON {{ table "user_history" }} (id, valid_from);
+CREATE TRIGGER user_history
+    AFTER UPDATE OR DELETE
+    ON {{ table "user" }}
+    FOR EACH ROW
+EXECUTE FUNCTION {{ table "record_history" }}('{{ prefix }}user_history');
+
+CREATE TABLE IF NOT EXISTS {{ table "account_history" }}
+(
+    LIKE {{ table "account" }},
+    valid_from  TIMESTAMPTZ NOT NULL,
+    valid_to    TIMESTAMPTZ NOT NULL,
+    sync_run_id BIGINT
+);
+CREATE INDEX IF NOT EXISTS {{ name "idx_account_history_key" }} ON {{ table "account_history" }} (id, valid_from);
+CREATE TRIGGER account_history
+    AFTER UPDATE OR DELETE
+    ON {{ table "account" }}
+    FOR EACH ROW
+EXECUTE FUNCTION {{ table "record_history" }}('{{ prefix }}account_history');
+
+CREATE TABLE IF NOT EXISTS {{ table "tag_history" }}
+(
+    LIKE {{ table "tag" }},
+    valid_from  TIMESTAMPTZ NOT NULL,
+    valid_to    TIMESTAMPTZ NOT NULL,
+    sync_run_id BIGINT
+);
+CREATE INDEX IF NOT EXISTS {{ name "idx_tag_history_key" }} ON {{ table "tag_history" }} (id, valid_from);
+CREATE TRIGGER tag_history
+    AFTER UPDATE OR DELETE
+    ON {{ table "tag" }}
+    FOR EACH ROW
+EXECUTE FUNCTION {{ table "record_history" }}('{{ prefix }}tag_history');
+
+CREATE TABLE IF NOT EXISTS {{ table "merchant_history" }}
+(
+    LIKE {{ table "merchant" }},
+    valid_from  TIMESTAMPTZ NOT NULL,
+    valid_to    TIMESTAMPTZ NOT NULL,
+    sync_run_id BIGINT
+);
+CREATE INDEX IF NOT EXISTS {{ name "idx_merchant_history_key" }} ON {{ table "merchant_history" }} (id, valid_from);
+CREATE TRIGGER merchant_history
+    AFTER UPDATE OR DELETE
+    ON {{ table "merchant" }}
+    FOR EACH ROW
+EXECUTE FUNCTION {{ table "record_history" }}('{{ prefix }}merchant_history');
+
+CREATE TABLE IF NOT EXISTS {{ table "budget_history" }}
+(
+    LIKE {{ table "budget" }},
+    valid_from  TIMESTAMPTZ NOT NULL,
+    valid_to    TIMESTAMPTZ NOT NULL,
+    sync_run_id BIGINT
+);
+CREATE INDEX IF NOT EXISTS {{ name "idx_budget_history_key" }} ON {{ table "budget_history" }} ("user", tag, date, valid_from);
+CREATE TRIGGER budget_history
+    AFTER UPDATE OR DELETE
+    ON {{ table "budget" }}
+    FOR EACH ROW
+EXECUTE FUNCTION {{ table "record_history" }}('{{ prefix }}budget_history');
+
+CREATE TABLE IF NOT EXISTS {{ table "reminder_history" }}
+(
+    LIKE {{ table "reminder" }},
+    valid_from  TIMESTAMPTZ NOT NULL,
+    valid_to    TIMESTAMPTZ NOT NULL,
+    sync_run_id BIGINT
+);
+CREATE INDEX IF NOT EXISTS {{ name "idx_reminder_history_key" }} ON {{ table "reminder_history" }} (id, valid_from);
+CREATE TRIGGER reminder_history
+    AFTER UPDATE OR DELETE
+    ON {{ table "reminder" }}
+    FOR EACH ROW
+EXECUTE FUNCTION {{ table "record_history" }}('{{ prefix }}reminder_history');
+
+CREATE TABLE IF NOT EXISTS {{ table "reminder_marker_history" }}
+(
+    LIKE {{ table "reminder_marker" }},
+    valid_from  TIMESTAMPTZ NOT NULL,
+    valid_to    TIMESTAMPTZ NOT NULL,
+    sync_run_id BIGINT
+);
+CREATE INDEX IF NOT EXISTS {{ name "idx_reminder_marker_history_key" }} ON {{ table "reminder_marker_history" }} (id, valid_from);
+CREATE TRIGGER reminder_marker_history
+    AFTER UPDATE OR DELETE
+    ON {{ table "reminder_marker" }}
+    FOR EACH ROW
+EXECUTE FUNCTION {{ table "record_history" }}('{{ prefix }}reminder_marker_history');
+
+CREATE TABLE IF NOT EXISTS {{ table "transaction_history" }}
+(
+    LIKE {{ table "transaction" }},
+    valid_from  TIMESTAMPTZ NOT NULL,
+    valid_to    TIMESTAMPTZ NOT NULL,
+    sync_run_id BIGINT
+);
+CREATE INDEX IF NOT EXISTS {{ name "idx_transaction_history_key" }} ON {{ table "transaction_history" }} (id, valid_from);
+CREATE TRIGGER transaction_history
+    AFTER UPDATE OR DELETE
+    ON {{ table "transaction" }}
+    FOR EACH ROW
+EXECUTE FUNCTION {{ table "record_history" }}('{{ prefix }}transaction_history');
@@ -0,0 +1,5 @@
+SELECT {{ table "repartition_table" }}('{{ table "reminder_marker" }}', NULL);
+SELECT {{ table "repartition_table" }}('{{ table "transaction" }}', NULL);
+
+DROP FUNCTION IF EXISTS {{ table "repartition_table" }}(REGCLASS, TEXT);
+DROP FUNCTION IF EXISTS {{ table "ensure_partition" }}(REGCLASS, TEXT, DATE);
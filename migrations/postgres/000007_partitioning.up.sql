@@ -0,0 +1,137 @@
+-- Optional declarative partitioning of transaction and reminder_marker by range of date.
+-- The layout follows the partitioning setting (month or year) when this migration runs,
+-- later the tables can be converted with repartition_table, e.g.
+--   SELECT repartition_table('transaction', 'year');
+
+-- ensure_partition creates the partition of a table partitioned by date holding the given day.
+-- Partitions are named after the parent, e.g. transaction_2024_01 or transaction_2024.
+CREATE OR REPLACE FUNCTION {{ table "ensure_partition" }}(parent REGCLASS, granularity TEXT, day DATE) RETURNS VOID
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    parent_schema TEXT;
+    parent_name   TEXT;
+    lower_bound   DATE := date_trunc(granularity, day)::DATE;
+    suffix        TEXT := to_char(day, CASE granularity WHEN 'year' THEN 'YYYY' ELSE 'YYYY_MM' END);
+BEGIN
+    SELECT n.nspname, c.relname
+    INTO parent_schema, parent_name
+    FROM pg_class c
+             JOIN pg_namespace n ON n.oid = c.relnamespace
+    WHERE c.oid = parent;
+
+    EXECUTE format('CREATE TABLE IF NOT EXISTS %I.%I PARTITION OF %I.%I FOR VALUES FROM (%L) TO (%L)',
+                   parent_schema, parent_name || '_' || suffix, parent_schema, parent_name,
+                   lower_bound, (lower_bound + ('1 ' || granularity)::INTERVAL)::DATE);
+END
+$$;
+
+-- repartition_table rebuilds a table partitioned by month or year of date, or as a plain table
+-- when granularity is NULL. Rows, indexes, foreign keys and triggers are kept.
+-- The primary key of a partitioned table has to include the partition key, so it becomes (id, date).
+CREATE OR REPLACE FUNCTION {{ table "repartition_table" }}(parent REGCLASS, granularity TEXT) RETURNS VOID
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    parent_schema TEXT;
+    parent_name   TEXT;
+    partitioned   BOOLEAN;
+    qualified     TEXT;
+    indexes       TEXT[];
+    constraints   TEXT[];
+    triggers      TEXT[];
+    definition    TEXT;
+    day           DATE;
+BEGIN
+    SELECT n.nspname, c.relname, c.relkind = 'p'
+    INTO parent_schema, parent_name, partitioned
+    FROM pg_class c
+             JOIN pg_namespace n ON n.oid = c.relnamespace
+    WHERE c.oid = parent;
+
+    IF coalesce(granularity, '') = '' AND NOT partitioned THEN
+        RETURN;
+    END IF;
+
+    qualified := format('%I.%I', parent_schema, parent_name);
+
+    SELECT coalesce(array_agg(replace(pg_get_indexdef(indexrelid), ' ON ONLY ', ' ON ')), '{}')
+    INTO indexes
+    FROM pg_index
+    WHERE indrelid = parent
+      AND NOT indisprimary;
+
+    -- NOT VALID foreign keys are not supported on partitioned tables, they are validated instead
+    SELECT coalesce(array_agg(format('ALTER TABLE %s ADD CONSTRAINT %I %s', qualified, conname,
+                                     CASE
+                                         WHEN coalesce(granularity, '') = '' THEN pg_get_constraintdef(oid)
+                                         ELSE replace(pg_get_constraintdef(oid), ' NOT VALID', '') END)), '{}')
+    INTO constraints
+    FROM pg_constraint
+    WHERE conrelid = parent
+      AND contype = 'f';
+
+    SELECT coalesce(array_agg(pg_get_triggerdef(oid)), '{}')
+    INTO triggers
+    FROM pg_trigger
+    WHERE tgrelid = parent
+      AND NOT tgisinternal;
+
+    EXECUTE format('CREATE TEMPORARY TABLE repartition_rows ON COMMIT DROP AS SELECT * FROM %s', qualified);
+
+    IF coalesce(granularity, '') = '' THEN
+        EXECUTE format('CREATE TABLE %I.%I (LIKE %s INCLUDING DEFAULTS)',
+                       parent_schema, parent_name || '_rebuild', qualified);
+    ELSE
+        EXECUTE format('CREATE TABLE %I.%I (LIKE %s INCLUDING DEFAULTS) PARTITION BY RANGE (date)',
+                       parent_schema, parent_name || '_rebuild', qualified);
+    END IF;
+
+    EXECUTE format('DROP TABLE %s', qualified);
+    EXECUTE format('ALTER TABLE %I.%I RENAME TO %I', parent_schema, parent_name || '_rebuild', parent_name);
+
+    IF coalesce(granularity, '') <> '' THEN
+        FOR day IN SELECT DISTINCT date_trunc(granularity, r.date)::DATE FROM repartition_rows r WHERE r.date IS NOT NULL
+            LOOP
+                PERFORM {{ table "ensure_partition" }}(qualified::REGCLASS, granularity, day);
+            END LOOP;
+        EXECUTE format('ALTER TABLE %s ADD PRIMARY KEY (id, date)', qualified);
+    ELSE
+        EXECUTE format('ALTER TABLE %s ADD PRIMARY KEY (id)', qualified);
+    END IF;
+
+    EXECUTE format('INSERT INTO %s SELECT * FROM repartition_rows', qualified);
+    DROP TABLE repartition_rows;
+
+    FOREACH definition IN ARRAY indexes || constraints || triggers
+        LOOP
+            EXECUTE definition;
+        END LOOP;
+END
+$$;
+{{ if partition }}
+-- Foreign keys of partitioned tables can't be NOT VALID, so dangling references are cleared before they are validated
+UPDATE {{ table "transaction" }} t
+SET income_account = NULL
+WHERE income_account IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM {{ table "account" }} p WHERE p.id = t.income_account);
+UPDATE {{ table "transaction" }} t
+SET outcome_account = NULL
+WHERE outcome_account IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM {{ table "account" }} p WHERE p.id = t.outcome_account);
+UPDATE {{ table "transaction" }} t
+SET income_instrument = NULL
+WHERE income_instrument IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM {{ table "instrument" }} p WHERE p.id = t.income_instrument);
+UPDATE {{ table "transaction" }} t
+SET outcome_instrument = NULL
+WHERE outcome_instrument IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM {{ table "instrument" }} p WHERE p.id = t.outcome_instrument);
+UPDATE {{ table "transaction" }} t
+SET merchant = NULL
+WHERE merchant IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM {{ table "merchant" }} p WHERE p.id = t.merchant);
+
+SELECT {{ table "repartition_table" }}('{{ table "transaction" }}', '{{ partition }}');
+SELECT {{ table "repartition_table" }}('{{ table "reminder_marker" }}', '{{ partition }}');
+{{ end }}
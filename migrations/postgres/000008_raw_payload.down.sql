@@ -0,0 +1,85 @@
+-- Restores the positional history insert of 000006_history
+CREATE OR REPLACE FUNCTION {{ table "record_history" }}() RETURNS trigger
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    valid_to TIMESTAMPTZ;
+BEGIN
+    IF coalesce(current_setting('zenexport.history', true), '') <> 'on' THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP = 'UPDATE' THEN
+        -- Nothing changed or the row was only marked as deleted: the row itself still describes the version
+        IF to_jsonb(NEW) - 'deleted_at' = to_jsonb(OLD) - 'deleted_at' THEN
+            RETURN NULL;
+        END IF;
+        valid_to := coalesce(OLD.deleted_at, (to_jsonb(NEW) ->> 'changed')::TIMESTAMPTZ, now());
+    ELSE
+        valid_to := coalesce(OLD.deleted_at, now());
+    END IF;
+
+    EXECUTE format('INSERT INTO %I.%I SELECT ($1).*, $2, $3, $4', TG_TABLE_SCHEMA, TG_ARGV[0])
+        USING OLD,
+            coalesce((to_jsonb(OLD) ->> 'changed')::TIMESTAMPTZ, '-infinity'),
+            valid_to,
+            nullif(current_setting('zenexport.sync_run_id', true), '')::BIGINT;
+
+    RETURN NULL;
+END
+$$;
+
+ALTER TABLE {{ table "transaction_history" }}
+    DROP COLUMN IF EXISTS raw;
+ALTER TABLE {{ table "transaction" }}
+    DROP COLUMN IF EXISTS raw;
+
+ALTER TABLE {{ table "reminder_marker_history" }}
+    DROP COLUMN IF EXISTS raw;
+ALTER TABLE {{ table "reminder_marker" }}
+    DROP COLUMN IF EXISTS raw;
+
+ALTER TABLE {{ table "reminder_history" }}
+    DROP COLUMN IF EXISTS raw;
+ALTER TABLE {{ table "reminder" }}
+    DROP COLUMN IF EXISTS raw;
+
+ALTER TABLE {{ table "budget_history" }}
+    DROP COLUMN IF EXISTS raw;
+ALTER TABLE {{ table "budget" }}
+    DROP COLUMN IF EXISTS raw;
+
+ALTER TABLE {{ table "merchant_history" }}
+    DROP COLUMN IF EXISTS raw;
+ALTER TABLE {{ table "merchant" }}
+    DROP COLUMN IF EXISTS raw;
+
+ALTER TABLE {{ table "tag_history" }}
+    DROP COLUMN IF EXISTS raw;
+ALTER TABLE {{ table "tag" }}
+    DROP COLUMN IF EXISTS raw;
+
+ALTER TABLE {{ table "account_history" }}
+    DROP COLUMN IF EXISTS raw;
+ALTER TABLE {{ table "account" }}
+    DROP COLUMN IF EXISTS raw;
+
+ALTER TABLE {{ table "user_history" }}
+    DROP COLUMN IF EXISTS raw;
+ALTER TABLE {{ table "user" }}
+    DROP COLUMN IF EXISTS raw;
+
+ALTER TABLE {{ table "company_history" }}
+    DROP COLUMN IF EXISTS raw;
+ALTER TABLE {{ table "company" }}
+    DROP COLUMN IF EXISTS raw;
+
+ALTER TABLE {{ table "country_history" }}
+    DROP COLUMN IF EXISTS raw;
+ALTER TABLE {{ table "country" }}
+    DROP COLUMN IF EXISTS raw;
+
+ALTER TABLE {{ table "instrument_history" }}
+    DROP COLUMN IF EXISTS raw;
+ALTER TABLE {{ table "instrument" }}
+    DROP COLUMN IF EXISTS raw;
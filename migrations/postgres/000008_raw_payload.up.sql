@@ -0,0 +1,89 @@
+-- Raw payload of every synced object, serialized from the SDK model at save time.
+-- Fields without a column of their own are kept there, see `zenexport schema drift`.
+
+ALTER TABLE {{ table "instrument" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+ALTER TABLE {{ table "instrument_history" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+
+ALTER TABLE {{ table "country" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+ALTER TABLE {{ table "country_history" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+
+ALTER TABLE {{ table "company" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+ALTER TABLE {{ table "company_history" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+
+ALTER TABLE {{ table "user" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+ALTER TABLE {{ table "user_history" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+
+ALTER TABLE {{ table "account" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+ALTER TABLE {{ table "account_history" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+
+ALTER TABLE {{ table "tag" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+ALTER TABLE {{ table "tag_history" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+
+ALTER TABLE {{ table "merchant" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+ALTER TABLE {{ table "merchant_history" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+
+ALTER TABLE {{ table "budget" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+ALTER TABLE {{ table "budget_history" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+
+ALTER TABLE {{ table "reminder" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+ALTER TABLE {{ table "reminder_history" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+
+ALTER TABLE {{ table "reminder_marker" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+ALTER TABLE {{ table "reminder_marker_history" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+
+ALTER TABLE {{ table "transaction" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+ALTER TABLE {{ table "transaction_history" }}
+    ADD COLUMN IF NOT EXISTS raw JSONB;
+
+-- History rows are built by column name, the new column follows sync_run_id in the history tables
+CREATE OR REPLACE FUNCTION {{ table "record_history" }}() RETURNS trigger
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    valid_to TIMESTAMPTZ;
+BEGIN
+    IF coalesce(current_setting('zenexport.history', true), '') <> 'on' THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP = 'UPDATE' THEN
+        -- Nothing changed or the row was only marked as deleted: the row itself still describes the version
+        IF to_jsonb(NEW) - 'deleted_at' = to_jsonb(OLD) - 'deleted_at' THEN
+            RETURN NULL;
+        END IF;
+        valid_to := coalesce(OLD.deleted_at, (to_jsonb(NEW) ->> 'changed')::TIMESTAMPTZ, now());
+    ELSE
+        valid_to := coalesce(OLD.deleted_at, now());
+    END IF;
+
+    EXECUTE format('INSERT INTO %I.%I SELECT (jsonb_populate_record(NULL::%I.%I, $1)).*',
+                   TG_TABLE_SCHEMA, TG_ARGV[0], TG_TABLE_SCHEMA, TG_ARGV[0])
+        USING to_jsonb(OLD) || jsonb_build_object(
+            'valid_from', coalesce((to_jsonb(OLD) ->> 'changed')::TIMESTAMPTZ, '-infinity'),
+            'valid_to', valid_to,
+            'sync_run_id', nullif(current_setting('zenexport.sync_run_id', true), '')::BIGINT);
+
+    RETURN NULL;
+END
+$$;
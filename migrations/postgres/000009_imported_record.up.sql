@@ -0,0 +1,20 @@
+-- Lines of bank statements imported with `zenexport import`. A line is imported into an account once,
+-- keyed by the ID the bank gives it, and refers to the transaction it matched or was created as.
+
+CREATE TABLE IF NOT EXISTS {{ table "imported_record" }}
+(
+    account        TEXT        NOT NULL,
+    external_id    TEXT        NOT NULL,
+    date           DATE        NOT NULL,
+    amount         NUMERIC     NOT NULL,
+    currency       TEXT,
+    payee          TEXT,
+    memo           TEXT,
+    source         TEXT        NOT NULL,
+    transaction_id UUID,
+    imported_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (account, external_id)
+);
+
+CREATE INDEX IF NOT EXISTS {{ name "idx_imported_record_date" }}
+    ON {{ table "imported_record" }} (date);
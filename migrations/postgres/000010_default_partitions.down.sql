@@ -0,0 +1,86 @@
+-- The default partitions are kept, they may hold rows without a date
+-- repartition_table rebuilds a table partitioned by month or year of date, or as a plain table
+-- when granularity is NULL. Rows, indexes, foreign keys and triggers are kept.
+-- The primary key of a partitioned table has to include the partition key, so it becomes (id, date).
+CREATE OR REPLACE FUNCTION {{ table "repartition_table" }}(parent REGCLASS, granularity TEXT) RETURNS VOID
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    parent_schema TEXT;
+    parent_name   TEXT;
+    partitioned   BOOLEAN;
+    qualified     TEXT;
+    indexes       TEXT[];
+    constraints   TEXT[];
+    triggers      TEXT[];
+    definition    TEXT;
+    day           DATE;
+BEGIN
+    SELECT n.nspname, c.relname, c.relkind = 'p'
+    INTO parent_schema, parent_name, partitioned
+    FROM pg_class c
+             JOIN pg_namespace n ON n.oid = c.relnamespace
+    WHERE c.oid = parent;
+
+    IF coalesce(granularity, '') = '' AND NOT partitioned THEN
+        RETURN;
+    END IF;
+
+    qualified := format('%I.%I', parent_schema, parent_name);
+
+    SELECT coalesce(array_agg(replace(pg_get_indexdef(indexrelid), ' ON ONLY ', ' ON ')), '{}')
+    INTO indexes
+    FROM pg_index
+    WHERE indrelid = parent
+      AND NOT indisprimary;
+
+    -- NOT VALID foreign keys are not supported on partitioned tables, they are validated instead
+    SELECT coalesce(array_agg(format('ALTER TABLE %s ADD CONSTRAINT %I %s', qualified, conname,
+                                     CASE
+                                         WHEN coalesce(granularity, '') = '' THEN pg_get_constraintdef(oid)
+                                         ELSE replace(pg_get_constraintdef(oid), ' NOT VALID', '') END)), '{}')
+    INTO constraints
+    FROM pg_constraint
+    WHERE conrelid = parent
+      AND contype = 'f';
+
+    SELECT coalesce(array_agg(pg_get_triggerdef(oid)), '{}')
+    INTO triggers
+    FROM pg_trigger
+    WHERE tgrelid = parent
+      AND NOT tgisinternal;
+
+    EXECUTE format('CREATE TEMPORARY TABLE repartition_rows ON COMMIT DROP AS SELECT * FROM %s', qualified);
+
+    IF coalesce(granularity, '') = '' THEN
+        EXECUTE format('CREATE TABLE %I.%I (LIKE %s INCLUDING DEFAULTS)',
+                       parent_schema, parent_name || '_rebuild', qualified);
+    ELSE
+        EXECUTE format('CREATE TABLE %I.%I (LIKE %s INCLUDING DEFAULTS) PARTITION BY RANGE (date)',
+                       parent_schema, parent_name || '_rebuild', qualified);
+    END IF;
+
+    EXECUTE format('DROP TABLE %s', qualified);
+    EXECUTE format('ALTER TABLE %I.%I RENAME TO %I', parent_schema, parent_name || '_rebuild', parent_name);
+
+    IF coalesce(granularity, '') <> '' THEN
+        FOR day IN SELECT DISTINCT date_trunc(granularity, r.date)::DATE FROM repartition_rows r WHERE r.date IS NOT NULL
+            LOOP
+                PERFORM {{ table "ensure_partition" }}(qualified::REGCLASS, granularity, day);
+            END LOOP;
+        EXECUTE format('ALTER TABLE %s ADD PRIMARY KEY (id, date)', qualified);
+    ELSE
+        EXECUTE format('ALTER TABLE %s ADD PRIMARY KEY (id)', qualified);
+    END IF;
+
+    EXECUTE format('INSERT INTO %s SELECT * FROM repartition_rows', qualified);
+    DROP TABLE repartition_rows;
+
+    FOREACH definition IN ARRAY indexes || constraints || triggers
+        LOOP
+            EXECUTE definition;
+        END LOOP;
+END
+$$;
+
+DROP FUNCTION IF EXISTS {{ table "ensure_default_partition" }}(REGCLASS);
@@ -0,0 +1,115 @@
+-- Tables partitioned by date get a default partition for rows without a date, which no range
+-- partition holds, and repartition_table keeps such rows instead of failing on them.
+
+-- ensure_default_partition creates the default partition of a partitioned table, e.g. transaction_default,
+-- and does nothing for a plain table
+CREATE OR REPLACE FUNCTION {{ table "ensure_default_partition" }}(parent REGCLASS) RETURNS VOID
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    parent_schema TEXT;
+    parent_name   TEXT;
+    partitioned   BOOLEAN;
+BEGIN
+    SELECT n.nspname, c.relname, c.relkind = 'p'
+    INTO parent_schema, parent_name, partitioned
+    FROM pg_class c
+             JOIN pg_namespace n ON n.oid = c.relnamespace
+    WHERE c.oid = parent;
+
+    IF partitioned THEN
+        EXECUTE format('CREATE TABLE IF NOT EXISTS %I.%I PARTITION OF %I.%I DEFAULT',
+                       parent_schema, parent_name || '_default', parent_schema, parent_name);
+    END IF;
+END
+$$;
+
+-- repartition_table rebuilds a table partitioned by month or year of date, or as a plain table
+-- when granularity is NULL. Rows, indexes, foreign keys and triggers are kept.
+-- A unique key of a partitioned table has to include the partition key, so it becomes (id, date).
+-- It is a unique constraint rather than a primary key, which would make date NOT NULL:
+-- rows without a date are kept in the default partition.
+CREATE OR REPLACE FUNCTION {{ table "repartition_table" }}(parent REGCLASS, granularity TEXT) RETURNS VOID
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    parent_schema TEXT;
+    parent_name   TEXT;
+    partitioned   BOOLEAN;
+    qualified     TEXT;
+    indexes       TEXT[];
+    constraints   TEXT[];
+    triggers      TEXT[];
+    definition    TEXT;
+    day           DATE;
+BEGIN
+    SELECT n.nspname, c.relname, c.relkind = 'p'
+    INTO parent_schema, parent_name, partitioned
+    FROM pg_class c
+             JOIN pg_namespace n ON n.oid = c.relnamespace
+    WHERE c.oid = parent;
+
+    IF coalesce(granularity, '') = '' AND NOT partitioned THEN
+        RETURN;
+    END IF;
+
+    qualified := format('%I.%I', parent_schema, parent_name);
+
+    SELECT coalesce(array_agg(replace(pg_get_indexdef(indexrelid), ' ON ONLY ', ' ON ')), '{}')
+    INTO indexes
+    FROM pg_index
+    WHERE indrelid = parent
+      AND indexrelid NOT IN (SELECT conindid FROM pg_constraint WHERE conrelid = parent AND contype IN ('p', 'u'));
+
+    -- NOT VALID foreign keys are not supported on partitioned tables, they are validated instead
+    SELECT coalesce(array_agg(format('ALTER TABLE %s ADD CONSTRAINT %I %s', qualified, conname,
+                                     CASE
+                                         WHEN coalesce(granularity, '') = '' THEN pg_get_constraintdef(oid)
+                                         ELSE replace(pg_get_constraintdef(oid), ' NOT VALID', '') END)), '{}')
+    INTO constraints
+    FROM pg_constraint
+    WHERE conrelid = parent
+      AND contype = 'f';
+
+    SELECT coalesce(array_agg(pg_get_triggerdef(oid)), '{}')
+    INTO triggers
+    FROM pg_trigger
+    WHERE tgrelid = parent
+      AND NOT tgisinternal;
+
+    EXECUTE format('CREATE TEMPORARY TABLE repartition_rows ON COMMIT DROP AS SELECT * FROM %s', qualified);
+
+    IF coalesce(granularity, '') = '' THEN
+        EXECUTE format('CREATE TABLE %I.%I (LIKE %s INCLUDING DEFAULTS)',
+                       parent_schema, parent_name || '_rebuild', qualified);
+    ELSE
+        EXECUTE format('CREATE TABLE %I.%I (LIKE %s INCLUDING DEFAULTS) PARTITION BY RANGE (date)',
+                       parent_schema, parent_name || '_rebuild', qualified);
+    END IF;
+
+    EXECUTE format('DROP TABLE %s', qualified);
+    EXECUTE format('ALTER TABLE %I.%I RENAME TO %I', parent_schema, parent_name || '_rebuild', parent_name);
+
+    IF coalesce(granularity, '') <> '' THEN
+        FOR day IN SELECT DISTINCT date_trunc(granularity, r.date)::DATE FROM repartition_rows r WHERE r.date IS NOT NULL
+            LOOP
+                PERFORM {{ table "ensure_partition" }}(qualified::REGCLASS, granularity, day);
+            END LOOP;
+        PERFORM {{ table "ensure_default_partition" }}(qualified::REGCLASS);
+        EXECUTE format('ALTER TABLE %s ADD CONSTRAINT %I UNIQUE (id, date)', qualified, parent_name || '_id_date_key');
+    ELSE
+        EXECUTE format('ALTER TABLE %s ADD PRIMARY KEY (id)', qualified);
+    END IF;
+
+    EXECUTE format('INSERT INTO %s SELECT * FROM repartition_rows', qualified);
+    DROP TABLE repartition_rows;
+
+    FOREACH definition IN ARRAY indexes || constraints || triggers
+        LOOP
+            EXECUTE definition;
+        END LOOP;
+END
+$$;
+
+SELECT {{ table "ensure_default_partition" }}('{{ table "transaction" }}');
+SELECT {{ table "ensure_default_partition" }}('{{ table "reminder_marker" }}');
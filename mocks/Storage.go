@@ -18,6 +18,42 @@ type Storage struct {
 	mock.Mock
 }
 
+// CheckPartitioning provides a mock function with given fields: ctx
+func (_m *Storage) CheckPartitioning(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckPartitioning")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CheckWrite provides a mock function with given fields: ctx
+func (_m *Storage) CheckWrite(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckWrite")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Close provides a mock function with given fields: ctx
 func (_m *Storage) Close(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -450,6 +486,66 @@ func (_m *Storage) DeleteUser(ctx context.Context, id int) error {
 	return r0
 }
 
+// FindDuplicates provides a mock function with given fields: ctx
+func (_m *Storage) FindDuplicates(ctx context.Context) ([]interfaces.Duplicate, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindDuplicates")
+	}
+
+	var r0 []interfaces.Duplicate
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]interfaces.Duplicate, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []interfaces.Duplicate); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]interfaces.Duplicate)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindOrphans provides a mock function with given fields: ctx
+func (_m *Storage) FindOrphans(ctx context.Context) ([]interfaces.Orphan, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindOrphans")
+	}
+
+	var r0 []interfaces.Orphan
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]interfaces.Orphan, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []interfaces.Orphan); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]interfaces.Orphan)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAccount provides a mock function with given fields: ctx, id
 func (_m *Storage) GetAccount(ctx context.Context, id string) (*models.Account, error) {
 	ret := _m.Called(ctx, id)
@@ -481,7 +577,7 @@ func (_m *Storage) GetAccount(ctx context.Context, id string) (*models.Account,
 }
 
 // GetBudget provides a mock function with given fields: ctx, userID, tagID, date
-func (_m *Storage) GetBudget(ctx context.Context, userID int, tagID string, date time.Time) (*models.Budget, error) {
+func (_m *Storage) GetBudget(ctx context.Context, userID int, tagID *string, date time.Time) (*models.Budget, error) {
 	ret := _m.Called(ctx, userID, tagID, date)
 
 	if len(ret) == 0 {
@@ -490,10 +586,10 @@ func (_m *Storage) GetBudget(ctx context.Context, userID int, tagID string, date
 
 	var r0 *models.Budget
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int, string, time.Time) (*models.Budget, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int, *string, time.Time) (*models.Budget, error)); ok {
 		return rf(ctx, userID, tagID, date)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int, string, time.Time) *models.Budget); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int, *string, time.Time) *models.Budget); ok {
 		r0 = rf(ctx, userID, tagID, date)
 	} else {
 		if ret.Get(0) != nil {
@@ -501,7 +597,7 @@ func (_m *Storage) GetBudget(ctx context.Context, userID int, tagID string, date
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int, string, time.Time) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, int, *string, time.Time) error); ok {
 		r1 = rf(ctx, userID, tagID, date)
 	} else {
 		r1 = ret.Error(1)
@@ -928,6 +1024,66 @@ func (_m *Storage) ListCountries(ctx context.Context, filter interfaces.Filter)
 	return r0, r1
 }
 
+// ListDeletionHistory provides a mock function with given fields: ctx, filter
+func (_m *Storage) ListDeletionHistory(ctx context.Context, filter interfaces.Filter) ([]interfaces.DeletionRecord, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDeletionHistory")
+	}
+
+	var r0 []interfaces.DeletionRecord
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, interfaces.Filter) ([]interfaces.DeletionRecord, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, interfaces.Filter) []interfaces.DeletionRecord); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]interfaces.DeletionRecord)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, interfaces.Filter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListImportedRecords provides a mock function with given fields: ctx, filter
+func (_m *Storage) ListImportedRecords(ctx context.Context, filter interfaces.Filter) ([]interfaces.ImportedRecord, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListImportedRecords")
+	}
+
+	var r0 []interfaces.ImportedRecord
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, interfaces.Filter) ([]interfaces.ImportedRecord, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, interfaces.Filter) []interfaces.ImportedRecord); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]interfaces.ImportedRecord)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, interfaces.Filter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListInstruments provides a mock function with given fields: ctx, filter
 func (_m *Storage) ListInstruments(ctx context.Context, filter interfaces.Filter) ([]models.Instrument, error) {
 	ret := _m.Called(ctx, filter)
@@ -988,6 +1144,36 @@ func (_m *Storage) ListMerchants(ctx context.Context, filter interfaces.Filter)
 	return r0, r1
 }
 
+// ListRecentSyncStatuses provides a mock function with given fields: ctx, limit, status
+func (_m *Storage) ListRecentSyncStatuses(ctx context.Context, limit int, status string) ([]interfaces.SyncStatus, error) {
+	ret := _m.Called(ctx, limit, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListRecentSyncStatuses")
+	}
+
+	var r0 []interfaces.SyncStatus
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) ([]interfaces.SyncStatus, error)); ok {
+		return rf(ctx, limit, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, string) []interfaces.SyncStatus); ok {
+		r0 = rf(ctx, limit, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]interfaces.SyncStatus)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, string) error); ok {
+		r1 = rf(ctx, limit, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListReminderMarkers provides a mock function with given fields: ctx, filter
 func (_m *Storage) ListReminderMarkers(ctx context.Context, filter interfaces.Filter) ([]models.ReminderMarker, error) {
 	ret := _m.Called(ctx, filter)
@@ -1048,6 +1234,36 @@ func (_m *Storage) ListReminders(ctx context.Context, filter interfaces.Filter)
 	return r0, r1
 }
 
+// ListSyncStatuses provides a mock function with given fields: ctx, filter
+func (_m *Storage) ListSyncStatuses(ctx context.Context, filter interfaces.Filter) ([]interfaces.SyncStatus, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListSyncStatuses")
+	}
+
+	var r0 []interfaces.SyncStatus
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, interfaces.Filter) ([]interfaces.SyncStatus, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, interfaces.Filter) []interfaces.SyncStatus); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]interfaces.SyncStatus)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, interfaces.Filter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListTags provides a mock function with given fields: ctx, filter
 func (_m *Storage) ListTags(ctx context.Context, filter interfaces.Filter) ([]models.Tag, error) {
 	ret := _m.Called(ctx, filter)
@@ -1156,6 +1372,66 @@ func (_m *Storage) Ping(ctx context.Context) error {
 	return r0
 }
 
+// PurgeDeleted provides a mock function with given fields: ctx, before
+func (_m *Storage) PurgeDeleted(ctx context.Context, before time.Time) (map[string]int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PurgeDeleted")
+	}
+
+	var r0 map[string]int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (map[string]int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) map[string]int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Repartition provides a mock function with given fields: ctx
+func (_m *Storage) Repartition(ctx context.Context) ([]string, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Repartition")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]string, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []string); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Save provides a mock function with given fields: ctx, response
 func (_m *Storage) Save(ctx context.Context, response *models.Response) error {
 	ret := _m.Called(ctx, response)
@@ -1246,6 +1522,42 @@ func (_m *Storage) SaveCountries(ctx context.Context, countries []models.Country
 	return r0
 }
 
+// SaveDeletionHistory provides a mock function with given fields: ctx, records
+func (_m *Storage) SaveDeletionHistory(ctx context.Context, records []interfaces.DeletionRecord) error {
+	ret := _m.Called(ctx, records)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveDeletionHistory")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []interfaces.DeletionRecord) error); ok {
+		r0 = rf(ctx, records)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveImportedRecords provides a mock function with given fields: ctx, records
+func (_m *Storage) SaveImportedRecords(ctx context.Context, records []interfaces.ImportedRecord) error {
+	ret := _m.Called(ctx, records)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveImportedRecords")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []interfaces.ImportedRecord) error); ok {
+		r0 = rf(ctx, records)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SaveInstruments provides a mock function with given fields: ctx, instruments
 func (_m *Storage) SaveInstruments(ctx context.Context, instruments []models.Instrument) error {
 	ret := _m.Called(ctx, instruments)
@@ -1390,6 +1702,66 @@ func (_m *Storage) SaveUsers(ctx context.Context, users []models.User) error {
 	return r0
 }
 
+// SchemaDrift provides a mock function with given fields: ctx
+func (_m *Storage) SchemaDrift(ctx context.Context) ([]interfaces.Drift, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SchemaDrift")
+	}
+
+	var r0 []interfaces.Drift
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]interfaces.Drift, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []interfaces.Drift); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]interfaces.Drift)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TableStats provides a mock function with given fields: ctx
+func (_m *Storage) TableStats(ctx context.Context) ([]interfaces.TableStats, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TableStats")
+	}
+
+	var r0 []interfaces.TableStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]interfaces.TableStats, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []interfaces.TableStats); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]interfaces.TableStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateAccount provides a mock function with given fields: ctx, account
 func (_m *Storage) UpdateAccount(ctx context.Context, account *models.Account) error {
 	ret := _m.Called(ctx, account)